@@ -21,13 +21,16 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"gorm.io/gorm"
 
 	"github.com/Raylynd6299/babel/internal/content"
+	"github.com/Raylynd6299/babel/internal/content/metadata"
 	"github.com/Raylynd6299/babel/internal/shared/config"
 	"github.com/Raylynd6299/babel/internal/shared/database"
+	"github.com/Raylynd6299/babel/internal/shared/storage"
 )
 
 func main() {
@@ -47,12 +50,52 @@ func main() {
 	}
 	log.Println("Content database migration completed successfully")
 
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up file storage: %v", err)
+	}
+	files := storage.NewFiles(db, store, cfg.PublicURL)
+
+	provider, err := metadata.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up metadata provider: %v", err)
+	}
+
 	// Seed initial data
-	contentService := content.NewService(db, cfg.JWTSecret)
+	contentService := content.NewService(db, cfg.JWTSecret, files, provider)
 	if err := seedInitialData(contentService); err != nil {
 		log.Printf("Warning: Failed to seed initial data: %v", err)
 	}
 
+	// Move free text genres of older content into the genre taxonomy
+	if backfilled, err := contentService.BackfillGenres(context.Background()); err != nil {
+		log.Printf("Warning: Failed to backfill genres: %v", err)
+	} else if backfilled > 0 {
+		log.Printf("Backfilled genres of %d contents", backfilled)
+	}
+
+	// Duplicate detection compares normalised titles
+	if backfilled, err := contentService.BackfillTitleKeys(context.Background()); err != nil {
+		log.Printf("Warning: Failed to backfill title keys: %v", err)
+	} else if backfilled > 0 {
+		log.Printf("Backfilled title keys of %d contents", backfilled)
+	}
+
+	// Rating stats are kept current as ratings change; rebuild them in case
+	// ratings were changed outside the service
+	if err := contentService.RefreshAllRatingStats(context.Background()); err != nil {
+		log.Printf("Warning: Failed to refresh rating stats: %v", err)
+	}
+
+	// Remove uploads nothing links to anymore
+	contentService.StartUploadCleanup(context.Background())
+
+	// Pick up new episodes and rating changes of imported content
+	contentService.StartMetadataRefresh(context.Background())
+
+	// Keep difficulty estimates current with new transcripts and feedback
+	contentService.StartDifficultyRecompute(context.Background())
+
 	router := content.NewRouter(contentService)
 
 	log.Printf("Content service starting on port %s", cfg.Port)
@@ -65,17 +108,50 @@ func migrateContentDatabase(db *gorm.DB) error {
 	// Auto-migrate content models
 	if err := db.AutoMigrate(
 		&content.Language{},
+		&content.Genre{},
 		&content.Content{},
 		&content.ContentEpisode{},
 		&content.ContentRating{},
+		&content.ContentRatingStats{},
+		&content.ReviewVote{},
+		&content.ReviewReport{},
+		&content.TranscriptSegment{},
+		&content.SubtitleTrack{},
+		&content.DifficultyEstimate{},
+		&content.ContentAuditEntry{},
+		&storage.Upload{},
 	); err != nil {
 		return err
 	}
 
+	// Add indexes for content and subtitle search
+	if err := addContentIndexes(db); err != nil {
+		log.Printf("Warning: Failed to add some indexes: %v", err)
+	}
+
 	log.Println("Content migration completed successfully")
 	return nil
 }
 
+func addContentIndexes(db *gorm.DB) error {
+	indexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_transcript_segments_text_search ON transcript_segments USING GIN (to_tsvector('simple', text))",
+		"CREATE INDEX IF NOT EXISTS idx_contents_text_search ON contents USING GIN ((" +
+			"setweight(to_tsvector('simple', coalesce(title, '')), 'A') || " +
+			"setweight(to_tsvector('simple', coalesce(genre, '')), 'B') || " +
+			"setweight(to_tsvector('simple', coalesce(description, '')), 'C')))",
+		"CREATE INDEX IF NOT EXISTS idx_content_genres_genre ON content_genres (genre_id)",
+	}
+
+	for _, indexSQL := range indexes {
+		if err := db.Exec(indexSQL).Error; err != nil {
+			log.Printf("Failed to create index: %s - %v", indexSQL, err)
+		}
+	}
+
+	return nil
+}
+
 func seedInitialData(service *content.Service) error {
 	log.Println("Seeding initial content data...")
 
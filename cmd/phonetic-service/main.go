@@ -28,6 +28,7 @@ import (
 	"github.com/Raylynd6299/babel/internal/phonetic"
 	"github.com/Raylynd6299/babel/internal/shared/config"
 	"github.com/Raylynd6299/babel/internal/shared/database"
+	"github.com/Raylynd6299/babel/internal/shared/storage"
 )
 
 func main() {
@@ -47,8 +48,14 @@ func main() {
 	}
 	log.Println("Phonetic database migration completed successfully")
 
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up file storage: %v", err)
+	}
+	files := storage.NewFiles(db, store, cfg.PublicURL)
+
 	// Seed initial phonetic data
-	phoneticService := phonetic.NewService(db, cfg.JWTSecret)
+	phoneticService := phonetic.NewService(db, cfg.JWTSecret, files)
 	if err := seedInitialPhoneticData(phoneticService); err != nil {
 		log.Printf("Warning: Failed to seed phonetic data: %v", err)
 	}
@@ -70,6 +77,7 @@ func migratePhoneticDatabase(db *gorm.DB) error {
 		&phonetic.MinimalPair{},
 		&phonetic.UserExerciseSession{},
 		&phonetic.PracticePlan{},
+		&storage.Upload{},
 	); err != nil {
 		return err
 	}
@@ -62,6 +62,8 @@ func migrateProgressDatabase(db *gorm.DB) error {
 	if err := db.AutoMigrate(
 		&progress.UserProgress{},
 		&progress.UserStats{},
+		&progress.Watchlist{},
+		&progress.WatchlistItem{},
 	); err != nil {
 		return err
 	}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/Raylynd6299/babel/internal/shared/config"
 	"github.com/Raylynd6299/babel/internal/shared/database"
+	"github.com/Raylynd6299/babel/internal/shared/storage"
 	"github.com/Raylynd6299/babel/internal/social"
 )
 
@@ -47,7 +48,13 @@ func main() {
 	}
 	log.Println("Social database migration completed successfully")
 
-	socialService := social.NewService(db, cfg.JWTSecret)
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up file storage: %v", err)
+	}
+	files := storage.NewFiles(db, store, cfg.PublicURL)
+
+	socialService := social.NewService(db, cfg.JWTSecret, files)
 	router := social.NewRouter(socialService)
 
 	log.Printf("Social service starting on port %s", cfg.Port)
@@ -68,6 +75,7 @@ func migrateSocialDatabase(db *gorm.DB) error {
 		&social.Mentorship{},
 		&social.UserInteraction{},
 		&social.Leaderboard{},
+		&storage.Upload{},
 	); err != nil {
 		return err
 	}
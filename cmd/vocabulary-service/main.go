@@ -21,6 +21,7 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"gorm.io/gorm"
@@ -49,6 +50,9 @@ func main() {
 
 	vocabularyService := vocabulary.NewService(db, cfg.JWTSecret)
 
+	// Background import/export workers
+	vocabularyService.StartJobWorkers(context.Background(), cfg.JobWorkers)
+
 	router := vocabulary.NewRouter(vocabularyService)
 
 	log.Printf("Vocabulary service starting on port %s", cfg.Port)
@@ -58,16 +62,43 @@ func main() {
 func migrateVocabularyDatabase(db *gorm.DB) error {
 	log.Println("Checking vocabulary database schema...")
 
+	if err := vocabulary.RenameLegacyVocabularyTables(db); err != nil {
+		return err
+	}
+
 	// Auto-migrate vocabulary models
 	if err := db.AutoMigrate(
 		&vocabulary.Vocabulary{},
 		&vocabulary.UserVocabulary{},
+		&vocabulary.VocabularyCard{},
 		&vocabulary.VocabularyList{},
+		&vocabulary.VocabularyListItem{},
+		&vocabulary.VocabularyListSubscription{},
+		&vocabulary.VocabularyListMember{},
+		&vocabulary.VocabularyListGroup{},
 		&vocabulary.UserSRSConfig{},
+		&vocabulary.Job{},
+		&vocabulary.SmartFilter{},
+		&vocabulary.WordFrequency{},
+		&vocabulary.ReviewSession{},
+		&vocabulary.ReviewSessionAnswer{},
+		&vocabulary.ReviewEvent{},
+		&vocabulary.DeletedCard{},
+		&vocabulary.ReviewLog{},
 	); err != nil {
 		return err
 	}
 
+	// Merge duplicated dictionary entries
+	if err := vocabulary.MigrateDictionary(db); err != nil {
+		return err
+	}
+
+	// Full-text, trigram and romanization search
+	if err := vocabulary.MigrateSearch(db); err != nil {
+		return err
+	}
+
 	log.Println("Vocabulary migration completed successfully")
 	return nil
 }
@@ -14,6 +14,7 @@ type User struct {
 	FirstName     string     `json:"first_name"`
 	LastName      string     `json:"last_name"`
 	Timezone      string     `json:"timezone" gorm:"default:'UTC'"`
+	Role          string     `json:"role" gorm:"not null;default:'learner'"` // learner, editor or admin
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 	LastLogin     *time.Time `json:"last_login"`
@@ -65,6 +66,10 @@ type RefreshTokenResponse struct {
 	ExpiresIn   int    `json:"expires_in"`
 }
 
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=learner editor admin"`
+}
+
 type UpdateProfileRequest struct {
 	FirstName string `json:"first_name" validate:"omitempty,min=1,max=100"`
 	LastName  string `json:"last_name" validate:"omitempty,min=1,max=100"`
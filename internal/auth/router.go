@@ -61,6 +61,13 @@ func NewRouter(service *Service) *gin.Engine {
 			protected.PUT("/me", authRouter.UpdateProfile)
 			protected.DELETE("/me", authRouter.DeleteAccount)
 		}
+
+		// Admin routes
+		admin := v1.Group("/users")
+		admin.Use(authRouter.jwtService.AdminMiddleware())
+		{
+			admin.PUT("/:id/role", authRouter.UpdateUserRole)
+		}
 	}
 
 	return router
@@ -270,3 +277,44 @@ func (r *Router) DeleteAccount(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
 }
+
+// UpdateUserRole godoc
+// @Summary      Update user role
+// @Description  Make a user a learner, an editor, who reviews and publishes catalogue content, or an admin. The role applies to access tokens issued after the change, when the user next logs in or refreshes. Admins can't change their own role.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Param        request body UpdateRoleRequest true "Role"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /users/{id}/role [put]
+func (r *Router) UpdateUserRole(c *gin.Context) {
+	adminID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := r.service.UpdateUserRole(c.Request.Context(), adminID, c.Param("id"), req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
@@ -31,9 +31,9 @@ func NewService(db *gorm.DB, jwtSecret string) *Service {
 	}
 }
 
-func (s *Service) generateAccessToken(userID string, userEmail string) (string, error) {
+func (s *Service) generateAccessToken(userID string, userEmail string, role string) (string, error) {
 
-	return s.jwtService.GenerateAccessToken(userID, userEmail)
+	return s.jwtService.GenerateAccessTokenWithRole(userID, userEmail, role)
 }
 
 func (s *Service) generateRefreshToken(userID string, userEmail string) (string, error) {
@@ -67,6 +67,7 @@ func (s *Service) Register(ctx context.Context, req RegisterRequest) (*AuthRespo
 		PasswordHash: string(hashedPassword),
 		FirstName:    req.FirstName,
 		LastName:     req.LastName,
+		Role:         polyfyjwt.RoleLearner,
 		IsActive:     true,
 	}
 
@@ -76,7 +77,7 @@ func (s *Service) Register(ctx context.Context, req RegisterRequest) (*AuthRespo
 	}
 
 	// Generate Tokens
-	accessToken, err := s.generateAccessToken(user.ID, user.Email)
+	accessToken, err := s.generateAccessToken(user.ID, user.Email, user.Role)
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +116,7 @@ func (s *Service) Login(ctx context.Context, req LoginRequest) (*AuthResponse, e
 	s.db.Save(&user)
 
 	// Generate Tokens
-	accessToken, err := s.generateAccessToken(user.ID, user.Email)
+	accessToken, err := s.generateAccessToken(user.ID, user.Email, user.Role)
 	if err != nil {
 		return nil, err
 	}
@@ -136,7 +137,7 @@ func (s *Service) Login(ctx context.Context, req LoginRequest) (*AuthResponse, e
 func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*RefreshTokenResponse, error) {
 	// Parse and validate refresh token
 
-	if claims, err := s.jwtService.ValidateRefreshToken(refreshToken); err != nil {
+	if claims, err := s.jwtService.ValidateRefreshToken(refreshToken); err == nil {
 		// Verificar que es un refresh token
 		if claims.Type != "refresh" {
 			return nil, errors.New("not a refresh token")
@@ -149,7 +150,7 @@ func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*Refre
 		}
 
 		// Generar nuevo access token
-		accessToken, err := s.generateAccessToken(claims.UserID, claims.Email)
+		accessToken, err := s.generateAccessToken(user.ID, user.Email, user.Role)
 		if err != nil {
 			return nil, err
 		}
@@ -163,6 +164,28 @@ func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*Refre
 	return nil, errors.New("invalid token")
 }
 
+// UpdateUserRole grants a user the learner, editor or admin role. Admins
+// can't change their own role, so there is always an admin left to undo it.
+func (s *Service) UpdateUserRole(ctx context.Context, adminID, userID, role string) (*User, error) {
+	if !polyfyjwt.IsValidRole(role) {
+		return nil, errors.New("invalid role")
+	}
+	if adminID == userID {
+		return nil, errors.New("cannot change your own role")
+	}
+
+	var user User
+	if err := s.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := s.db.Model(&user).Update("role", role).Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
 func (s *Service) GetUserByID(ctx context.Context, userID string) (*User, error) {
 	var user User
 	err := s.db.Where("id = ? AND is_active = ?", userID, true).First(&user).Error
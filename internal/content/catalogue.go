@@ -0,0 +1,805 @@
+package content
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// Catalogue file formats
+const (
+	CatalogueFormatJSON = "json"
+	CatalogueFormatCSV  = "csv"
+)
+
+// Import actions reported per content
+const (
+	catalogueCreate    = "create"
+	catalogueUpdate    = "update"
+	catalogueUnchanged = "unchanged"
+)
+
+const (
+	maxCatalogueFileSize = 20 << 20
+	maxCatalogueContents = 5000
+	maxCatalogueEpisodes = 2000 // Per content
+	catalogueExportBatch = 100
+)
+
+// catalogueCSVColumns are the columns of a catalogue spreadsheet: one row
+// per episode, or per content without episodes, with the content columns
+// repeated on each of its rows
+var catalogueCSVColumns = []string{
+	"title", "content_type", "language", "year_released", "country", "genres", "description",
+	"poster_url", "imdb_rating", "average_episode_duration", "status",
+	"season_number", "episode_number", "episode_title", "episode_duration_minutes", "episode_description",
+}
+
+var catalogueValidator = newCatalogueValidator()
+
+// CatalogueContent is a content with its seasons and episodes as it appears
+// in catalogue imports and exports. Imports match existing content by title,
+// year and language.
+type CatalogueContent struct {
+	ID                     string            `json:"id,omitempty"` // Exports only
+	Title                  string            `json:"title" validate:"required,max=255"`
+	ContentType            string            `json:"content_type" validate:"required,oneof=series movie podcast book"`
+	Language               string            `json:"language" validate:"required"` // Language code, e.g. "es"
+	YearReleased           int               `json:"year_released" validate:"required,min=1900,max=2030"`
+	Country                string            `json:"country,omitempty" validate:"max=100"`
+	Genres                 []string          `json:"genres,omitempty" validate:"max=10,dive,min=1,max=50"`
+	Description            string            `json:"description,omitempty"`
+	PosterURL              string            `json:"poster_url,omitempty" validate:"omitempty,url"`
+	IMDbRating             float32           `json:"imdb_rating,omitempty" validate:"min=0,max=10"`
+	AverageEpisodeDuration int               `json:"average_episode_duration,omitempty" validate:"min=0"`
+	Status                 string            `json:"status,omitempty"` // Exports only
+	Seasons                []CatalogueSeason `json:"seasons,omitempty" validate:"dive"`
+
+	source string // Where the content was read from, for the report
+}
+
+type CatalogueSeason struct {
+	Number   int                `json:"number" validate:"min=1"`
+	Episodes []CatalogueEpisode `json:"episodes" validate:"dive"`
+}
+
+type CatalogueEpisode struct {
+	Number          int    `json:"number" validate:"min=1"`
+	Title           string `json:"title,omitempty" validate:"max=255"`
+	DurationMinutes int    `json:"duration_minutes,omitempty" validate:"min=0"`
+	Description     string `json:"description,omitempty"`
+}
+
+// CatalogueIssue is a problem found in an import file
+type CatalogueIssue struct {
+	Location string `json:"location"` // e.g. "line 12" or "contents[3]"
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+}
+
+// CatalogueImportItem is what an import did, or would do, to one content
+type CatalogueImportItem struct {
+	Location        string `json:"location"`
+	Title           string `json:"title"`
+	YearReleased    int    `json:"year_released"`
+	Language        string `json:"language"`
+	Action          string `json:"action"` // create, update, unchanged
+	ContentID       string `json:"content_id,omitempty"`
+	EpisodesCreated int    `json:"episodes_created"`
+	EpisodesUpdated int    `json:"episodes_updated"`
+}
+
+// CatalogueImportReport validates an import file and sums up its changes.
+// Nothing is written when there are errors or on a dry run.
+type CatalogueImportReport struct {
+	DryRun          bool                  `json:"dry_run"`
+	Valid           bool                  `json:"valid"`
+	Applied         bool                  `json:"applied"`
+	Contents        int                   `json:"contents"`
+	Created         int                   `json:"created"`
+	Updated         int                   `json:"updated"`
+	Unchanged       int                   `json:"unchanged"`
+	EpisodesCreated int                   `json:"episodes_created"`
+	EpisodesUpdated int                   `json:"episodes_updated"`
+	Errors          []CatalogueIssue      `json:"errors"`
+	Warnings        []CatalogueIssue      `json:"warnings"`
+	Items           []CatalogueImportItem `json:"items"`
+}
+
+// cataloguePlan is the change an import makes to one content
+type cataloguePlan struct {
+	content  Content
+	before   Content
+	genres   []string // Set when the genres change
+	created  []ContentEpisode
+	updated  []ContentEpisode
+	action   string
+	location string
+}
+
+func newCatalogueValidator() *validator.Validate {
+	v := validator.New()
+	// Report fields by their names in the file
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// ImportCatalogue upserts content with its seasons and episodes from a CSV
+// or JSON catalogue file, matching existing content by title, year and
+// language. Content the import creates is published. The whole file is
+// validated first and applied in one transaction, so a file with errors
+// changes nothing.
+func (s *Service) ImportCatalogue(ctx context.Context, editorID string, r io.Reader, format string, dryRun bool) (*CatalogueImportReport, error) {
+	report := &CatalogueImportReport{DryRun: dryRun, Errors: []CatalogueIssue{}, Warnings: []CatalogueIssue{}, Items: []CatalogueImportItem{}}
+
+	var records []CatalogueContent
+	var err error
+	switch format {
+	case CatalogueFormatJSON:
+		records, err = parseCatalogueJSON(r)
+	case CatalogueFormatCSV:
+		records, report.Warnings, err = parseCatalogueCSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported catalogue format %q, expected json or csv", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > maxCatalogueContents {
+		return nil, fmt.Errorf("a catalogue import can hold at most %d contents", maxCatalogueContents)
+	}
+	report.Contents = len(records)
+
+	var languages []Language
+	if err := s.db.Find(&languages).Error; err != nil {
+		return nil, err
+	}
+	languageIDs := make(map[string]int, len(languages))
+	for _, language := range languages {
+		languageIDs[strings.ToLower(language.Code)] = language.ID
+	}
+
+	report.Errors = validateCatalogue(records, languageIDs)
+	report.Valid = len(report.Errors) == 0
+	if !report.Valid {
+		return report, nil
+	}
+
+	plans := make([]cataloguePlan, 0, len(records))
+	for _, record := range records {
+		plan, err := s.planCatalogueContent(record, languageIDs[strings.ToLower(record.Language)])
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, *plan)
+	}
+
+	if !dryRun {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			for i := range plans {
+				if err := applyCataloguePlan(tx, &plans[i], editorID); err != nil {
+					return fmt.Errorf("%s: %w", plans[i].location, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		report.Applied = true
+	}
+
+	for i, plan := range plans {
+		switch plan.action {
+		case catalogueCreate:
+			report.Created++
+		case catalogueUpdate:
+			report.Updated++
+		default:
+			report.Unchanged++
+		}
+		report.EpisodesCreated += len(plan.created)
+		report.EpisodesUpdated += len(plan.updated)
+		report.Items = append(report.Items, CatalogueImportItem{
+			Location:        plan.location,
+			Title:           plan.content.Title,
+			YearReleased:    plan.content.YearReleased,
+			Language:        records[i].Language,
+			Action:          plan.action,
+			ContentID:       plan.content.ID,
+			EpisodesCreated: len(plan.created),
+			EpisodesUpdated: len(plan.updated),
+		})
+	}
+	return report, nil
+}
+
+// ExportCatalogue writes every content with its seasons and episodes as a
+// catalogue file that ImportCatalogue reads back. Statuses narrow the export,
+// e.g. to published content for partners.
+func (s *Service) ExportCatalogue(ctx context.Context, w io.Writer, format string, statuses []string) error {
+	var write func(CatalogueContent) error
+	var finish func() error
+	switch format {
+	case CatalogueFormatJSON:
+		if _, err := io.WriteString(w, `{"contents":[`); err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(w)
+		first := true
+		write = func(record CatalogueContent) error {
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+			return encoder.Encode(record)
+		}
+		finish = func() error {
+			_, err := io.WriteString(w, "]}\n")
+			return err
+		}
+	case CatalogueFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(catalogueCSVColumns); err != nil {
+			return err
+		}
+		write = func(record CatalogueContent) error {
+			return writer.WriteAll(catalogueCSVRows(record))
+		}
+		finish = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	default:
+		return fmt.Errorf("unsupported catalogue format %q, expected json or csv", format)
+	}
+
+	lastID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		query := s.db.Preload("Language").Preload("Genres").
+			Preload("Episodes", func(db *gorm.DB) *gorm.DB {
+				return db.Order("season_number ASC, episode_number ASC")
+			}).
+			Where("CAST(id AS text) > ?", lastID)
+		if len(statuses) > 0 {
+			query = query.Where("status IN ?", statuses)
+		}
+
+		var contents []Content
+		if err := query.Order("CAST(id AS text) ASC").Limit(catalogueExportBatch).Find(&contents).Error; err != nil {
+			return err
+		}
+
+		for i := range contents {
+			if err := write(catalogueRecord(&contents[i])); err != nil {
+				return err
+			}
+		}
+
+		if len(contents) < catalogueExportBatch {
+			return finish()
+		}
+		lastID = contents[len(contents)-1].ID
+	}
+}
+
+// parseCatalogueJSON reads {"contents": [...]} or a bare array of contents
+func parseCatalogueJSON(r io.Reader) ([]CatalogueContent, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []CatalogueContent
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &records)
+	} else {
+		var file struct {
+			Contents []CatalogueContent `json:"contents"`
+		}
+		err = json.Unmarshal(data, &file)
+		records = file.Contents
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid catalogue JSON: %w", err)
+	}
+
+	for i := range records {
+		records[i].source = fmt.Sprintf("contents[%d]", i)
+	}
+	return records, nil
+}
+
+// parseCatalogueCSV reads a catalogue spreadsheet, gathering the rows of
+// each content by title, year and language. Columns are matched by header
+// name in any order; only title, content_type, language and year_released
+// are required. Content values that differ between a content's rows are
+// reported as warnings and the first is kept.
+func parseCatalogueCSV(r io.Reader) ([]CatalogueContent, []CatalogueIssue, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, errors.New("catalogue CSV is empty")
+		}
+		return nil, nil, fmt.Errorf("invalid catalogue CSV: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == "year" {
+			name = "year_released"
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"title", "content_type", "language", "year_released"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("catalogue CSV is missing the %s column", required)
+		}
+	}
+
+	var records []*CatalogueContent
+	byKey := make(map[string]*CatalogueContent)
+	seasons := make(map[*CatalogueContent]map[int]int) // Season number to index in Seasons
+	warnings := []CatalogueIssue{}
+	line := 1
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid catalogue CSV: %w", err)
+		}
+		location := fmt.Sprintf("line %d", line)
+
+		cell := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		number := func(name string) int {
+			value := cell(name)
+			if value == "" {
+				return 0
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				// Left at -1 so validation reports it
+				warnings = append(warnings, CatalogueIssue{Location: location, Field: name, Message: fmt.Sprintf("%q is not a whole number", value)})
+				return -1
+			}
+			return n
+		}
+
+		if strings.Join(row, "") == "" {
+			continue
+		}
+
+		record := CatalogueContent{
+			Title:                  cell("title"),
+			ContentType:            strings.ToLower(cell("content_type")),
+			Language:               strings.ToLower(cell("language")),
+			YearReleased:           number("year_released"),
+			Country:                cell("country"),
+			Genres:                 splitGenreNames(cell("genres")),
+			Description:            cell("description"),
+			PosterURL:              cell("poster_url"),
+			AverageEpisodeDuration: number("average_episode_duration"),
+			source:                 location,
+		}
+		if rating := cell("imdb_rating"); rating != "" {
+			parsed, err := strconv.ParseFloat(rating, 32)
+			if err != nil {
+				warnings = append(warnings, CatalogueIssue{Location: location, Field: "imdb_rating", Message: fmt.Sprintf("%q is not a number", rating)})
+				parsed = -1
+			}
+			record.IMDbRating = float32(parsed)
+		}
+
+		key := fmt.Sprintf("%s|%d|%s", titleKey(record.Title), record.YearReleased, record.Language)
+		content, seen := byKey[key]
+		if !seen {
+			content = &record
+			byKey[key] = content
+			seasons[content] = make(map[int]int)
+			records = append(records, content)
+		} else {
+			warnings = append(warnings, mergeCatalogueRow(content, record, location)...)
+		}
+
+		episodeNumber := number("episode_number")
+		if episodeNumber == 0 {
+			continue
+		}
+		seasonNumber := number("season_number")
+		if seasonNumber == 0 {
+			seasonNumber = 1
+		}
+		index, ok := seasons[content][seasonNumber]
+		if !ok {
+			content.Seasons = append(content.Seasons, CatalogueSeason{Number: seasonNumber})
+			index = len(content.Seasons) - 1
+			seasons[content][seasonNumber] = index
+		}
+		content.Seasons[index].Episodes = append(content.Seasons[index].Episodes, CatalogueEpisode{
+			Number:          episodeNumber,
+			Title:           cell("episode_title"),
+			DurationMinutes: number("episode_duration_minutes"),
+			Description:     cell("episode_description"),
+		})
+	}
+
+	parsed := make([]CatalogueContent, len(records))
+	for i, record := range records {
+		parsed[i] = *record
+	}
+	return parsed, warnings, nil
+}
+
+// mergeCatalogueRow fills in a content's blank values from another of its
+// rows, warning where the rows disagree
+func mergeCatalogueRow(content *CatalogueContent, row CatalogueContent, location string) []CatalogueIssue {
+	var warnings []CatalogueIssue
+	merge := func(field string, current *string, value string) {
+		switch {
+		case value == "" || value == *current:
+		case *current == "":
+			*current = value
+		default:
+			warnings = append(warnings, CatalogueIssue{
+				Location: location,
+				Field:    field,
+				Message:  fmt.Sprintf("differs from %s; keeping %q", content.source, *current),
+			})
+		}
+	}
+	merge("content_type", &content.ContentType, row.ContentType)
+	merge("country", &content.Country, row.Country)
+	merge("description", &content.Description, row.Description)
+	merge("poster_url", &content.PosterURL, row.PosterURL)
+
+	if len(content.Genres) == 0 {
+		content.Genres = row.Genres
+	}
+	if content.IMDbRating == 0 {
+		content.IMDbRating = row.IMDbRating
+	}
+	if content.AverageEpisodeDuration == 0 {
+		content.AverageEpisodeDuration = row.AverageEpisodeDuration
+	}
+	return warnings
+}
+
+// validateCatalogue checks every content of an import, its language and
+// that no content or episode appears twice
+func validateCatalogue(records []CatalogueContent, languageIDs map[string]int) []CatalogueIssue {
+	issues := []CatalogueIssue{}
+	keys := make(map[string]string, len(records))
+	for _, record := range records {
+		if err := catalogueValidator.Struct(record); err != nil {
+			var fieldErrors validator.ValidationErrors
+			if !errors.As(err, &fieldErrors) {
+				issues = append(issues, CatalogueIssue{Location: record.source, Message: err.Error()})
+				continue
+			}
+			for _, fieldError := range fieldErrors {
+				_, field, _ := strings.Cut(fieldError.Namespace(), ".")
+				message := "failed " + fieldError.Tag()
+				if fieldError.Param() != "" {
+					message += "=" + fieldError.Param()
+				}
+				issues = append(issues, CatalogueIssue{Location: record.source, Field: field, Message: message})
+			}
+		}
+
+		if record.Language != "" {
+			if _, ok := languageIDs[strings.ToLower(record.Language)]; !ok {
+				issues = append(issues, CatalogueIssue{Location: record.source, Field: "language", Message: fmt.Sprintf("unknown language %q", record.Language)})
+			}
+		}
+
+		key := fmt.Sprintf("%s|%d|%s", titleKey(record.Title), record.YearReleased, strings.ToLower(record.Language))
+		if first, ok := keys[key]; ok {
+			issues = append(issues, CatalogueIssue{Location: record.source, Message: fmt.Sprintf("same title, year and language as %s", first)})
+		} else {
+			keys[key] = record.source
+		}
+
+		episodes := 0
+		seen := make(map[[2]int]bool)
+		for _, season := range record.Seasons {
+			for _, episode := range season.Episodes {
+				episodes++
+				number := [2]int{season.Number, episode.Number}
+				if seen[number] {
+					issues = append(issues, CatalogueIssue{Location: record.source, Field: "seasons", Message: fmt.Sprintf("season %d episode %d is listed twice", season.Number, episode.Number)})
+				}
+				seen[number] = true
+			}
+		}
+		if episodes > maxCatalogueEpisodes {
+			issues = append(issues, CatalogueIssue{Location: record.source, Field: "seasons", Message: fmt.Sprintf("at most %d episodes per content", maxCatalogueEpisodes)})
+		}
+	}
+	return issues
+}
+
+// planCatalogueContent works out how an import changes the content matching
+// record, without writing anything
+func (s *Service) planCatalogueContent(record CatalogueContent, languageID int) (*cataloguePlan, error) {
+	var existing []Content
+	err := s.db.Preload("Episodes").
+		Where("title_key = ? AND year_released = ? AND language_id = ?", titleKey(record.Title), record.YearReleased, languageID).
+		Order("created_at ASC").
+		Limit(1).
+		Find(&existing).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var current *Content
+	if len(existing) > 0 {
+		current = &existing[0]
+	}
+	plan := planCatalogueChange(current, record, languageID)
+	return &plan, nil
+}
+
+// planCatalogueChange applies record to a copy of the current content, or a
+// new one when current is nil. Values the record leaves blank are kept;
+// episodes are matched by season and episode number, and episodes the
+// record doesn't list are kept.
+func planCatalogueChange(current *Content, record CatalogueContent, languageID int) cataloguePlan {
+	plan := cataloguePlan{action: catalogueCreate, location: record.source}
+	var episodes []ContentEpisode
+	if current != nil {
+		plan.action = catalogueUnchanged
+		plan.before = *current
+		plan.content = *current
+		episodes = current.Episodes
+	}
+	plan.content.Episodes = nil
+
+	content := &plan.content
+	content.Title = record.Title
+	content.TitleKey = titleKey(record.Title)
+	content.ContentType = record.ContentType
+	content.LanguageID = languageID
+	content.YearReleased = record.YearReleased
+	if record.Country != "" {
+		content.Country = record.Country
+	}
+	if record.Description != "" {
+		content.Description = record.Description
+	}
+	if record.PosterURL != "" {
+		content.PosterURL = record.PosterURL
+	}
+	if record.IMDbRating > 0 {
+		content.IMDbRating = record.IMDbRating
+	}
+	if record.AverageEpisodeDuration > 0 {
+		content.AverageEpisodeDuration = record.AverageEpisodeDuration
+	}
+
+	if genres := uniqueGenreNames(record.Genres); len(genres) > 0 && !sameGenres(genres, splitGenreNames(content.Genre)) {
+		plan.genres = genres
+	}
+
+	type key struct{ season, episode int }
+	byNumber := make(map[key]ContentEpisode, len(episodes))
+	for _, episode := range episodes {
+		byNumber[key{episode.SeasonNumber, episode.EpisodeNumber}] = episode
+	}
+	total := len(episodes)
+	for _, season := range record.Seasons {
+		for _, episode := range season.Episodes {
+			existing, ok := byNumber[key{season.Number, episode.Number}]
+			if !ok {
+				plan.created = append(plan.created, ContentEpisode{
+					ContentID:       content.ID,
+					SeasonNumber:    season.Number,
+					EpisodeNumber:   episode.Number,
+					Title:           episode.Title,
+					DurationMinutes: episode.DurationMinutes,
+					Description:     episode.Description,
+				})
+				total++
+				continue
+			}
+
+			changed := false
+			if episode.Title != "" && episode.Title != existing.Title {
+				existing.Title = episode.Title
+				changed = true
+			}
+			if episode.DurationMinutes > 0 && episode.DurationMinutes != existing.DurationMinutes {
+				existing.DurationMinutes = episode.DurationMinutes
+				changed = true
+			}
+			if episode.Description != "" && episode.Description != existing.Description {
+				existing.Description = episode.Description
+				changed = true
+			}
+			if changed {
+				plan.updated = append(plan.updated, existing)
+			}
+		}
+	}
+	if total > content.TotalEpisodes {
+		content.TotalEpisodes = total
+	} else if content.TotalEpisodes == 0 {
+		content.TotalEpisodes = 1
+	}
+
+	if plan.action == catalogueUnchanged &&
+		(len(auditDiff(plan.before, *content)) > 0 || plan.genres != nil || len(plan.created) > 0 || len(plan.updated) > 0) {
+		plan.action = catalogueUpdate
+	}
+	return plan
+}
+
+func sameGenres(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if genreSlug(a[i]) != genreSlug(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyCataloguePlan writes a planned change and records it in the audit
+// trail. Content the import creates is published by the editor running it.
+func applyCataloguePlan(tx *gorm.DB, plan *cataloguePlan, editorID string) error {
+	if plan.action == catalogueUnchanged {
+		return nil
+	}
+
+	content := &plan.content
+	if plan.action == catalogueCreate {
+		now := time.Now()
+		content.CreatedBy = editorID
+		content.Status = ContentStatusPublished
+		content.IsVerified = true
+		content.ReviewedBy = editorID
+		content.ReviewedAt = &now
+		if err := tx.Create(content).Error; err != nil {
+			return err
+		}
+	} else if err := tx.Save(content).Error; err != nil {
+		return err
+	}
+
+	if plan.genres != nil {
+		if err := setContentGenres(tx, content, plan.genres); err != nil {
+			return err
+		}
+	}
+
+	for i := range plan.created {
+		plan.created[i].ContentID = content.ID
+	}
+	if len(plan.created) > 0 {
+		if err := tx.CreateInBatches(&plan.created, 200).Error; err != nil {
+			return err
+		}
+	}
+	for i := range plan.updated {
+		if err := tx.Save(&plan.updated[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	return recordAudit(tx, ContentAuditEntry{
+		ContentID: content.ID,
+		UserID:    editorID,
+		Action:    auditContentImported,
+		Changes:   auditDiff(plan.before, *content),
+		Notes:     fmt.Sprintf("Catalogue import %s: %d episodes created, %d updated", plan.location, len(plan.created), len(plan.updated)),
+	})
+}
+
+// catalogueRecord converts content to its catalogue form
+func catalogueRecord(content *Content) CatalogueContent {
+	record := CatalogueContent{
+		ID:                     content.ID,
+		Title:                  content.Title,
+		ContentType:            content.ContentType,
+		Language:               content.Language.Code,
+		YearReleased:           content.YearReleased,
+		Country:                content.Country,
+		Description:            content.Description,
+		PosterURL:              content.PosterURL,
+		IMDbRating:             content.IMDbRating,
+		AverageEpisodeDuration: content.AverageEpisodeDuration,
+		Status:                 content.Status,
+	}
+	for _, genre := range content.Genres {
+		record.Genres = append(record.Genres, genre.Name)
+	}
+	if len(record.Genres) == 0 {
+		record.Genres = splitGenreNames(content.Genre)
+	}
+
+	// Episodes are loaded in season and episode order
+	for _, episode := range content.Episodes {
+		if n := len(record.Seasons); n == 0 || record.Seasons[n-1].Number != episode.SeasonNumber {
+			record.Seasons = append(record.Seasons, CatalogueSeason{Number: episode.SeasonNumber})
+		}
+		season := &record.Seasons[len(record.Seasons)-1]
+		season.Episodes = append(season.Episodes, CatalogueEpisode{
+			Number:          episode.EpisodeNumber,
+			Title:           episode.Title,
+			DurationMinutes: episode.DurationMinutes,
+			Description:     episode.Description,
+		})
+	}
+	return record
+}
+
+// catalogueCSVRows lays a content out as spreadsheet rows in the order of
+// catalogueCSVColumns, one per episode
+func catalogueCSVRows(record CatalogueContent) [][]string {
+	base := []string{
+		record.Title,
+		record.ContentType,
+		record.Language,
+		strconv.Itoa(record.YearReleased),
+		record.Country,
+		strings.Join(record.Genres, ", "),
+		record.Description,
+		record.PosterURL,
+		strconv.FormatFloat(float64(record.IMDbRating), 'f', -1, 32),
+		strconv.Itoa(record.AverageEpisodeDuration),
+		record.Status,
+	}
+
+	var rows [][]string
+	for _, season := range record.Seasons {
+		for _, episode := range season.Episodes {
+			row := append(append([]string{}, base...),
+				strconv.Itoa(season.Number),
+				strconv.Itoa(episode.Number),
+				episode.Title,
+				strconv.Itoa(episode.DurationMinutes),
+				episode.Description,
+			)
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == 0 {
+		rows = append(rows, append(base, "", "", "", "", ""))
+	}
+	return rows
+}
@@ -0,0 +1,205 @@
+package content
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var catalogueLanguages = map[string]int{"es": 2, "fr": 3}
+
+func TestParseCatalogueCSV(t *testing.T) {
+	t.Run("GroupsEpisodesByContent", func(t *testing.T) {
+		file := `Title,Content_Type,Language,Year,Genres,Season_Number,Episode_Number,Episode_Title
+La casa de papel,series,es,2017,"Crime, Drama",1,1,Efectuar lo acordado
+La casa de papel,series,ES,2017,,1,2,Imprudencias letales
+La casa de papel,series,es,2017,,2,1,Se acabaron las máscaras
+Amélie,movie,fr,2001,Comedy,,,
+`
+		records, warnings, err := parseCatalogueCSV(strings.NewReader(file))
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+		require.Len(t, records, 2)
+
+		series := records[0]
+		assert.Equal(t, "La casa de papel", series.Title)
+		assert.Equal(t, 2017, series.YearReleased)
+		assert.Equal(t, []string{"Crime", "Drama"}, series.Genres)
+		assert.Equal(t, "line 2", series.source)
+		assert.Equal(t, []CatalogueSeason{
+			{Number: 1, Episodes: []CatalogueEpisode{{Number: 1, Title: "Efectuar lo acordado"}, {Number: 2, Title: "Imprudencias letales"}}},
+			{Number: 2, Episodes: []CatalogueEpisode{{Number: 1, Title: "Se acabaron las máscaras"}}},
+		}, series.Seasons)
+
+		movie := records[1]
+		assert.Equal(t, "Amélie", movie.Title)
+		assert.Equal(t, "line 5", movie.source)
+		assert.Empty(t, movie.Seasons)
+	})
+
+	t.Run("WarnsOnConflictsAndBadNumbers", func(t *testing.T) {
+		file := `title,content_type,language,year_released,country,episode_number,episode_duration_minutes
+Lupin,series,fr,2021,France,1,forty
+Lupin,series,fr,2021,Belgium,2,45
+`
+		records, warnings, err := parseCatalogueCSV(strings.NewReader(file))
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, "France", records[0].Country)
+		assert.Equal(t, -1, records[0].Seasons[0].Episodes[0].DurationMinutes)
+		assert.Equal(t, []CatalogueIssue{
+			{Location: "line 2", Field: "episode_duration_minutes", Message: `"forty" is not a whole number`},
+			{Location: "line 3", Field: "country", Message: `differs from line 2; keeping "France"`},
+		}, warnings)
+	})
+
+	t.Run("MissingColumn", func(t *testing.T) {
+		_, _, err := parseCatalogueCSV(strings.NewReader("title,content_type,year_released\n"))
+		assert.EqualError(t, err, "catalogue CSV is missing the language column")
+	})
+}
+
+func TestParseCatalogueJSON(t *testing.T) {
+	wrapped, err := parseCatalogueJSON(strings.NewReader(`{"contents":[{"title":"Roma","content_type":"movie","language":"es","year_released":2018}]}`))
+	require.NoError(t, err)
+	bare, err := parseCatalogueJSON(strings.NewReader(` [{"title":"Roma","content_type":"movie","language":"es","year_released":2018}]`))
+	require.NoError(t, err)
+
+	assert.Equal(t, wrapped, bare)
+	require.Len(t, bare, 1)
+	assert.Equal(t, "contents[0]", bare[0].source)
+
+	_, err = parseCatalogueJSON(strings.NewReader(`{"contents":`))
+	assert.Error(t, err)
+}
+
+func TestValidateCatalogue(t *testing.T) {
+	records := []CatalogueContent{
+		{Title: "Roma", ContentType: "movie", Language: "es", YearReleased: 2018, source: "contents[0]"},
+		{Title: "ROMA", ContentType: "movie", Language: "es", YearReleased: 2018, source: "contents[1]"},
+		{Title: "Dark", ContentType: "show", Language: "de", YearReleased: 2017, source: "contents[2]",
+			Seasons: []CatalogueSeason{{Number: 1, Episodes: []CatalogueEpisode{{Number: 1}, {Number: 1}, {Number: 0}}}}},
+	}
+
+	issues := validateCatalogue(records, catalogueLanguages)
+
+	assert.ElementsMatch(t, []CatalogueIssue{
+		{Location: "contents[1]", Message: "same title, year and language as contents[0]"},
+		{Location: "contents[2]", Field: "content_type", Message: "failed oneof=series movie podcast book"},
+		{Location: "contents[2]", Field: "seasons[0].episodes[2].number", Message: "failed min=1"},
+		{Location: "contents[2]", Field: "language", Message: `unknown language "de"`},
+		{Location: "contents[2]", Field: "seasons", Message: "season 1 episode 1 is listed twice"},
+	}, issues)
+}
+
+func TestPlanCatalogueChange(t *testing.T) {
+	record := CatalogueContent{
+		Title:        "La Casa de Papel",
+		ContentType:  "series",
+		Language:     "es",
+		YearReleased: 2017,
+		Genres:       []string{"crime", "Drama"},
+		Seasons: []CatalogueSeason{{Number: 1, Episodes: []CatalogueEpisode{
+			{Number: 1, Title: "Efectuar lo acordado"},
+			{Number: 2, Title: "Imprudencias letales", DurationMinutes: 41},
+			{Number: 3, Title: "Errar al disparar"},
+		}}},
+		source: "line 2",
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		plan := planCatalogueChange(nil, record, 2)
+
+		assert.Equal(t, catalogueCreate, plan.action)
+		assert.Equal(t, "la casa de papel", plan.content.TitleKey)
+		assert.Equal(t, 2, plan.content.LanguageID)
+		assert.Equal(t, 3, plan.content.TotalEpisodes)
+		assert.Equal(t, []string{"crime", "Drama"}, plan.genres)
+		assert.Len(t, plan.created, 3)
+		assert.Empty(t, plan.updated)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		current := &Content{
+			ID:            "c1",
+			Title:         "La casa de papel",
+			TitleKey:      "la casa de papel",
+			ContentType:   "series",
+			LanguageID:    2,
+			YearReleased:  2017,
+			Description:   "Un atraco.",
+			Genre:         "Crime, Drama",
+			TotalEpisodes: 2,
+			Episodes: []ContentEpisode{
+				{ID: "e1", ContentID: "c1", SeasonNumber: 1, EpisodeNumber: 1, Title: "Efectuar lo acordado"},
+				{ID: "e2", ContentID: "c1", SeasonNumber: 1, EpisodeNumber: 2, Title: "Imprudencias letales", DurationMinutes: 40},
+			},
+		}
+
+		plan := planCatalogueChange(current, record, 2)
+
+		assert.Equal(t, catalogueUpdate, plan.action)
+		assert.Equal(t, "La Casa de Papel", plan.content.Title)
+		assert.Equal(t, "Un atraco.", plan.content.Description, "blank values keep what is stored")
+		assert.Equal(t, 3, plan.content.TotalEpisodes)
+		assert.Nil(t, plan.genres, "genres differing only in case are unchanged")
+		require.Len(t, plan.created, 1)
+		assert.Equal(t, "c1", plan.created[0].ContentID)
+		assert.Equal(t, 3, plan.created[0].EpisodeNumber)
+		require.Len(t, plan.updated, 1)
+		assert.Equal(t, "e2", plan.updated[0].ID)
+		assert.Equal(t, 41, plan.updated[0].DurationMinutes)
+	})
+
+	t.Run("Unchanged", func(t *testing.T) {
+		current := &Content{ID: "c1", Title: "Roma", TitleKey: "roma", ContentType: "movie", LanguageID: 2, YearReleased: 2018, TotalEpisodes: 1}
+		plan := planCatalogueChange(current, CatalogueContent{Title: "Roma", ContentType: "movie", Language: "es", YearReleased: 2018}, 2)
+
+		assert.Equal(t, catalogueUnchanged, plan.action)
+	})
+}
+
+func TestCatalogueCSVRoundTrip(t *testing.T) {
+	content := &Content{
+		ID:           "c1",
+		Title:        "Lupin",
+		ContentType:  "series",
+		Language:     Language{Code: "fr"},
+		YearReleased: 2021,
+		Country:      "France",
+		IMDbRating:   7.5,
+		Status:       ContentStatusPublished,
+		Genres:       []Genre{{Name: "Crime"}, {Name: "Mystery"}},
+		Episodes: []ContentEpisode{
+			{SeasonNumber: 1, EpisodeNumber: 1, Title: "Chapitre 1", DurationMinutes: 47},
+			{SeasonNumber: 1, EpisodeNumber: 2, Title: "Chapitre 2", DurationMinutes: 45},
+			{SeasonNumber: 2, EpisodeNumber: 1, Title: "Chapitre 6", DurationMinutes: 50},
+		},
+	}
+	record := catalogueRecord(content)
+	require.Len(t, record.Seasons, 2)
+
+	var file bytes.Buffer
+	writer := csv.NewWriter(&file)
+	require.NoError(t, writer.Write(catalogueCSVColumns))
+	require.NoError(t, writer.WriteAll(catalogueCSVRows(record)))
+
+	records, warnings, err := parseCatalogueCSV(&file)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	require.Len(t, records, 1)
+
+	parsed := records[0]
+	assert.Equal(t, record.Title, parsed.Title)
+	assert.Equal(t, record.Language, parsed.Language)
+	assert.Equal(t, record.YearReleased, parsed.YearReleased)
+	assert.Equal(t, record.Country, parsed.Country)
+	assert.Equal(t, record.IMDbRating, parsed.IMDbRating)
+	assert.Equal(t, record.Genres, parsed.Genres)
+	assert.Equal(t, record.Seasons, parsed.Seasons)
+	assert.Empty(t, validateCatalogue(records, catalogueLanguages))
+}
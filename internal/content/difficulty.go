@@ -0,0 +1,621 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// CEFR bands difficulty scores map to. A score of 0-1 is A1, 1-2 A2 and so
+// on up to C2.
+var cefrLevels = []string{"A1", "A2", "B1", "B2", "C1", "C2"}
+
+const difficultyRecomputeInterval = 24 * time.Hour
+
+// Upper bounds of A1 to C1 for each transcript feature. Values above the
+// last bound are C2.
+var (
+	// Words a learner must know to understand 95% of the running text
+	vocabularyBounds = [5]float64{500, 1000, 2000, 4000, 8000}
+	// Words per sentence
+	sentenceLengthBounds = [5]float64{5, 7, 9, 12, 15}
+	// Words per minute while someone is speaking
+	speechRateBounds = [5]float64{100, 120, 140, 160, 180}
+)
+
+// How much each signal counts. Learner feedback is scaled down further when
+// there is little of it.
+const (
+	vocabularyWeight     = 0.50
+	sentenceLengthWeight = 0.15
+	speechRateWeight     = 0.15
+	ratingWeight         = 0.12
+	comprehensionWeight  = 0.08
+	// Ratings or progress entries at which feedback counts half
+	feedbackHalfWeight = 5
+)
+
+// Too little dialogue gives unreliable text features
+const (
+	minTokensForVocabulary = 50
+	minSpeechMsForRate     = 30 * 1000
+)
+
+// Languages written without spaces, whose transcripts are measured in
+// characters
+var ideographicLanguages = map[string]bool{"ja": true, "zh": true}
+
+// Characters per word in ideographic languages, to compare their sentence
+// lengths and speech rates with other languages
+const charactersPerWord = 2.0
+
+// DifficultyEstimate records how the difficulty of a content or one of its
+// episodes was estimated. EpisodeID is empty for the content as a whole.
+type DifficultyEstimate struct {
+	ID        string  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ContentID string  `json:"content_id" gorm:"type:uuid;not null;index"`
+	EpisodeID *string `json:"episode_id,omitempty" gorm:"type:uuid;index"`
+	Score     float64 `json:"score"` // 0-6, see Level
+	Level     string  `json:"level"` // A1-C2
+
+	// Transcript features
+	Tokens            int     `json:"tokens"`
+	VocabularyFor95   int     `json:"vocabulary_for_95"` // Frequency rank covering 95% of the words, 0 without a frequency list
+	AvgSentenceLength float64 `json:"avg_sentence_length"`
+	WordsPerMinute    float64 `json:"words_per_minute"`
+
+	// Learner feedback
+	RatingCount         int     `json:"rating_count"`
+	AvgDifficultyRating float64 `json:"avg_difficulty_rating"` // 1-5
+	ProgressCount       int     `json:"progress_count"`
+	AvgComprehension    float64 `json:"avg_comprehension"` // Percentage
+
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// ContentDifficulty is the estimate of a content and of each of its episodes
+type ContentDifficulty struct {
+	Content  *DifficultyEstimate  `json:"content"`
+	Episodes []DifficultyEstimate `json:"episodes"`
+}
+
+// CEFRLevel maps a difficulty score to its CEFR band
+func CEFRLevel(score float64) string {
+	index := int(math.Floor(score))
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(cefrLevels) {
+		index = len(cefrLevels) - 1
+	}
+	return cefrLevels[index]
+}
+
+// bandScore places a value on the 0-6 difficulty scale, interpolating
+// within the band whose bounds contain it
+func bandScore(value float64, bounds [5]float64) float64 {
+	lower := 0.0
+	for i, upper := range bounds {
+		if value <= upper {
+			return float64(i) + (value-lower)/(upper-lower)
+		}
+		lower = upper
+	}
+	// C2 has no upper bound; twice C1's bound is treated as the top
+	return math.Min(5+(value-lower)/lower, 5.99)
+}
+
+// dialogueLine is a transcript line or subtitle cue
+type dialogueLine struct {
+	EpisodeID string
+	Text      string
+	StartMs   int
+	EndMs     int
+}
+
+// dialogueFeatures are the measurable properties of an episode's dialogue
+type dialogueFeatures struct {
+	Words     float64 // Word count, estimated from characters for ideographic languages
+	Sentences int
+	SpeechMs  int
+	Ranks     []int // Frequency rank of each word, listSize+1 for words not on the list
+}
+
+func (f *dialogueFeatures) add(other dialogueFeatures) {
+	f.Words += other.Words
+	f.Sentences += other.Sentences
+	f.SpeechMs += other.SpeechMs
+	f.Ranks = append(f.Ranks, other.Ranks...)
+}
+
+// frequencyList holds the ranks of a language's words by their lower-case
+// form and lemma
+type frequencyList struct {
+	ranks map[string]int
+	size  int
+}
+
+// analyzeDialogue measures dialogue lines. Words missing from the frequency
+// list are counted as rarer than any listed word, except capitalised ones,
+// which are most likely names.
+func analyzeDialogue(languageCode string, lines []dialogueLine, list *frequencyList) dialogueFeatures {
+	var features dialogueFeatures
+	ideographic := ideographicLanguages[languageCode]
+
+	for _, line := range lines {
+		if line.EndMs > line.StartMs {
+			features.SpeechMs += line.EndMs - line.StartMs
+		}
+		features.Sentences += countSentenceEnds(line.Text)
+
+		if ideographic {
+			for _, r := range line.Text {
+				if unicode.IsLetter(r) {
+					features.Words += 1 / charactersPerWord
+				}
+			}
+			continue
+		}
+
+		for _, word := range dialogueWords(languageCode, line.Text) {
+			features.Words++
+			if list == nil {
+				continue
+			}
+			if rank, ok := list.ranks[strings.ToLower(word)]; ok {
+				features.Ranks = append(features.Ranks, rank)
+			} else if !unicode.IsUpper([]rune(word)[0]) {
+				features.Ranks = append(features.Ranks, list.size+1)
+			}
+		}
+	}
+
+	// Dialogue without end punctuation still has at least one sentence
+	if features.Sentences == 0 && features.Words > 0 {
+		features.Sentences = 1
+	}
+	return features
+}
+
+// dialogueWords splits text into words of letters and combining marks.
+// Hyphens and, in English, apostrophes inside words are kept, as in
+// vocabulary mining.
+func dialogueWords(languageCode, text string) []string {
+	joiners := "-‐"
+	if languageCode == "en" {
+		joiners += "'’"
+	}
+
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsMark(r) && !strings.ContainsRune(joiners, r)
+	})
+	words := fields[:0]
+	for _, field := range fields {
+		if word := strings.Trim(field, joiners); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// countSentenceEnds counts runs of sentence-ending punctuation
+func countSentenceEnds(text string) int {
+	count := 0
+	inRun := false
+	for _, r := range text {
+		switch r {
+		case '.', '!', '?', '…', '。', '！', '？':
+			if !inRun {
+				count++
+			}
+			inRun = true
+		default:
+			inRun = false
+		}
+	}
+	return count
+}
+
+// difficultyFeedback is what learners reported about a content or episode
+type difficultyFeedback struct {
+	RatingCount         int
+	AvgDifficultyRating float64
+	ProgressCount       int
+	AvgComprehension    float64
+}
+
+// estimateDifficulty combines dialogue features and learner feedback into a
+// score. It returns nil when there is nothing to go on.
+func estimateDifficulty(features dialogueFeatures, feedback difficultyFeedback) *DifficultyEstimate {
+	estimate := &DifficultyEstimate{
+		Tokens:              int(math.Round(features.Words)),
+		RatingCount:         feedback.RatingCount,
+		AvgDifficultyRating: feedback.AvgDifficultyRating,
+		ProgressCount:       feedback.ProgressCount,
+		AvgComprehension:    feedback.AvgComprehension,
+	}
+
+	var total, weights float64
+	add := func(score, weight float64) {
+		total += score * weight
+		weights += weight
+	}
+
+	if len(features.Ranks) >= minTokensForVocabulary {
+		ranks := append([]int(nil), features.Ranks...)
+		sort.Ints(ranks)
+		estimate.VocabularyFor95 = ranks[int(math.Ceil(0.95*float64(len(ranks))))-1]
+		add(bandScore(float64(estimate.VocabularyFor95), vocabularyBounds), vocabularyWeight)
+	}
+	if features.Sentences > 0 && features.Words > 0 {
+		estimate.AvgSentenceLength = features.Words / float64(features.Sentences)
+		add(bandScore(estimate.AvgSentenceLength, sentenceLengthBounds), sentenceLengthWeight)
+	}
+	if features.SpeechMs >= minSpeechMsForRate {
+		estimate.WordsPerMinute = features.Words / (float64(features.SpeechMs) / 60000)
+		add(bandScore(estimate.WordsPerMinute, speechRateBounds), speechRateWeight)
+	}
+
+	if feedback.RatingCount > 0 {
+		// 1 (very easy) to 5 (very hard) spread over the six bands
+		score := math.Min((feedback.AvgDifficultyRating-1)*1.5, 5.99)
+		add(score, ratingWeight*feedbackConfidence(feedback.RatingCount))
+	}
+	if feedback.ProgressCount > 0 {
+		// Learners who understand everything suggest easy content
+		score := math.Min((1-feedback.AvgComprehension/100)*6, 5.99)
+		add(score, comprehensionWeight*feedbackConfidence(feedback.ProgressCount))
+	}
+
+	if weights == 0 {
+		return nil
+	}
+	estimate.Score = math.Round(total/weights*100) / 100
+	estimate.Level = CEFRLevel(estimate.Score)
+	return estimate
+}
+
+func feedbackConfidence(count int) float64 {
+	return float64(count) / float64(count+feedbackHalfWeight)
+}
+
+// RecomputeDifficulty estimates the difficulty of a content and its episodes
+// and stores it on them
+func (s *Service) RecomputeDifficulty(ctx context.Context, contentID string) (*ContentDifficulty, error) {
+	var content Content
+	if err := s.db.Preload("Language").Where("id = ?", contentID).First(&content).Error; err != nil {
+		return nil, errors.New("content not found")
+	}
+
+	var episodes []ContentEpisode
+	if err := s.db.Where("content_id = ?", contentID).Order("season_number ASC, episode_number ASC").Find(&episodes).Error; err != nil {
+		return nil, err
+	}
+	episodeIDs := make([]string, len(episodes))
+	for i, episode := range episodes {
+		episodeIDs[i] = episode.ID
+	}
+
+	dialogue, err := s.loadDialogue(episodeIDs, content.LanguageID)
+	if err != nil {
+		return nil, err
+	}
+
+	var list *frequencyList
+	if !ideographicLanguages[content.Language.Code] {
+		list, err = s.loadFrequencyList(content.LanguageID, content.Language.Code, dialogue)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	contentFeedback, episodeFeedback, err := s.loadDifficultyFeedback(contentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := &ContentDifficulty{Episodes: make([]DifficultyEstimate, 0, len(episodes))}
+	var pooled dialogueFeatures
+	for i := range episodes {
+		features := analyzeDialogue(content.Language.Code, dialogue[episodes[i].ID], list)
+		pooled.add(features)
+
+		// Episodes share the content's ratings; comprehension is per episode
+		feedback := episodeFeedback[episodes[i].ID]
+		feedback.RatingCount, feedback.AvgDifficultyRating = contentFeedback.RatingCount, contentFeedback.AvgDifficultyRating
+
+		if estimate := estimateDifficulty(features, feedback); estimate != nil {
+			estimate.ContentID = contentID
+			estimate.EpisodeID = &episodes[i].ID
+			estimate.ComputedAt = now
+			result.Episodes = append(result.Episodes, *estimate)
+		}
+	}
+
+	result.Content = estimateDifficulty(pooled, contentFeedback)
+	if result.Content != nil {
+		result.Content.ContentID = contentID
+		result.Content.ComputedAt = now
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("content_id = ?", contentID).Delete(&DifficultyEstimate{}).Error; err != nil {
+			return err
+		}
+
+		if result.Content != nil {
+			if err := tx.Create(result.Content).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&Content{}).Where("id = ?", contentID).UpdateColumns(map[string]interface{}{
+				"difficulty_level":      result.Content.Level,
+				"difficulty_score":      result.Content.Score,
+				"difficulty_updated_at": now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range result.Episodes {
+			estimate := &result.Episodes[i]
+			if err := tx.Create(estimate).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&ContentEpisode{}).Where("id = ?", *estimate.EpisodeID).UpdateColumns(map[string]interface{}{
+				"difficulty_level": estimate.Level,
+				"difficulty_score": estimate.Score,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetContentDifficulty returns the latest difficulty estimates of a content
+// and its episodes
+func (s *Service) GetContentDifficulty(ctx context.Context, contentID, viewerID string) (*ContentDifficulty, error) {
+	if _, err := s.visibleContent(contentID, viewerID); err != nil {
+		return nil, err
+	}
+
+	var estimates []DifficultyEstimate
+	err := s.db.Table("difficulty_estimates").
+		Select("difficulty_estimates.*").
+		Joins("LEFT JOIN content_episodes ON content_episodes.id = difficulty_estimates.episode_id").
+		Where("difficulty_estimates.content_id = ?", contentID).
+		Order("content_episodes.season_number ASC NULLS FIRST, content_episodes.episode_number ASC NULLS FIRST").
+		Scan(&estimates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ContentDifficulty{Episodes: make([]DifficultyEstimate, 0, len(estimates))}
+	for i := range estimates {
+		if estimates[i].EpisodeID == nil {
+			result.Content = &estimates[i]
+		} else {
+			result.Episodes = append(result.Episodes, estimates[i])
+		}
+	}
+	return result, nil
+}
+
+// RecomputeAllDifficulties re-estimates every content. It returns how many
+// were updated.
+func (s *Service) RecomputeAllDifficulties(ctx context.Context) (int, error) {
+	updated := 0
+	lastID := ""
+	for {
+		var ids []string
+		err := s.db.Model(&Content{}).
+			Where("CAST(id AS text) > ?", lastID).
+			Order("CAST(id AS text) ASC").
+			Limit(50).
+			Pluck("CAST(id AS text)", &ids).Error
+		if err != nil {
+			return updated, err
+		}
+
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return updated, err
+			}
+			if _, err := s.RecomputeDifficulty(ctx, id); err != nil {
+				log.Printf("Failed to estimate difficulty of content %s: %v", id, err)
+				continue
+			}
+			updated++
+		}
+
+		if len(ids) < 50 {
+			return updated, nil
+		}
+		lastID = ids[len(ids)-1]
+	}
+}
+
+// StartDifficultyRecompute re-estimates content difficulty periodically until
+// ctx is done, picking up new transcripts, ratings and learner progress
+func (s *Service) StartDifficultyRecompute(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(difficultyRecomputeInterval)
+		defer ticker.Stop()
+
+		for {
+			updated, err := s.RecomputeAllDifficulties(ctx)
+			if err != nil {
+				log.Printf("Failed to recompute content difficulty: %v", err)
+			} else {
+				log.Printf("Estimated difficulty of %d contents", updated)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// loadDialogue returns the dialogue of each episode in playback order: its
+// transcript, or else its subtitles in the language of the content
+func (s *Service) loadDialogue(episodeIDs []string, languageID int) (map[string][]dialogueLine, error) {
+	dialogue := make(map[string][]dialogueLine)
+	if len(episodeIDs) == 0 {
+		return dialogue, nil
+	}
+
+	var rows []struct {
+		EpisodeID     string
+		Text          string
+		StartMs       int
+		EndMs         int
+		FromSubtitles bool
+	}
+	err := s.db.Table("transcript_segments").
+		Select("transcript_segments.episode_id, transcript_segments.text, transcript_segments.start_ms, transcript_segments.end_ms, transcript_segments.track_id IS NOT NULL AS from_subtitles").
+		Joins("LEFT JOIN subtitle_tracks ON subtitle_tracks.id = transcript_segments.track_id").
+		Where("transcript_segments.episode_id IN ?", episodeIDs).
+		Where("transcript_segments.track_id IS NULL OR subtitle_tracks.language_id = ?", languageID).
+		Order("transcript_segments.episode_id, transcript_segments.position ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	transcribed := make(map[string]bool)
+	for _, row := range rows {
+		if !row.FromSubtitles {
+			transcribed[row.EpisodeID] = true
+		}
+	}
+	for _, row := range rows {
+		if row.FromSubtitles && transcribed[row.EpisodeID] {
+			continue
+		}
+		dialogue[row.EpisodeID] = append(dialogue[row.EpisodeID], dialogueLine{
+			EpisodeID: row.EpisodeID,
+			Text:      row.Text,
+			StartMs:   row.StartMs,
+			EndMs:     row.EndMs,
+		})
+	}
+	return dialogue, nil
+}
+
+// loadFrequencyList looks up the dialogue's words in the language's frequency
+// list. It returns nil when the language has no list.
+func (s *Service) loadFrequencyList(languageID int, languageCode string, dialogue map[string][]dialogueLine) (*frequencyList, error) {
+	if !s.db.Migrator().HasTable("word_frequencies") {
+		return nil, nil
+	}
+
+	var size int
+	if err := s.db.Table("word_frequencies").Where("language_id = ?", languageID).Select("COALESCE(MAX(rank), 0)").Scan(&size).Error; err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool)
+	words := make([]string, 0)
+	for _, lines := range dialogue {
+		for _, line := range lines {
+			for _, word := range dialogueWords(languageCode, line.Text) {
+				lower := strings.ToLower(word)
+				if !seen[lower] {
+					seen[lower] = true
+					words = append(words, lower)
+				}
+			}
+		}
+	}
+
+	list := &frequencyList{ranks: make(map[string]int, len(words)), size: size}
+	for start := 0; start < len(words); start += 1000 {
+		batch := words[start:min(start+1000, len(words))]
+
+		var entries []struct {
+			Word  string
+			Lemma string
+			Rank  int
+		}
+		err := s.db.Table("word_frequencies").
+			Select("LOWER(word) AS word, lemma, rank").
+			Where("language_id = ? AND (LOWER(word) IN ? OR lemma IN ?)", languageID, batch, batch).
+			Scan(&entries).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			for _, key := range []string{entry.Word, entry.Lemma} {
+				if rank, ok := list.ranks[key]; !ok || entry.Rank < rank {
+					list.ranks[key] = entry.Rank
+				}
+			}
+		}
+	}
+	return list, nil
+}
+
+// loadDifficultyFeedback aggregates the difficulty ratings of a content and
+// the comprehension learners logged for it, overall and per episode
+func (s *Service) loadDifficultyFeedback(contentID string) (difficultyFeedback, map[string]difficultyFeedback, error) {
+	var content difficultyFeedback
+	err := s.db.Model(&ContentRating{}).
+		Select("COUNT(*) AS rating_count, COALESCE(AVG(difficulty_rating), 0) AS avg_difficulty_rating").
+		Where("content_id = ? AND difficulty_rating > 0", contentID).
+		Scan(&content).Error
+	if err != nil {
+		return content, nil, err
+	}
+
+	episodes := make(map[string]difficultyFeedback)
+	if !s.db.Migrator().HasTable("user_progress") {
+		return content, episodes, nil
+	}
+
+	var rows []struct {
+		EpisodeID        *string
+		ProgressCount    int
+		AvgComprehension float64
+	}
+	err = s.db.Table("user_progress").
+		Select("episode_id, COUNT(*) AS progress_count, AVG(comprehension_percentage) AS avg_comprehension").
+		Where("content_id = ? AND comprehension_percentage > 0", contentID).
+		Group("episode_id").
+		Scan(&rows).Error
+	if err != nil {
+		return content, nil, err
+	}
+
+	var total float64
+	for _, row := range rows {
+		content.ProgressCount += row.ProgressCount
+		total += row.AvgComprehension * float64(row.ProgressCount)
+		if row.EpisodeID != nil {
+			episodes[*row.EpisodeID] = difficultyFeedback{ProgressCount: row.ProgressCount, AvgComprehension: row.AvgComprehension}
+		}
+	}
+	if content.ProgressCount > 0 {
+		content.AvgComprehension = total / float64(content.ProgressCount)
+	}
+	return content, episodes, nil
+}
@@ -0,0 +1,170 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCEFRLevel(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{-1, "A1"},
+		{0.5, "A1"},
+		{1, "A2"},
+		{2.5, "B1"},
+		{3.99, "B2"},
+		{4, "C1"},
+		{5.99, "C2"},
+		{9, "C2"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CEFRLevel(tt.score), "score %v", tt.score)
+	}
+}
+
+func TestBandScore(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  float64
+	}{
+		{0, 0},
+		{250, 0.5},
+		{500, 1},
+		{1500, 2.5},
+		{8000, 5},
+		{12000, 5.5},  // Above C1, up to twice its bound
+		{20000, 5.99}, // Capped below 6
+	}
+
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, bandScore(tt.value, vocabularyBounds), 1e-9, "value %v", tt.value)
+	}
+}
+
+func TestCountSentenceEnds(t *testing.T) {
+	assert.Equal(t, 0, countSentenceEnds(""))
+	assert.Equal(t, 0, countSentenceEnds("no punctuation"))
+	assert.Equal(t, 2, countSentenceEnds("Hi. How are you?!"))
+	assert.Equal(t, 1, countSentenceEnds("Wait..."))
+	assert.Equal(t, 2, countSentenceEnds("こんにちは。元気？"))
+}
+
+func TestDialogueWords(t *testing.T) {
+	assert.Equal(t, []string{"Don't", "go", "it's", "well-known", "OK"}, dialogueWords("en", "Don't go—it's well-known, 'OK'?"))
+	// Apostrophes only join words in English
+	assert.Equal(t, []string{"L", "homme", "arrive"}, dialogueWords("fr", "L'homme arrive!"))
+	assert.Equal(t, []string{"Qué", "año"}, dialogueWords("es", "¿Qué año?"))
+	assert.Empty(t, dialogueWords("en", "... 42 --"))
+}
+
+func TestAnalyzeDialogue(t *testing.T) {
+	list := &frequencyList{ranks: map[string]int{"the": 1, "cat": 50, "sat": 300}, size: 1000}
+
+	t.Run("Alphabetic", func(t *testing.T) {
+		features := analyzeDialogue("en", []dialogueLine{
+			{Text: "The cat sat. Bob ran!", StartMs: 0, EndMs: 2000},
+			{Text: "no end", StartMs: 3000, EndMs: 2500}, // Bad timing adds no speech
+		}, list)
+
+		assert.Equal(t, 7.0, features.Words)
+		assert.Equal(t, 2, features.Sentences)
+		assert.Equal(t, 2000, features.SpeechMs)
+		// "Bob" is capitalised and unlisted, so most likely a name and skipped
+		assert.Equal(t, []int{1, 50, 300, 1001, 1001, 1001}, features.Ranks)
+	})
+
+	t.Run("WithoutFrequencyList", func(t *testing.T) {
+		features := analyzeDialogue("en", []dialogueLine{{Text: "hello there"}}, nil)
+		assert.Equal(t, 2.0, features.Words)
+		assert.Equal(t, 1, features.Sentences) // No end punctuation still counts as one
+		assert.Empty(t, features.Ranks)
+	})
+
+	t.Run("Ideographic", func(t *testing.T) {
+		features := analyzeDialogue("ja", []dialogueLine{{Text: "こんにちは。", StartMs: 0, EndMs: 1000}}, list)
+		assert.Equal(t, 2.5, features.Words) // Five characters at two per word
+		assert.Equal(t, 1, features.Sentences)
+		assert.Empty(t, features.Ranks)
+	})
+}
+
+func TestEstimateDifficulty(t *testing.T) {
+	ranks := func(common, rare int) []int {
+		r := make([]int, 0, common+rare)
+		for i := 0; i < common; i++ {
+			r = append(r, 100)
+		}
+		for i := 0; i < rare; i++ {
+			r = append(r, 5000)
+		}
+		return r
+	}
+
+	t.Run("NothingToGoOn", func(t *testing.T) {
+		assert.Nil(t, estimateDifficulty(dialogueFeatures{}, difficultyFeedback{}))
+	})
+
+	t.Run("SentenceLengthOnly", func(t *testing.T) {
+		// Too few ranked words and too little speech for the other features
+		estimate := estimateDifficulty(dialogueFeatures{Words: 70, Sentences: 10, SpeechMs: 10000, Ranks: ranks(49, 0)}, difficultyFeedback{})
+		require.NotNil(t, estimate)
+		assert.Equal(t, 70, estimate.Tokens)
+		assert.Equal(t, 0, estimate.VocabularyFor95)
+		assert.Equal(t, 7.0, estimate.AvgSentenceLength)
+		assert.Equal(t, 0.0, estimate.WordsPerMinute)
+		assert.Equal(t, 2.0, estimate.Score)
+		assert.Equal(t, "B1", estimate.Level)
+	})
+
+	t.Run("TranscriptFeatures", func(t *testing.T) {
+		features := dialogueFeatures{Words: 100, Sentences: 10, SpeechMs: 60000, Ranks: ranks(95, 5)}
+		estimate := estimateDifficulty(features, difficultyFeedback{})
+		require.NotNil(t, estimate)
+		assert.Equal(t, 100, estimate.VocabularyFor95)
+		assert.Equal(t, 10.0, estimate.AvgSentenceLength)
+		assert.Equal(t, 100.0, estimate.WordsPerMinute)
+		// (0.2*0.50 + 3.33*0.15 + 1*0.15) / 0.80
+		assert.Equal(t, 0.94, estimate.Score)
+		assert.Equal(t, "A1", estimate.Level)
+
+		// One more rare word pushes the 95th percentile onto it
+		features.Ranks = ranks(94, 6)
+		assert.Equal(t, 5000, estimateDifficulty(features, difficultyFeedback{}).VocabularyFor95)
+	})
+
+	t.Run("FeedbackWeighsByConfidence", func(t *testing.T) {
+		features := dialogueFeatures{Words: 70, Sentences: 10}
+
+		few := estimateDifficulty(features, difficultyFeedback{RatingCount: 1, AvgDifficultyRating: 5})
+		many := estimateDifficulty(features, difficultyFeedback{RatingCount: 100, AvgDifficultyRating: 5})
+		require.NotNil(t, few)
+		require.NotNil(t, many)
+		// (2*0.15 + 5.99*0.12/6) / (0.15 + 0.12/6)
+		assert.Equal(t, 2.47, few.Score)
+		assert.Greater(t, many.Score, few.Score)
+		assert.Equal(t, 1, few.RatingCount)
+	})
+
+	t.Run("FeedbackOnly", func(t *testing.T) {
+		hard := estimateDifficulty(dialogueFeatures{}, difficultyFeedback{RatingCount: 5, AvgDifficultyRating: 5})
+		require.NotNil(t, hard)
+		assert.Equal(t, 5.99, hard.Score)
+		assert.Equal(t, "C2", hard.Level)
+
+		understood := estimateDifficulty(dialogueFeatures{}, difficultyFeedback{ProgressCount: 5, AvgComprehension: 100})
+		require.NotNil(t, understood)
+		assert.Equal(t, 0.0, understood.Score)
+		assert.Equal(t, "A1", understood.Level)
+	})
+}
+
+func TestFeedbackConfidence(t *testing.T) {
+	assert.Equal(t, 0.0, feedbackConfidence(0))
+	assert.Equal(t, 0.5, feedbackConfidence(feedbackHalfWeight))
+	assert.InDelta(t, 0.95, feedbackConfidence(95), 1e-9)
+}
@@ -0,0 +1,153 @@
+package content
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GenreCount is a genre with how many contents are in it
+type GenreCount struct {
+	Genre
+	ContentCount int64 `json:"content_count"`
+}
+
+// genreSlug normalises a genre name so "Sci-Fi", "sci fi" and "SCI_FI" are
+// the same genre
+func genreSlug(name string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			slug.WriteRune(r)
+			dash = false
+		case !dash && slug.Len() > 0:
+			slug.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(slug.String(), "-")
+}
+
+// splitGenreNames reads the comma, slash or pipe separated genres of a free
+// text genre string, keeping the first spelling of each
+func splitGenreNames(genre string) []string {
+	fields := strings.FieldsFunc(genre, func(r rune) bool { return r == ',' || r == '/' || r == '|' })
+	return uniqueGenreNames(fields)
+}
+
+func uniqueGenreNames(names []string) []string {
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		slug := genreSlug(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		unique = append(unique, name)
+	}
+	return unique
+}
+
+// requestGenres returns the genres a create or update request asks for
+func requestGenres(genres []string, genre string) []string {
+	if len(genres) > 0 {
+		return uniqueGenreNames(genres)
+	}
+	return splitGenreNames(genre)
+}
+
+// resolveGenres finds the genres with the given names, adding new ones to
+// the taxonomy
+func resolveGenres(tx *gorm.DB, names []string) ([]Genre, error) {
+	if len(names) == 0 {
+		return []Genre{}, nil
+	}
+
+	slugs := make([]string, len(names))
+	genres := make([]Genre, len(names))
+	for i, name := range names {
+		slugs[i] = genreSlug(name)
+		genres[i] = Genre{Slug: slugs[i], Name: name}
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&genres).Error; err != nil {
+		return nil, err
+	}
+
+	var existing []Genre
+	if err := tx.Where("slug IN ?", slugs).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	// Keep the order they were given in
+	bySlug := make(map[string]Genre, len(existing))
+	for _, genre := range existing {
+		bySlug[genre.Slug] = genre
+	}
+	resolved := make([]Genre, 0, len(slugs))
+	for _, slug := range slugs {
+		resolved = append(resolved, bySlug[slug])
+	}
+	return resolved, nil
+}
+
+// setContentGenres replaces a content's genres and keeps its genre string in
+// step for clients that read it
+func setContentGenres(tx *gorm.DB, content *Content, names []string) error {
+	genres, err := resolveGenres(tx, names)
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(content).Association("Genres").Replace(genres); err != nil {
+		return err
+	}
+
+	displayNames := make([]string, len(genres))
+	for i, genre := range genres {
+		displayNames[i] = genre.Name
+	}
+	content.Genres = genres
+	content.Genre = strings.Join(displayNames, ", ")
+	return tx.Model(content).UpdateColumn("genre", content.Genre).Error
+}
+
+// GetGenres lists the genre taxonomy with how many contents each has
+func (s *Service) GetGenres(ctx context.Context) ([]GenreCount, error) {
+	var genres []GenreCount
+	err := s.db.Model(&Genre{}).
+		Select("genres.*, COUNT(contents.id) AS content_count").
+		Joins("LEFT JOIN content_genres ON content_genres.genre_id = genres.id").
+		Joins("LEFT JOIN contents ON contents.id = content_genres.content_id AND contents.deleted_at IS NULL AND contents.status = 'published'").
+		Group("genres.id").
+		Order("genres.name ASC").
+		Scan(&genres).Error
+	return genres, err
+}
+
+// BackfillGenres moves the free text genres of content created before the
+// taxonomy into it
+func (s *Service) BackfillGenres(ctx context.Context) (int, error) {
+	var contents []Content
+	err := s.db.Where("genre <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM content_genres WHERE content_genres.content_id = contents.id)").
+		Find(&contents).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range contents {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			return setContentGenres(tx, &contents[i], splitGenreNames(contents[i].Genre))
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(contents), nil
+}
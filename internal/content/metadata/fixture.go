@@ -0,0 +1,78 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Fixture serves titles recorded as JSON files, one Title per file, so
+// imports can be developed and tested without the network
+type Fixture struct {
+	titles []Title
+}
+
+// NewFixture loads every .json file in dir
+func NewFixture(dir string) (*Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	fixture := &Fixture{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var title Title
+		if err := json.Unmarshal(data, &title); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		fixture.titles = append(fixture.titles, title)
+	}
+	return fixture, nil
+}
+
+// NewFixtureTitles serves the given titles
+func NewFixtureTitles(titles ...Title) *Fixture {
+	return &Fixture{titles: titles}
+}
+
+func (f *Fixture) Name() string {
+	return ProviderFixture
+}
+
+func (f *Fixture) Search(ctx context.Context, query string, year int, kind string) ([]Summary, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	var exact, partial []Summary
+	for _, title := range f.titles {
+		if (year > 0 && title.Year != year) || (kind != "" && title.Kind != kind) {
+			continue
+		}
+		name, original := strings.ToLower(title.Title), strings.ToLower(title.OriginalTitle)
+		switch {
+		case name == query || original == query:
+			exact = append(exact, title.Summary)
+		case strings.Contains(name, query) || strings.Contains(original, query):
+			partial = append(partial, title.Summary)
+		}
+	}
+	return append(exact, partial...), nil
+}
+
+func (f *Fixture) Title(ctx context.Context, externalID string) (*Title, error) {
+	for _, title := range f.titles {
+		if title.ExternalID == externalID {
+			found := title
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
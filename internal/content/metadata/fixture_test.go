@@ -0,0 +1,40 @@
+package metadata
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixture(t *testing.T) {
+	fixture, err := NewFixture("testdata/titles")
+	require.NoError(t, err)
+
+	t.Run("SearchesTitlesAndOriginalTitles", func(t *testing.T) {
+		results, err := fixture.Search(context.Background(), "la casa de papel", 0, "")
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "tv/71446", results[0].ExternalID)
+	})
+
+	t.Run("FiltersByYearAndKind", func(t *testing.T) {
+		results, err := fixture.Search(context.Background(), "roma", 2017, "")
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		results, err = fixture.Search(context.Background(), "roma", 2018, KindSeries)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("Title", func(t *testing.T) {
+		title, err := fixture.Title(context.Background(), "tv/71446")
+		require.NoError(t, err)
+		assert.Len(t, title.Seasons, 2)
+
+		_, err = fixture.Title(context.Background(), "tv/1")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
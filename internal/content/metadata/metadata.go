@@ -0,0 +1,93 @@
+// Package metadata looks up catalogue metadata (year, country, genres,
+// poster, rating, seasons and episodes) of films and series in external
+// databases such as TMDB.
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Raylynd6299/babel/internal/shared/config"
+)
+
+// Providers selectable with METADATA_PROVIDER
+const (
+	ProviderNone    = "none"
+	ProviderTMDB    = "tmdb"
+	ProviderFixture = "fixture"
+)
+
+// Kinds of title
+const (
+	KindMovie  = "movie"
+	KindSeries = "series"
+)
+
+var ErrNotFound = errors.New("title not found")
+
+// Provider is an external metadata database
+type Provider interface {
+	// Name identifies the provider on imported content
+	Name() string
+	// Search finds titles by name, best match first. Year and kind narrow
+	// the search when set.
+	Search(ctx context.Context, query string, year int, kind string) ([]Summary, error)
+	// Title fetches a title with its seasons and episodes by its ID at the
+	// provider, as returned by Search
+	Title(ctx context.Context, externalID string) (*Title, error)
+}
+
+// Summary is a search result
+type Summary struct {
+	ExternalID       string  `json:"external_id"`
+	Kind             string  `json:"kind"`
+	Title            string  `json:"title"`
+	OriginalTitle    string  `json:"original_title"`
+	OriginalLanguage string  `json:"original_language"` // ISO 639-1
+	Year             int     `json:"year"`
+	Overview         string  `json:"overview"`
+	PosterURL        string  `json:"poster_url"`
+	Rating           float32 `json:"rating"` // 0-10
+}
+
+// Title is a film or series as the provider describes it
+type Title struct {
+	Summary
+	Countries      []string `json:"countries"`
+	Genres         []string `json:"genres"`
+	RuntimeMinutes int      `json:"runtime_minutes"` // Of the film, or of a typical episode
+	IMDbID         string   `json:"imdb_id,omitempty"`
+	Seasons        []Season `json:"seasons,omitempty"`
+}
+
+type Season struct {
+	Number   int       `json:"number"`
+	Episodes []Episode `json:"episodes"`
+}
+
+type Episode struct {
+	Number         int    `json:"number"`
+	Title          string `json:"title"`
+	Overview       string `json:"overview"`
+	RuntimeMinutes int    `json:"runtime_minutes"`
+	AirDate        string `json:"air_date,omitempty"` // YYYY-MM-DD
+}
+
+// New builds the provider selected in the configuration. It returns nil when
+// metadata import is turned off.
+func New(cfg *config.Config) (Provider, error) {
+	switch cfg.MetadataProvider {
+	case ProviderNone, "":
+		return nil, nil
+	case ProviderTMDB:
+		if cfg.TMDBAPIKey == "" {
+			return nil, errors.New("TMDB_API_KEY is required for the tmdb metadata provider")
+		}
+		return NewTMDB(cfg.TMDBBaseURL, cfg.TMDBAPIKey, cfg.MetadataLanguage), nil
+	case ProviderFixture:
+		return NewFixture(cfg.MetadataFixturePath)
+	default:
+		return nil, fmt.Errorf("unknown metadata provider %q", cfg.MetadataProvider)
+	}
+}
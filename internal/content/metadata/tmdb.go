@@ -0,0 +1,264 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTMDBBaseURL = "https://api.themoviedb.org/3"
+	tmdbImageBaseURL   = "https://image.tmdb.org/t/p/w500"
+	// Series with more seasons are cut short rather than making hundreds of
+	// requests
+	tmdbMaxSeasons = 50
+)
+
+// TMDB looks titles up in The Movie Database. External IDs are "movie/<id>"
+// or "tv/<id>".
+type TMDB struct {
+	baseURL  string
+	apiKey   string
+	language string
+	client   *http.Client
+}
+
+// NewTMDB creates a TMDB client. apiKey is a v3 API key or a v4 read access
+// token; language picks the language of titles and overviews, e.g. "en-US".
+func NewTMDB(baseURL, apiKey, language string) *TMDB {
+	if baseURL == "" {
+		baseURL = DefaultTMDBBaseURL
+	}
+	return &TMDB{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		apiKey:   apiKey,
+		language: language,
+		client:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (t *TMDB) Name() string {
+	return ProviderTMDB
+}
+
+type tmdbResult struct {
+	ID               int     `json:"id"`
+	MediaType        string  `json:"media_type"`
+	Title            string  `json:"title"` // Films
+	OriginalTitle    string  `json:"original_title"`
+	ReleaseDate      string  `json:"release_date"`
+	Name             string  `json:"name"` // Series
+	OriginalName     string  `json:"original_name"`
+	FirstAirDate     string  `json:"first_air_date"`
+	OriginalLanguage string  `json:"original_language"`
+	Overview         string  `json:"overview"`
+	PosterPath       string  `json:"poster_path"`
+	VoteAverage      float32 `json:"vote_average"`
+	Runtime          int     `json:"runtime"`
+	EpisodeRunTime   []int   `json:"episode_run_time"`
+	Genres           []struct {
+		Name string `json:"name"`
+	} `json:"genres"`
+	ProductionCountries []struct {
+		Name string `json:"name"`
+	} `json:"production_countries"`
+	ExternalIDs struct {
+		IMDbID string `json:"imdb_id"`
+	} `json:"external_ids"`
+	Seasons []struct {
+		SeasonNumber int `json:"season_number"`
+	} `json:"seasons"`
+}
+
+type tmdbSeason struct {
+	SeasonNumber int `json:"season_number"`
+	Episodes     []struct {
+		EpisodeNumber int    `json:"episode_number"`
+		Name          string `json:"name"`
+		Overview      string `json:"overview"`
+		Runtime       int    `json:"runtime"`
+		AirDate       string `json:"air_date"`
+	} `json:"episodes"`
+}
+
+func (t *TMDB) Search(ctx context.Context, query string, year int, kind string) ([]Summary, error) {
+	params := url.Values{"query": {query}}
+	path := "/search/multi"
+	switch kind {
+	case KindMovie:
+		path = "/search/movie"
+		if year > 0 {
+			params.Set("year", strconv.Itoa(year))
+		}
+	case KindSeries:
+		path = "/search/tv"
+		if year > 0 {
+			params.Set("first_air_date_year", strconv.Itoa(year))
+		}
+	}
+
+	var response struct {
+		Results []tmdbResult `json:"results"`
+	}
+	if err := t.get(ctx, path, params, &response); err != nil {
+		return nil, err
+	}
+
+	summaries := make([]Summary, 0, len(response.Results))
+	for _, result := range response.Results {
+		mediaType := result.MediaType
+		if mediaType == "" {
+			mediaType = map[string]string{KindMovie: "movie", KindSeries: "tv"}[kind]
+		}
+		if mediaType != "movie" && mediaType != "tv" {
+			continue
+		}
+		summary := result.summary(mediaType)
+		// Multi search cannot filter by year
+		if kind == "" && year > 0 && summary.Year != 0 && summary.Year != year {
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func (t *TMDB) Title(ctx context.Context, externalID string) (*Title, error) {
+	mediaType, id, ok := strings.Cut(externalID, "/")
+	if !ok || (mediaType != "movie" && mediaType != "tv") || id == "" {
+		return nil, fmt.Errorf("invalid TMDB ID %q, expected movie/<id> or tv/<id>", externalID)
+	}
+
+	var result tmdbResult
+	params := url.Values{"append_to_response": {"external_ids"}}
+	if err := t.get(ctx, "/"+mediaType+"/"+url.PathEscape(id), params, &result); err != nil {
+		return nil, err
+	}
+
+	title := &Title{
+		Summary: result.summary(mediaType),
+		IMDbID:  result.ExternalIDs.IMDbID,
+	}
+	for _, genre := range result.Genres {
+		title.Genres = append(title.Genres, genre.Name)
+	}
+	for _, country := range result.ProductionCountries {
+		title.Countries = append(title.Countries, country.Name)
+	}
+
+	title.RuntimeMinutes = result.Runtime
+	if mediaType == "movie" {
+		return title, nil
+	}
+	if len(result.EpisodeRunTime) > 0 {
+		title.RuntimeMinutes = result.EpisodeRunTime[0]
+	}
+
+	for _, s := range result.Seasons {
+		// Season 0 holds specials
+		if s.SeasonNumber <= 0 {
+			continue
+		}
+		if len(title.Seasons) == tmdbMaxSeasons {
+			break
+		}
+
+		var season tmdbSeason
+		path := fmt.Sprintf("/tv/%s/season/%d", url.PathEscape(id), s.SeasonNumber)
+		if err := t.get(ctx, path, url.Values{}, &season); err != nil {
+			return nil, fmt.Errorf("season %d: %w", s.SeasonNumber, err)
+		}
+
+		converted := Season{Number: season.SeasonNumber}
+		for _, episode := range season.Episodes {
+			runtime := episode.Runtime
+			if runtime == 0 {
+				runtime = title.RuntimeMinutes
+			}
+			converted.Episodes = append(converted.Episodes, Episode{
+				Number:         episode.EpisodeNumber,
+				Title:          episode.Name,
+				Overview:       episode.Overview,
+				RuntimeMinutes: runtime,
+				AirDate:        episode.AirDate,
+			})
+		}
+		title.Seasons = append(title.Seasons, converted)
+	}
+	return title, nil
+}
+
+func (r tmdbResult) summary(mediaType string) Summary {
+	summary := Summary{
+		ExternalID:       mediaType + "/" + strconv.Itoa(r.ID),
+		Kind:             KindMovie,
+		Title:            r.Title,
+		OriginalTitle:    r.OriginalTitle,
+		OriginalLanguage: r.OriginalLanguage,
+		Year:             yearOf(r.ReleaseDate),
+		Overview:         r.Overview,
+		Rating:           r.VoteAverage,
+	}
+	if mediaType == "tv" {
+		summary.Kind = KindSeries
+		summary.Title = r.Name
+		summary.OriginalTitle = r.OriginalName
+		summary.Year = yearOf(r.FirstAirDate)
+	}
+	if r.PosterPath != "" {
+		summary.PosterURL = tmdbImageBaseURL + r.PosterPath
+	}
+	return summary
+}
+
+// yearOf reads the year of a YYYY-MM-DD date, 0 when unknown
+func yearOf(date string) int {
+	if len(date) < 4 {
+		return 0
+	}
+	year, _ := strconv.Atoi(date[:4])
+	return year
+}
+
+func (t *TMDB) get(ctx context.Context, path string, params url.Values, target interface{}) error {
+	if t.language != "" {
+		params.Set("language", t.language)
+	}
+	// v4 read access tokens are JWTs and go in a header; v3 keys are a
+	// query parameter
+	bearer := strings.Count(t.apiKey, ".") == 2
+	if !bearer {
+		params.Set("api_key", t.apiKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("TMDB request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("TMDB returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
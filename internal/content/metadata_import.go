@@ -0,0 +1,362 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Raylynd6299/babel/internal/content/metadata"
+)
+
+// Imported metadata is refreshed once it is this old, picking up new
+// episodes and rating changes
+const (
+	metadataStaleAfter      = 7 * 24 * time.Hour
+	metadataRefreshInterval = 24 * time.Hour
+)
+
+var errNoMetadataProvider = errors.New("no metadata provider is configured")
+
+type ImportMetadataRequest struct {
+	ExternalID  string `json:"external_id"`                                                // ID at the provider, as returned by metadata search
+	Title       string `json:"title" validate:"required_without_all=ExternalID ContentID"` // Imports the best match when no ID is given
+	Year        int    `json:"year" validate:"omitempty,min=1900,max=2100"`
+	ContentType string `json:"content_type" validate:"omitempty,oneof=movie series"`
+	LanguageID  int    `json:"language_id"` // Defaults to the title's original language
+	ContentID   string `json:"content_id"`  // Fill in this content instead of creating one
+}
+
+// MetadataImportResult is the content an import created or updated
+type MetadataImportResult struct {
+	Content         *Content `json:"content"`
+	Created         bool     `json:"created"`
+	AlreadyImported bool     `json:"already_imported"` // Another import of the same title was returned unchanged
+	EpisodesCreated int      `json:"episodes_created"`
+	EpisodesUpdated int      `json:"episodes_updated"`
+}
+
+// SearchMetadata looks titles up at the metadata provider
+func (s *Service) SearchMetadata(ctx context.Context, query string, year int, kind string) ([]metadata.Summary, error) {
+	if s.metadata == nil {
+		return nil, errNoMetadataProvider
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, errors.New("search query required")
+	}
+	return s.metadata.Search(ctx, strings.TrimSpace(query), year, kind)
+}
+
+// ImportMetadata fills in content, its seasons and its episodes from the
+// metadata provider, creating the content unless req.ContentID is set
+func (s *Service) ImportMetadata(ctx context.Context, userID string, req ImportMetadataRequest) (*MetadataImportResult, error) {
+	if s.metadata == nil {
+		return nil, errNoMetadataProvider
+	}
+
+	var content *Content
+	if req.ContentID != "" {
+		var existing Content
+		if err := s.db.Where("id = ?", req.ContentID).First(&existing).Error; err != nil {
+			return nil, errors.New("content not found")
+		}
+		if existing.CreatedBy != userID {
+			return nil, errors.New("unauthorized to update this content")
+		}
+		content = &existing
+
+		if req.ExternalID == "" && req.Title == "" {
+			req.Title, req.Year, req.ContentType = existing.Title, existing.YearReleased, existing.ContentType
+		}
+	}
+
+	externalID := req.ExternalID
+	if externalID == "" {
+		matches, err := s.metadata.Search(ctx, req.Title, req.Year, req.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no title matching %q found", req.Title)
+		}
+		externalID = matches[0].ExternalID
+	}
+
+	title, err := s.metadata.Title(ctx, externalID)
+	if err != nil {
+		if errors.Is(err, metadata.ErrNotFound) {
+			return nil, fmt.Errorf("title %s not found", externalID)
+		}
+		return nil, err
+	}
+
+	if content == nil {
+		var existing Content
+		err := s.db.Where("external_provider = ? AND external_id = ?", s.metadata.Name(), title.ExternalID).First(&existing).Error
+		if err == nil {
+			return &MetadataImportResult{Content: &existing, AlreadyImported: true}, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		languageID := req.LanguageID
+		if languageID == 0 {
+			var language Language
+			if err := s.db.Where("code = ?", title.OriginalLanguage).First(&language).Error; err != nil {
+				return nil, fmt.Errorf("language_id required: %q is not a supported language", title.OriginalLanguage)
+			}
+			languageID = language.ID
+		}
+		content = &Content{LanguageID: languageID, CreatedBy: userID, Status: ContentStatusDraft}
+	}
+
+	return s.saveMetadata(content, title, userID)
+}
+
+// RefreshMetadata updates imported content from its provider
+func (s *Service) RefreshMetadata(ctx context.Context, contentID string) (*MetadataImportResult, error) {
+	if s.metadata == nil {
+		return nil, errNoMetadataProvider
+	}
+
+	var content Content
+	if err := s.db.Where("id = ?", contentID).First(&content).Error; err != nil {
+		return nil, errors.New("content not found")
+	}
+	if content.ExternalID == "" {
+		return nil, errors.New("content was not imported from a metadata provider")
+	}
+	if content.ExternalProvider != s.metadata.Name() {
+		return nil, fmt.Errorf("content was imported from %s, not %s", content.ExternalProvider, s.metadata.Name())
+	}
+
+	title, err := s.metadata.Title(ctx, content.ExternalID)
+	if err != nil {
+		return nil, err
+	}
+	return s.saveMetadata(&content, title, "")
+}
+
+// RefreshStaleMetadata refreshes imported content whose metadata is older
+// than metadataStaleAfter
+func (s *Service) RefreshStaleMetadata(ctx context.Context) (int, error) {
+	if s.metadata == nil {
+		return 0, nil
+	}
+
+	refreshed := 0
+	lastID := ""
+	for {
+		var ids []string
+		err := s.db.Model(&Content{}).
+			Where("external_provider = ? AND external_id <> ''", s.metadata.Name()).
+			Where("metadata_updated_at IS NULL OR metadata_updated_at < ?", time.Now().Add(-metadataStaleAfter)).
+			Where("CAST(id AS text) > ?", lastID).
+			Order("CAST(id AS text) ASC").
+			Limit(50).
+			Pluck("CAST(id AS text)", &ids).Error
+		if err != nil {
+			return refreshed, err
+		}
+
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return refreshed, err
+			}
+			if _, err := s.RefreshMetadata(ctx, id); err != nil {
+				log.Printf("Failed to refresh metadata of content %s: %v", id, err)
+				continue
+			}
+			refreshed++
+		}
+
+		if len(ids) < 50 {
+			return refreshed, nil
+		}
+		lastID = ids[len(ids)-1]
+	}
+}
+
+// StartMetadataRefresh refreshes stale imported metadata periodically until
+// ctx is done
+func (s *Service) StartMetadataRefresh(ctx context.Context) {
+	if s.metadata == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(metadataRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			refreshed, err := s.RefreshStaleMetadata(ctx)
+			if err != nil {
+				log.Printf("Failed to refresh content metadata: %v", err)
+			} else if refreshed > 0 {
+				log.Printf("Refreshed metadata of %d contents", refreshed)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// saveMetadata applies a title to content and its episodes on behalf of
+// userID, empty for the background refresh
+func (s *Service) saveMetadata(content *Content, title *metadata.Title, userID string) (*MetadataImportResult, error) {
+	result := &MetadataImportResult{Content: content, Created: content.ID == ""}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		before := *content
+		applyMetadata(content, title, s.metadata.Name())
+		if err := tx.Save(content).Error; err != nil {
+			return err
+		}
+
+		if len(title.Genres) > 0 {
+			if err := setContentGenres(tx, content, uniqueGenreNames(title.Genres)); err != nil {
+				return err
+			}
+		}
+
+		var existing []ContentEpisode
+		if !result.Created {
+			if err := tx.Where("content_id = ?", content.ID).Find(&existing).Error; err != nil {
+				return err
+			}
+		}
+
+		created, updated := mergeEpisodes(content.ID, existing, title)
+		result.EpisodesCreated, result.EpisodesUpdated = len(created), len(updated)
+		if len(created) > 0 {
+			if err := tx.CreateInBatches(&created, 200).Error; err != nil {
+				return err
+			}
+		}
+		for i := range updated {
+			if err := tx.Save(&updated[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		changes := auditDiff(before, *content)
+		if len(changes) == 0 && len(created) == 0 && len(updated) == 0 {
+			return nil
+		}
+		return recordAudit(tx, ContentAuditEntry{
+			ContentID: content.ID,
+			UserID:    userID,
+			Action:    auditMetadataImported,
+			Changes:   changes,
+			Notes:     fmt.Sprintf("%s %s: %d episodes created, %d updated", content.ExternalProvider, content.ExternalID, len(created), len(updated)),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyMetadata copies a title onto content. Facts the provider keeps up to
+// date (year, country, rating, length) are overwritten; the title,
+// description and poster are only filled in when missing, so edits and
+// uploaded posters are kept.
+func applyMetadata(content *Content, title *metadata.Title, provider string) {
+	if content.Title == "" {
+		content.Title = title.Title
+		content.TitleKey = titleKey(title.Title)
+	}
+	if content.ContentType == "" {
+		content.ContentType = title.Kind
+	}
+	if content.Description == "" {
+		content.Description = title.Overview
+	}
+	if content.PosterURL == "" {
+		content.PosterURL = title.PosterURL
+	}
+
+	if title.Year > 0 {
+		content.YearReleased = title.Year
+	}
+	if len(title.Countries) > 0 {
+		content.Country = strings.Join(title.Countries, ", ")
+	}
+	if title.Rating > 0 {
+		content.IMDbRating = float32(math.Round(float64(title.Rating)*10) / 10)
+	}
+	if title.RuntimeMinutes > 0 {
+		content.AverageEpisodeDuration = title.RuntimeMinutes
+	}
+
+	content.TotalEpisodes = 1
+	if episodes := titleEpisodeCount(title); episodes > 0 {
+		content.TotalEpisodes = episodes
+	}
+
+	now := time.Now()
+	content.ExternalProvider = provider
+	content.ExternalID = title.ExternalID
+	content.MetadataUpdatedAt = &now
+}
+
+func titleEpisodeCount(title *metadata.Title) int {
+	count := 0
+	for _, season := range title.Seasons {
+		count += len(season.Episodes)
+	}
+	return count
+}
+
+// mergeEpisodes matches a title's episodes to the content's by season and
+// episode number. Missing episodes are created; existing ones get their
+// blanks filled in. Episodes the provider doesn't know are left alone.
+func mergeEpisodes(contentID string, existing []ContentEpisode, title *metadata.Title) (created, updated []ContentEpisode) {
+	type key struct{ season, episode int }
+	byNumber := make(map[key]ContentEpisode, len(existing))
+	for _, episode := range existing {
+		byNumber[key{episode.SeasonNumber, episode.EpisodeNumber}] = episode
+	}
+
+	for _, season := range title.Seasons {
+		for _, episode := range season.Episodes {
+			current, ok := byNumber[key{season.Number, episode.Number}]
+			if !ok {
+				created = append(created, ContentEpisode{
+					ContentID:       contentID,
+					SeasonNumber:    season.Number,
+					EpisodeNumber:   episode.Number,
+					Title:           episode.Title,
+					Description:     episode.Overview,
+					DurationMinutes: episode.RuntimeMinutes,
+				})
+				continue
+			}
+
+			changed := false
+			if current.Title == "" && episode.Title != "" {
+				current.Title, changed = episode.Title, true
+			}
+			if current.Description == "" && episode.Overview != "" {
+				current.Description, changed = episode.Overview, true
+			}
+			if current.DurationMinutes == 0 && episode.RuntimeMinutes > 0 {
+				current.DurationMinutes, changed = episode.RuntimeMinutes, true
+			}
+			if changed {
+				updated = append(updated, current)
+			}
+		}
+	}
+	return created, updated
+}
@@ -0,0 +1,112 @@
+package content
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Raylynd6299/babel/internal/content/metadata"
+)
+
+func loadFixtureTitle(t *testing.T, externalID string) *metadata.Title {
+	provider, err := metadata.NewFixture("metadata/testdata/titles")
+	require.NoError(t, err)
+
+	title, err := provider.Title(context.Background(), externalID)
+	require.NoError(t, err)
+	return title
+}
+
+func TestApplyMetadata(t *testing.T) {
+	t.Run("NewSeries", func(t *testing.T) {
+		content := &Content{LanguageID: 2}
+		applyMetadata(content, loadFixtureTitle(t, "tv/71446"), metadata.ProviderFixture)
+
+		assert.Equal(t, "Money Heist", content.Title)
+		assert.Equal(t, "series", content.ContentType)
+		assert.Equal(t, 2017, content.YearReleased)
+		assert.Equal(t, "Spain", content.Country)
+		assert.Equal(t, float32(8.2), content.IMDbRating)
+		assert.Equal(t, 70, content.AverageEpisodeDuration)
+		assert.Equal(t, 4, content.TotalEpisodes)
+		assert.Equal(t, "https://image.tmdb.org/t/p/w500/reEMJA1uzscCbkpeRJeTT2bjqUp.jpg", content.PosterURL)
+		assert.Equal(t, metadata.ProviderFixture, content.ExternalProvider)
+		assert.Equal(t, "tv/71446", content.ExternalID)
+		assert.NotNil(t, content.MetadataUpdatedAt)
+	})
+
+	t.Run("KeepsEditsAndUploadedPoster", func(t *testing.T) {
+		content := &Content{
+			Title:        "La casa de papel",
+			ContentType:  "series",
+			Description:  "Atraco a la Fábrica Nacional de Moneda y Timbre.",
+			PosterURL:    "http://localhost:8080/api/v1/files/posters/2024/03/a.jpg",
+			YearReleased: 2016,
+		}
+		applyMetadata(content, loadFixtureTitle(t, "tv/71446"), metadata.ProviderFixture)
+
+		assert.Equal(t, "La casa de papel", content.Title)
+		assert.Equal(t, "Atraco a la Fábrica Nacional de Moneda y Timbre.", content.Description)
+		assert.Equal(t, "http://localhost:8080/api/v1/files/posters/2024/03/a.jpg", content.PosterURL)
+		assert.Equal(t, 2017, content.YearReleased)
+	})
+
+	t.Run("Movie", func(t *testing.T) {
+		content := &Content{}
+		applyMetadata(content, loadFixtureTitle(t, "movie/426426"), metadata.ProviderFixture)
+
+		assert.Equal(t, "movie", content.ContentType)
+		assert.Equal(t, 1, content.TotalEpisodes)
+		assert.Equal(t, 135, content.AverageEpisodeDuration)
+		assert.Equal(t, "Mexico, United States of America", content.Country)
+	})
+}
+
+func TestMergeEpisodes(t *testing.T) {
+	title := loadFixtureTitle(t, "tv/71446")
+
+	t.Run("CreatesAllForNewContent", func(t *testing.T) {
+		created, updated := mergeEpisodes("content-1", nil, title)
+		require.Len(t, created, 4)
+		assert.Empty(t, updated)
+		assert.Equal(t, ContentEpisode{
+			ContentID:       "content-1",
+			SeasonNumber:    2,
+			EpisodeNumber:   1,
+			Title:           "Episode 1",
+			Description:     "Raquel reaches a key decision.",
+			DurationMinutes: 42,
+		}, created[3])
+	})
+
+	t.Run("FillsInExistingEpisodes", func(t *testing.T) {
+		existing := []ContentEpisode{
+			{ID: "e1", ContentID: "content-1", SeasonNumber: 1, EpisodeNumber: 1, Title: "Efectuar lo acordado", DurationMinutes: 47},
+			{ID: "e2", ContentID: "content-1", SeasonNumber: 1, EpisodeNumber: 2},
+			{ID: "e9", ContentID: "content-1", SeasonNumber: 3, EpisodeNumber: 1, Title: "Not known to the provider"},
+		}
+		created, updated := mergeEpisodes("content-1", existing, title)
+
+		require.Len(t, created, 2)
+		assert.Equal(t, 3, created[0].EpisodeNumber)
+		assert.Equal(t, 2, created[1].SeasonNumber)
+
+		require.Len(t, updated, 2)
+		// Hand-entered titles are kept, blanks filled in
+		assert.Equal(t, "e1", updated[0].ID)
+		assert.Equal(t, "Efectuar lo acordado", updated[0].Title)
+		assert.Equal(t, "The Professor recruits a young female robber and seven other criminals.", updated[0].Description)
+		assert.Equal(t, "e2", updated[1].ID)
+		assert.Equal(t, "Episode 2", updated[1].Title)
+		assert.Equal(t, 41, updated[1].DurationMinutes)
+	})
+
+	t.Run("NothingToDoWhenComplete", func(t *testing.T) {
+		created, _ := mergeEpisodes("content-1", nil, title)
+		again, updated := mergeEpisodes("content-1", created, title)
+		assert.Empty(t, again)
+		assert.Empty(t, updated)
+	})
+}
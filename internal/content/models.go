@@ -10,28 +10,41 @@ import (
 type Content struct {
 	ID                     string         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	Title                  string         `json:"title" gorm:"not null"`
+	TitleKey               string         `json:"-" gorm:"index"`               // Normalised title used to find duplicates, see titleKey
 	ContentType            string         `json:"content_type" gorm:"not null"` // series, movie, podcast, book
 	LanguageID             int            `json:"language_id" gorm:"not null"`
 	TotalEpisodes          int            `json:"total_episodes" gorm:"default:1"`
 	AverageEpisodeDuration int            `json:"average_episode_duration"` // minutes
 	YearReleased           int            `json:"year_released"`
 	Country                string         `json:"country"`
-	Genre                  string         `json:"genre"`
+	Genre                  string         `json:"genre"` // Names of Genres, comma-separated
 	Description            string         `json:"description"`
 	PosterURL              string         `json:"poster_url"`
 	IMDbRating             float32        `json:"imdb_rating"`
-	DifficultyLevel        string         `json:"difficulty_level"`
+	DifficultyLevel        string         `json:"difficulty_level"` // CEFR band, estimated from transcripts and learner feedback
+	DifficultyScore        float64        `json:"difficulty_score"` // 0-6, see DifficultyEstimate
+	DifficultyUpdatedAt    *time.Time     `json:"difficulty_updated_at,omitempty"`
+	ExternalProvider       string         `json:"external_provider,omitempty" gorm:"index:idx_content_external"` // Metadata provider the content was imported from
+	ExternalID             string         `json:"external_id,omitempty" gorm:"index:idx_content_external"`
+	MetadataUpdatedAt      *time.Time     `json:"metadata_updated_at,omitempty"`
 	CreatedBy              string         `json:"created_by"`
 	CreatedAt              time.Time      `json:"created_at"`
 	DeletedAt              gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index" swaggerignore:"true"`
 	UpdatedAt              time.Time      `json:"updated_at"`
-	IsVerified             bool           `json:"is_verified" gorm:"default:false"`
+	IsVerified             bool           `json:"is_verified" gorm:"default:false"`               // Published by an editor
+	Status                 string         `json:"status" gorm:"not null;default:published;index"` // draft, pending_review, published, rejected
+	SubmittedAt            *time.Time     `json:"submitted_at,omitempty"`
+	ReviewedBy             string         `json:"reviewed_by,omitempty"`
+	ReviewedAt             *time.Time     `json:"reviewed_at,omitempty"`
+	ReviewNotes            string         `json:"review_notes,omitempty"` // Left by the editor on the last review
 	ViewCount              int            `json:"view_count" gorm:"default:0"`
 
 	// Relations
-	Episodes []ContentEpisode `json:"episodes,omitempty" gorm:"foreignKey:ContentID"`
-	Language Language         `json:"language,omitempty" gorm:"foreignKey:LanguageID"`
-	Ratings  []ContentRating  `json:"ratings,omitempty" gorm:"foreignKey:ContentID"`
+	Episodes    []ContentEpisode    `json:"episodes,omitempty" gorm:"foreignKey:ContentID"`
+	Language    Language            `json:"language,omitempty" gorm:"foreignKey:LanguageID"`
+	Ratings     []ContentRating     `json:"ratings,omitempty" gorm:"foreignKey:ContentID"`
+	RatingStats *ContentRatingStats `json:"rating_stats,omitempty" gorm:"foreignKey:ContentID"`
+	Genres      []Genre             `json:"genres,omitempty" gorm:"many2many:content_genres"`
 }
 
 type ContentEpisode struct {
@@ -42,9 +55,14 @@ type ContentEpisode struct {
 	DurationMinutes int            `json:"duration_minutes"`
 	SeasonNumber    int            `json:"season_number" gorm:"default:1"`
 	Description     string         `json:"description"`
+	DifficultyLevel string         `json:"difficulty_level"` // CEFR band
+	DifficultyScore float64        `json:"difficulty_score"`
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Relations
+	SubtitleTracks []SubtitleTrack `json:"subtitle_tracks,omitempty" gorm:"foreignKey:EpisodeID"`
 }
 
 type Language struct {
@@ -59,6 +77,14 @@ type Language struct {
 	DeletedAt        gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
 
+// Genre is an entry in the shared genre taxonomy
+type Genre struct {
+	ID        int       `json:"id" gorm:"primary_key"`
+	Slug      string    `json:"slug" gorm:"uniqueIndex;not null"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type ContentRating struct {
 	ID                  string         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	UserID              string         `json:"user_id" gorm:"not null"`
@@ -67,40 +93,53 @@ type ContentRating struct {
 	UsefulnessRating    int            `json:"usefulness_rating" gorm:"check:usefulness_rating >= 1 AND usefulness_rating <= 5"`
 	EntertainmentRating int            `json:"entertainment_rating" gorm:"check:entertainment_rating >= 1 AND entertainment_rating <= 5"`
 	ReviewText          string         `json:"review_text"`
+	HelpfulCount        int            `json:"helpful_count" gorm:"default:0"`
+	UnhelpfulCount      int            `json:"unhelpful_count" gorm:"default:0"`
+	ReportCount         int            `json:"report_count" gorm:"default:0"` // Open reports
+	HiddenAt            *time.Time     `json:"hidden_at,omitempty"`           // Hidden from reviews after reports
 	CreatedAt           time.Time      `json:"created_at"`
 	UpdatedAt           time.Time      `json:"updated_at"`
 	DeletedAt           gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
 
 type CreateContentRequest struct {
-	Title                  string  `json:"title" validate:"required,min=1,max=255"`
-	ContentType            string  `json:"content_type" validate:"required,oneof=series movie podcast book"`
-	LanguageID             int     `json:"language_id" validate:"required"`
-	TotalEpisodes          int     `json:"total_episodes" validate:"min=1"`
-	AverageEpisodeDuration int     `json:"average_episode_duration" validate:"min=1"`
-	YearReleased           int     `json:"year_released" validate:"min=1900,max=2030"`
-	Country                string  `json:"country" validate:"max=100"`
-	Genre                  string  `json:"genre" validate:"max=100"`
-	Description            string  `json:"description"`
-	PosterURL              string  `json:"poster_url" validate:"url"`
-	IMDbRating             float32 `json:"imdb_rating" validate:"min=0,max=10"`
+	Title                  string   `json:"title" validate:"required,min=1,max=255"`
+	ContentType            string   `json:"content_type" validate:"required,oneof=series movie podcast book"`
+	LanguageID             int      `json:"language_id" validate:"required"`
+	TotalEpisodes          int      `json:"total_episodes" validate:"min=1"`
+	AverageEpisodeDuration int      `json:"average_episode_duration" validate:"min=1"`
+	YearReleased           int      `json:"year_released" validate:"min=1900,max=2030"`
+	Country                string   `json:"country" validate:"max=100"`
+	Genre                  string   `json:"genre" validate:"max=100"` // Comma-separated, used when Genres is empty
+	Genres                 []string `json:"genres" validate:"omitempty,max=10,dive,min=1,max=50"`
+	Description            string   `json:"description"`
+	PosterURL              string   `json:"poster_url" validate:"url"`
+	IMDbRating             float32  `json:"imdb_rating" validate:"min=0,max=10"`
 }
 
 type ContentFilter struct {
-	LanguageID    int      `json:"language_id"`
-	ContentType   string   `json:"content_type"`
-	Genre         string   `json:"genre"`
-	Country       string   `json:"country"`
-	MinRating     float32  `json:"min_rating"`
-	MaxRating     float32  `json:"max_rating"`
-	Difficulty    []string `json:"difficulty"`
-	YearFrom      int      `json:"year_from"`
-	YearTo        int      `json:"year_to"`
-	Search        string   `json:"search"`
-	Limit         int      `json:"limit"`
-	Offset        int      `json:"offset"`
-	SortBy        string   `json:"sort_by"`        // title, year, rating, difficulty, created_at
-	SortDirection string   `json:"sort_direction"` // asc, desc
+	LanguageID             int      `json:"language_id"`
+	ContentType            string   `json:"content_type"`
+	Genre                  string   `json:"genre"`
+	Country                string   `json:"country"`
+	MinRating              float32  `json:"min_rating"`
+	MaxRating              float32  `json:"max_rating"`
+	MinCommunityRating     float32  `json:"min_community_rating"` // Community ratings are 1-5
+	MinUsefulness          float32  `json:"min_usefulness"`
+	MinEntertainment       float32  `json:"min_entertainment"`
+	MaxPerceivedDifficulty float32  `json:"max_perceived_difficulty"`
+	MinRatingCount         int      `json:"min_rating_count"`
+	Difficulty             []string `json:"difficulty"`
+	YearFrom               int      `json:"year_from"`
+	YearTo                 int      `json:"year_to"`
+	Decade                 int      `json:"decade"` // e.g. 1990 for 1990-1999
+	CreatedBy              string   `json:"created_by"`
+	Statuses               []string `json:"statuses"` // Published only unless CreatedBy is set
+	Search                 string   `json:"search"`
+	Limit                  int      `json:"limit"`
+	Offset                 int      `json:"offset"`
+	SortBy                 string   `json:"sort_by"`        // title, year, rating, difficulty, created_at, view_count, community_rating, usefulness, entertainment, perceived_difficulty, rating_count, relevance
+	SortDirection          string   `json:"sort_direction"` // asc, desc
 }
 
 type RateContentRequest struct {
@@ -119,13 +158,14 @@ type CreateEpisodeRequest struct {
 }
 
 type UpdateContentRequest struct {
-	Title                  string  `json:"title" validate:"omitempty,min=1,max=255"`
-	TotalEpisodes          int     `json:"total_episodes" validate:"omitempty,min=1"`
-	AverageEpisodeDuration int     `json:"average_episode_duration" validate:"omitempty,min=1"`
-	YearReleased           int     `json:"year_released" validate:"omitempty,min=1900,max=2030"`
-	Country                string  `json:"country" validate:"omitempty,max=100"`
-	Genre                  string  `json:"genre" validate:"omitempty,max=100"`
-	Description            string  `json:"description"`
-	PosterURL              string  `json:"poster_url" validate:"omitempty,url"`
-	IMDbRating             float32 `json:"imdb_rating" validate:"omitempty,min=0,max=10"`
+	Title                  string   `json:"title" validate:"omitempty,min=1,max=255"`
+	TotalEpisodes          int      `json:"total_episodes" validate:"omitempty,min=1"`
+	AverageEpisodeDuration int      `json:"average_episode_duration" validate:"omitempty,min=1"`
+	YearReleased           int      `json:"year_released" validate:"omitempty,min=1900,max=2030"`
+	Country                string   `json:"country" validate:"omitempty,max=100"`
+	Genre                  string   `json:"genre" validate:"omitempty,max=100"`
+	Genres                 []string `json:"genres" validate:"omitempty,max=10,dive,min=1,max=50"`
+	Description            string   `json:"description"`
+	PosterURL              string   `json:"poster_url" validate:"omitempty,url"`
+	IMDbRating             float32  `json:"imdb_rating" validate:"omitempty,min=0,max=10"`
 }
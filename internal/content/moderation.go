@@ -0,0 +1,432 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// Submission states of catalogue content. Content created by learners starts
+// as a draft and only appears in public listings once an editor publishes it.
+const (
+	ContentStatusDraft         = "draft"
+	ContentStatusPendingReview = "pending_review"
+	ContentStatusPublished     = "published"
+	ContentStatusRejected      = "rejected"
+)
+
+// contentTransitions lists the states content can move to from each state.
+// Owners submit drafts and rejected content and withdraw pending content;
+// editors publish or reject it, and can take published content down. An
+// owner's edit sends published content back for review.
+var contentTransitions = map[string][]string{
+	ContentStatusDraft:         {ContentStatusPendingReview},
+	ContentStatusPendingReview: {ContentStatusPublished, ContentStatusRejected, ContentStatusDraft},
+	ContentStatusRejected:      {ContentStatusPendingReview},
+	ContentStatusPublished:     {ContentStatusRejected, ContentStatusPendingReview},
+}
+
+// Review decisions
+const (
+	ReviewDecisionPublish = "publish"
+	ReviewDecisionReject  = "reject"
+)
+
+// Audit trail actions
+const (
+	auditContentCreated   = "content.created"
+	auditContentUpdated   = "content.updated"
+	auditContentDeleted   = "content.deleted"
+	auditStatusChanged    = "content.status_changed"
+	auditMetadataImported = "content.metadata_imported"
+	auditContentImported  = "content.catalogue_imported"
+	auditEpisodeCreated   = "episode.created"
+	auditEpisodeUpdated   = "episode.updated"
+	auditEpisodeDeleted   = "episode.deleted"
+)
+
+// ContentAuditEntry records a change to content or one of its episodes
+type ContentAuditEntry struct {
+	ID        string                 `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ContentID string                 `json:"content_id" gorm:"not null;index"`
+	EpisodeID *string                `json:"episode_id,omitempty" gorm:"index"`
+	UserID    string                 `json:"user_id"`                                   // Empty for background jobs
+	Action    string                 `json:"action" gorm:"not null"`                    // e.g. content.updated, content.status_changed, episode.deleted
+	Changes   map[string]AuditChange `json:"changes" gorm:"type:jsonb;serializer:json"` // By JSON field name
+	Notes     string                 `json:"notes,omitempty"`
+	CreatedAt time.Time              `json:"created_at" gorm:"index"`
+}
+
+// AuditChange is a field's value before and after a change
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type AuditFilter struct {
+	ContentID string
+	UserID    string
+	Action    string
+	Limit     int
+	Offset    int
+}
+
+type ReviewContentRequest struct {
+	Decision string `json:"decision" validate:"required,oneof=publish reject"`
+	Notes    string `json:"notes" validate:"required_if=Decision reject,max=2000"` // Shown to the owner; required when rejecting
+}
+
+// ReviewQueueItem is content waiting for review with the catalogue entries
+// it may duplicate
+type ReviewQueueItem struct {
+	Content            Content   `json:"content"`
+	PossibleDuplicates []Content `json:"possible_duplicates"`
+}
+
+// DuplicateContentError reports catalogue entries with the same title, year,
+// type and language as content being created, edited or submitted
+type DuplicateContentError struct {
+	Duplicates []Content
+}
+
+func (e *DuplicateContentError) Error() string {
+	return "content already exists in the catalogue"
+}
+
+var errOwnContentReview = errors.New("content can't be reviewed by its owner")
+
+// canTransition reports whether content may move from one state to another
+func canTransition(from, to string) bool {
+	for _, allowed := range contentTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// titleKey normalises a title so spellings differing only in case, accents
+// or punctuation compare equal, e.g. "La Casa de Papel" and "la casa de papél!"
+func titleKey(title string) string {
+	var key strings.Builder
+	space := false
+	for _, r := range norm.NFD.String(strings.ToLower(title)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			key.WriteRune(r)
+			space = false
+		case !space && key.Len() > 0:
+			key.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSuffix(key.String(), " ")
+}
+
+// findExactDuplicates finds content in one of the statuses with the same
+// title, year, type and language as content
+func findExactDuplicates(tx *gorm.DB, content *Content, statuses ...string) ([]Content, error) {
+	query := tx.Where("title_key = ? AND year_released = ? AND content_type = ? AND language_id = ?",
+		titleKey(content.Title), content.YearReleased, content.ContentType, content.LanguageID).
+		Where("status IN ?", statuses)
+	if content.ID != "" {
+		query = query.Where("id <> ?", content.ID)
+	}
+
+	var duplicates []Content
+	err := query.Order("created_at ASC").Limit(10).Find(&duplicates).Error
+	return duplicates, err
+}
+
+// checkDuplicates fails with a DuplicateContentError when content would
+// duplicate a catalogue entry in one of the statuses
+func checkDuplicates(tx *gorm.DB, content *Content, statuses ...string) error {
+	duplicates, err := findExactDuplicates(tx, content, statuses...)
+	if err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return &DuplicateContentError{Duplicates: duplicates}
+	}
+	return nil
+}
+
+// findPossibleDuplicates finds other content with the same title released
+// within a year of content, in any language or type, for editors to compare
+func (s *Service) findPossibleDuplicates(content *Content) ([]Content, error) {
+	query := s.db.Preload("Language").
+		Where("title_key = ? AND id <> ?", titleKey(content.Title), content.ID).
+		Where("status <> ?", ContentStatusRejected)
+	if content.YearReleased > 0 {
+		query = query.Where("year_released = 0 OR year_released BETWEEN ? AND ?", content.YearReleased-1, content.YearReleased+1)
+	}
+
+	duplicates := []Content{}
+	err := query.Order("created_at ASC").Limit(10).Find(&duplicates).Error
+	return duplicates, err
+}
+
+// SubmitContent sends a draft or rejected content to the editors' review queue
+func (s *Service) SubmitContent(ctx context.Context, contentID, userID string) (*Content, error) {
+	content, err := s.ownedContent(contentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if content.Status == ContentStatusPublished {
+		return nil, errors.New("content is already published")
+	}
+	if err := checkDuplicates(s.db, content, ContentStatusPublished, ContentStatusPendingReview); err != nil {
+		return nil, err
+	}
+	if err := s.transitionContent(s.db, content, ContentStatusPendingReview, userID, ""); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// WithdrawContent takes pending content out of the review queue, back to a
+// draft
+func (s *Service) WithdrawContent(ctx context.Context, contentID, userID string) (*Content, error) {
+	content, err := s.ownedContent(contentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if content.Status != ContentStatusPendingReview {
+		return nil, errors.New("only content pending review can be withdrawn")
+	}
+	if err := s.transitionContent(s.db, content, ContentStatusDraft, userID, ""); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// ReviewContent publishes or rejects content as an editor. Publishing
+// verifies the content; rejecting explains why in the notes.
+func (s *Service) ReviewContent(ctx context.Context, contentID, editorID string, req ReviewContentRequest) (*Content, error) {
+	var content Content
+	if err := s.db.Where("id = ?", contentID).First(&content).Error; err != nil {
+		return nil, errors.New("content not found")
+	}
+
+	// Someone else has to review content, even when its owner is an editor
+	if content.CreatedBy == editorID {
+		return nil, errOwnContentReview
+	}
+
+	to := ContentStatusRejected
+	if req.Decision == ReviewDecisionPublish {
+		to = ContentStatusPublished
+		// Pending duplicates are left for the editor to reject
+		if err := checkDuplicates(s.db, &content, ContentStatusPublished); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.transitionContent(s.db, &content, to, editorID, strings.TrimSpace(req.Notes)); err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
+// GetReviewQueue lists content pending review, longest waiting first
+func (s *Service) GetReviewQueue(ctx context.Context, limit, offset int) ([]ReviewQueueItem, int64, error) {
+	query := s.db.Model(&Content{}).Where("status = ?", ContentStatusPendingReview)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var contents []Content
+	err := query.Preload("Language").Preload("Genres").Preload("Episodes").
+		Order("submitted_at ASC, created_at ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&contents).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	queue := make([]ReviewQueueItem, len(contents))
+	for i := range contents {
+		duplicates, err := s.findPossibleDuplicates(&contents[i])
+		if err != nil {
+			return nil, 0, err
+		}
+		queue[i] = ReviewQueueItem{Content: contents[i], PossibleDuplicates: duplicates}
+	}
+	return queue, total, nil
+}
+
+// GetContentHistory lists the audit trail of content its owner can see,
+// newest first
+func (s *Service) GetContentHistory(ctx context.Context, contentID, userID string, limit, offset int) ([]ContentAuditEntry, int64, error) {
+	if _, err := s.ownedContent(contentID, userID); err != nil {
+		return nil, 0, err
+	}
+	return s.GetAuditTrail(ctx, AuditFilter{ContentID: contentID, Limit: limit, Offset: offset})
+}
+
+// GetAuditTrail lists audit entries matching the filter, newest first
+func (s *Service) GetAuditTrail(ctx context.Context, filter AuditFilter) ([]ContentAuditEntry, int64, error) {
+	query := s.db.Model(&ContentAuditEntry{})
+	if filter.ContentID != "" {
+		query = query.Where("content_id = ?", filter.ContentID)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	entries := []ContentAuditEntry{}
+	err := query.Order("created_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// BackfillTitleKeys fills in the title key of content created before
+// duplicate detection
+func (s *Service) BackfillTitleKeys(ctx context.Context) (int, error) {
+	var contents []Content
+	if err := s.db.Select("id", "title").Where("title_key = '' AND title <> ''").Find(&contents).Error; err != nil {
+		return 0, err
+	}
+
+	for i, content := range contents {
+		err := s.db.Model(&Content{}).Where("id = ?", content.ID).UpdateColumn("title_key", titleKey(content.Title)).Error
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(contents), nil
+}
+
+func (s *Service) ownedContent(contentID, userID string) (*Content, error) {
+	var content Content
+	if err := s.db.Where("id = ?", contentID).First(&content).Error; err != nil {
+		return nil, errors.New("content not found")
+	}
+	if content.CreatedBy != userID {
+		return nil, errors.New("unauthorized to update this content")
+	}
+	return &content, nil
+}
+
+// reopenForReview sends published content back to the review queue after its
+// owner changes it, so the change only goes public once an editor approves
+// it. Content in other states is left as it is.
+func (s *Service) reopenForReview(tx *gorm.DB, content *Content, userID string) error {
+	if content.Status != ContentStatusPublished {
+		return nil
+	}
+	return s.transitionContent(tx, content, ContentStatusPendingReview, userID, "")
+}
+
+// transitionContent moves content to another state and records it in the
+// audit trail
+func (s *Service) transitionContent(db *gorm.DB, content *Content, to, userID, notes string) error {
+	from := content.Status
+	if !canTransition(from, to) {
+		return fmt.Errorf("content cannot move from %s to %s", from, to)
+	}
+
+	before := *content
+	now := time.Now()
+	content.Status = to
+	switch to {
+	case ContentStatusPendingReview:
+		content.IsVerified = false
+		content.SubmittedAt = &now
+	case ContentStatusPublished, ContentStatusRejected:
+		content.IsVerified = to == ContentStatusPublished
+		content.ReviewedBy = userID
+		content.ReviewedAt = &now
+		content.ReviewNotes = notes
+	case ContentStatusDraft:
+		content.SubmittedAt = nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(content).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, ContentAuditEntry{
+			ContentID: content.ID,
+			UserID:    userID,
+			Action:    auditStatusChanged,
+			Changes:   auditDiff(before, *content),
+			Notes:     notes,
+		})
+	})
+}
+
+func recordAudit(tx *gorm.DB, entry ContentAuditEntry) error {
+	return tx.Create(&entry).Error
+}
+
+// auditDiff compares the plain fields of two values of the same struct type,
+// returning the changed ones by JSON name. Relations and timestamps are left
+// out.
+func auditDiff(before, after interface{}) map[string]AuditChange {
+	changes := make(map[string]AuditChange)
+	b, a := reflect.ValueOf(before), reflect.ValueOf(after)
+	for i := 0; i < b.NumField(); i++ {
+		field := b.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" || name == "" ||
+			field.Name == "ID" || field.Name == "CreatedAt" || field.Name == "DeletedAt" || strings.HasSuffix(field.Name, "UpdatedAt") {
+			continue
+		}
+
+		from, ok := auditValue(b.Field(i))
+		if !ok {
+			continue
+		}
+		to, _ := auditValue(a.Field(i))
+		if !reflect.DeepEqual(from, to) {
+			changes[name] = AuditChange{From: from, To: to}
+		}
+	}
+	return changes
+}
+
+// auditValue reads a scalar, time or pointer to one; ok is false for
+// relations
+func auditValue(v reflect.Value) (value interface{}, ok bool) {
+	if v.Kind() == reflect.Ptr {
+		if _, isTime := v.Interface().(*time.Time); !isTime && v.Type().Elem().Kind() == reflect.Struct {
+			return nil, false
+		}
+		if v.IsNil() {
+			return nil, true
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return v.Interface(), true
+	case reflect.Struct:
+		if t, isTime := v.Interface().(time.Time); isTime {
+			return t, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,134 @@
+package content
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, canTransition(ContentStatusDraft, ContentStatusPendingReview))
+	assert.True(t, canTransition(ContentStatusPendingReview, ContentStatusPublished))
+	assert.True(t, canTransition(ContentStatusPendingReview, ContentStatusRejected))
+	assert.True(t, canTransition(ContentStatusPendingReview, ContentStatusDraft))
+	assert.True(t, canTransition(ContentStatusRejected, ContentStatusPendingReview))
+	assert.True(t, canTransition(ContentStatusPublished, ContentStatusRejected))
+	assert.True(t, canTransition(ContentStatusPublished, ContentStatusPendingReview))
+
+	// Publishing always goes through review
+	assert.False(t, canTransition(ContentStatusDraft, ContentStatusPublished))
+	assert.False(t, canTransition(ContentStatusRejected, ContentStatusPublished))
+	assert.False(t, canTransition(ContentStatusPublished, ContentStatusPublished))
+	assert.False(t, canTransition("", ContentStatusPendingReview))
+}
+
+func TestReopenForReview(t *testing.T) {
+	t.Run("Published", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "contents" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO "content_audit_entries"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("audit-1"))
+		mock.ExpectCommit()
+
+		content := Content{ID: "content-1", CreatedBy: "owner", Status: ContentStatusPublished, IsVerified: true}
+		require.NoError(t, service.reopenForReview(service.db, &content, "owner"))
+		assert.Equal(t, ContentStatusPendingReview, content.Status)
+		assert.False(t, content.IsVerified)
+		assert.NotNil(t, content.SubmittedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotPublished", func(t *testing.T) {
+		service, mock := newMockService(t)
+		for _, status := range []string{ContentStatusDraft, ContentStatusPendingReview, ContentStatusRejected} {
+			content := Content{ID: "content-1", Status: status}
+			require.NoError(t, service.reopenForReview(service.db, &content, "owner"))
+			assert.Equal(t, status, content.Status)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCanView(t *testing.T) {
+	published := &Content{CreatedBy: "owner", Status: ContentStatusPublished}
+	assert.True(t, canView(published, ""))
+	assert.True(t, canView(published, "someone"))
+
+	for _, status := range []string{ContentStatusDraft, ContentStatusPendingReview, ContentStatusRejected} {
+		content := &Content{CreatedBy: "owner", Status: status}
+		assert.True(t, canView(content, "owner"), status)
+		assert.False(t, canView(content, "someone"), status)
+		assert.False(t, canView(content, ""), status)
+	}
+}
+
+func TestUnpublishedContentIsHidden(t *testing.T) {
+	service, mock := newMockService(t)
+	expectContent := func() {
+		mock.ExpectQuery(`SELECT \* FROM "contents" WHERE id = \$1`).
+			WithArgs("content-1", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_by", "status"}).AddRow("content-1", "owner", ContentStatusPendingReview))
+	}
+
+	// Nothing under the content is read
+	expectContent()
+	_, _, err := service.GetReviews(context.Background(), "content-1", "someone", ReviewFilter{})
+	assert.EqualError(t, err, "content not found")
+	expectContent()
+	_, err = service.GetEpisodeTranscript(context.Background(), "content-1", "episode-1", "")
+	assert.EqualError(t, err, "content not found")
+	expectContent()
+	assert.EqualError(t, service.RateContent(context.Background(), "owner", "content-1", ContentRating{}), "content not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTitleKey(t *testing.T) {
+	assert.Equal(t, "la casa de papel", titleKey("La Casa de Papel"))
+	assert.Equal(t, "la casa de papel", titleKey("  la casa de papél! "))
+	assert.Equal(t, "amelie", titleKey("Amélie"))
+	assert.Equal(t, "spider man 2", titleKey("Spider-Man 2"))
+	assert.Equal(t, "千と千尋の神隠し", titleKey("千と千尋の神隠し"))
+	assert.Equal(t, "", titleKey("?!"))
+}
+
+func TestAuditDiff(t *testing.T) {
+	reviewed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	before := Content{
+		ID:           "c1",
+		Title:        "Roma",
+		TitleKey:     "roma",
+		YearReleased: 2018,
+		Status:       ContentStatusPendingReview,
+		UpdatedAt:    reviewed.Add(-time.Hour),
+		Genres:       []Genre{{Slug: "drama"}},
+	}
+	after := before
+	after.Status = ContentStatusPublished
+	after.IsVerified = true
+	after.ReviewedAt = &reviewed
+	after.UpdatedAt = reviewed
+	after.Genres = nil
+
+	changes := auditDiff(before, after)
+
+	assert.Equal(t, map[string]AuditChange{
+		"status":      {From: ContentStatusPendingReview, To: ContentStatusPublished},
+		"is_verified": {From: false, To: true},
+		"reviewed_at": {From: nil, To: reviewed},
+	}, changes)
+}
+
+func TestAuditDiffOfCreatedEpisode(t *testing.T) {
+	changes := auditDiff(ContentEpisode{}, ContentEpisode{ID: "e1", ContentID: "c1", SeasonNumber: 1, EpisodeNumber: 2, Title: "Pilot"})
+
+	assert.Equal(t, map[string]AuditChange{
+		"content_id":     {From: "", To: "c1"},
+		"season_number":  {From: 0, To: 1},
+		"episode_number": {From: 0, To: 2},
+		"title":          {From: "", To: "Pilot"},
+	}, changes)
+}
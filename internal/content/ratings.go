@@ -0,0 +1,390 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Reviews are hidden from the public listing once this many learners report
+// them, until an admin restores them
+const reviewHideReports = 3
+
+// ContentRatingStats aggregates a content's ratings. It is refreshed whenever
+// a rating or review changes.
+type ContentRatingStats struct {
+	ContentID        string  `json:"content_id" gorm:"type:uuid;primary_key"`
+	RatingCount      int     `json:"rating_count" gorm:"index"`
+	ReviewCount      int     `json:"review_count"` // Visible ratings with review text
+	AvgDifficulty    float64 `json:"avg_difficulty"`
+	AvgUsefulness    float64 `json:"avg_usefulness"`
+	AvgEntertainment float64 `json:"avg_entertainment"`
+	AvgOverall       float64 `json:"avg_overall" gorm:"index"` // Mean of usefulness and entertainment
+	// Ratings given for each star, one to five
+	DifficultyDistribution    []int     `json:"difficulty_distribution" gorm:"type:jsonb;serializer:json"`
+	UsefulnessDistribution    []int     `json:"usefulness_distribution" gorm:"type:jsonb;serializer:json"`
+	EntertainmentDistribution []int     `json:"entertainment_distribution" gorm:"type:jsonb;serializer:json"`
+	RefreshedAt               time.Time `json:"refreshed_at"`
+}
+
+// ReviewVote is a learner marking a review helpful or not
+type ReviewVote struct {
+	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RatingID  string    `json:"rating_id" gorm:"type:uuid;not null;uniqueIndex:idx_review_vote_user"`
+	UserID    string    `json:"user_id" gorm:"not null;uniqueIndex:idx_review_vote_user"`
+	Helpful   bool      `json:"helpful"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ReviewReport is a learner flagging a review for moderation
+type ReviewReport struct {
+	ID         string     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RatingID   string     `json:"rating_id" gorm:"type:uuid;not null;uniqueIndex:idx_review_report_user"`
+	UserID     string     `json:"user_id" gorm:"not null;uniqueIndex:idx_review_report_user"`
+	Reason     string     `json:"reason" gorm:"not null"` // spam, offensive, spoiler, off_topic, other
+	Details    string     `json:"details"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type ReviewFilter struct {
+	SortBy string // helpful, recent, highest, lowest
+	Limit  int
+	Offset int
+}
+
+type VoteReviewRequest struct {
+	Helpful *bool `json:"helpful" validate:"required"`
+}
+
+type ReportReviewRequest struct {
+	Reason  string `json:"reason" validate:"required,oneof=spam offensive spoiler off_topic other"`
+	Details string `json:"details" validate:"max=500"`
+}
+
+type ResolveReviewReportsRequest struct {
+	Hide bool `json:"hide"` // Keep the review hidden, otherwise restore it
+}
+
+// ReportedReview is a review awaiting moderation with its open reports
+type ReportedReview struct {
+	Review  ContentRating  `json:"review"`
+	Reports []ReviewReport `json:"reports"`
+}
+
+var reviewSorts = map[string]string{
+	"helpful": "helpful_count - unhelpful_count DESC, helpful_count DESC, created_at DESC",
+	"recent":  "created_at DESC",
+	"highest": "(usefulness_rating + entertainment_rating) DESC, created_at DESC",
+	"lowest":  "(usefulness_rating + entertainment_rating) ASC, created_at DESC",
+}
+
+// Community rating sorts for content listings. Unrated content sorts as zero.
+var ratingSortColumns = map[string]string{
+	"community_rating":     "COALESCE(content_rating_stats.avg_overall, 0)",
+	"usefulness":           "COALESCE(content_rating_stats.avg_usefulness, 0)",
+	"entertainment":        "COALESCE(content_rating_stats.avg_entertainment, 0)",
+	"perceived_difficulty": "COALESCE(content_rating_stats.avg_difficulty, 0)",
+	"rating_count":         "COALESCE(content_rating_stats.rating_count, 0)",
+}
+
+// usesRatingStats reports whether listing content needs its rating stats
+func (f ContentFilter) usesRatingStats() bool {
+	_, sorted := ratingSortColumns[f.SortBy]
+	return sorted || f.MinCommunityRating > 0 || f.MinUsefulness > 0 || f.MinEntertainment > 0 ||
+		f.MaxPerceivedDifficulty > 0 || f.MinRatingCount > 0
+}
+
+// refreshRatingStats recomputes the stats of the given contents, or of all
+// contents when none are given
+func refreshRatingStats(tx *gorm.DB, contentIDs ...string) error {
+	scope := "TRUE"
+	var args []interface{}
+	if len(contentIDs) > 0 {
+		scope = "content_id IN ?"
+		args = append(args, contentIDs)
+	}
+
+	// Contents whose last rating went away have no stats
+	deleted := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Where(scope, args...).Delete(&ContentRatingStats{})
+	if deleted.Error != nil {
+		return deleted.Error
+	}
+
+	return tx.Exec(`
+        INSERT INTO content_rating_stats (content_id, rating_count, review_count, avg_difficulty, avg_usefulness, avg_entertainment, avg_overall,
+            difficulty_distribution, usefulness_distribution, entertainment_distribution, refreshed_at)
+        SELECT CAST(content_id AS uuid), COUNT(*),
+            COUNT(*) FILTER (WHERE review_text <> '' AND hidden_at IS NULL),
+            AVG(difficulty_rating), AVG(usefulness_rating), AVG(entertainment_rating),
+            AVG((usefulness_rating + entertainment_rating) / 2.0),
+            jsonb_build_array(`+starCounts("difficulty_rating")+`),
+            jsonb_build_array(`+starCounts("usefulness_rating")+`),
+            jsonb_build_array(`+starCounts("entertainment_rating")+`),
+            NOW()
+        FROM content_ratings
+        WHERE deleted_at IS NULL AND `+scope+`
+        GROUP BY content_id`, args...).Error
+}
+
+func starCounts(column string) string {
+	counts := make([]string, 5)
+	for star := 1; star <= 5; star++ {
+		counts[star-1] = fmt.Sprintf("COUNT(*) FILTER (WHERE %s = %d)", column, star)
+	}
+	return strings.Join(counts, ", ")
+}
+
+// RefreshAllRatingStats rebuilds the rating stats of every content
+func (s *Service) RefreshAllRatingStats(ctx context.Context) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return refreshRatingStats(tx)
+	})
+}
+
+// GetRatingStats returns a content's aggregated ratings. Content nobody has
+// rated yet has empty stats.
+func (s *Service) GetRatingStats(ctx context.Context, contentID, viewerID string) (*ContentRatingStats, error) {
+	if _, err := s.visibleContent(contentID, viewerID); err != nil {
+		return nil, err
+	}
+
+	stats := ContentRatingStats{
+		ContentID:                 contentID,
+		DifficultyDistribution:    make([]int, 5),
+		UsefulnessDistribution:    make([]int, 5),
+		EntertainmentDistribution: make([]int, 5),
+	}
+	err := s.db.Where("content_id = ?", contentID).Limit(1).Find(&stats).Error
+	return &stats, err
+}
+
+// GetReviews lists a content's visible reviews
+func (s *Service) GetReviews(ctx context.Context, contentID, viewerID string, filter ReviewFilter) ([]ContentRating, int64, error) {
+	if _, err := s.visibleContent(contentID, viewerID); err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.Model(&ContentRating{}).
+		Where("content_id = ? AND review_text <> '' AND hidden_at IS NULL", contentID)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order, ok := reviewSorts[filter.SortBy]
+	if !ok {
+		order = reviewSorts["helpful"]
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var reviews []ContentRating
+	err := query.Order(order).Limit(limit).Offset(filter.Offset).Find(&reviews).Error
+	return reviews, total, err
+}
+
+// findReview loads a rating with review text that is visible to learners
+func findReview(tx *gorm.DB, reviewID string) (*ContentRating, error) {
+	var review ContentRating
+	err := tx.Where("id = ? AND review_text <> '' AND hidden_at IS NULL", reviewID).First(&review).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("review not found")
+		}
+		return nil, err
+	}
+	return &review, nil
+}
+
+// VoteReview records whether the user found a review helpful, replacing any
+// earlier vote
+func (s *Service) VoteReview(ctx context.Context, reviewID, userID string, helpful bool) (*ContentRating, error) {
+	var review *ContentRating
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if review, err = findReview(tx, reviewID); err != nil {
+			return err
+		}
+		if review.UserID == userID {
+			return errors.New("cannot vote on your own review")
+		}
+
+		vote := ReviewVote{RatingID: reviewID, UserID: userID, Helpful: helpful}
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "rating_id"}, {Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"helpful": helpful, "updated_at": time.Now()}),
+		}).Create(&vote).Error
+		if err != nil {
+			return err
+		}
+		return countReviewVotes(tx, review)
+	})
+	return review, err
+}
+
+// RemoveReviewVote withdraws the user's vote on a review
+func (s *Service) RemoveReviewVote(ctx context.Context, reviewID, userID string) (*ContentRating, error) {
+	var review *ContentRating
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if review, err = findReview(tx, reviewID); err != nil {
+			return err
+		}
+		if err := tx.Where("rating_id = ? AND user_id = ?", reviewID, userID).Delete(&ReviewVote{}).Error; err != nil {
+			return err
+		}
+		return countReviewVotes(tx, review)
+	})
+	return review, err
+}
+
+// countReviewVotes recounts a review's votes from the votes themselves so
+// concurrent voters cannot skew them
+func countReviewVotes(tx *gorm.DB, review *ContentRating) error {
+	var counts struct {
+		Helpful   int
+		Unhelpful int
+	}
+	err := tx.Model(&ReviewVote{}).
+		Select("COUNT(*) FILTER (WHERE helpful) AS helpful, COUNT(*) FILTER (WHERE NOT helpful) AS unhelpful").
+		Where("rating_id = ?", review.ID).
+		Scan(&counts).Error
+	if err != nil {
+		return err
+	}
+
+	review.HelpfulCount, review.UnhelpfulCount = counts.Helpful, counts.Unhelpful
+	return tx.Model(review).UpdateColumns(map[string]interface{}{
+		"helpful_count":   counts.Helpful,
+		"unhelpful_count": counts.Unhelpful,
+	}).Error
+}
+
+// ReportReview flags a review for moderation. Reviews reported by several
+// learners are hidden until an admin looks at them.
+func (s *Service) ReportReview(ctx context.Context, reviewID, userID string, req ReportReviewRequest) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		review, err := findReview(tx, reviewID)
+		if err != nil {
+			return err
+		}
+		if review.UserID == userID {
+			return errors.New("cannot report your own review")
+		}
+
+		report := ReviewReport{RatingID: reviewID, UserID: userID, Reason: req.Reason, Details: req.Details}
+		created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&report)
+		if created.Error != nil {
+			return created.Error
+		}
+		if created.RowsAffected == 0 {
+			return errors.New("you already reported this review")
+		}
+
+		var open int64
+		if err := tx.Model(&ReviewReport{}).Where("rating_id = ? AND resolved_at IS NULL", reviewID).Count(&open).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"report_count": open}
+		if open >= reviewHideReports {
+			updates["hidden_at"] = time.Now()
+		}
+		if err := tx.Model(review).UpdateColumns(updates).Error; err != nil {
+			return err
+		}
+		if open >= reviewHideReports {
+			return refreshRatingStats(tx, review.ContentID)
+		}
+		return nil
+	})
+}
+
+// GetReportedReviews lists reviews with open reports, most reported first
+func (s *Service) GetReportedReviews(ctx context.Context, limit, offset int) ([]ReportedReview, int64, error) {
+	query := s.db.Model(&ContentRating{}).Where("report_count > 0")
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+	var reviews []ContentRating
+	if err := query.Order("report_count DESC, updated_at DESC").Limit(limit).Offset(offset).Find(&reviews).Error; err != nil {
+		return nil, 0, err
+	}
+
+	reported := make([]ReportedReview, len(reviews))
+	if len(reviews) == 0 {
+		return reported, total, nil
+	}
+
+	ids := make([]string, len(reviews))
+	for i, review := range reviews {
+		ids[i] = review.ID
+		reported[i].Review = review
+		reported[i].Reports = []ReviewReport{}
+	}
+	var reports []ReviewReport
+	if err := s.db.Where("rating_id IN ? AND resolved_at IS NULL", ids).Order("created_at ASC").Find(&reports).Error; err != nil {
+		return nil, 0, err
+	}
+	for _, report := range reports {
+		for i := range reported {
+			if reported[i].Review.ID == report.RatingID {
+				reported[i].Reports = append(reported[i].Reports, report)
+			}
+		}
+	}
+	return reported, total, nil
+}
+
+// ResolveReviewReports closes a review's open reports and either hides it or
+// restores it to the public listing
+func (s *Service) ResolveReviewReports(ctx context.Context, reviewID string, hide bool) (*ContentRating, error) {
+	var review ContentRating
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", reviewID).First(&review).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("review not found")
+			}
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&ReviewReport{}).Where("rating_id = ? AND resolved_at IS NULL", reviewID).Update("resolved_at", now).Error; err != nil {
+			return err
+		}
+
+		review.ReportCount = 0
+		review.HiddenAt = nil
+		if hide {
+			review.HiddenAt = &now
+		}
+		err := tx.Model(&review).UpdateColumns(map[string]interface{}{
+			"report_count": 0,
+			"hidden_at":    review.HiddenAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return refreshRatingStats(tx, review.ContentID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
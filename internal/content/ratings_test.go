@@ -0,0 +1,175 @@
+package content
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockService returns a service backed by sqlmock
+func newMockService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	require.NoError(t, err)
+
+	return &Service{db: db}, mock
+}
+
+func TestStarCounts(t *testing.T) {
+	assert.Equal(t,
+		"COUNT(*) FILTER (WHERE usefulness_rating = 1), COUNT(*) FILTER (WHERE usefulness_rating = 2), "+
+			"COUNT(*) FILTER (WHERE usefulness_rating = 3), COUNT(*) FILTER (WHERE usefulness_rating = 4), "+
+			"COUNT(*) FILTER (WHERE usefulness_rating = 5)",
+		starCounts("usefulness_rating"))
+}
+
+func TestUsesRatingStats(t *testing.T) {
+	assert.False(t, ContentFilter{}.usesRatingStats())
+	assert.False(t, ContentFilter{SortBy: "title", MinRating: 4}.usesRatingStats())
+	assert.True(t, ContentFilter{SortBy: "community_rating"}.usesRatingStats())
+	assert.True(t, ContentFilter{SortBy: "rating_count"}.usesRatingStats())
+	assert.True(t, ContentFilter{MinUsefulness: 3}.usesRatingStats())
+	assert.True(t, ContentFilter{MaxPerceivedDifficulty: 2}.usesRatingStats())
+	assert.True(t, ContentFilter{MinRatingCount: 10}.usesRatingStats())
+}
+
+// expectRefreshRatingStats expects the stats of one content to be rebuilt
+func expectRefreshRatingStats(mock sqlmock.Sqlmock, contentID string) {
+	mock.ExpectExec(`DELETE FROM "content_rating_stats" WHERE content_id IN \(\$1\)`).
+		WithArgs(contentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO content_rating_stats .* FROM content_ratings\s+WHERE deleted_at IS NULL AND content_id IN \(\$1\)\s+GROUP BY content_id`).
+		WithArgs(contentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestRefreshRatingStats(t *testing.T) {
+	t.Run("GivenContents", func(t *testing.T) {
+		service, mock := newMockService(t)
+		expectRefreshRatingStats(mock, "content-1")
+
+		require.NoError(t, refreshRatingStats(service.db, "content-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Aggregates", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectExec(`DELETE FROM "content_rating_stats" WHERE TRUE`).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+		// Hidden reviews still count as ratings but not as reviews, and the
+		// overall rating is the mean of usefulness and entertainment
+		mock.ExpectExec(`COUNT\(\*\) FILTER \(WHERE review_text <> '' AND hidden_at IS NULL\),\s+` +
+			`AVG\(difficulty_rating\), AVG\(usefulness_rating\), AVG\(entertainment_rating\),\s+` +
+			`AVG\(\(usefulness_rating \+ entertainment_rating\) / 2\.0\),\s+` +
+			`jsonb_build_array\(COUNT\(\*\) FILTER \(WHERE difficulty_rating = 1\).*` +
+			`WHERE deleted_at IS NULL AND TRUE`).
+			WithoutArgs().
+			WillReturnResult(sqlmock.NewResult(0, 4))
+
+		require.NoError(t, refreshRatingStats(service.db))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestReportReview(t *testing.T) {
+	req := ReportReviewRequest{Reason: "spam"}
+	reviewRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "user_id", "content_id", "review_text"}).
+			AddRow("review-1", "author", "content-1", "Great show")
+	}
+	expectReport := func(mock sqlmock.Sqlmock, open int) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "content_ratings" WHERE \(id = \$1 AND review_text <> '' AND hidden_at IS NULL\)`).
+			WithArgs("review-1", 1).
+			WillReturnRows(reviewRows())
+		mock.ExpectQuery(`INSERT INTO "review_reports" .* ON CONFLICT DO NOTHING`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("report-1"))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "review_reports" WHERE rating_id = \$1 AND resolved_at IS NULL`).
+			WithArgs("review-1").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(open))
+	}
+
+	t.Run("BelowThreshold", func(t *testing.T) {
+		service, mock := newMockService(t)
+		expectReport(mock, reviewHideReports-1)
+		mock.ExpectExec(`UPDATE "content_ratings" SET "report_count"=\$1 WHERE`).
+			WithArgs(reviewHideReports-1, "review-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, service.ReportReview(context.Background(), "review-1", "user-1", req))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("HidesAtThreshold", func(t *testing.T) {
+		service, mock := newMockService(t)
+		expectReport(mock, reviewHideReports)
+		mock.ExpectExec(`UPDATE "content_ratings" SET "hidden_at"=\$1,"report_count"=\$2 WHERE`).
+			WithArgs(sqlmock.AnyArg(), reviewHideReports, "review-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		// A hidden review leaves the review count of the stats
+		expectRefreshRatingStats(mock, "content-1")
+		mock.ExpectCommit()
+
+		require.NoError(t, service.ReportReview(context.Background(), "review-1", "user-1", req))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OwnReview", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "content_ratings"`).WillReturnRows(reviewRows())
+		mock.ExpectRollback()
+
+		assert.EqualError(t, service.ReportReview(context.Background(), "review-1", "author", req), "cannot report your own review")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadyReported", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "content_ratings"`).WillReturnRows(reviewRows())
+		mock.ExpectQuery(`INSERT INTO "review_reports"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		assert.EqualError(t, service.ReportReview(context.Background(), "review-1", "user-1", req), "you already reported this review")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestResolveReviewReports(t *testing.T) {
+	for _, hide := range []bool{true, false} {
+		service, mock := newMockService(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "content_ratings" WHERE id = \$1`).
+			WithArgs("review-1", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "content_id", "report_count"}).AddRow("review-1", "content-1", 4))
+		mock.ExpectExec(`UPDATE "review_reports" SET "resolved_at"=\$1 WHERE rating_id = \$2 AND resolved_at IS NULL`).
+			WithArgs(sqlmock.AnyArg(), "review-1").
+			WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectExec(`UPDATE "content_ratings" SET "hidden_at"=\$1,"report_count"=\$2 WHERE`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectRefreshRatingStats(mock, "content-1")
+		mock.ExpectCommit()
+
+		review, err := service.ResolveReviewReports(context.Background(), "review-1", hide)
+		require.NoError(t, err)
+		assert.Equal(t, 0, review.ReportCount)
+		assert.Equal(t, hide, review.HiddenAt != nil)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
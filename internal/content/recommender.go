@@ -0,0 +1,633 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// RecommendationOptions tune which content is recommended
+type RecommendationOptions struct {
+	LanguageID  int     // 0 uses the languages the user has watched content in
+	Limit       int     // Defaults to 10
+	Diversity   float64 // 0-1, how strongly content in genres already picked is held back
+	MaxPerGenre int     // Most recommendations sharing a genre, 0 for no cap
+}
+
+// Recommendation is a content picked for a user and why
+type Recommendation struct {
+	Content Content  `json:"content"`
+	Score   float64  `json:"score"`   // 0-1
+	Reasons []string `json:"reasons"` // Most important first
+}
+
+// How much each signal counts towards a recommendation. Signals without data
+// for a user or content are left out and the rest reweighted.
+const (
+	levelFitWeight      = 0.35
+	genreAffinityWeight = 0.25
+	collaborativeWeight = 0.25
+	qualityWeight       = 0.15
+)
+
+const (
+	// Recommended content is ideally this far above the learner's level (i+1)
+	levelStretch = 0.5
+	// Spread of the level fit around that target, in difficulty bands
+	levelTolerance = 0.75
+	// Preference assumed for content a learner watched without rating it
+	implicitPreference = 3.5
+	// Peers compared when predicting what a user will enjoy
+	maxPeers = 30
+	// Co-watched contents at which peer similarity counts half
+	peerOverlapHalfWeight = 3
+	// Ratings a content's average is shrunk towards the prior with
+	qualityPriorWeight = 5
+	qualityPrior       = 3.0
+	defaultDiversity   = 0.3
+	maxReasons         = 2
+)
+
+// recommendationItem is a content as the recommender sees it
+type recommendationItem struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Genres      []string `json:"genres"`
+	Difficulty  *float64 `json:"difficulty,omitempty"` // 0-6, nil when not estimated
+	RatingCount int      `json:"rating_count"`
+	AvgRating   float64  `json:"avg_rating"` // 1-5
+}
+
+// historyEntry is what the user did with a content
+type historyEntry struct {
+	ContentID     string  `json:"content_id"`
+	Minutes       int     `json:"minutes"`
+	Comprehension float64 `json:"comprehension"` // Percentage, 0 when never logged
+	Preference    float64 `json:"preference"`    // 1-5, 0 when the user gave no rating
+}
+
+// recommendationInput is everything the recommender needs. It is loaded from
+// the database, or from fixtures in tests.
+type recommendationInput struct {
+	Items   []recommendationItem `json:"items"`
+	History []historyEntry       `json:"history"`
+	// Other learners' preferences (1-5) by user and content
+	Peers map[string]map[string]float64 `json:"peers"`
+}
+
+// scoredItem is a recommended content with its score and reasons
+type scoredItem struct {
+	ID      string
+	Score   float64
+	Reasons []string
+	genres  []string
+}
+
+// reason is an explanation weighted by how much its signal contributed
+type reason struct {
+	text   string
+	weight float64
+}
+
+// recommend ranks the items the user has not watched and picks a diverse set
+func recommend(input recommendationInput, opts RecommendationOptions) []scoredItem {
+	if opts.Limit <= 0 {
+		opts.Limit = 10
+	}
+
+	items := make(map[string]recommendationItem, len(input.Items))
+	for _, item := range input.Items {
+		items[item.ID] = item
+	}
+	watched := make(map[string]historyEntry, len(input.History))
+	for _, entry := range input.History {
+		watched[entry.ContentID] = entry
+	}
+
+	level, hasLevel := learnerLevel(input.History, items)
+	affinity := genreAffinity(input.History, items)
+	predictions := collaborativePredictions(input.History, input.Peers)
+
+	candidates := make([]scoredItem, 0, len(input.Items))
+	for _, item := range input.Items {
+		if _, seen := watched[item.ID]; seen {
+			continue
+		}
+
+		var total, weights float64
+		var reasons []reason
+		add := func(score, weight float64) {
+			total += score * weight
+			weights += weight
+		}
+
+		if hasLevel && item.Difficulty != nil {
+			fit := levelFit(*item.Difficulty, level)
+			add(fit, levelFitWeight)
+			if fit >= 0.6 {
+				reasons = append(reasons, reason{levelReason(*item.Difficulty, level), fit * levelFitWeight})
+			}
+		}
+
+		if len(affinity) > 0 {
+			score := itemAffinity(item, affinity)
+			add(score, genreAffinityWeight)
+			if score > 0.6 {
+				if favourite := favouriteMatch(item, input.History, items); favourite != "" {
+					reasons = append(reasons, reason{fmt.Sprintf("Because you enjoyed %s", favourite), score * genreAffinityWeight})
+				} else {
+					reasons = append(reasons, reason{fmt.Sprintf("Matches your taste for %s", strings.Join(item.Genres, ", ")), score * genreAffinityWeight})
+				}
+			}
+		}
+
+		if prediction, ok := predictions[item.ID]; ok {
+			add(prediction.score, collaborativeWeight)
+			if prediction.score >= 0.65 && prediction.supporters >= 2 {
+				reasons = append(reasons, reason{"Learners with similar taste rated it highly", prediction.score * collaborativeWeight})
+			}
+		}
+
+		quality := qualityScore(item)
+		add(quality, qualityWeight)
+		if item.RatingCount >= 5 && item.AvgRating >= 4 {
+			reasons = append(reasons, reason{fmt.Sprintf("Rated %.1f/5 by %d learners", item.AvgRating, item.RatingCount), quality * qualityWeight})
+		}
+
+		sort.SliceStable(reasons, func(i, j int) bool { return reasons[i].weight > reasons[j].weight })
+		texts := make([]string, 0, maxReasons)
+		for _, r := range reasons {
+			if len(texts) == maxReasons {
+				break
+			}
+			texts = append(texts, r.text)
+		}
+		if len(texts) == 0 {
+			texts = append(texts, "Something new to explore")
+		}
+
+		candidates = append(candidates, scoredItem{
+			ID:      item.ID,
+			Score:   math.Round(total/weights*1000) / 1000,
+			Reasons: texts,
+			genres:  item.Genres,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].ID < candidates[j].ID
+	})
+
+	return diversify(candidates, opts)
+}
+
+// learnerLevel estimates the user's level on the 0-6 difficulty scale from
+// how much they understood of content of known difficulty. Understanding
+// three quarters of something is taken to mean it is at their level.
+func learnerLevel(history []historyEntry, items map[string]recommendationItem) (float64, bool) {
+	var total, weights float64
+	for _, entry := range history {
+		item, ok := items[entry.ContentID]
+		if !ok || item.Difficulty == nil || entry.Comprehension <= 0 {
+			continue
+		}
+		weight := math.Max(float64(entry.Minutes), 1)
+		total += (*item.Difficulty + (entry.Comprehension-75)/50) * weight
+		weights += weight
+	}
+	if weights == 0 {
+		return 0, false
+	}
+	return total / weights, true
+}
+
+// levelFit is 1 for content half a band above the learner's level and falls
+// off on either side
+func levelFit(difficulty, level float64) float64 {
+	distance := difficulty - (level + levelStretch)
+	return math.Exp(-distance * distance / (2 * levelTolerance * levelTolerance))
+}
+
+func levelReason(difficulty, level float64) string {
+	if CEFRLevel(difficulty) == CEFRLevel(level) {
+		return fmt.Sprintf("At your level (%s)", CEFRLevel(level))
+	}
+	if difficulty > level {
+		return fmt.Sprintf("A step up from your level (%s, you are around %s)", CEFRLevel(difficulty), CEFRLevel(level))
+	}
+	return fmt.Sprintf("Comfortable at your level (%s, you are around %s)", CEFRLevel(difficulty), CEFRLevel(level))
+}
+
+// preference is how much the user liked a content on a -1 to 1 scale.
+// Watching without rating counts as mild interest.
+func preference(entry historyEntry) float64 {
+	value := entry.Preference
+	if value == 0 {
+		value = implicitPreference
+	}
+	return (value - 3) / 2
+}
+
+// genreAffinity scores each genre from -1 to 1 by how much the user liked
+// what they watched in it
+func genreAffinity(history []historyEntry, items map[string]recommendationItem) map[string]float64 {
+	affinity := make(map[string]float64)
+	for _, entry := range history {
+		for _, genre := range items[entry.ContentID].Genres {
+			affinity[genre] += preference(entry)
+		}
+	}
+
+	var largest float64
+	for _, value := range affinity {
+		largest = math.Max(largest, math.Abs(value))
+	}
+	if largest == 0 {
+		return nil
+	}
+	for genre := range affinity {
+		affinity[genre] /= largest
+	}
+	return affinity
+}
+
+// itemAffinity maps the user's affinity for an item's genres to 0-1, with
+// 0.5 for genres they have not watched
+func itemAffinity(item recommendationItem, affinity map[string]float64) float64 {
+	if len(item.Genres) == 0 {
+		return 0.5
+	}
+	var total float64
+	for _, genre := range item.Genres {
+		total += affinity[genre]
+	}
+	return (total/float64(len(item.Genres)) + 1) / 2
+}
+
+// favouriteMatch returns the title of the best-liked content the user rated
+// 4 or more that shares the most genres with item
+func favouriteMatch(item recommendationItem, history []historyEntry, items map[string]recommendationItem) string {
+	best, bestShared, bestPreference := "", 0, 0.0
+	for _, entry := range history {
+		if entry.Preference < 4 {
+			continue
+		}
+		watched := items[entry.ContentID]
+		shared := sharedGenres(item.Genres, watched.Genres)
+		if shared > bestShared || (shared == bestShared && shared > 0 && entry.Preference > bestPreference) {
+			best, bestShared, bestPreference = watched.Title, shared, entry.Preference
+		}
+	}
+	return best
+}
+
+func sharedGenres(a, b []string) int {
+	shared := 0
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				shared++
+			}
+		}
+	}
+	return shared
+}
+
+// prediction is how much similar learners liked a content
+type prediction struct {
+	score      float64 // 0-1
+	supporters int     // Similar learners who watched it
+}
+
+// collaborativePredictions predicts the user's preference for what similar
+// learners watched. Similarity is the cosine of mean-centred preferences on
+// co-watched content, shrunk when they share little.
+func collaborativePredictions(history []historyEntry, peers map[string]map[string]float64) map[string]prediction {
+	predictions := make(map[string]prediction)
+	if len(history) == 0 || len(peers) == 0 {
+		return predictions
+	}
+
+	mine := make(map[string]float64, len(history))
+	for _, entry := range history {
+		mine[entry.ContentID] = preference(entry)
+	}
+	myMean := meanPreference(mine)
+
+	type neighbour struct {
+		id         string
+		similarity float64
+		mean       float64
+	}
+	neighbours := make([]neighbour, 0, len(peers))
+	for id, ratings := range peers {
+		theirs := make(map[string]float64, len(ratings))
+		for contentID, value := range ratings {
+			theirs[contentID] = (value - 3) / 2
+		}
+		theirMean := meanPreference(theirs)
+
+		var dot, myNorm, theirNorm float64
+		overlap := 0
+		for contentID, value := range mine {
+			other, ok := theirs[contentID]
+			if !ok {
+				continue
+			}
+			a, b := value-myMean, other-theirMean
+			dot += a * b
+			myNorm += a * a
+			theirNorm += b * b
+			overlap++
+		}
+		if overlap == 0 || myNorm == 0 || theirNorm == 0 {
+			continue
+		}
+
+		similarity := dot / math.Sqrt(myNorm*theirNorm) * float64(overlap) / float64(overlap+peerOverlapHalfWeight)
+		if similarity > 0 {
+			neighbours = append(neighbours, neighbour{id: id, similarity: similarity, mean: theirMean})
+		}
+	}
+
+	sort.Slice(neighbours, func(i, j int) bool {
+		if neighbours[i].similarity != neighbours[j].similarity {
+			return neighbours[i].similarity > neighbours[j].similarity
+		}
+		return neighbours[i].id < neighbours[j].id
+	})
+	if len(neighbours) > maxPeers {
+		neighbours = neighbours[:maxPeers]
+	}
+
+	sums := make(map[string]float64)
+	weights := make(map[string]float64)
+	supporters := make(map[string]int)
+	for _, n := range neighbours {
+		for contentID, value := range peers[n.id] {
+			if _, seen := mine[contentID]; seen {
+				continue
+			}
+			sums[contentID] += n.similarity * ((value-3)/2 - n.mean)
+			weights[contentID] += n.similarity
+			supporters[contentID]++
+		}
+	}
+
+	for contentID, sum := range sums {
+		// Back to -1..1 around the user's own mean, then to 0-1
+		predicted := math.Max(-1, math.Min(1, myMean+sum/weights[contentID]))
+		predictions[contentID] = prediction{score: (predicted + 1) / 2, supporters: supporters[contentID]}
+	}
+	return predictions
+}
+
+func meanPreference(values map[string]float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var total float64
+	for _, value := range values {
+		total += value
+	}
+	return total / float64(len(values))
+}
+
+// qualityScore is the content's average rating shrunk towards an average
+// one when few learners rated it, on a 0-1 scale
+func qualityScore(item recommendationItem) float64 {
+	average := (qualityPrior*qualityPriorWeight + item.AvgRating*float64(item.RatingCount)) / float64(qualityPriorWeight+item.RatingCount)
+	return (average - 1) / 4
+}
+
+// diversify picks recommendations greedily, holding back content in genres
+// that were already picked and skipping genres at their cap
+func diversify(candidates []scoredItem, opts RecommendationOptions) []scoredItem {
+	diversity := opts.Diversity
+	if diversity < 0 || diversity > 1 {
+		diversity = defaultDiversity
+	}
+
+	picked := make([]scoredItem, 0, opts.Limit)
+	genreCounts := make(map[string]int)
+	used := make([]bool, len(candidates))
+
+	for len(picked) < opts.Limit {
+		best, bestScore := -1, -1.0
+		for i, candidate := range candidates {
+			if used[i] {
+				continue
+			}
+
+			repeats := 0
+			capped := false
+			for _, genre := range candidate.genres {
+				repeats = max(repeats, genreCounts[genre])
+				if opts.MaxPerGenre > 0 && genreCounts[genre] >= opts.MaxPerGenre {
+					capped = true
+				}
+			}
+			if capped {
+				continue
+			}
+
+			score := candidate.Score * math.Pow(1-diversity, float64(repeats))
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		used[best] = true
+		picked = append(picked, candidates[best])
+		for _, genre := range candidates[best].genres {
+			genreCounts[genre]++
+		}
+	}
+	return picked
+}
+
+// splitGenres returns the slugs of a content's genres
+func splitGenres(genre string) []string {
+	names := splitGenreNames(genre)
+	for i, name := range names {
+		names[i] = genreSlug(name)
+	}
+	return names
+}
+
+// GetRecommendations recommends content the user has not watched, blending
+// their level, genre tastes, what similar learners enjoyed and ratings
+func (s *Service) GetRecommendations(ctx context.Context, userID string, opts RecommendationOptions) ([]Recommendation, error) {
+	input, err := s.loadRecommendationInput(userID, opts.LanguageID)
+	if err != nil {
+		return nil, err
+	}
+
+	scored := recommend(*input, opts)
+	if len(scored) == 0 {
+		return []Recommendation{}, nil
+	}
+
+	ids := make([]string, len(scored))
+	for i, item := range scored {
+		ids[i] = item.ID
+	}
+	var contents []Content
+	if err := s.db.Preload("Language").Where("id IN ?", ids).Find(&contents).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]Content, len(contents))
+	for _, content := range contents {
+		byID[content.ID] = content
+	}
+
+	recommendations := make([]Recommendation, 0, len(scored))
+	for _, item := range scored {
+		if content, ok := byID[item.ID]; ok {
+			recommendations = append(recommendations, Recommendation{Content: content, Score: item.Score, Reasons: item.Reasons})
+		}
+	}
+	return recommendations, nil
+}
+
+// loadRecommendationInput gathers the catalogue in the user's languages, what
+// they watched and rated, and the preferences of learners who watched the
+// same content
+func (s *Service) loadRecommendationInput(userID string, languageID int) (*recommendationInput, error) {
+	input := &recommendationInput{Peers: make(map[string]map[string]float64)}
+
+	// Minutes and comprehension from progress, preference from the
+	// enjoyment logged with progress and from ratings
+	err := s.db.Raw(`
+        SELECT content_id, SUM(minutes) AS minutes, COALESCE(MAX(comprehension), 0) AS comprehension, COALESCE(AVG(preference), 0) AS preference
+        FROM (
+            SELECT content_id, SUM(duration_minutes) AS minutes, AVG(NULLIF(comprehension_percentage, 0)) AS comprehension, AVG(NULLIF(enjoyment_rating, 0)) AS preference
+            FROM user_progress WHERE user_id = @user GROUP BY content_id
+            UNION ALL
+            SELECT content_id, 0, NULL, (entertainment_rating + usefulness_rating) / 2.0
+            FROM content_ratings WHERE user_id = @user AND deleted_at IS NULL AND entertainment_rating > 0
+        ) activity
+        GROUP BY content_id`, map[string]interface{}{"user": userID}).
+		Scan(&input.History).Error
+	if err != nil {
+		return nil, err
+	}
+
+	watchedIDs := make([]string, len(input.History))
+	for i, entry := range input.History {
+		watchedIDs[i] = entry.ContentID
+	}
+
+	languages := []int{}
+	if languageID > 0 {
+		languages = append(languages, languageID)
+	} else if len(watchedIDs) > 0 {
+		if err := s.db.Model(&Content{}).Where("id IN ?", watchedIDs).Distinct().Pluck("language_id", &languages).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var rows []struct {
+		ID           string
+		Title        string
+		Genre        string
+		DifficultyAt *string
+		Difficulty   float64
+		RatingCount  int
+		AvgRating    float64
+	}
+	query := s.db.Model(&Content{}).
+		Select(`contents.id, contents.title, contents.genre, CAST(contents.difficulty_updated_at AS text) AS difficulty_at, contents.difficulty_score AS difficulty,
+            COUNT(content_ratings.id) AS rating_count, COALESCE(AVG((content_ratings.entertainment_rating + content_ratings.usefulness_rating) / 2.0), 0) AS avg_rating`).
+		Joins("LEFT JOIN content_ratings ON content_ratings.content_id = contents.id AND content_ratings.deleted_at IS NULL AND content_ratings.entertainment_rating > 0").
+		Group("contents.id")
+	// Unpublished content is only loaded when the learner has watched it
+	if len(watchedIDs) > 0 {
+		query = query.Where("contents.status = ? OR contents.id IN ?", ContentStatusPublished, watchedIDs)
+	} else {
+		query = query.Where("contents.status = ?", ContentStatusPublished)
+	}
+	switch {
+	case len(languages) > 0 && len(watchedIDs) > 0:
+		query = query.Where("contents.language_id IN ? OR contents.id IN ?", languages, watchedIDs)
+	case len(languages) > 0:
+		query = query.Where("contents.language_id IN ?", languages)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		item := recommendationItem{
+			ID:          row.ID,
+			Title:       row.Title,
+			Genres:      splitGenres(row.Genre),
+			RatingCount: row.RatingCount,
+			AvgRating:   row.AvgRating,
+		}
+		if row.DifficultyAt != nil {
+			difficulty := row.Difficulty
+			item.Difficulty = &difficulty
+		}
+		input.Items = append(input.Items, item)
+	}
+
+	if len(watchedIDs) == 0 {
+		return input, nil
+	}
+
+	// Learners who watched the most of the same content, and everything
+	// they watched in the catalogue loaded above
+	var peerIDs []string
+	err = s.db.Table("user_progress").
+		Select("user_id").
+		Where("content_id IN ? AND user_id <> ?", watchedIDs, userID).
+		Group("user_id").
+		Order("COUNT(DISTINCT content_id) DESC").
+		Limit(200).
+		Pluck("user_id", &peerIDs).Error
+	if err != nil || len(peerIDs) == 0 {
+		return input, err
+	}
+
+	itemIDs := make([]string, len(input.Items))
+	for i, item := range input.Items {
+		itemIDs[i] = item.ID
+	}
+
+	var preferences []struct {
+		UserID     string
+		ContentID  string
+		Preference float64
+	}
+	err = s.db.Raw(`
+        SELECT user_id, content_id, COALESCE(AVG(preference), ?) AS preference
+        FROM (
+            SELECT user_id, content_id, CAST(NULLIF(enjoyment_rating, 0) AS numeric) AS preference
+            FROM user_progress WHERE user_id IN ? AND content_id IN ?
+            UNION ALL
+            SELECT user_id, content_id, (entertainment_rating + usefulness_rating) / 2.0
+            FROM content_ratings WHERE user_id IN ? AND content_id IN ? AND deleted_at IS NULL AND entertainment_rating > 0
+        ) activity
+        GROUP BY user_id, content_id`, implicitPreference, peerIDs, itemIDs, peerIDs, itemIDs).
+		Scan(&preferences).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range preferences {
+		if input.Peers[p.UserID] == nil {
+			input.Peers[p.UserID] = make(map[string]float64)
+		}
+		input.Peers[p.UserID][p.ContentID] = p.Preference
+	}
+	return input, nil
+}
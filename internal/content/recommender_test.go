@@ -0,0 +1,150 @@
+package content
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The fixture is a B1 learner who loved a crime drama, liked a comedy drama
+// and did not enjoy a documentary. Two peers share their taste, one does not.
+func loadRecommendationFixture(t *testing.T) recommendationInput {
+	data, err := os.ReadFile("testdata/recommendations.json")
+	require.NoError(t, err)
+
+	var input recommendationInput
+	require.NoError(t, json.Unmarshal(data, &input))
+	return input
+}
+
+func recommendedIDs(items []scoredItem) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func TestLearnerLevel(t *testing.T) {
+	input := loadRecommendationFixture(t)
+	items := make(map[string]recommendationItem)
+	for _, item := range input.Items {
+		items[item.ID] = item
+	}
+
+	level, ok := learnerLevel(input.History, items)
+	require.True(t, ok)
+	assert.InDelta(t, 2.52, level, 0.01)
+	assert.Equal(t, "B1", CEFRLevel(level))
+
+	_, ok = learnerLevel(nil, items)
+	assert.False(t, ok)
+}
+
+func TestLevelFitPrefersSlightlyHarderContent(t *testing.T) {
+	level := 2.5
+	assert.InDelta(t, 1.0, levelFit(3.0, level), 0.001)
+	assert.Greater(t, levelFit(3.0, level), levelFit(2.5, level))
+	assert.Greater(t, levelFit(2.5, level), levelFit(5.5, level))
+	assert.Greater(t, levelFit(2.5, level), levelFit(0.5, level))
+}
+
+func TestRecommend(t *testing.T) {
+	input := loadRecommendationFixture(t)
+	picked := recommend(input, RecommendationOptions{Limit: 20})
+	ids := recommendedIDs(picked)
+
+	t.Run("SkipsWatchedContent", func(t *testing.T) {
+		for _, entry := range input.History {
+			assert.NotContains(t, ids, entry.ContentID)
+		}
+		assert.Len(t, ids, len(input.Items)-len(input.History))
+	})
+
+	t.Run("RanksLikedGenresAtTheNextLevelFirst", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"elite", "vis-a-vis", "narcos"}, ids[:3])
+		assert.Less(t, indexOf(ids, "elite"), indexOf(ids, "ministerio-del-tiempo"))
+		assert.Less(t, indexOf(ids, "elite"), indexOf(ids, "peppa"))
+		assert.Equal(t, "peppa", ids[len(ids)-1])
+	})
+
+	t.Run("ExplainsRecommendations", func(t *testing.T) {
+		assert.Contains(t, picked[indexOf(ids, "elite")].Reasons, "Because you enjoyed La casa de papel")
+		assert.Contains(t, picked[indexOf(ids, "pequenas-coincidencias")].Reasons, "Learners with similar taste rated it highly")
+		for _, item := range picked {
+			assert.NotEmpty(t, item.Reasons)
+			assert.LessOrEqual(t, len(item.Reasons), maxReasons)
+			assert.GreaterOrEqual(t, item.Score, 0.0)
+			assert.LessOrEqual(t, item.Score, 1.0)
+		}
+	})
+}
+
+func TestRecommendDiversity(t *testing.T) {
+	input := loadRecommendationFixture(t)
+
+	t.Run("WithoutDiversityTopPicksShareGenres", func(t *testing.T) {
+		ids := recommendedIDs(recommend(input, RecommendationOptions{Limit: 3}))
+		assert.ElementsMatch(t, []string{"elite", "vis-a-vis", "narcos"}, ids)
+	})
+
+	t.Run("DiversityHoldsBackRepeatedGenres", func(t *testing.T) {
+		ids := recommendedIDs(recommend(input, RecommendationOptions{Limit: 3, Diversity: 0.5}))
+		assert.Equal(t, "elite", ids[0])
+		assert.Contains(t, ids, "pequenas-coincidencias")
+	})
+
+	t.Run("MaxPerGenreCapsEachGenre", func(t *testing.T) {
+		picked := recommend(input, RecommendationOptions{Limit: 10, MaxPerGenre: 1})
+		seen := make(map[string]bool)
+		for _, item := range picked {
+			for _, genre := range item.genres {
+				assert.False(t, seen[genre], "genre %s picked twice", genre)
+				seen[genre] = true
+			}
+		}
+		assert.Equal(t, "elite", picked[0].ID)
+	})
+}
+
+func TestCollaborativePredictions(t *testing.T) {
+	input := loadRecommendationFixture(t)
+	predictions := collaborativePredictions(input.History, input.Peers)
+
+	require.Contains(t, predictions, "pequenas-coincidencias")
+	assert.Equal(t, 2, predictions["pequenas-coincidencias"].supporters)
+	assert.GreaterOrEqual(t, predictions["pequenas-coincidencias"].score, 0.65)
+
+	// Only the peer with the opposite taste watched it
+	assert.NotContains(t, predictions, "planeta-tierra")
+	// Already watched
+	assert.NotContains(t, predictions, "casa-de-papel")
+}
+
+func TestRecommendColdStart(t *testing.T) {
+	input := loadRecommendationFixture(t)
+	input.History = nil
+
+	picked := recommend(input, RecommendationOptions{Limit: 3})
+	require.Len(t, picked, 3)
+	assert.Equal(t, "casa-de-papel", picked[0].ID)
+	assert.Equal(t, []string{"Rated 4.4/5 by 40 learners"}, picked[0].Reasons)
+}
+
+func TestSplitGenres(t *testing.T) {
+	assert.Equal(t, []string{"crime", "drama"}, splitGenres("Crime, Drama"))
+	assert.Equal(t, []string{"sci-fi", "comedy"}, splitGenres("Sci-Fi / Comedy|"))
+	assert.Empty(t, splitGenres(""))
+}
+
+func indexOf(ids []string, id string) int {
+	for i, candidate := range ids {
+		if candidate == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -1,6 +1,11 @@
 package content
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,6 +16,7 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/Raylynd6299/babel/internal/shared/storage"
 	polyfyjwt "github.com/Raylynd6299/babel/pkg/jwt"
 
 	_ "github.com/Raylynd6299/babel/cmd/content-service/docs"
@@ -55,11 +61,23 @@ func NewRouter(service *Service) *gin.Engine {
 	public := v1.Group("/content")
 	{
 		public.GET("/", contentRouter.GetContentList)
-		public.GET("/:id", contentRouter.GetContent)
-		public.GET("/:id/episodes", contentRouter.GetContentEpisodes)
+		public.GET("/:id", contentRouter.jwtService.OptionalAuthMiddleware(), contentRouter.GetContent)
+		public.GET("/:id/episodes", contentRouter.jwtService.OptionalAuthMiddleware(), contentRouter.GetContentEpisodes)
+		public.GET("/:id/episodes/:episode_id/transcript", contentRouter.jwtService.OptionalAuthMiddleware(), contentRouter.GetEpisodeTranscript)
+		public.GET("/:id/episodes/:episode_id/subtitles", contentRouter.jwtService.OptionalAuthMiddleware(), contentRouter.GetSubtitleTracks)
+		public.GET("/:id/episodes/:episode_id/subtitles/:language", contentRouter.jwtService.OptionalAuthMiddleware(), contentRouter.GetSubtitleTrack)
+		public.GET("/subtitles/search", contentRouter.SearchSubtitles)
+		public.GET("/:id/difficulty", contentRouter.jwtService.OptionalAuthMiddleware(), contentRouter.GetContentDifficulty)
+		public.GET("/:id/ratings", contentRouter.jwtService.OptionalAuthMiddleware(), contentRouter.GetRatingStats)
+		public.GET("/:id/reviews", contentRouter.jwtService.OptionalAuthMiddleware(), contentRouter.GetReviews)
 		public.GET("/languages", contentRouter.GetLanguages)
+		public.GET("/search", contentRouter.SearchContent)
+		public.GET("/genres", contentRouter.GetGenres)
 	}
 
+	// Stored files (posters, audio and avatars) are linked from public records
+	v1.GET("/files/*key", contentRouter.GetFile)
+
 	// Protected routes
 	protected := v1.Group("/content")
 	protected.Use(contentRouter.jwtService.AuthMiddleware())
@@ -67,11 +85,47 @@ func NewRouter(service *Service) *gin.Engine {
 		protected.POST("/", contentRouter.CreateContent)
 		protected.PUT("/:id", contentRouter.UpdateContent)
 		protected.DELETE("/:id", contentRouter.DeleteContent)
+		protected.GET("/mine", contentRouter.GetMyContent)
+		protected.POST("/:id/submit", contentRouter.SubmitContent)
+		protected.POST("/:id/withdraw", contentRouter.WithdrawContent)
+		protected.GET("/:id/history", contentRouter.GetContentHistory)
 		protected.POST("/:id/rate", contentRouter.RateContent)
+		protected.POST("/reviews/:review_id/vote", contentRouter.VoteReview)
+		protected.DELETE("/reviews/:review_id/vote", contentRouter.RemoveReviewVote)
+		protected.POST("/reviews/:review_id/report", contentRouter.ReportReview)
 		protected.POST("/:id/episodes", contentRouter.CreateEpisode)
 		protected.PUT("/:id/episodes/:episode_id", contentRouter.UpdateEpisode)
 		protected.DELETE("/:id/episodes/:episode_id", contentRouter.DeleteEpisode)
+		protected.PUT("/:id/episodes/:episode_id/transcript", contentRouter.SetEpisodeTranscript)
+		protected.DELETE("/:id/episodes/:episode_id/transcript", contentRouter.DeleteEpisodeTranscript)
+		protected.PUT("/:id/episodes/:episode_id/subtitles/:language", contentRouter.UploadSubtitleTrack)
+		protected.DELETE("/:id/episodes/:episode_id/subtitles/:language", contentRouter.DeleteSubtitleTrack)
 		protected.GET("/recommendations", contentRouter.GetRecommendations)
+		protected.POST("/:id/poster", contentRouter.UploadPoster)
+		protected.GET("/metadata/search", contentRouter.SearchMetadata)
+		protected.POST("/import", contentRouter.ImportMetadata)
+	}
+
+	// Admin routes
+	admin := v1.Group("/content/admin")
+	admin.Use(contentRouter.jwtService.AdminMiddleware())
+	{
+		admin.POST("/storage/cleanup", contentRouter.CleanupOrphanedUploads)
+		admin.POST("/difficulty/recompute", contentRouter.RecomputeDifficulty)
+		admin.POST("/metadata/refresh", contentRouter.RefreshMetadata)
+	}
+
+	// Editor routes
+	editor := v1.Group("/content/admin")
+	editor.Use(contentRouter.jwtService.EditorMiddleware())
+	{
+		editor.GET("/review-queue", contentRouter.GetReviewQueue)
+		editor.POST("/review-queue/:id", contentRouter.ReviewContent)
+		editor.GET("/audit", contentRouter.GetAuditTrail)
+		editor.GET("/reviews/reported", contentRouter.GetReportedReviews)
+		editor.POST("/reviews/:review_id/resolve", contentRouter.ResolveReviewReports)
+		editor.POST("/catalogue/import", contentRouter.ImportCatalogue)
+		editor.GET("/catalogue/export", contentRouter.ExportCatalogue)
 	}
 
 	return router
@@ -79,7 +133,7 @@ func NewRouter(service *Service) *gin.Engine {
 
 // CreateContent godoc
 // @Summary      Create new content
-// @Description  Create a new piece of content (movie, series, etc.)
+// @Description  Create a new piece of content (movie, series, etc.) as a draft, to be submitted for review. Fails when the same title, year, type and language is already in the catalogue.
 // @Tags         content
 // @Accept       json
 // @Produce      json
@@ -87,6 +141,7 @@ func NewRouter(service *Service) *gin.Engine {
 // @Success      201 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
+// @Failure      409 {object} map[string]interface{}
 // @Security     BearerAuth
 // @Router       /content [post]
 func (r *Router) CreateContent(c *gin.Context) {
@@ -109,7 +164,7 @@ func (r *Router) CreateContent(c *gin.Context) {
 
 	content, err := r.service.CreateContent(c.Request.Context(), req, userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeContentError(c, err)
 		return
 	}
 
@@ -118,7 +173,7 @@ func (r *Router) CreateContent(c *gin.Context) {
 
 // GetContent godoc
 // @Summary      Get content by ID
-// @Description  Get detailed information about a specific content item
+// @Description  Get detailed information about a specific content item. Unpublished content is only returned to its owner.
 // @Tags         content
 // @Accept       json
 // @Produce      json
@@ -126,6 +181,7 @@ func (r *Router) CreateContent(c *gin.Context) {
 // @Success      200 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
 // @Failure      404 {object} map[string]string
+// @Security     BearerAuth
 // @Router       /content/{id} [get]
 func (r *Router) GetContent(c *gin.Context) {
 	id := c.Param("id")
@@ -134,7 +190,10 @@ func (r *Router) GetContent(c *gin.Context) {
 		return
 	}
 
-	content, err := r.service.GetContent(c.Request.Context(), id)
+	// Set by the optional authentication on this route
+	viewerID, _ := polyfyjwt.GetUserIDFromContext(c)
+
+	content, err := r.service.GetContent(c.Request.Context(), id, viewerID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -145,7 +204,7 @@ func (r *Router) GetContent(c *gin.Context) {
 
 // UpdateContent godoc
 // @Summary      Update content
-// @Description  Update an existing content item
+// @Description  Update an existing content item. Changing published content, its episodes, transcripts, subtitles or poster sends it back to the review queue, unverified, until an editor publishes it again.
 // @Tags         content
 // @Accept       json
 // @Produce      json
@@ -154,6 +213,7 @@ func (r *Router) GetContent(c *gin.Context) {
 // @Success      200 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
+// @Failure      409 {object} map[string]interface{}
 // @Security     BearerAuth
 // @Router       /content/{id} [put]
 func (r *Router) UpdateContent(c *gin.Context) {
@@ -182,7 +242,7 @@ func (r *Router) UpdateContent(c *gin.Context) {
 
 	content, err := r.service.UpdateContent(c.Request.Context(), id, req, userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeContentError(c, err)
 		return
 	}
 
@@ -223,166 +283,70 @@ func (r *Router) DeleteContent(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Content deleted successfully"})
 }
 
-// RateContent godoc
-// @Summary      Rate content
-// @Description  Rate a content item with difficulty, usefulness and entertainment ratings
-// @Tags         ratings
-// @Accept       json
-// @Produce      json
-// @Param        id path string true "Content ID"
-// @Param        request body RateContentRequest true "Rating data"
-// @Success      200 {object} map[string]string
-// @Failure      400 {object} map[string]string
-// @Failure      401 {object} map[string]string
-// @Security     BearerAuth
-// @Router       /content/{id}/rate [post]
-func (r *Router) RateContent(c *gin.Context) {
-	userID, exists := polyfyjwt.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
-
-	contentID := c.Param("id")
-	if contentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Content ID required"})
-		return
-	}
-
-	var req RateContentRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// writeContentError responds to a failed content change, listing the
+// catalogue entries it would duplicate when that is why it failed
+func writeContentError(c *gin.Context, err error) {
+	var duplicate *DuplicateContentError
+	if errors.As(err, &duplicate) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "duplicates": duplicate.Duplicates})
 		return
 	}
-
-	if err := r.validator.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if errors.Is(err, errOwnContentReview) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		return
 	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
 
-	rating := ContentRating{
-		UserID:              userID,
-		ContentID:           contentID,
-		DifficultyRating:    req.DifficultyRating,
-		UsefulnessRating:    req.UsefulnessRating,
-		EntertainmentRating: req.EntertainmentRating,
-		ReviewText:          req.ReviewText,
+// parsePage reads the limit and offset query parameters
+func parsePage(c *gin.Context) (limit, offset int) {
+	limit, offset = 20, 0
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
 	}
-
-	err := r.service.RateContent(c.Request.Context(), userID, contentID, rating)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Content rated successfully"})
+	return limit, offset
 }
 
-// GetContentList godoc
-// @Summary      Get content list
-// @Description  Get a paginated list of content with filtering options
-// @Tags         content
+// GetMyContent godoc
+// @Summary      List my content
+// @Description  List the content the current user created in any submission state, with the editor's notes on reviewed content
+// @Tags         moderation
 // @Accept       json
 // @Produce      json
-// @Param        language_id query int false "Language ID"
-// @Param        content_type query string false "Content type (movie, series, etc.)"
-// @Param        genre query string false "Genre"
-// @Param        country query string false "Country"
-// @Param        min_rating query number false "Minimum rating"
-// @Param        max_rating query number false "Maximum rating"
-// @Param        difficulty query string false "Difficulty levels (comma-separated)"
-// @Param        year_from query int false "Year from"
-// @Param        year_to query int false "Year to"
-// @Param        search query string false "Search term"
+// @Param        status query string false "Submission states (draft, pending_review, published, rejected), comma-separated"
 // @Param        limit query int false "Limit (max 100)" default(20)
 // @Param        offset query int false "Offset" default(0)
-// @Param        sort_by query string false "Sort by field"
-// @Param        sort_direction query string false "Sort direction (asc/desc)"
 // @Success      200 {object} map[string]interface{}
-// @Failure      500 {object} map[string]string
-// @Router       /content [get]
-func (r *Router) GetContentList(c *gin.Context) {
-	filter := ContentFilter{
-		Limit:  20,
-		Offset: 0,
-	}
-
-	// Parse query parameters
-	if languageID := c.Query("language_id"); languageID != "" {
-		if id, err := strconv.Atoi(languageID); err == nil {
-			filter.LanguageID = id
-		}
-	}
-
-	if contentType := c.Query("content_type"); contentType != "" {
-		filter.ContentType = contentType
-	}
-
-	if genre := c.Query("genre"); genre != "" {
-		filter.Genre = genre
-	}
-
-	if country := c.Query("country"); country != "" {
-		filter.Country = country
-	}
-
-	if minRating := c.Query("min_rating"); minRating != "" {
-		if rating, err := strconv.ParseFloat(minRating, 32); err == nil {
-			filter.MinRating = float32(rating)
-		}
-	}
-
-	if maxRating := c.Query("max_rating"); maxRating != "" {
-		if rating, err := strconv.ParseFloat(maxRating, 32); err == nil {
-			filter.MaxRating = float32(rating)
-		}
-	}
-
-	if difficulty := c.Query("difficulty"); difficulty != "" {
-		filter.Difficulty = strings.Split(difficulty, ",")
-	}
-
-	if yearFrom := c.Query("year_from"); yearFrom != "" {
-		if year, err := strconv.Atoi(yearFrom); err == nil {
-			filter.YearFrom = year
-		}
-	}
-
-	if yearTo := c.Query("year_to"); yearTo != "" {
-		if year, err := strconv.Atoi(yearTo); err == nil {
-			filter.YearTo = year
-		}
-	}
-
-	if search := c.Query("search"); search != "" {
-		filter.Search = search
-	}
-
-	if limit := c.Query("limit"); limit != "" {
-		if limit_v, err := strconv.Atoi(limit); err == nil && limit_v > 0 && limit_v <= 100 {
-			filter.Limit = limit_v
-		}
-	}
-
-	if offset := c.Query("offset"); offset != "" {
-		if offset_v, err := strconv.Atoi(offset); err == nil && offset_v >= 0 {
-			filter.Offset = offset_v
-		}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/mine [get]
+func (r *Router) GetMyContent(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
 	}
 
-	if sortBy := c.Query("sort_by"); sortBy != "" {
-		validSorts := []string{"title", "year", "rating", "difficulty", "created_at", "view_count"}
-		for _, valid := range validSorts {
-			if sortBy == valid {
-				filter.SortBy = sortBy
-				break
+	filter := ContentFilter{CreatedBy: userID, SortBy: "created_at", SortDirection: "desc"}
+	if status := c.Query("status"); status != "" {
+		for _, s := range strings.Split(status, ",") {
+			s = strings.TrimSpace(s)
+			if _, ok := contentTransitions[s]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid status %q", s)})
+				return
 			}
+			filter.Statuses = append(filter.Statuses, s)
 		}
 	}
-
-	if sortDirection := c.Query("sort_direction"); sortDirection == "asc" || sortDirection == "desc" {
-		filter.SortDirection = sortDirection
-	}
+	filter.Limit, filter.Offset = parsePage(c)
 
 	contents, total, err := r.service.GetContentList(c.Request.Context(), filter)
 	if err != nil {
@@ -398,153 +362,1015 @@ func (r *Router) GetContentList(c *gin.Context) {
 	})
 }
 
-// GetContentEpisodes godoc
-// @Summary      Get content episodes
-// @Description  Get all episodes for a specific content item
-// @Tags         episodes
+// SubmitContent godoc
+// @Summary      Submit content for review
+// @Description  Send draft or rejected content to the editors' review queue. Content only appears in public listings once an editor publishes it.
+// @Tags         moderation
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "Content ID"
 // @Success      200 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
-// @Router       /content/{id}/episodes [get]
-func (r *Router) GetContentEpisodes(c *gin.Context) {
-	contentID := c.Param("id")
-	if contentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Content ID required"})
+// @Failure      401 {object} map[string]string
+// @Failure      409 {object} map[string]interface{}
+// @Security     BearerAuth
+// @Router       /content/{id}/submit [post]
+func (r *Router) SubmitContent(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	episodes, err := r.service.GetContentEpisodes(c.Request.Context(), contentID)
+	content, err := r.service.SubmitContent(c.Request.Context(), c.Param("id"), userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeContentError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"episodes": episodes})
+	c.JSON(http.StatusOK, gin.H{"content": content})
 }
 
-// CreateEpisode godoc
-// @Summary      Create episode
-// @Description  Create a new episode for a content item
-// @Tags         episodes
+// WithdrawContent godoc
+// @Summary      Withdraw content from review
+// @Description  Take content pending review out of the review queue, back to a draft
+// @Tags         moderation
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "Content ID"
-// @Param        request body CreateEpisodeRequest true "Episode data"
-// @Success      201 {object} map[string]interface{}
+// @Success      200 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
 // @Security     BearerAuth
-// @Router       /content/{id}/episodes [post]
-func (r *Router) CreateEpisode(c *gin.Context) {
+// @Router       /content/{id}/withdraw [post]
+func (r *Router) WithdrawContent(c *gin.Context) {
 	userID, exists := polyfyjwt.GetUserIDFromContext(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	contentID := c.Param("id")
-	if contentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Content ID required"})
-		return
-	}
-
-	var req CreateEpisodeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	if err := r.validator.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	episode, err := r.service.CreateEpisode(c.Request.Context(), contentID, req, userID)
+	content, err := r.service.WithdrawContent(c.Request.Context(), c.Param("id"), userID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"episode": episode})
+	c.JSON(http.StatusOK, gin.H{"content": content})
 }
 
-// UpdateEpisode godoc
-// @Summary      Update episode
-// @Description  Update an existing episode
-// @Tags         episodes
+// GetContentHistory godoc
+// @Summary      Get content history
+// @Description  Get the audit trail of changes to the current user's content and its episodes, newest first
+// @Tags         moderation
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "Content ID"
-// @Param        episode_id path string true "Episode ID"
-// @Param        request body CreateEpisodeRequest true "Episode update data"
+// @Param        limit query int false "Limit (max 100)" default(20)
+// @Param        offset query int false "Offset" default(0)
 // @Success      200 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
 // @Security     BearerAuth
-// @Router       /content/{id}/episodes/{episode_id} [put]
-func (r *Router) UpdateEpisode(c *gin.Context) {
+// @Router       /content/{id}/history [get]
+func (r *Router) GetContentHistory(c *gin.Context) {
 	userID, exists := polyfyjwt.GetUserIDFromContext(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	episodeID := c.Param("episode_id")
-	if episodeID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Episode ID required"})
-		return
-	}
-
-	var req CreateEpisodeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	limit, offset := parsePage(c)
+	entries, total, err := r.service.GetContentHistory(c.Request.Context(), c.Param("id"), userID, limit, offset)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := r.validator.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
 
-	episode, err := r.service.UpdateEpisode(c.Request.Context(), episodeID, req, userID)
+// GetReviewQueue godoc
+// @Summary      Get review queue
+// @Description  List content pending review, longest waiting first, each with other catalogue entries of the same title released within a year that it may duplicate
+// @Tags         moderation
+// @Accept       json
+// @Produce      json
+// @Param        limit query int false "Limit (max 100)" default(20)
+// @Param        offset query int false "Offset" default(0)
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/admin/review-queue [get]
+func (r *Router) GetReviewQueue(c *gin.Context) {
+	limit, offset := parsePage(c)
+	queue, total, err := r.service.GetReviewQueue(c.Request.Context(), limit, offset)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"episode": episode})
+	c.JSON(http.StatusOK, gin.H{
+		"queue":  queue,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
 }
 
-// DeleteEpisode godoc
-// @Summary      Delete episode
-// @Description  Delete an existing episode
-// @Tags         episodes
+// ReviewContent godoc
+// @Summary      Review content
+// @Description  Publish content pending review, marking it verified, or reject it with notes for its owner. Published content can also be rejected to take it down. Requires the editor role, and editors can't review content they created.
+// @Tags         moderation
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "Content ID"
-// @Param        episode_id path string true "Episode ID"
-// @Success      200 {object} map[string]string
+// @Param        request body ReviewContentRequest true "Decision"
+// @Success      200 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      409 {object} map[string]interface{}
 // @Security     BearerAuth
-// @Router       /content/{id}/episodes/{episode_id} [delete]
-func (r *Router) DeleteEpisode(c *gin.Context) {
-	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+// @Router       /content/admin/review-queue/{id} [post]
+func (r *Router) ReviewContent(c *gin.Context) {
+	editorID, exists := polyfyjwt.GetUserIDFromContext(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	episodeID := c.Param("episode_id")
-	if episodeID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Episode ID required"})
+	var req ReviewContentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err := r.service.DeleteEpisode(c.Request.Context(), episodeID, userID)
-	if err != nil {
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	content, err := r.service.ReviewContent(c.Request.Context(), c.Param("id"), editorID, req)
+	if err != nil {
+		writeContentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"content": content})
+}
+
+// GetAuditTrail godoc
+// @Summary      Get audit trail
+// @Description  List changes to content and episodes, newest first
+// @Tags         moderation
+// @Accept       json
+// @Produce      json
+// @Param        content_id query string false "Content ID"
+// @Param        user_id query string false "User who made the changes"
+// @Param        action query string false "Action, e.g. content.updated, content.status_changed, content.metadata_imported, episode.deleted"
+// @Param        limit query int false "Limit (max 100)" default(20)
+// @Param        offset query int false "Offset" default(0)
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/admin/audit [get]
+func (r *Router) GetAuditTrail(c *gin.Context) {
+	filter := AuditFilter{
+		ContentID: c.Query("content_id"),
+		UserID:    c.Query("user_id"),
+		Action:    c.Query("action"),
+	}
+	filter.Limit, filter.Offset = parsePage(c)
+
+	entries, total, err := r.service.GetAuditTrail(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
+
+// ImportCatalogue godoc
+// @Summary      Import catalogue
+// @Description  Create or update content with its seasons and episodes from a CSV or JSON catalogue file (up to 20 MB), sent as the request body or as the multipart field "file". Content is matched by title, year and language; blank values keep what is stored and episodes are matched by season and episode number. CSV files have one row per episode with the columns title, content_type, language, year_released, country, genres, description, poster_url, imdb_rating, average_episode_duration, season_number, episode_number, episode_title, episode_duration_minutes and episode_description. The whole file is validated first and nothing is written if it has errors or on a dry run. Requires the editor role, as the content it creates is published.
+// @Tags         content
+// @Accept       json,text/csv,multipart/form-data
+// @Produce      json
+// @Param        format query string false "File format, detected from the file name or content type when omitted" Enums(json, csv)
+// @Param        dry_run query bool false "Validate and report the changes without writing them"
+// @Param        file formData file false "Catalogue file"
+// @Success      200 {object} CatalogueImportReport
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      413 {object} map[string]string
+// @Failure      422 {object} CatalogueImportReport
+// @Security     BearerAuth
+// @Router       /content/admin/catalogue/import [post]
+func (r *Router) ImportCatalogue(c *gin.Context) {
+	editorID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCatalogueFileSize+64<<10)
+	body := io.Reader(c.Request.Body)
+	filename := ""
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Catalogue files are limited to 20 MB"})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "multipart field \"file\" required"})
+			return
+		}
+		defer file.Close()
+		body, filename = file, header.Filename
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = CatalogueFormatJSON
+		if strings.HasSuffix(strings.ToLower(filename), ".csv") || strings.Contains(c.ContentType(), "csv") {
+			format = CatalogueFormatCSV
+		}
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	report, err := r.service.ImportCatalogue(c.Request.Context(), editorID, body, format, dryRun)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Catalogue files are limited to 20 MB"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !report.Valid {
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportCatalogue godoc
+// @Summary      Export catalogue
+// @Description  Download every content with its seasons and episodes as a JSON or CSV catalogue file, in the format the catalogue import reads. Requires the editor role, as drafts are included unless status is set.
+// @Tags         content
+// @Produce      json,text/csv
+// @Param        format query string false "File format" Enums(json, csv) default(json)
+// @Param        status query string false "Comma-separated statuses to export, e.g. published"
+// @Success      200 {file} file
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/admin/catalogue/export [get]
+func (r *Router) ExportCatalogue(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", CatalogueFormatJSON))
+	contentType := "application/json"
+	switch format {
+	case CatalogueFormatJSON:
+	case CatalogueFormatCSV:
+		contentType = "text/csv; charset=utf-8"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	var statuses []string
+	if status := c.Query("status"); status != "" {
+		for _, s := range strings.Split(status, ",") {
+			s = strings.TrimSpace(s)
+			if _, ok := contentTransitions[s]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid status %q", s)})
+				return
+			}
+			statuses = append(statuses, s)
+		}
+	}
+
+	filename := fmt.Sprintf("catalogue-%s.%s", time.Now().Format("20060102"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// Headers are sent by now, so a failure can only cut the file short
+	if err := r.service.ExportCatalogue(c.Request.Context(), c.Writer, format, statuses); err != nil {
+		log.Printf("Failed to export catalogue: %v", err)
+	}
+}
+
+// RateContent godoc
+// @Summary      Rate content
+// @Description  Rate a published content item with difficulty, usefulness and entertainment ratings
+// @Tags         ratings
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Param        request body RateContentRequest true "Rating data"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/{id}/rate [post]
+func (r *Router) RateContent(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	contentID := c.Param("id")
+	if contentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Content ID required"})
+		return
+	}
+
+	var req RateContentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rating := ContentRating{
+		UserID:              userID,
+		ContentID:           contentID,
+		DifficultyRating:    req.DifficultyRating,
+		UsefulnessRating:    req.UsefulnessRating,
+		EntertainmentRating: req.EntertainmentRating,
+		ReviewText:          req.ReviewText,
+	}
+
+	err := r.service.RateContent(c.Request.Context(), userID, contentID, rating)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Content rated successfully"})
+}
+
+// GetRatingStats godoc
+// @Summary      Get content rating stats
+// @Description  Get the average difficulty, usefulness and entertainment ratings of a content, how many learners rated it and how ratings are spread over one to five stars
+// @Tags         ratings
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Success      200 {object} ContentRatingStats
+// @Failure      404 {object} map[string]string
+// @Router       /content/{id}/ratings [get]
+func (r *Router) GetRatingStats(c *gin.Context) {
+	viewerID, _ := polyfyjwt.GetUserIDFromContext(c)
+	stats, err := r.service.GetRatingStats(c.Request.Context(), c.Param("id"), viewerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetReviews godoc
+// @Summary      Get content reviews
+// @Description  Get a paginated list of learners' reviews of a content. Reviews hidden after reports are left out.
+// @Tags         ratings
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Param        sort_by query string false "Sort order (helpful, recent, highest, lowest)" default(helpful)
+// @Param        limit query int false "Limit (max 100)" default(20)
+// @Param        offset query int false "Offset" default(0)
+// @Success      200 {object} map[string]interface{}
+// @Failure      404 {object} map[string]string
+// @Router       /content/{id}/reviews [get]
+func (r *Router) GetReviews(c *gin.Context) {
+	filter := ReviewFilter{
+		SortBy: c.DefaultQuery("sort_by", "helpful"),
+		Limit:  20,
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if limit_v, err := strconv.Atoi(limit); err == nil && limit_v > 0 && limit_v <= 100 {
+			filter.Limit = limit_v
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if offset_v, err := strconv.Atoi(offset); err == nil && offset_v >= 0 {
+			filter.Offset = offset_v
+		}
+	}
+
+	viewerID, _ := polyfyjwt.GetUserIDFromContext(c)
+	reviews, total, err := r.service.GetReviews(c.Request.Context(), c.Param("id"), viewerID, filter)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
+
+// VoteReview godoc
+// @Summary      Vote on a review
+// @Description  Mark a review as helpful or unhelpful. Voting again replaces your earlier vote.
+// @Tags         ratings
+// @Accept       json
+// @Produce      json
+// @Param        review_id path string true "Review (rating) ID"
+// @Param        request body VoteReviewRequest true "Vote"
+// @Success      200 {object} ContentRating
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/reviews/{review_id}/vote [post]
+func (r *Router) VoteReview(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req VoteReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := r.service.VoteReview(c.Request.Context(), c.Param("review_id"), userID, *req.Helpful)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// RemoveReviewVote godoc
+// @Summary      Remove a review vote
+// @Description  Withdraw your helpful or unhelpful vote on a review
+// @Tags         ratings
+// @Accept       json
+// @Produce      json
+// @Param        review_id path string true "Review (rating) ID"
+// @Success      200 {object} ContentRating
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/reviews/{review_id}/vote [delete]
+func (r *Router) RemoveReviewVote(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	review, err := r.service.RemoveReviewVote(c.Request.Context(), c.Param("review_id"), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// ReportReview godoc
+// @Summary      Report a review
+// @Description  Flag a review for moderation. Reviews reported by three learners are hidden until an editor resolves the reports.
+// @Tags         ratings
+// @Accept       json
+// @Produce      json
+// @Param        review_id path string true "Review (rating) ID"
+// @Param        request body ReportReviewRequest true "Report"
+// @Success      201 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/reviews/{review_id}/report [post]
+func (r *Router) ReportReview(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req ReportReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.service.ReportReview(c.Request.Context(), c.Param("review_id"), userID, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Review reported"})
+}
+
+// GetReportedReviews godoc
+// @Summary      Get reported reviews
+// @Description  Get reviews with open reports, most reported first, with the reports. Requires the editor role.
+// @Tags         ratings
+// @Accept       json
+// @Produce      json
+// @Param        limit query int false "Limit (max 100)" default(20)
+// @Param        offset query int false "Offset" default(0)
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/admin/reviews/reported [get]
+func (r *Router) GetReportedReviews(c *gin.Context) {
+	limit, offset := 20, 0
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	reviews, total, err := r.service.GetReportedReviews(c.Request.Context(), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// ResolveReviewReports godoc
+// @Summary      Resolve review reports
+// @Description  Close a review's open reports and either keep it hidden or restore it to the public reviews. Requires the editor role.
+// @Tags         ratings
+// @Accept       json
+// @Produce      json
+// @Param        review_id path string true "Review (rating) ID"
+// @Param        request body ResolveReviewReportsRequest true "Resolution"
+// @Success      200 {object} ContentRating
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/admin/reviews/{review_id}/resolve [post]
+func (r *Router) ResolveReviewReports(c *gin.Context) {
+	var req ResolveReviewReportsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := r.service.ResolveReviewReports(c.Request.Context(), c.Param("review_id"), req.Hide)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// GetContentList godoc
+// @Summary      Get content list
+// @Description  Get a paginated list of content with filtering options
+// @Tags         content
+// @Accept       json
+// @Produce      json
+// @Param        language_id query int false "Language ID"
+// @Param        content_type query string false "Content type (movie, series, etc.)"
+// @Param        genre query string false "Genre slugs or names (comma-separated)"
+// @Param        country query string false "Country"
+// @Param        min_rating query number false "Minimum IMDb rating"
+// @Param        max_rating query number false "Maximum IMDb rating"
+// @Param        min_community_rating query number false "Minimum average of learners' usefulness and entertainment ratings (1-5)"
+// @Param        min_usefulness query number false "Minimum average usefulness rating (1-5)"
+// @Param        min_entertainment query number false "Minimum average entertainment rating (1-5)"
+// @Param        max_perceived_difficulty query number false "Maximum average difficulty rating (1-5)"
+// @Param        min_rating_count query int false "Minimum number of ratings"
+// @Param        difficulty query string false "CEFR levels, e.g. A2,B1 (comma-separated)"
+// @Param        year_from query int false "Year from"
+// @Param        year_to query int false "Year to"
+// @Param        decade query int false "Decade, e.g. 1990"
+// @Param        search query string false "Search term"
+// @Param        limit query int false "Limit (max 100)" default(20)
+// @Param        offset query int false "Offset" default(0)
+// @Param        sort_by query string false "Sort by field (title, year, rating, difficulty, created_at, view_count, community_rating, usefulness, entertainment, perceived_difficulty, rating_count)"
+// @Param        sort_direction query string false "Sort direction (asc/desc)"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /content [get]
+func (r *Router) GetContentList(c *gin.Context) {
+	filter, err := parseContentFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contents, total, err := r.service.GetContentList(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contents": contents,
+		"total":    total,
+		"limit":    filter.Limit,
+		"offset":   filter.Offset,
+	})
+}
+
+// SearchContent godoc
+// @Summary      Search content
+// @Description  Full-text search over content titles, genres and descriptions, best matches first, with result counts by language, type, genre, country, difficulty and decade. Each facet is counted ignoring its own filter.
+// @Tags         content
+// @Accept       json
+// @Produce      json
+// @Param        q query string false "Search text; supports quoted phrases, OR and -excluded words"
+// @Param        language_id query int false "Language ID"
+// @Param        content_type query string false "Content type (movie, series, etc.)"
+// @Param        genre query string false "Genre slugs or names (comma-separated)"
+// @Param        country query string false "Country"
+// @Param        difficulty query string false "CEFR levels, e.g. A2,B1 (comma-separated)"
+// @Param        decade query int false "Decade, e.g. 1990"
+// @Param        year_from query int false "Year from"
+// @Param        year_to query int false "Year to"
+// @Param        min_community_rating query number false "Minimum average of learners' usefulness and entertainment ratings (1-5)"
+// @Param        limit query int false "Limit (max 100)" default(20)
+// @Param        offset query int false "Offset" default(0)
+// @Param        sort_by query string false "Sort by relevance (default when searching) or a content list sort field"
+// @Param        sort_direction query string false "Sort direction (asc/desc)"
+// @Success      200 {object} ContentSearchResult
+// @Failure      400 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /content/search [get]
+func (r *Router) SearchContent(c *gin.Context) {
+	filter, err := parseContentFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := r.service.SearchContent(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contents": result.Contents,
+		"total":    result.Total,
+		"facets":   result.Facets,
+		"limit":    filter.Limit,
+		"offset":   filter.Offset,
+	})
+}
+
+// GetGenres godoc
+// @Summary      Get genres
+// @Description  Get the genre taxonomy with how many contents are in each genre
+// @Tags         content
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} map[string]interface{}
+// @Failure      500 {object} map[string]string
+// @Router       /content/genres [get]
+func (r *Router) GetGenres(c *gin.Context) {
+	genres, err := r.service.GetGenres(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"genres": genres})
+}
+
+// parseContentFilter reads the filters, sorting and paging of content
+// listings and searches
+func parseContentFilter(c *gin.Context) (ContentFilter, error) {
+	filter := ContentFilter{
+		Limit:  20,
+		Offset: 0,
+	}
+
+	// Parse query parameters
+	if languageID := c.Query("language_id"); languageID != "" {
+		if id, err := strconv.Atoi(languageID); err == nil {
+			filter.LanguageID = id
+		}
+	}
+
+	if contentType := c.Query("content_type"); contentType != "" {
+		filter.ContentType = contentType
+	}
+
+	if genre := c.Query("genre"); genre != "" {
+		filter.Genre = genre
+	}
+
+	if country := c.Query("country"); country != "" {
+		filter.Country = country
+	}
+
+	if minRating := c.Query("min_rating"); minRating != "" {
+		if rating, err := strconv.ParseFloat(minRating, 32); err == nil {
+			filter.MinRating = float32(rating)
+		}
+	}
+
+	if maxRating := c.Query("max_rating"); maxRating != "" {
+		if rating, err := strconv.ParseFloat(maxRating, 32); err == nil {
+			filter.MaxRating = float32(rating)
+		}
+	}
+
+	if minCommunityRating := c.Query("min_community_rating"); minCommunityRating != "" {
+		if rating, err := strconv.ParseFloat(minCommunityRating, 32); err == nil {
+			filter.MinCommunityRating = float32(rating)
+		}
+	}
+
+	if minUsefulness := c.Query("min_usefulness"); minUsefulness != "" {
+		if rating, err := strconv.ParseFloat(minUsefulness, 32); err == nil {
+			filter.MinUsefulness = float32(rating)
+		}
+	}
+
+	if minEntertainment := c.Query("min_entertainment"); minEntertainment != "" {
+		if rating, err := strconv.ParseFloat(minEntertainment, 32); err == nil {
+			filter.MinEntertainment = float32(rating)
+		}
+	}
+
+	if maxPerceivedDifficulty := c.Query("max_perceived_difficulty"); maxPerceivedDifficulty != "" {
+		if rating, err := strconv.ParseFloat(maxPerceivedDifficulty, 32); err == nil {
+			filter.MaxPerceivedDifficulty = float32(rating)
+		}
+	}
+
+	if minRatingCount := c.Query("min_rating_count"); minRatingCount != "" {
+		if count, err := strconv.Atoi(minRatingCount); err == nil && count > 0 {
+			filter.MinRatingCount = count
+		}
+	}
+
+	if difficulty := c.Query("difficulty"); difficulty != "" {
+		filter.Difficulty = strings.Split(difficulty, ",")
+	}
+
+	if yearFrom := c.Query("year_from"); yearFrom != "" {
+		if year, err := strconv.Atoi(yearFrom); err == nil {
+			filter.YearFrom = year
+		}
+	}
+
+	if yearTo := c.Query("year_to"); yearTo != "" {
+		if year, err := strconv.Atoi(yearTo); err == nil {
+			filter.YearTo = year
+		}
+	}
+
+	if decade := c.Query("decade"); decade != "" {
+		if year, err := strconv.Atoi(decade); err == nil && year > 0 {
+			filter.Decade = year - year%10
+		}
+	}
+
+	if search := c.Query("search"); search != "" {
+		filter.Search = search
+	}
+
+	if q := c.Query("q"); q != "" {
+		filter.Search = q
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if limit_v, err := strconv.Atoi(limit); err == nil && limit_v > 0 && limit_v <= 100 {
+			filter.Limit = limit_v
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if offset_v, err := strconv.Atoi(offset); err == nil && offset_v >= 0 {
+			filter.Offset = offset_v
+		}
+	}
+
+	if sortBy := c.Query("sort_by"); sortBy != "" {
+		if !ValidContentSort(sortBy) {
+			return filter, fmt.Errorf("cannot sort by %q", sortBy)
+		}
+		filter.SortBy = sortBy
+	}
+
+	if sortDirection := c.Query("sort_direction"); sortDirection == "asc" || sortDirection == "desc" {
+		filter.SortDirection = sortDirection
+	}
+
+	return filter, nil
+}
+
+// GetContentEpisodes godoc
+// @Summary      Get content episodes
+// @Description  Get all episodes for a specific content item. Episodes of unpublished content are only returned to its owner.
+// @Tags         episodes
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /content/{id}/episodes [get]
+func (r *Router) GetContentEpisodes(c *gin.Context) {
+	contentID := c.Param("id")
+	if contentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Content ID required"})
+		return
+	}
+
+	viewerID, _ := polyfyjwt.GetUserIDFromContext(c)
+	episodes, err := r.service.GetContentEpisodes(c.Request.Context(), contentID, viewerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"episodes": episodes})
+}
+
+// CreateEpisode godoc
+// @Summary      Create episode
+// @Description  Create a new episode for a content item
+// @Tags         episodes
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Param        request body CreateEpisodeRequest true "Episode data"
+// @Success      201 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/{id}/episodes [post]
+func (r *Router) CreateEpisode(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	contentID := c.Param("id")
+	if contentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Content ID required"})
+		return
+	}
+
+	var req CreateEpisodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	episode, err := r.service.CreateEpisode(c.Request.Context(), contentID, req, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"episode": episode})
+}
+
+// UpdateEpisode godoc
+// @Summary      Update episode
+// @Description  Update an existing episode
+// @Tags         episodes
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Param        episode_id path string true "Episode ID"
+// @Param        request body CreateEpisodeRequest true "Episode update data"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/{id}/episodes/{episode_id} [put]
+func (r *Router) UpdateEpisode(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	episodeID := c.Param("episode_id")
+	if episodeID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Episode ID required"})
+		return
+	}
+
+	var req CreateEpisodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	episode, err := r.service.UpdateEpisode(c.Request.Context(), episodeID, req, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"episode": episode})
+}
+
+// DeleteEpisode godoc
+// @Summary      Delete episode
+// @Description  Delete an existing episode
+// @Tags         episodes
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Param        episode_id path string true "Episode ID"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/{id}/episodes/{episode_id} [delete]
+func (r *Router) DeleteEpisode(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	episodeID := c.Param("episode_id")
+	if episodeID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Episode ID required"})
+		return
+	}
+
+	err := r.service.DeleteEpisode(c.Request.Context(), episodeID, userID)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -552,6 +1378,97 @@ func (r *Router) DeleteEpisode(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Episode deleted successfully"})
 }
 
+// GetEpisodeTranscript godoc
+// @Summary      Get episode transcript
+// @Description  Get the timestamped transcript of an episode in playback order
+// @Tags         episodes
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Param        episode_id path string true "Episode ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      404 {object} map[string]string
+// @Router       /content/{id}/episodes/{episode_id}/transcript [get]
+func (r *Router) GetEpisodeTranscript(c *gin.Context) {
+	viewerID, _ := polyfyjwt.GetUserIDFromContext(c)
+	segments, err := r.service.GetEpisodeTranscript(c.Request.Context(), c.Param("id"), c.Param("episode_id"), viewerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"segments": segments})
+}
+
+// SetEpisodeTranscript godoc
+// @Summary      Set episode transcript
+// @Description  Replace the timestamped transcript of an episode
+// @Tags         episodes
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Param        episode_id path string true "Episode ID"
+// @Param        request body SetTranscriptRequest true "Transcript segments"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/{id}/episodes/{episode_id}/transcript [put]
+func (r *Router) SetEpisodeTranscript(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req SetTranscriptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	segments, err := r.service.SetEpisodeTranscript(c.Request.Context(), c.Param("id"), c.Param("episode_id"), req, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"segments": segments})
+}
+
+// DeleteEpisodeTranscript godoc
+// @Summary      Delete episode transcript
+// @Description  Delete the transcript of an episode
+// @Tags         episodes
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Param        episode_id path string true "Episode ID"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/{id}/episodes/{episode_id}/transcript [delete]
+func (r *Router) DeleteEpisodeTranscript(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := r.service.DeleteEpisodeTranscript(c.Request.Context(), c.Param("id"), c.Param("episode_id"), userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transcript deleted successfully"})
+}
+
 // GetLanguages godoc
 // @Summary      Get available languages
 // @Description  Get list of all available languages
@@ -571,14 +1488,487 @@ func (r *Router) GetLanguages(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"languages": languages})
 }
 
+// GetSubtitleTracks godoc
+// @Summary      List episode subtitles
+// @Description  List the languages an episode has subtitles in
+// @Tags         subtitles
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Param        episode_id path string true "Episode ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      404 {object} map[string]string
+// @Router       /content/{id}/episodes/{episode_id}/subtitles [get]
+func (r *Router) GetSubtitleTracks(c *gin.Context) {
+	viewerID, _ := polyfyjwt.GetUserIDFromContext(c)
+	tracks, err := r.service.GetSubtitleTracks(c.Request.Context(), c.Param("id"), c.Param("episode_id"), viewerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tracks": tracks})
+}
+
+// GetSubtitleTrack godoc
+// @Summary      Get episode subtitles
+// @Description  Get an episode's subtitles in a language as WebVTT, whatever format they were uploaded in. Pass format=json for the track and its cues as JSON.
+// @Tags         subtitles
+// @Produce      text/vtt
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Param        episode_id path string true "Episode ID"
+// @Param        language path string true "Language code, e.g. es"
+// @Param        format query string false "vtt (default) or json"
+// @Success      200 {string} string "WebVTT file"
+// @Failure      404 {object} map[string]string
+// @Router       /content/{id}/episodes/{episode_id}/subtitles/{language} [get]
+func (r *Router) GetSubtitleTrack(c *gin.Context) {
+	viewerID, _ := polyfyjwt.GetUserIDFromContext(c)
+	track, segments, err := r.service.GetSubtitleTrack(c.Request.Context(), c.Param("id"), c.Param("episode_id"), c.Param("language"), viewerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") == "json" {
+		c.JSON(http.StatusOK, gin.H{
+			"track":    track,
+			"segments": segments,
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte(RenderWebVTT(segments)))
+}
+
+// UploadSubtitleTrack godoc
+// @Summary      Upload episode subtitles
+// @Description  Upload an SRT, WebVTT or ASS file (up to 2 MB) as the episode's subtitles in a language, replacing any existing track. The format is taken from the file extension or detected from the contents.
+// @Tags         subtitles
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Param        episode_id path string true "Episode ID"
+// @Param        language path string true "Language code, e.g. es"
+// @Param        file formData file true "Subtitle file"
+// @Param        label formData string false "Track label, defaults to the language name"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      413 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/{id}/episodes/{episode_id}/subtitles/{language} [put]
+func (r *Router) UploadSubtitleTrack(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSubtitleFileSize+64<<10)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Subtitle files are limited to 2 MB"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart field \"file\" required"})
+		return
+	}
+	defer file.Close()
+
+	track, err := r.service.SaveSubtitleTrack(c.Request.Context(), c.Param("id"), c.Param("episode_id"), c.Param("language"),
+		userID, header.Filename, c.PostForm("label"), file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Subtitles uploaded successfully",
+		"track":   track,
+	})
+}
+
+// DeleteSubtitleTrack godoc
+// @Summary      Delete episode subtitles
+// @Description  Delete an episode's subtitles in a language
+// @Tags         subtitles
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Param        episode_id path string true "Episode ID"
+// @Param        language path string true "Language code, e.g. es"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/{id}/episodes/{episode_id}/subtitles/{language} [delete]
+func (r *Router) DeleteSubtitleTrack(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := r.service.DeleteSubtitleTrack(c.Request.Context(), c.Param("id"), c.Param("episode_id"), c.Param("language"), userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subtitles deleted successfully"})
+}
+
+// SearchSubtitles godoc
+// @Summary      Search subtitles
+// @Description  Find subtitle lines containing words or a phrase across all episodes, with highlighted snippets and where each line is said
+// @Tags         subtitles
+// @Accept       json
+// @Produce      json
+// @Param        q query string true "Words or a quoted phrase"
+// @Param        language_id query int false "Subtitle language"
+// @Param        content_id query string false "Limit to one title"
+// @Param        limit query int false "Results per page (default 20, max 100)"
+// @Param        offset query int false "Results to skip"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Router       /content/subtitles/search [get]
+func (r *Router) SearchSubtitles(c *gin.Context) {
+	filter := SubtitleSearchFilter{
+		Query:     c.Query("q"),
+		ContentID: c.Query("content_id"),
+		Limit:     20,
+	}
+
+	if languageID, err := strconv.Atoi(c.Query("language_id")); err == nil {
+		filter.LanguageID = languageID
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 && limit <= 100 {
+		filter.Limit = limit
+	}
+	if offset, err := strconv.Atoi(c.Query("offset")); err == nil && offset >= 0 {
+		filter.Offset = offset
+	}
+
+	results, total, err := r.service.SearchSubtitles(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
+
+// GetContentDifficulty godoc
+// @Summary      Get content difficulty
+// @Description  Get the estimated CEFR level of a content and each of its episodes, with the transcript features and learner feedback behind it
+// @Tags         content
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Success      200 {object} ContentDifficulty
+// @Failure      404 {object} map[string]string
+// @Router       /content/{id}/difficulty [get]
+func (r *Router) GetContentDifficulty(c *gin.Context) {
+	viewerID, _ := polyfyjwt.GetUserIDFromContext(c)
+	difficulty, err := r.service.GetContentDifficulty(c.Request.Context(), c.Param("id"), viewerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, difficulty)
+}
+
+// RecomputeDifficulty godoc
+// @Summary      Recompute content difficulty
+// @Description  Re-estimate the difficulty of one content, or start re-estimating all content in the background when content_id is omitted. This also runs daily. Requires the admin role.
+// @Tags         content
+// @Accept       json
+// @Produce      json
+// @Param        content_id query string false "Content ID"
+// @Success      200 {object} ContentDifficulty
+// @Success      202 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/admin/difficulty/recompute [post]
+func (r *Router) RecomputeDifficulty(c *gin.Context) {
+	contentID := c.Query("content_id")
+	if contentID == "" {
+		go func() {
+			if _, err := r.service.RecomputeAllDifficulties(context.Background()); err != nil {
+				log.Printf("Failed to recompute content difficulty: %v", err)
+			}
+		}()
+		c.JSON(http.StatusAccepted, gin.H{"message": "Difficulty recompute started"})
+		return
+	}
+
+	difficulty, err := r.service.RecomputeDifficulty(c.Request.Context(), contentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, difficulty)
+}
+
+// SearchMetadata godoc
+// @Summary      Search external catalogue metadata
+// @Description  Look titles up at the configured metadata provider (e.g. TMDB) to pick one to import
+// @Tags         content
+// @Accept       json
+// @Produce      json
+// @Param        q query string true "Title to search for"
+// @Param        year query int false "Release year"
+// @Param        type query string false "movie or series"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      503 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/metadata/search [get]
+func (r *Router) SearchMetadata(c *gin.Context) {
+	year := 0
+	if y := c.Query("year"); y != "" {
+		if parsed, err := strconv.Atoi(y); err == nil {
+			year = parsed
+		}
+	}
+
+	kind := c.Query("type")
+	if kind != "" && kind != "movie" && kind != "series" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be movie or series"})
+		return
+	}
+
+	results, err := r.service.SearchMetadata(c.Request.Context(), c.Query("q"), year, kind)
+	if err != nil {
+		c.JSON(metadataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// ImportMetadata godoc
+// @Summary      Import content from external metadata
+// @Description  Create content, or fill in content you created, with its year, country, genres, poster, rating, seasons and episodes from the metadata provider. Give the provider's external_id, or a title (with optional year and content_type) to import the best match. Imported content is refreshed daily once its metadata is a week old.
+// @Tags         content
+// @Accept       json
+// @Produce      json
+// @Param        request body ImportMetadataRequest true "Title to import"
+// @Success      200 {object} MetadataImportResult
+// @Success      201 {object} MetadataImportResult
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      503 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/import [post]
+func (r *Router) ImportMetadata(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req ImportMetadataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := r.service.ImportMetadata(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(metadataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if result.Created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, result)
+}
+
+// RefreshMetadata godoc
+// @Summary      Refresh imported metadata
+// @Description  Refresh one imported content from its metadata provider, or start refreshing all stale imported content in the background when content_id is omitted. This also runs daily. Requires the admin role.
+// @Tags         content
+// @Accept       json
+// @Produce      json
+// @Param        content_id query string false "Content ID"
+// @Success      200 {object} MetadataImportResult
+// @Success      202 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      503 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/admin/metadata/refresh [post]
+func (r *Router) RefreshMetadata(c *gin.Context) {
+	contentID := c.Query("content_id")
+	if contentID == "" {
+		go func() {
+			if _, err := r.service.RefreshStaleMetadata(context.Background()); err != nil {
+				log.Printf("Failed to refresh content metadata: %v", err)
+			}
+		}()
+		c.JSON(http.StatusAccepted, gin.H{"message": "Metadata refresh started"})
+		return
+	}
+
+	result, err := r.service.RefreshMetadata(c.Request.Context(), contentID)
+	if err != nil {
+		c.JSON(metadataErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func metadataErrorStatus(err error) int {
+	if errors.Is(err, errNoMetadataProvider) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadRequest
+}
+
+// UploadPoster godoc
+// @Summary      Upload content poster
+// @Description  Upload a poster image (JPEG, PNG, WebP or GIF, up to 5 MB) for content you created. The type is detected from the file itself.
+// @Tags         content
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id path string true "Content ID"
+// @Param        file formData file true "Poster image"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      413 {object} map[string]string
+// @Failure      415 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/{id}/poster [post]
+func (r *Router) UploadPoster(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	file, err := storage.OpenFormFile(c.Writer, c.Request, storage.KindPoster)
+	if err != nil {
+		c.JSON(storage.StatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	content, err := r.service.UploadPoster(c.Request.Context(), c.Param("id"), userID, file)
+	if err != nil {
+		c.JSON(storage.StatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Poster uploaded successfully",
+		"content": content,
+	})
+}
+
+// GetFile godoc
+// @Summary      Get stored file
+// @Description  Redirect to a short-lived presigned URL for a stored poster, audio clip or avatar. Pass redirect=false to get the URL as JSON instead.
+// @Tags         files
+// @Produce      json
+// @Param        key path string true "File key"
+// @Param        redirect query bool false "Redirect to the file (default true)"
+// @Success      200 {object} map[string]interface{}
+// @Success      307
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /files/{key} [get]
+func (r *Router) GetFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	// Presigned links of the local backend come back here with a signature
+	if signature := c.Query("signature"); signature != "" {
+		path, ok, err := r.service.LocalFilePath(key, c.Query("expires"), signature)
+		if ok {
+			if err != nil {
+				status := http.StatusForbidden
+				if errors.Is(err, storage.ErrNotFound) {
+					status = http.StatusNotFound
+				}
+				c.JSON(status, gin.H{"error": err.Error()})
+				return
+			}
+			c.File(path)
+			return
+		}
+	}
+
+	url, expiresAt, err := r.service.GetFileURL(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(storage.StatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("redirect") == "false" {
+		c.JSON(http.StatusOK, gin.H{
+			"url":        url,
+			"expires_at": expiresAt,
+		})
+		return
+	}
+
+	c.Redirect(http.StatusTemporaryRedirect, url)
+}
+
+// CleanupOrphanedUploads godoc
+// @Summary      Clean up orphaned uploads
+// @Description  Delete stored files older than a day that no content, phoneme, minimal pair or profile links to. This also runs every few hours. Requires the admin role.
+// @Tags         files
+// @Produce      json
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /content/admin/storage/cleanup [post]
+func (r *Router) CleanupOrphanedUploads(c *gin.Context) {
+	removed, err := r.service.CleanupOrphanedUploads(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"removed": removed})
+}
+
 // GetRecommendations godoc
 // @Summary      Get content recommendations
-// @Description  Get personalized content recommendations for the user
+// @Description  Get personalized content recommendations for the user, based on their level, genre tastes, ratings and what similar learners enjoyed, each with the reasons it was picked
 // @Tags         recommendations
 // @Accept       json
 // @Produce      json
-// @Param        language_id query int false "Language ID for filtering"
+// @Param        language_id query int false "Language ID for filtering, defaults to the languages the user has watched"
 // @Param        limit query int false "Number of recommendations (max 50)" default(10)
+// @Param        diversity query number false "How strongly repeated genres are held back, from 0 to 1" default(0.3)
+// @Param        max_per_genre query int false "Most recommendations sharing a genre, 0 for no cap"
 // @Success      200 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
@@ -605,7 +1995,25 @@ func (r *Router) GetRecommendations(c *gin.Context) {
 		}
 	}
 
-	recommendations, err := r.service.GetRecommendations(c.Request.Context(), userID, languageID, limit)
+	opts := RecommendationOptions{LanguageID: languageID, Limit: limit, Diversity: defaultDiversity}
+	if d := c.Query("diversity"); d != "" {
+		parsed, err := strconv.ParseFloat(d, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "diversity must be between 0 and 1"})
+			return
+		}
+		opts.Diversity = parsed
+	}
+	if m := c.Query("max_per_genre"); m != "" {
+		parsed, err := strconv.Atoi(m)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_per_genre must be a non-negative number"})
+			return
+		}
+		opts.MaxPerGenre = parsed
+	}
+
+	recommendations, err := r.service.GetRecommendations(c.Request.Context(), userID, opts)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,305 @@
+package content
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Content is matched on its title, genres and description, in that order of
+// weight. Titles come in every language so words are not stemmed.
+const contentSearchDocumentSQL = `setweight(to_tsvector('simple', coalesce(contents.title, '')), 'A') || ` +
+	`setweight(to_tsvector('simple', coalesce(contents.genre, '')), 'B') || ` +
+	`setweight(to_tsvector('simple', coalesce(contents.description, '')), 'C')`
+
+const contentSearchQuerySQL = `websearch_to_tsquery('simple', @query)`
+
+// Substring matches on the title cover partly typed words and scripts
+// without spaces between words
+const contentSearchMatchSQL = `(` + contentSearchDocumentSQL + ` @@ ` + contentSearchQuerySQL + ` OR strpos(lower(contents.title), @term) > 0)`
+
+// Full-text rank, boosted when the title is or starts with the search
+const contentSearchRankSQL = `ts_rank_cd(` + contentSearchDocumentSQL + `, ` + contentSearchQuerySQL + `, 32) + ` +
+	`CASE WHEN lower(contents.title) = @term THEN 1 WHEN strpos(lower(contents.title), @term) = 1 THEN 0.5 ` +
+	`WHEN strpos(lower(contents.title), @term) > 0 THEN 0.2 ELSE 0 END`
+
+// Fields content can be sorted by. Sort fields are never passed to SQL as
+// given.
+var contentSortColumns = map[string]string{
+	"title":      "contents.title",
+	"year":       "contents.year_released",
+	"rating":     "contents.imdb_rating",
+	"difficulty": "contents.difficulty_score",
+	"created_at": "contents.created_at",
+	"view_count": "contents.view_count",
+}
+
+// Facets searches are counted over
+const (
+	facetLanguage    = "language"
+	facetContentType = "content_type"
+	facetGenre       = "genre"
+	facetCountry     = "country"
+	facetDifficulty  = "difficulty"
+	facetDecade      = "decade"
+)
+
+// Most values returned for facets with many of them
+const maxFacetValues = 50
+
+// FacetCount is how many results have a value
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// ContentFacets count search results by each filterable field. Each facet is
+// counted with every filter but its own, so the other values can be offered.
+type ContentFacets struct {
+	Languages    []FacetCount `json:"languages"`
+	ContentTypes []FacetCount `json:"content_types"`
+	Genres       []FacetCount `json:"genres"`
+	Countries    []FacetCount `json:"countries"`
+	Difficulties []FacetCount `json:"difficulties"`
+	Decades      []FacetCount `json:"decades"`
+}
+
+type ContentSearchResult struct {
+	Contents []Content     `json:"contents"`
+	Total    int64         `json:"total"`
+	Facets   ContentFacets `json:"facets"`
+}
+
+// ValidContentSort reports whether content can be sorted by a field
+func ValidContentSort(sortBy string) bool {
+	_, plain := contentSortColumns[sortBy]
+	_, rating := ratingSortColumns[sortBy]
+	return plain || rating || sortBy == "relevance"
+}
+
+func (f ContentFilter) searchArgs() map[string]interface{} {
+	query := strings.TrimSpace(f.Search)
+	return map[string]interface{}{
+		"query": query,
+		"term":  strings.ToLower(query),
+	}
+}
+
+// applyContentFilters narrows a contents query to the filter, leaving out the
+// filter of the skipped facet
+func (s *Service) applyContentFilters(query *gorm.DB, filter ContentFilter, skip string) *gorm.DB {
+	if filter.usesRatingStats() {
+		query = query.Joins("LEFT JOIN content_rating_stats ON content_rating_stats.content_id = contents.id")
+	}
+
+	// Owners list their own content in any state; everyone else sees
+	// published content only
+	statuses := filter.Statuses
+	if filter.CreatedBy != "" {
+		query = query.Where("contents.created_by = ?", filter.CreatedBy)
+	} else {
+		statuses = []string{ContentStatusPublished}
+	}
+	if len(statuses) > 0 {
+		query = query.Where("contents.status IN ?", statuses)
+	}
+
+	if filter.LanguageID > 0 && skip != facetLanguage {
+		query = query.Where("contents.language_id = ?", filter.LanguageID)
+	}
+
+	if filter.ContentType != "" && skip != facetContentType {
+		query = query.Where("contents.content_type = ?", filter.ContentType)
+	}
+
+	if filter.Genre != "" && skip != facetGenre {
+		names := splitGenreNames(filter.Genre)
+		slugs := make([]string, len(names))
+		for i, name := range names {
+			slugs[i] = genreSlug(name)
+		}
+		query = query.Where(`EXISTS (SELECT 1 FROM content_genres JOIN genres ON genres.id = content_genres.genre_id
+            WHERE content_genres.content_id = contents.id AND genres.slug IN ?)`, slugs)
+	}
+
+	if filter.Country != "" && skip != facetCountry {
+		query = query.Where("contents.country ILIKE ?", "%"+filter.Country+"%")
+	}
+
+	if filter.MinRating > 0 {
+		query = query.Where("contents.imdb_rating >= ?", filter.MinRating)
+	}
+
+	if filter.MaxRating > 0 {
+		query = query.Where("contents.imdb_rating <= ?", filter.MaxRating)
+	}
+
+	if filter.MinCommunityRating > 0 {
+		query = query.Where("content_rating_stats.avg_overall >= ?", filter.MinCommunityRating)
+	}
+
+	if filter.MinUsefulness > 0 {
+		query = query.Where("content_rating_stats.avg_usefulness >= ?", filter.MinUsefulness)
+	}
+
+	if filter.MinEntertainment > 0 {
+		query = query.Where("content_rating_stats.avg_entertainment >= ?", filter.MinEntertainment)
+	}
+
+	if filter.MaxPerceivedDifficulty > 0 {
+		query = query.Where("content_rating_stats.avg_difficulty <= ?", filter.MaxPerceivedDifficulty)
+	}
+
+	if filter.MinRatingCount > 0 {
+		query = query.Where("content_rating_stats.rating_count >= ?", filter.MinRatingCount)
+	}
+
+	if len(filter.Difficulty) > 0 && skip != facetDifficulty {
+		query = query.Where("contents.difficulty_level IN ?", filter.Difficulty)
+	}
+
+	if filter.YearFrom > 0 {
+		query = query.Where("contents.year_released >= ?", filter.YearFrom)
+	}
+
+	if filter.YearTo > 0 {
+		query = query.Where("contents.year_released <= ?", filter.YearTo)
+	}
+
+	if filter.Decade > 0 && skip != facetDecade {
+		query = query.Where("contents.year_released BETWEEN ? AND ?", filter.Decade, filter.Decade+9)
+	}
+
+	if strings.TrimSpace(filter.Search) != "" {
+		query = query.Where(contentSearchMatchSQL, filter.searchArgs())
+	}
+
+	return query
+}
+
+// contentOrder sorts by a whitelisted field, or by relevance when searching
+func contentOrder(filter ContentFilter) clause.OrderBy {
+	direction := "DESC"
+	if filter.SortDirection == "asc" {
+		direction = "ASC"
+	}
+
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = "created_at"
+		if strings.TrimSpace(filter.Search) != "" {
+			sortBy = "relevance"
+		}
+	}
+
+	column, ok := contentSortColumns[sortBy]
+	if !ok {
+		column, ok = ratingSortColumns[sortBy]
+	}
+	switch {
+	case ok:
+		return clause.OrderBy{Expression: clause.Expr{SQL: column + " " + direction + ", contents.created_at DESC"}}
+	case sortBy == "relevance" && strings.TrimSpace(filter.Search) != "":
+		return clause.OrderBy{Expression: clause.NamedExpr{
+			SQL:  "(" + contentSearchRankSQL + ") " + direction + ", contents.view_count DESC",
+			Vars: []interface{}{filter.searchArgs()},
+		}}
+	default:
+		return clause.OrderBy{Expression: clause.Expr{SQL: "contents.created_at " + direction}}
+	}
+}
+
+// listContents returns a page of the contents matching the filter and how
+// many match in total
+func (s *Service) listContents(filter ContentFilter) ([]Content, int64, error) {
+	query := s.applyContentFilters(s.db.Model(&Content{}), filter, "")
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var contents []Content
+	err := query.Preload("Language").Preload("RatingStats").Preload("Genres").
+		Order(contentOrder(filter)).
+		Limit(limit).
+		Offset(filter.Offset).
+		Find(&contents).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return contents, total, nil
+}
+
+// SearchContent ranks the contents matching a search and counts them by
+// language, type, genre, country, difficulty and decade
+func (s *Service) SearchContent(ctx context.Context, filter ContentFilter) (*ContentSearchResult, error) {
+	contents, total, err := s.listContents(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	facets, err := s.contentFacets(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContentSearchResult{Contents: contents, Total: total, Facets: *facets}, nil
+}
+
+func (s *Service) contentFacets(filter ContentFilter) (*ContentFacets, error) {
+	facets := &ContentFacets{}
+	counts := []struct {
+		facet  string
+		target *[]FacetCount
+		build  func(*gorm.DB) *gorm.DB
+	}{
+		{facetLanguage, &facets.Languages, func(q *gorm.DB) *gorm.DB {
+			return q.Joins("JOIN languages ON languages.id = contents.language_id").
+				Select("CAST(contents.language_id AS text) AS value, languages.name AS label, COUNT(*) AS count").
+				Group("contents.language_id, languages.name")
+		}},
+		{facetContentType, &facets.ContentTypes, func(q *gorm.DB) *gorm.DB {
+			return q.Select("contents.content_type AS value, COUNT(*) AS count").
+				Group("contents.content_type")
+		}},
+		{facetGenre, &facets.Genres, func(q *gorm.DB) *gorm.DB {
+			return q.Joins("JOIN content_genres ON content_genres.content_id = contents.id").
+				Joins("JOIN genres ON genres.id = content_genres.genre_id").
+				Select("genres.slug AS value, genres.name AS label, COUNT(*) AS count").
+				Group("genres.slug, genres.name")
+		}},
+		{facetCountry, &facets.Countries, func(q *gorm.DB) *gorm.DB {
+			return q.Where("contents.country <> ''").
+				Select("contents.country AS value, COUNT(*) AS count").
+				Group("contents.country")
+		}},
+		{facetDifficulty, &facets.Difficulties, func(q *gorm.DB) *gorm.DB {
+			return q.Where("contents.difficulty_level <> ''").
+				Select("contents.difficulty_level AS value, COUNT(*) AS count").
+				Group("contents.difficulty_level")
+		}},
+		{facetDecade, &facets.Decades, func(q *gorm.DB) *gorm.DB {
+			return q.Where("contents.year_released > 0").
+				Select("CAST(contents.year_released / 10 * 10 AS text) AS value, COUNT(*) AS count").
+				Group("contents.year_released / 10 * 10")
+		}},
+	}
+
+	for _, c := range counts {
+		query := c.build(s.applyContentFilters(s.db.Model(&Content{}), filter, c.facet))
+		*c.target = []FacetCount{}
+		if err := query.Order("count DESC, value ASC").Limit(maxFacetValues).Scan(c.target).Error; err != nil {
+			return nil, err
+		}
+	}
+	return facets, nil
+}
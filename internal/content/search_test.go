@@ -0,0 +1,54 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/clause"
+)
+
+func TestGenreSlug(t *testing.T) {
+	assert.Equal(t, "sci-fi", genreSlug("Sci-Fi"))
+	assert.Equal(t, "sci-fi", genreSlug("  sci fi "))
+	assert.Equal(t, "sci-fi", genreSlug("SCI_FI"))
+	assert.Equal(t, "comédie-romantique", genreSlug("Comédie romantique!"))
+	assert.Equal(t, "", genreSlug(" - "))
+}
+
+func TestRequestGenres(t *testing.T) {
+	assert.Equal(t, []string{"Crime", "Drama"}, requestGenres(nil, "Crime, Drama, crime"))
+	assert.Equal(t, []string{"Sci-Fi"}, requestGenres([]string{"Sci-Fi", "sci fi", " "}, "Ignored"))
+	assert.Empty(t, requestGenres(nil, ""))
+}
+
+func TestContentOrder(t *testing.T) {
+	sql := func(order clause.OrderBy) string {
+		switch expr := order.Expression.(type) {
+		case clause.Expr:
+			return expr.SQL
+		case clause.NamedExpr:
+			return expr.SQL
+		}
+		return ""
+	}
+
+	t.Run("WhitelistedField", func(t *testing.T) {
+		assert.Equal(t, "contents.year_released ASC, contents.created_at DESC", sql(contentOrder(ContentFilter{SortBy: "year", SortDirection: "asc"})))
+	})
+
+	t.Run("UnknownFieldIsNeverPassedToSQL", func(t *testing.T) {
+		order := sql(contentOrder(ContentFilter{SortBy: "title; DROP TABLE contents", SortDirection: "desc; --"}))
+		assert.Equal(t, "contents.created_at DESC", order)
+		assert.False(t, ValidContentSort("title; DROP TABLE contents"))
+	})
+
+	t.Run("RelevanceWhenSearching", func(t *testing.T) {
+		assert.Contains(t, sql(contentOrder(ContentFilter{Search: "casa"})), "ts_rank_cd")
+		assert.Equal(t, "contents.created_at DESC", sql(contentOrder(ContentFilter{SortBy: "relevance"})))
+	})
+
+	t.Run("CommunityRatings", func(t *testing.T) {
+		assert.Contains(t, sql(contentOrder(ContentFilter{SortBy: "community_rating"})), "content_rating_stats.avg_overall")
+		assert.True(t, ContentFilter{SortBy: "community_rating"}.usesRatingStats())
+	})
+}
@@ -3,20 +3,26 @@ package content
 import (
 	"context"
 	"errors"
-	"strings"
 
 	"gorm.io/gorm"
+
+	"github.com/Raylynd6299/babel/internal/content/metadata"
+	"github.com/Raylynd6299/babel/internal/shared/storage"
 )
 
 type Service struct {
 	db        *gorm.DB
 	jwtSecret string
+	files     *storage.Files
+	metadata  metadata.Provider // nil when metadata import is turned off
 }
 
-func NewService(db *gorm.DB, jwtSecret string) *Service {
+func NewService(db *gorm.DB, jwtSecret string, files *storage.Files, provider metadata.Provider) *Service {
 	return &Service{
 		db:        db,
 		jwtSecret: jwtSecret,
+		files:     files,
+		metadata:  provider,
 	}
 }
 
@@ -26,6 +32,7 @@ func (s *Service) GetDB() *gorm.DB {
 func (s *Service) CreateContent(ctx context.Context, req CreateContentRequest, userID string) (*Content, error) {
 	content := Content{
 		Title:                  req.Title,
+		TitleKey:               titleKey(req.Title),
 		ContentType:            req.ContentType,
 		LanguageID:             req.LanguageID,
 		TotalEpisodes:          req.TotalEpisodes,
@@ -37,18 +44,37 @@ func (s *Service) CreateContent(ctx context.Context, req CreateContentRequest, u
 		PosterURL:              req.PosterURL,
 		IMDbRating:             req.IMDbRating,
 		CreatedBy:              userID,
+		Status:                 ContentStatusDraft,
 	}
 
-	if err := s.db.Create(&content).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkDuplicates(tx, &content, ContentStatusPublished, ContentStatusPendingReview); err != nil {
+			return err
+		}
+		if err := tx.Create(&content).Error; err != nil {
+			return err
+		}
+		if err := setContentGenres(tx, &content, requestGenres(req.Genres, req.Genre)); err != nil {
+			return err
+		}
+		return recordAudit(tx, ContentAuditEntry{
+			ContentID: content.ID,
+			UserID:    userID,
+			Action:    auditContentCreated,
+			Changes:   auditDiff(Content{}, content),
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return &content, nil
 }
 
-func (s *Service) GetContent(ctx context.Context, id string) (*Content, error) {
+// GetContent returns published content, or content in any state to its owner
+func (s *Service) GetContent(ctx context.Context, id, viewerID string) (*Content, error) {
 	var content Content
-	err := s.db.Preload("Episodes").Preload("Language").Where("id = ?", id).First(&content).Error
+	err := s.db.Preload("Episodes").Preload("Language").Preload("RatingStats").Where("id = ?", id).First(&content).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -57,136 +83,69 @@ func (s *Service) GetContent(ctx context.Context, id string) (*Content, error) {
 		return nil, err
 	}
 
-	return &content, nil
-}
-
-func (s *Service) GetContentList(ctx context.Context, filter ContentFilter) ([]Content, int64, error) {
-	query := s.db.Model(&Content{}).Preload("Language")
-
-	// Apply filters
-	if filter.LanguageID > 0 {
-		query = query.Where("language_id = ?", filter.LanguageID)
-	}
-
-	if filter.ContentType != "" {
-		query = query.Where("content_type = ?", filter.ContentType)
-	}
-
-	if filter.Genre != "" {
-		query = query.Where("genre ILIKE ?", "%"+filter.Genre+"%")
-	}
-
-	if filter.Country != "" {
-		query = query.Where("country ILIKE ?", "%"+filter.Country+"%")
-	}
-
-	if filter.MinRating > 0 {
-		query = query.Where("imdb_rating >= ?", filter.MinRating)
-	}
-
-	if filter.MaxRating > 0 {
-		query = query.Where("imdb_rating <= ?", filter.MaxRating)
-	}
-
-	if len(filter.Difficulty) > 0 {
-		query = query.Where("difficulty_level IN ?", filter.Difficulty)
-	}
-
-	if filter.YearFrom > 0 {
-		query = query.Where("year_released >= ?", filter.YearFrom)
-	}
-
-	if filter.YearTo > 0 {
-		query = query.Where("year_released <= ?", filter.YearTo)
-	}
-
-	if filter.Search != "" {
-		searchTerm := "%" + strings.ToLower(filter.Search) + "%"
-		query = query.Where("LOWER(title) LIKE ? OR LOWER(description) LIKE ?", searchTerm, searchTerm)
-	}
-
-	// Count total records
-	var total int64
-	query.Count(&total)
-
-	// Apply sorting
-	sortBy := "created_at"
-	if filter.SortBy != "" {
-		sortBy = filter.SortBy
-	}
-
-	sortDirection := "desc"
-	if filter.SortDirection == "asc" {
-		sortDirection = "asc"
-	}
-
-	query = query.Order(sortBy + " " + sortDirection)
-
-	// Apply pagination
-	if filter.Limit > 0 {
-		query = query.Limit(filter.Limit)
-	} else {
-		query = query.Limit(20) // Default limit
-	}
-
-	if filter.Offset > 0 {
-		query.Offset(filter.Offset)
+	if !canView(&content, viewerID) {
+		return nil, errors.New("content not found")
 	}
 
-	var contents []Content
-	if err := query.Find(&contents).Error; err != nil {
-		return nil, 0, err
-	}
+	return &content, nil
+}
 
-	return contents, total, nil
+// canView reports whether a viewer may see content: published content is
+// public, other states only to the owner. viewerID is empty for anonymous
+// requests.
+func canView(content *Content, viewerID string) bool {
+	return content.Status == ContentStatusPublished || (viewerID != "" && content.CreatedBy == viewerID)
 }
 
-func (s *Service) RateContent(ctx context.Context, userID, contentID string, rating ContentRating) error {
-	//  Check if content exists
+// visibleContent loads content the viewer may see, so unpublished content
+// and everything under it stay hidden from all but the owner
+func (s *Service) visibleContent(contentID, viewerID string) (*Content, error) {
 	var content Content
 	if err := s.db.Where("id = ?", contentID).First(&content).Error; err != nil {
-		return errors.New("content not found")
+		return nil, errors.New("content not found")
+	}
+	if !canView(&content, viewerID) {
+		return nil, errors.New("content not found")
 	}
+	return &content, nil
+}
 
-	// Check if user already rated this content
-	var existingRating ContentRating
-	err := s.db.Where("user_id = ? AND content_id = ?", userID, contentID).First(&existingRating).Error
+func (s *Service) GetContentList(ctx context.Context, filter ContentFilter) ([]Content, int64, error) {
+	return s.listContents(filter)
+}
 
-	if err == nil {
-		// Update existing rating
-		existingRating.DifficultyRating = rating.DifficultyRating
-		existingRating.UsefulnessRating = rating.UsefulnessRating
-		existingRating.EntertainmentRating = rating.EntertainmentRating
-		existingRating.ReviewText = rating.ReviewText
-
-		return s.db.Save(&existingRating).Error
-	} else if errors.Is(err, gorm.ErrRecordNotFound) {
-		// Create new Rating
-		rating.UserID = userID
-		rating.ContentID = contentID
-
-		return s.db.Create(&rating).Error
+func (s *Service) RateContent(ctx context.Context, userID, contentID string, rating ContentRating) error {
+	// Only published content can be rated, so drafts never gather stats
+	if _, err := s.visibleContent(contentID, ""); err != nil {
+		return err
 	}
 
-	return err
-}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// Check if user already rated this content
+		var existingRating ContentRating
+		err := tx.Where("user_id = ? AND content_id = ?", userID, contentID).First(&existingRating).Error
 
-func (s *Service) GetRecommendations(ctx context.Context, userID string, languageID int, limit int) ([]Content, error) {
-	// Simple recommendation: popular content in the user's target language
-	// that they haven't consumed yet
-	var contents []Content
+		if err == nil {
+			// Update existing rating
+			existingRating.DifficultyRating = rating.DifficultyRating
+			existingRating.UsefulnessRating = rating.UsefulnessRating
+			existingRating.EntertainmentRating = rating.EntertainmentRating
+			existingRating.ReviewText = rating.ReviewText
 
-	subquery := s.db.Table("user_progress").
-		Select("DISTINCT content_id").
-		Where("user_id = ?", userID)
+			err = tx.Save(&existingRating).Error
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Create new Rating
+			rating.UserID = userID
+			rating.ContentID = contentID
 
-	err := s.db.Where("language_id = ?", languageID).
-		Where("id NOT IN (?)", subquery).
-		Order("view_count DESC, imdb_rating DESC").
-		Limit(limit).
-		Find(&contents).Error
+			err = tx.Create(&rating).Error
+		}
+		if err != nil {
+			return err
+		}
 
-	return contents, err
+		return refreshRatingStats(tx, contentID)
+	})
 }
 
 func (s *Service) UpdateContent(ctx context.Context, id string, req CreateContentRequest, userID string) (*Content, error) {
@@ -201,7 +160,9 @@ func (s *Service) UpdateContent(ctx context.Context, id string, req CreateConten
 	}
 
 	// Update fields
+	before := content
 	content.Title = req.Title
+	content.TitleKey = titleKey(req.Title)
 	content.TotalEpisodes = req.TotalEpisodes
 	content.AverageEpisodeDuration = req.AverageEpisodeDuration
 	content.YearReleased = req.YearReleased
@@ -211,7 +172,32 @@ func (s *Service) UpdateContent(ctx context.Context, id string, req CreateConten
 	content.PosterURL = req.PosterURL
 	content.IMDbRating = req.IMDbRating
 
-	if err := s.db.Save(&content).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkDuplicates(tx, &content, ContentStatusPublished, ContentStatusPendingReview); err != nil {
+			return err
+		}
+		if err := tx.Save(&content).Error; err != nil {
+			return err
+		}
+		if err := setContentGenres(tx, &content, requestGenres(req.Genres, req.Genre)); err != nil {
+			return err
+		}
+		changes := auditDiff(before, content)
+		if len(changes) == 0 {
+			return nil
+		}
+		err := recordAudit(tx, ContentAuditEntry{
+			ContentID: content.ID,
+			UserID:    userID,
+			Action:    auditContentUpdated,
+			Changes:   changes,
+		})
+		if err != nil {
+			return err
+		}
+		return s.reopenForReview(tx, &content, userID)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -230,16 +216,27 @@ func (s *Service) DeleteContent(ctx context.Context, id string, userID string) e
 	}
 
 	// Soft delete
-	if err := s.db.Delete(&content).Error; err != nil {
-		return err
-	}
-
-	return nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&content).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, ContentAuditEntry{
+			ContentID: content.ID,
+			UserID:    userID,
+			Action:    auditContentDeleted,
+			Changes:   auditDiff(content, Content{}),
+		})
+	})
 }
 
-func (s *Service) GetContentEpisodes(ctx context.Context, contentID string) ([]ContentEpisode, error) {
+func (s *Service) GetContentEpisodes(ctx context.Context, contentID, viewerID string) ([]ContentEpisode, error) {
+	if _, err := s.visibleContent(contentID, viewerID); err != nil {
+		return nil, err
+	}
+
 	var episodes []ContentEpisode
-	err := s.db.Where("content_id = ?", contentID).
+	err := s.db.Preload("SubtitleTracks.Language").
+		Where("content_id = ?", contentID).
 		Order("season_number ASC, episode_number ASC").
 		Find(&episodes).Error
 	return episodes, err
@@ -273,7 +270,23 @@ func (s *Service) CreateEpisode(ctx context.Context, contentID string, req Creat
 		Description:     req.Description,
 	}
 
-	if err := s.db.Create(&episode).Error; err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&episode).Error; err != nil {
+			return err
+		}
+		err := recordAudit(tx, ContentAuditEntry{
+			ContentID: contentID,
+			EpisodeID: &episode.ID,
+			UserID:    userID,
+			Action:    auditEpisodeCreated,
+			Changes:   auditDiff(ContentEpisode{}, episode),
+		})
+		if err != nil {
+			return err
+		}
+		return s.reopenForReview(tx, &content, userID)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -297,13 +310,34 @@ func (s *Service) UpdateEpisode(ctx context.Context, episodeID string, req Creat
 	}
 
 	// Update episode
+	before := episode
 	episode.EpisodeNumber = req.EpisodeNumber
 	episode.Title = req.Title
 	episode.DurationMinutes = req.DurationMinutes
 	episode.SeasonNumber = req.SeasonNumber
 	episode.Description = req.Description
 
-	if err := s.db.Save(&episode).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&episode).Error; err != nil {
+			return err
+		}
+		changes := auditDiff(before, episode)
+		if len(changes) == 0 {
+			return nil
+		}
+		err := recordAudit(tx, ContentAuditEntry{
+			ContentID: episode.ContentID,
+			EpisodeID: &episode.ID,
+			UserID:    userID,
+			Action:    auditEpisodeUpdated,
+			Changes:   changes,
+		})
+		if err != nil {
+			return err
+		}
+		return s.reopenForReview(tx, &content, userID)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -326,11 +360,22 @@ func (s *Service) DeleteEpisode(ctx context.Context, episodeID string, userID st
 		return errors.New("unauthorized to delete this episode")
 	}
 
-	if err := s.db.Delete(&episode).Error; err != nil {
-		return err
-	}
-
-	return nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&episode).Error; err != nil {
+			return err
+		}
+		err := recordAudit(tx, ContentAuditEntry{
+			ContentID: episode.ContentID,
+			EpisodeID: &episode.ID,
+			UserID:    userID,
+			Action:    auditEpisodeDeleted,
+			Changes:   auditDiff(episode, ContentEpisode{}),
+		})
+		if err != nil {
+			return err
+		}
+		return s.reopenForReview(tx, &content, userID)
+	})
 }
 
 func (s *Service) GetLanguages(ctx context.Context) ([]Language, error) {
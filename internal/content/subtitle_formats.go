@@ -0,0 +1,385 @@
+package content
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Subtitle formats accepted on upload
+const (
+	SubtitleFormatSRT    = "srt"
+	SubtitleFormatWebVTT = "vtt"
+	SubtitleFormatASS    = "ass"
+)
+
+// Upper bound on cues in one track, a feature film has around 1500
+const maxSubtitleCues = 20000
+
+// subtitleCue is one parsed line of dialogue
+type subtitleCue struct {
+	StartMs int
+	EndMs   int
+	Speaker string
+	Text    string
+}
+
+var (
+	srtTimingPattern = regexp.MustCompile(`^\s*(\d+:\d{1,2}:\d{1,2}[,.]\d{1,3})\s*-->\s*(\d+:\d{1,2}:\d{1,2}[,.]\d{1,3})`)
+	vttTimingPattern = regexp.MustCompile(`^\s*((?:\d+:)?\d{1,2}:\d{1,2}\.\d{1,3})\s+-->\s+((?:\d+:)?\d{1,2}:\d{1,2}\.\d{1,3})`)
+	vttVoicePattern  = regexp.MustCompile(`^<v(?:\.[^\s>]*)?\s+([^>]*)>`)
+	markupPattern    = regexp.MustCompile(`</?[a-zA-Z][^>]*>|<\d+:\d+[^>]*>`)
+	assTagPattern    = regexp.MustCompile(`\{[^}]*\}`)
+)
+
+var htmlEntities = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&nbsp;", " ", "&lrm;", "", "&rlm;", "")
+
+// DetectSubtitleFormat picks the format from the file name, falling back to
+// the file's contents
+func DetectSubtitleFormat(filename string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".srt":
+		return SubtitleFormatSRT
+	case ".vtt":
+		return SubtitleFormatWebVTT
+	case ".ass", ".ssa":
+		return SubtitleFormatASS
+	}
+
+	head := strings.TrimSpace(decodeSubtitleText(data[:min(len(data), 4096)]))
+	switch {
+	case strings.HasPrefix(head, "WEBVTT"):
+		return SubtitleFormatWebVTT
+	case strings.Contains(head, "[Script Info]") || strings.Contains(head, "[Events]"):
+		return SubtitleFormatASS
+	default:
+		return SubtitleFormatSRT
+	}
+}
+
+// parseSubtitles decodes a subtitle file and returns its cues in playback
+// order. Styling is dropped; speakers are kept where the format names them.
+func parseSubtitles(format string, data []byte) ([]subtitleCue, error) {
+	text := decodeSubtitleText(data)
+
+	var cues []subtitleCue
+	var err error
+	switch format {
+	case SubtitleFormatSRT:
+		cues, err = parseSRT(text)
+	case SubtitleFormatWebVTT:
+		cues, err = parseWebVTT(text)
+	case SubtitleFormatASS:
+		cues, err = parseASS(text)
+	default:
+		return nil, fmt.Errorf("unsupported subtitle format %q, expected srt, vtt or ass", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cues) == 0 {
+		return nil, errors.New("no subtitle cues found")
+	}
+	if len(cues) > maxSubtitleCues {
+		return nil, fmt.Errorf("subtitle track has %d cues, the limit is %d", len(cues), maxSubtitleCues)
+	}
+
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].StartMs < cues[j].StartMs })
+	return cues, nil
+}
+
+// decodeSubtitleText handles byte order marks and UTF-16 files, and reads
+// files that are not valid UTF-8 as Windows-1252, which older SRT files use
+func decodeSubtitleText(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		data = data[3:]
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeUTF16(data[2:], false)
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeUTF16(data[2:], true)
+	}
+
+	var text string
+	if utf8.Valid(data) {
+		text = string(data)
+	} else {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = windows1252(b)
+		}
+		text = string(runes)
+	}
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	text := strings.ReplaceAll(string(utf16.Decode(units)), "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// windows1252High holds the characters of bytes 0x80-0x9F, where
+// Windows-1252 differs from Latin-1
+var windows1252High = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+func windows1252(b byte) rune {
+	if b >= 0x80 && b < 0xA0 {
+		return windows1252High[b-0x80]
+	}
+	return rune(b)
+}
+
+// parseSRT reads numbered blocks of a timing line followed by text
+func parseSRT(text string) ([]subtitleCue, error) {
+	var cues []subtitleCue
+	for _, block := range splitBlocks(text) {
+		timing := -1
+		for i, line := range block.lines {
+			if strings.Contains(line, "-->") {
+				timing = i
+				break
+			}
+		}
+		if timing == -1 {
+			continue
+		}
+
+		match := srtTimingPattern.FindStringSubmatch(block.lines[timing])
+		if match == nil {
+			return nil, fmt.Errorf("line %d: invalid timing %q", block.start+timing, strings.TrimSpace(block.lines[timing]))
+		}
+		start, end := parseTimestamp(match[1]), parseTimestamp(match[2])
+		if cue, ok := newCue(start, end, "", cleanMarkup(strings.Join(block.lines[timing+1:], "\n"))); ok {
+			cues = append(cues, cue)
+		}
+	}
+	return cues, nil
+}
+
+// parseWebVTT reads cues after the WEBVTT header, skipping NOTE, STYLE and
+// REGION blocks. A leading voice span names the speaker.
+func parseWebVTT(text string) ([]subtitleCue, error) {
+	if !strings.HasPrefix(strings.TrimSpace(text), "WEBVTT") {
+		return nil, errors.New("missing WEBVTT header")
+	}
+
+	var cues []subtitleCue
+	for i, block := range splitBlocks(text) {
+		first := strings.TrimSpace(block.lines[0])
+		if i == 0 && strings.HasPrefix(first, "WEBVTT") {
+			continue
+		}
+		if strings.HasPrefix(first, "NOTE") || first == "STYLE" || first == "REGION" {
+			continue
+		}
+
+		timing := -1
+		for j, line := range block.lines {
+			if strings.Contains(line, "-->") {
+				timing = j
+				break
+			}
+		}
+		if timing == -1 {
+			continue
+		}
+
+		match := vttTimingPattern.FindStringSubmatch(block.lines[timing])
+		if match == nil {
+			return nil, fmt.Errorf("line %d: invalid timing %q", block.start+timing, strings.TrimSpace(block.lines[timing]))
+		}
+
+		payload := strings.Join(block.lines[timing+1:], "\n")
+		speaker := ""
+		if voice := vttVoicePattern.FindStringSubmatch(payload); voice != nil {
+			speaker = strings.TrimSpace(voice[1])
+		}
+		if cue, ok := newCue(parseTimestamp(match[1]), parseTimestamp(match[2]), speaker, cleanMarkup(payload)); ok {
+			cues = append(cues, cue)
+		}
+	}
+	return cues, nil
+}
+
+// parseASS reads the Dialogue lines of the [Events] section in the field
+// order its Format line declares. Override tags such as {\i1} are dropped.
+func parseASS(text string) ([]subtitleCue, error) {
+	var cues []subtitleCue
+	inEvents := false
+	fields := []string{"layer", "start", "end", "style", "name", "marginl", "marginr", "marginv", "effect", "text"}
+
+	for number, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inEvents = strings.EqualFold(line, "[Events]")
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "Format":
+			fields = fields[:0]
+			for _, field := range strings.Split(value, ",") {
+				fields = append(fields, strings.ToLower(strings.TrimSpace(field)))
+			}
+		case "Dialogue":
+			values := strings.SplitN(strings.TrimSpace(value), ",", len(fields))
+			if len(values) != len(fields) {
+				return nil, fmt.Errorf("line %d: expected %d fields in dialogue", number+1, len(fields))
+			}
+
+			event := make(map[string]string, len(fields))
+			for i, field := range fields {
+				event[field] = values[i]
+			}
+
+			start, end := parseTimestamp(strings.TrimSpace(event["start"])), parseTimestamp(strings.TrimSpace(event["end"]))
+			if start < 0 || end < 0 {
+				return nil, fmt.Errorf("line %d: invalid timing", number+1)
+			}
+
+			dialogue := assTagPattern.ReplaceAllString(event["text"], "")
+			dialogue = strings.NewReplacer(`\N`, "\n", `\n`, "\n", `\h`, " ").Replace(dialogue)
+			if cue, ok := newCue(start, end, strings.TrimSpace(event["name"]), normalizeCueText(dialogue)); ok {
+				cues = append(cues, cue)
+			}
+		}
+	}
+	return cues, nil
+}
+
+type textBlock struct {
+	start int // Line number of the first line
+	lines []string
+}
+
+// splitBlocks splits text into runs of non-blank lines
+func splitBlocks(text string) []textBlock {
+	var blocks []textBlock
+	var current *textBlock
+	for i, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			current = nil
+			continue
+		}
+		if current == nil {
+			blocks = append(blocks, textBlock{start: i + 1})
+			current = &blocks[len(blocks)-1]
+		}
+		current.lines = append(current.lines, line)
+	}
+	return blocks
+}
+
+// parseTimestamp reads [hh:]mm:ss[.,]fff and H:MM:SS.cc. It returns -1 for
+// malformed timestamps.
+func parseTimestamp(value string) int {
+	value = strings.ReplaceAll(value, ",", ".")
+	clock, fraction, _ := strings.Cut(value, ".")
+
+	parts := strings.Split(clock, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return -1
+	}
+
+	total := 0
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return -1
+		}
+		total = total*60 + n
+	}
+
+	ms := 0
+	if fraction != "" {
+		// Centiseconds in ASS, milliseconds elsewhere
+		fraction = (fraction + "00")[:3]
+		n, err := strconv.Atoi(fraction)
+		if err != nil {
+			return -1
+		}
+		ms = n
+	}
+	return total*1000 + ms
+}
+
+func cleanMarkup(text string) string {
+	text = markupPattern.ReplaceAllString(text, "")
+	text = assTagPattern.ReplaceAllString(text, "") // {\an8} positioning in SRT files
+	return normalizeCueText(htmlEntities.Replace(text))
+}
+
+func normalizeCueText(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func newCue(start, end int, speaker, text string) (subtitleCue, bool) {
+	if text == "" || start < 0 || end <= start {
+		return subtitleCue{}, false
+	}
+	return subtitleCue{StartMs: start, EndMs: end, Speaker: speaker, Text: text}, true
+}
+
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// RenderWebVTT writes segments as a WebVTT file. Speakers become voice spans.
+func RenderWebVTT(segments []TranscriptSegment) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i, segment := range segments {
+		fmt.Fprintf(&b, "\n%d\n%s --> %s\n", i+1, formatVTTTimestamp(segment.StartMs), formatVTTTimestamp(segment.EndMs))
+		if speaker := strings.Join(strings.Fields(segment.Speaker), " "); speaker != "" {
+			fmt.Fprintf(&b, "<v %s>", vttEscaper.Replace(speaker))
+		}
+		b.WriteString(vttCueText(segment.Text))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// vttCueText makes text safe as a cue payload. A literal "-->" would be read
+// as a timing line and a blank line ends the cue, so ">" is escaped along
+// with the other markup characters and blank lines are dropped.
+func vttCueText(text string) string {
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+	return vttEscaper.Replace(normalizeCueText(text))
+}
+
+func formatVTTTimestamp(ms int) string {
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,273 @@
+package content
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"00:00:01,500", 1500},
+		{"01:02:03.004", 3723004},
+		{"02:03.250", 123250},
+		{"0:00:05.25", 5250}, // ASS centiseconds
+		{"0:00:05.2", 5200},
+		{"00:00:07", 7000},
+		{"100:00:00.000", 360000000},
+		{"5", -1},
+		{"1:2:3:4", -1},
+		{"aa:bb:cc", -1},
+		{"00:-1:00", -1},
+		{"00:00:01.x", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseTimestamp(tt.value))
+		})
+	}
+}
+
+func TestDecodeSubtitleText(t *testing.T) {
+	utf16 := func(text string, bigEndian bool) []byte {
+		data := []byte{0xFF, 0xFE}
+		if bigEndian {
+			data = []byte{0xFE, 0xFF}
+		}
+		for _, r := range text {
+			if bigEndian {
+				data = append(data, byte(r>>8), byte(r))
+			} else {
+				data = append(data, byte(r), byte(r>>8))
+			}
+		}
+		return data
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"UTF8", []byte("¿Qué?\nSí"), "¿Qué?\nSí"},
+		{"UTF8BOM", append([]byte{0xEF, 0xBB, 0xBF}, "Hola"...), "Hola"},
+		{"UTF16LE", utf16("Hé\r\nllo", false), "Hé\nllo"},
+		{"UTF16BE", utf16("Ñu\rya", true), "Ñu\nya"},
+		{"Windows1252", []byte{'c', 'a', 'f', 0xE9, ' ', 0x93, 'h', 'i', 0x94, ' ', 0x80}, "café “hi” €"},
+		{"CRLF", []byte("one\r\ntwo\rthree"), "one\ntwo\nthree"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, decodeSubtitleText(tt.data))
+		})
+	}
+}
+
+func TestDetectSubtitleFormat(t *testing.T) {
+	assert.Equal(t, SubtitleFormatSRT, DetectSubtitleFormat("movie.SRT", nil))
+	assert.Equal(t, SubtitleFormatWebVTT, DetectSubtitleFormat("movie.vtt", nil))
+	assert.Equal(t, SubtitleFormatASS, DetectSubtitleFormat("movie.ssa", nil))
+	assert.Equal(t, SubtitleFormatWebVTT, DetectSubtitleFormat("upload", []byte("\xEF\xBB\xBFWEBVTT\n\n00:01.000 --> 00:02.000\nHi")))
+	assert.Equal(t, SubtitleFormatASS, DetectSubtitleFormat("upload", []byte("[Script Info]\nTitle: x")))
+	assert.Equal(t, SubtitleFormatSRT, DetectSubtitleFormat("upload", []byte("1\n00:00:01,000 --> 00:00:02,000\nHi")))
+}
+
+func TestParseSRT(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []subtitleCue
+		wantErr bool
+	}{
+		{
+			name: "Basic",
+			text: "1\n00:00:01,000 --> 00:00:02,500\nHello\nthere\n\n2\n00:00:03,000 --> 00:00:04,000\nBye\n",
+			want: []subtitleCue{
+				{StartMs: 1000, EndMs: 2500, Text: "Hello\nthere"},
+				{StartMs: 3000, EndMs: 4000, Text: "Bye"},
+			},
+		},
+		{
+			name: "MarkupAndEntities",
+			text: "1\n00:00:01,000 --> 00:00:02,000 X1:0\n{\\an8}<i>Tom &amp; Jerry</i>\n",
+			want: []subtitleCue{{StartMs: 1000, EndMs: 2000, Text: "Tom & Jerry"}},
+		},
+		{
+			name: "SkipsEmptyAndBackwardsCues",
+			text: "1\n00:00:01,000 --> 00:00:02,000\n<b></b>\n\n2\n00:00:05,000 --> 00:00:04,000\nBackwards\n\nstray text\n",
+			want: nil,
+		},
+		{
+			name:    "InvalidTiming",
+			text:    "1\n00:00:01 --> soon\nHello\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cues, err := parseSRT(tt.text)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cues)
+		})
+	}
+}
+
+func TestParseWebVTT(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []subtitleCue
+		wantErr bool
+	}{
+		{
+			name: "CuesWithAndWithoutIdentifiers",
+			text: "WEBVTT - Episode 1\n\nintro\n00:01.000 --> 00:02.000 align:start\nHello\n\n00:00:03.000 --> 00:00:04.500\nBye\n",
+			want: []subtitleCue{
+				{StartMs: 1000, EndMs: 2000, Text: "Hello"},
+				{StartMs: 3000, EndMs: 4500, Text: "Bye"},
+			},
+		},
+		{
+			name: "VoiceSpanNamesSpeaker",
+			text: "WEBVTT\n\n00:01.000 --> 00:02.000\n<v.loud Ana María>¡Hola <c.yellow>amigo</c>!</v>\n",
+			want: []subtitleCue{{StartMs: 1000, EndMs: 2000, Speaker: "Ana María", Text: "¡Hola amigo!"}},
+		},
+		{
+			name: "SkipsNoteStyleAndRegion",
+			text: "WEBVTT\n\nNOTE 00:01.000 --> 00:02.000\n\nSTYLE\n::cue { color: red }\n\nREGION\nid:top\n\n00:05.000 --> 00:06.000\nKept\n",
+			want: []subtitleCue{{StartMs: 5000, EndMs: 6000, Text: "Kept"}},
+		},
+		{
+			name:    "MissingHeader",
+			text:    "00:01.000 --> 00:02.000\nHello\n",
+			wantErr: true,
+		},
+		{
+			name:    "SRTStyleTiming",
+			text:    "WEBVTT\n\n00:00:01,000 --> 00:00:02,000\nHello\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cues, err := parseWebVTT(tt.text)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cues)
+		})
+	}
+}
+
+func TestParseASS(t *testing.T) {
+	const header = "[Script Info]\nTitle: Test\n\n[V4+ Styles]\nFormat: Name, Fontname\nStyle: Default,Arial\n\n[Events]\n"
+
+	tests := []struct {
+		name    string
+		text    string
+		want    []subtitleCue
+		wantErr bool
+	}{
+		{
+			name: "DefaultFieldOrder",
+			text: header + "Dialogue: 0,0:00:01.00,0:00:02.50,Default,Ana,0,0,0,,{\\i1}Hola,{\\i0} qué\\Ntal\n",
+			want: []subtitleCue{{StartMs: 1000, EndMs: 2500, Speaker: "Ana", Text: "Hola, qué\ntal"}},
+		},
+		{
+			name: "DeclaredFieldOrder",
+			text: header + "Format: Start, End, Text\nComment: 0:00:00.00,0:00:01.00,ignored\nDialogue: 0:00:03.10,0:00:04.00,Hard\\hspace\n",
+			want: []subtitleCue{{StartMs: 3100, EndMs: 4000, Text: "Hard space"}},
+		},
+		{
+			name: "IgnoresLinesOutsideEvents",
+			text: "[Script Info]\nDialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,Not an event\n",
+			want: nil,
+		},
+		{
+			name:    "MissingFields",
+			text:    header + "Dialogue: 0,0:00:01.00\n",
+			wantErr: true,
+		},
+		{
+			name:    "InvalidTiming",
+			text:    header + "Dialogue: 0,soon,0:00:02.00,Default,,0,0,0,,Hi\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cues, err := parseASS(tt.text)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cues)
+		})
+	}
+}
+
+func TestParseSubtitles(t *testing.T) {
+	t.Run("SortsByStart", func(t *testing.T) {
+		data := []byte("1\n00:00:05,000 --> 00:00:06,000\nSecond\n\n2\n00:00:01,000 --> 00:00:02,000\nFirst\n")
+		cues, err := parseSubtitles(SubtitleFormatSRT, data)
+		require.NoError(t, err)
+		require.Len(t, cues, 2)
+		assert.Equal(t, "First", cues[0].Text)
+		assert.Equal(t, "Second", cues[1].Text)
+	})
+
+	t.Run("NoCues", func(t *testing.T) {
+		_, err := parseSubtitles(SubtitleFormatWebVTT, []byte("WEBVTT\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("UnsupportedFormat", func(t *testing.T) {
+		_, err := parseSubtitles("sub", []byte("{1}{2}Hi"))
+		assert.Error(t, err)
+	})
+}
+
+func TestRenderWebVTT(t *testing.T) {
+	segments := []TranscriptSegment{
+		{StartMs: 1500, EndMs: 3723004, Speaker: "Tom <Jr.>", Text: "Fish & chips"},
+		{StartMs: 4000, EndMs: 5000, Text: "Go --> there\n\nnow"},
+	}
+
+	vtt := RenderWebVTT(segments)
+	assert.Equal(t, "WEBVTT\n"+
+		"\n1\n00:00:01.500 --> 01:02:03.004\n<v Tom &lt;Jr.&gt;>Fish &amp; chips\n"+
+		"\n2\n00:00:04.000 --> 00:00:05.000\nGo --&gt; there\nnow\n", vtt)
+
+	// Cue text never contains a timing arrow, so every "-->" is a timing line
+	for _, line := range strings.Split(vtt, "\n") {
+		if strings.Contains(line, "-->") {
+			assert.Regexp(t, `^\d{2}:\d{2}:\d{2}\.\d{3} --> \d{2}:\d{2}:\d{2}\.\d{3}$`, line)
+		}
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		cues, err := parseSubtitles(SubtitleFormatWebVTT, []byte(vtt))
+		require.NoError(t, err)
+		assert.Equal(t, []subtitleCue{
+			{StartMs: 1500, EndMs: 3723004, Speaker: "Tom &lt;Jr.&gt;", Text: "Fish & chips"},
+			{StartMs: 4000, EndMs: 5000, Text: "Go --> there\nnow"},
+		}, cues)
+	})
+}
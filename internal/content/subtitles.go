@@ -0,0 +1,273 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Subtitle files are text; 2 MB is several times a feature film's
+const maxSubtitleFileSize = 2 << 20
+
+// SubtitleTrack is an episode's subtitles in one language. Its cues are
+// stored as transcript segments linked to the track.
+type SubtitleTrack struct {
+	ID           string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	EpisodeID    string    `json:"episode_id" gorm:"type:uuid;not null;uniqueIndex:idx_subtitle_track_episode_language"`
+	LanguageID   int       `json:"language_id" gorm:"not null;uniqueIndex:idx_subtitle_track_episode_language"`
+	Label        string    `json:"label"`
+	SourceFormat string    `json:"source_format" gorm:"not null"` // srt, vtt, ass
+	CueCount     int       `json:"cue_count"`
+	UploadedBy   string    `json:"uploaded_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Relations
+	Language Language `json:"language,omitempty" gorm:"foreignKey:LanguageID"`
+}
+
+type SubtitleSearchFilter struct {
+	Query      string
+	LanguageID int
+	ContentID  string
+	Limit      int
+	Offset     int
+}
+
+// SubtitleSearchResult is a cue matching a search, with where it was said
+type SubtitleSearchResult struct {
+	SegmentID     string  `json:"segment_id"`
+	TrackID       string  `json:"track_id"`
+	LanguageID    int     `json:"language_id"`
+	ContentID     string  `json:"content_id"`
+	ContentTitle  string  `json:"content_title"`
+	EpisodeID     string  `json:"episode_id"`
+	SeasonNumber  int     `json:"season_number"`
+	EpisodeNumber int     `json:"episode_number"`
+	StartMs       int     `json:"start_ms"`
+	EndMs         int     `json:"end_ms"`
+	Speaker       string  `json:"speaker,omitempty"`
+	Text          string  `json:"text"`
+	Snippet       string  `json:"snippet"`
+	Rank          float64 `json:"rank"`
+}
+
+// Cue text is searched without stemming since tracks come in every language
+const subtitleSearchDocumentSQL = `to_tsvector('simple', transcript_segments.text)`
+
+const subtitleSearchQuerySQL = `websearch_to_tsquery('simple', @query)`
+
+// SaveSubtitleTrack parses an uploaded SRT, WebVTT or ASS file and replaces
+// the episode's track in that language
+func (s *Service) SaveSubtitleTrack(ctx context.Context, contentID, episodeID, languageCode, userID, filename, label string, file io.Reader) (*SubtitleTrack, error) {
+	var content Content
+	if err := s.db.Where("id = ?", contentID).First(&content).Error; err != nil {
+		return nil, errors.New("content not found")
+	}
+
+	if content.CreatedBy != userID {
+		return nil, errors.New("unauthorized to update these subtitles")
+	}
+
+	var episode ContentEpisode
+	if err := s.db.Where("id = ? AND content_id = ?", episodeID, contentID).First(&episode).Error; err != nil {
+		return nil, errors.New("episode not found")
+	}
+
+	var language Language
+	if err := s.db.Where("code = ?", strings.ToLower(languageCode)).First(&language).Error; err != nil {
+		return nil, fmt.Errorf("unknown language %q", languageCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSubtitleFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSubtitleFileSize {
+		return nil, fmt.Errorf("subtitle files are limited to %d MB", maxSubtitleFileSize>>20)
+	}
+
+	format := DetectSubtitleFormat(filename, data)
+	cues, err := parseSubtitles(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s file: %w", format, err)
+	}
+
+	track := SubtitleTrack{
+		EpisodeID:  episodeID,
+		LanguageID: language.ID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("episode_id = ? AND language_id = ?", episodeID, language.ID).FirstOrInit(&track).Error; err != nil {
+			return err
+		}
+		track.Label = strings.TrimSpace(label)
+		if track.Label == "" {
+			track.Label = language.Name
+		}
+		track.SourceFormat = format
+		track.CueCount = len(cues)
+		track.UploadedBy = userID
+		if err := tx.Save(&track).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("track_id = ?", track.ID).Delete(&TranscriptSegment{}).Error; err != nil {
+			return err
+		}
+
+		segments := make([]TranscriptSegment, len(cues))
+		for i, cue := range cues {
+			segments[i] = TranscriptSegment{
+				EpisodeID: episodeID,
+				TrackID:   &track.ID,
+				Position:  i + 1,
+				StartMs:   cue.StartMs,
+				EndMs:     cue.EndMs,
+				Speaker:   cue.Speaker,
+				Text:      cue.Text,
+			}
+		}
+		if err := tx.CreateInBatches(&segments, 500).Error; err != nil {
+			return err
+		}
+		return s.reopenForReview(tx, &content, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	track.Language = language
+	return &track, nil
+}
+
+// GetSubtitleTracks lists the subtitle languages of an episode
+func (s *Service) GetSubtitleTracks(ctx context.Context, contentID, episodeID, viewerID string) ([]SubtitleTrack, error) {
+	if _, err := s.visibleContent(contentID, viewerID); err != nil {
+		return nil, err
+	}
+
+	var episode ContentEpisode
+	if err := s.db.Where("id = ? AND content_id = ?", episodeID, contentID).First(&episode).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("episode not found")
+		}
+		return nil, err
+	}
+
+	var tracks []SubtitleTrack
+	err := s.db.Preload("Language").
+		Where("episode_id = ?", episodeID).
+		Order("language_id ASC").
+		Find(&tracks).Error
+	return tracks, err
+}
+
+// GetSubtitleTrack returns an episode's track in a language with its cues in
+// playback order
+func (s *Service) GetSubtitleTrack(ctx context.Context, contentID, episodeID, languageCode, viewerID string) (*SubtitleTrack, []TranscriptSegment, error) {
+	if _, err := s.visibleContent(contentID, viewerID); err != nil {
+		return nil, nil, err
+	}
+
+	var track SubtitleTrack
+	err := s.db.Preload("Language").
+		Joins("JOIN content_episodes ON content_episodes.id = subtitle_tracks.episode_id").
+		Joins("JOIN languages ON languages.id = subtitle_tracks.language_id").
+		Where("subtitle_tracks.episode_id = ? AND content_episodes.content_id = ? AND content_episodes.deleted_at IS NULL", episodeID, contentID).
+		Where("languages.code = ?", strings.ToLower(languageCode)).
+		First(&track).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("subtitles not found")
+		}
+		return nil, nil, err
+	}
+
+	var segments []TranscriptSegment
+	err = s.db.Where("track_id = ?", track.ID).Order("position ASC").Find(&segments).Error
+	return &track, segments, err
+}
+
+// DeleteSubtitleTrack removes an episode's subtitles in a language
+func (s *Service) DeleteSubtitleTrack(ctx context.Context, contentID, episodeID, languageCode, userID string) error {
+	var content Content
+	if err := s.db.Where("id = ?", contentID).First(&content).Error; err != nil {
+		return errors.New("content not found")
+	}
+
+	if content.CreatedBy != userID {
+		return errors.New("unauthorized to delete these subtitles")
+	}
+
+	track, _, err := s.GetSubtitleTrack(ctx, contentID, episodeID, languageCode, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("track_id = ?", track.ID).Delete(&TranscriptSegment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(track).Error; err != nil {
+			return err
+		}
+		return s.reopenForReview(tx, &content, userID)
+	})
+}
+
+// SearchSubtitles finds subtitle cues containing the query, best matches
+// first
+func (s *Service) SearchSubtitles(ctx context.Context, filter SubtitleSearchFilter) ([]SubtitleSearchResult, int64, error) {
+	query := strings.TrimSpace(filter.Query)
+	if query == "" {
+		return nil, 0, errors.New("search query required")
+	}
+	args := map[string]interface{}{
+		"query": query,
+		"term":  strings.ToLower(query),
+	}
+
+	matches := s.db.Table("transcript_segments").
+		Joins("JOIN subtitle_tracks ON subtitle_tracks.id = transcript_segments.track_id").
+		Joins("JOIN content_episodes ON content_episodes.id = transcript_segments.episode_id").
+		Joins("JOIN contents ON contents.id = content_episodes.content_id").
+		Where("content_episodes.deleted_at IS NULL AND contents.deleted_at IS NULL").
+		Where("contents.status = ?", ContentStatusPublished).
+		// Substring matches cover scripts without spaces between words
+		Where("("+subtitleSearchDocumentSQL+" @@ "+subtitleSearchQuerySQL+" OR strpos(lower(transcript_segments.text), @term) > 0)", args)
+	if filter.LanguageID > 0 {
+		matches = matches.Where("subtitle_tracks.language_id = ?", filter.LanguageID)
+	}
+	if filter.ContentID != "" {
+		matches = matches.Where("contents.id = ?", filter.ContentID)
+	}
+
+	var total int64
+	if err := matches.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var results []SubtitleSearchResult
+	err := matches.Select(`transcript_segments.id AS segment_id, subtitle_tracks.id AS track_id, subtitle_tracks.language_id,
+            contents.id AS content_id, contents.title AS content_title,
+            content_episodes.id AS episode_id, content_episodes.season_number, content_episodes.episode_number,
+            transcript_segments.start_ms, transcript_segments.end_ms, transcript_segments.speaker, transcript_segments.text,
+            ts_headline('simple', transcript_segments.text, `+subtitleSearchQuerySQL+`, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS snippet,
+            ts_rank_cd(`+subtitleSearchDocumentSQL+`, `+subtitleSearchQuerySQL+`) AS rank`, args).
+		Order("rank DESC, contents.title ASC, content_episodes.season_number ASC, content_episodes.episode_number ASC, transcript_segments.start_ms ASC").
+		Limit(limit).
+		Offset(filter.Offset).
+		Scan(&results).Error
+	return results, total, err
+}
@@ -0,0 +1,127 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TranscriptSegment is one timestamped line of an episode's transcript or
+// subtitles. Transcript lines are in the language of the content and have no
+// track; subtitle cues belong to a SubtitleTrack.
+type TranscriptSegment struct {
+	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	EpisodeID string    `json:"episode_id" gorm:"type:uuid;not null;index:idx_transcript_episode_position"`
+	TrackID   *string   `json:"track_id,omitempty" gorm:"type:uuid;index"`
+	Position  int       `json:"position" gorm:"not null;index:idx_transcript_episode_position"`
+	StartMs   int       `json:"start_ms" gorm:"not null"`
+	EndMs     int       `json:"end_ms" gorm:"not null"`
+	Speaker   string    `json:"speaker,omitempty"`
+	Text      string    `json:"text" gorm:"type:text;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TranscriptSegmentInput struct {
+	StartMs int    `json:"start_ms" validate:"min=0"`
+	EndMs   int    `json:"end_ms" validate:"gtfield=StartMs"`
+	Speaker string `json:"speaker" validate:"max=100"`
+	Text    string `json:"text" validate:"required,max=2000"`
+}
+
+type SetTranscriptRequest struct {
+	Segments []TranscriptSegmentInput `json:"segments" validate:"required,min=1,max=5000,dive"`
+}
+
+// GetEpisodeTranscript returns the transcript of an episode in playback order
+func (s *Service) GetEpisodeTranscript(ctx context.Context, contentID, episodeID, viewerID string) ([]TranscriptSegment, error) {
+	if _, err := s.visibleContent(contentID, viewerID); err != nil {
+		return nil, err
+	}
+
+	var episode ContentEpisode
+	if err := s.db.Where("id = ? AND content_id = ?", episodeID, contentID).First(&episode).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("episode not found")
+		}
+		return nil, err
+	}
+
+	var segments []TranscriptSegment
+	err := s.db.Where("episode_id = ? AND track_id IS NULL", episodeID).Order("position ASC").Find(&segments).Error
+	return segments, err
+}
+
+// SetEpisodeTranscript replaces the transcript of an episode
+func (s *Service) SetEpisodeTranscript(ctx context.Context, contentID, episodeID string, req SetTranscriptRequest, userID string) ([]TranscriptSegment, error) {
+	var content Content
+	if err := s.db.Where("id = ?", contentID).First(&content).Error; err != nil {
+		return nil, errors.New("content not found")
+	}
+
+	if content.CreatedBy != userID {
+		return nil, errors.New("unauthorized to update this transcript")
+	}
+
+	var episode ContentEpisode
+	if err := s.db.Where("id = ? AND content_id = ?", episodeID, contentID).First(&episode).Error; err != nil {
+		return nil, errors.New("episode not found")
+	}
+
+	segments := make([]TranscriptSegment, 0, len(req.Segments))
+	for _, input := range req.Segments {
+		text := strings.TrimSpace(input.Text)
+		if text == "" {
+			continue
+		}
+		segments = append(segments, TranscriptSegment{
+			EpisodeID: episodeID,
+			Position:  len(segments) + 1,
+			StartMs:   input.StartMs,
+			EndMs:     input.EndMs,
+			Speaker:   strings.TrimSpace(input.Speaker),
+			Text:      text,
+		})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("episode_id = ? AND track_id IS NULL", episodeID).Delete(&TranscriptSegment{}).Error; err != nil {
+			return err
+		}
+		if len(segments) > 0 {
+			if err := tx.CreateInBatches(&segments, 500).Error; err != nil {
+				return err
+			}
+		}
+		return s.reopenForReview(tx, &content, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+// DeleteEpisodeTranscript removes the transcript of an episode
+func (s *Service) DeleteEpisodeTranscript(ctx context.Context, contentID, episodeID string, userID string) error {
+	var content Content
+	if err := s.db.Where("id = ?", contentID).First(&content).Error; err != nil {
+		return errors.New("content not found")
+	}
+
+	if content.CreatedBy != userID {
+		return errors.New("unauthorized to delete this transcript")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("track_id IS NULL AND episode_id IN (?)",
+			tx.Model(&ContentEpisode{}).Select("id").Where("id = ? AND content_id = ?", episodeID, contentID),
+		).Delete(&TranscriptSegment{}).Error
+		if err != nil {
+			return err
+		}
+		return s.reopenForReview(tx, &content, userID)
+	})
+}
@@ -0,0 +1,103 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Raylynd6299/babel/internal/shared/storage"
+)
+
+// Uploads nothing links to are kept for a day so a client can upload first
+// and save the record afterwards
+const (
+	orphanGracePeriod     = 24 * time.Hour
+	uploadCleanupInterval = 6 * time.Hour
+)
+
+// UploadPoster stores a poster image for content the user created and links
+// it from the content. The previous poster is cleaned up as an orphan.
+func (s *Service) UploadPoster(ctx context.Context, contentID, userID string, file io.Reader) (*Content, error) {
+	var content Content
+	if err := s.db.Where("id = ?", contentID).First(&content).Error; err != nil {
+		return nil, errors.New("content not found")
+	}
+	if content.CreatedBy != userID {
+		return nil, errors.New("unauthorized to update this content")
+	}
+
+	upload, err := s.files.Save(ctx, storage.KindPoster, userID, file)
+	if err != nil {
+		return nil, err
+	}
+
+	before := content
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&content).Update("poster_url", upload.URL).Error; err != nil {
+			return err
+		}
+		err := recordAudit(tx, ContentAuditEntry{
+			ContentID: content.ID,
+			UserID:    userID,
+			Action:    auditContentUpdated,
+			Changes:   auditDiff(before, content),
+		})
+		if err != nil {
+			return err
+		}
+		return s.reopenForReview(tx, &content, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &content, nil
+}
+
+// GetFileURL returns a presigned download URL for a stored file
+func (s *Service) GetFileURL(ctx context.Context, key string) (string, time.Time, error) {
+	return s.files.Presign(ctx, key)
+}
+
+// LocalFilePath resolves a presigned link when files are kept on the local
+// disk. ok is false for other backends, whose links point elsewhere.
+func (s *Service) LocalFilePath(key, expires, signature string) (path string, ok bool, err error) {
+	local, isLocal := s.files.Storage().(*storage.Local)
+	if !isLocal {
+		return "", false, nil
+	}
+	path, err = local.VerifiedPath(key, expires, signature)
+	return path, true, err
+}
+
+// CleanupOrphanedUploads deletes uploads no poster, audio or avatar links to
+func (s *Service) CleanupOrphanedUploads(ctx context.Context) (int, error) {
+	return s.files.CleanupOrphans(ctx, orphanGracePeriod)
+}
+
+// StartUploadCleanup removes orphaned uploads periodically until ctx is done
+func (s *Service) StartUploadCleanup(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(uploadCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			removed, err := s.CleanupOrphanedUploads(ctx)
+			if err != nil {
+				log.Printf("Failed to clean up orphaned uploads: %v", err)
+			} else if removed > 0 {
+				log.Printf("Removed %d orphaned uploads", removed)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -10,6 +10,7 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/Raylynd6299/babel/internal/shared/storage"
 	polyfyjwt "github.com/Raylynd6299/babel/pkg/jwt"
 
 	_ "github.com/Raylynd6299/babel/cmd/phonetic-service/docs"
@@ -82,6 +83,14 @@ func NewRouter(service *Service) *gin.Engine {
 		protected.POST("/practice-plan", phoneticRouter.CreatePracticePlan)
 	}
 
+	// Editor routes (pronunciation audio)
+	editor := v1.Group("/phonetic/admin")
+	editor.Use(phoneticRouter.jwtService.EditorMiddleware())
+	{
+		editor.POST("/phonemes/:id/audio", phoneticRouter.UploadPhonemeAudio)
+		editor.POST("/minimal-pairs/:id/audio/:word", phoneticRouter.UploadMinimalPairAudio)
+	}
+
 	return router
 }
 
@@ -185,6 +194,97 @@ func (r *Router) GetMinimalPairs(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"minimal_pairs": pairs})
 }
 
+// UploadPhonemeAudio godoc
+// @Summary      Upload phoneme audio
+// @Description  Upload the pronunciation recording of a phoneme (MP3, OGG, WAV, FLAC, AAC, M4A or WebM, up to 10 MB). It replaces the current recording. Requires the editor role.
+// @Tags         phonemes
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id path int true "Phoneme ID"
+// @Param        file formData file true "Audio recording"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      413 {object} map[string]string
+// @Failure      415 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /phonetic/admin/phonemes/{id}/audio [post]
+func (r *Router) UploadPhonemeAudio(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phoneme ID"})
+		return
+	}
+
+	file, err := storage.OpenFormFile(c.Writer, c.Request, storage.KindPhonemeAudio)
+	if err != nil {
+		c.JSON(storage.StatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	phoneme, err := r.service.UploadPhonemeAudio(c.Request.Context(), id, userID, file)
+	if err != nil {
+		c.JSON(storage.StatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"phoneme": phoneme})
+}
+
+// UploadMinimalPairAudio godoc
+// @Summary      Upload minimal pair audio
+// @Description  Upload the recording of the first or second word of a minimal pair (MP3, OGG, WAV, FLAC, AAC, M4A or WebM, up to 10 MB). Requires the editor role.
+// @Tags         phonemes
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id path string true "Minimal pair ID"
+// @Param        word path int true "Word of the pair (1 or 2)"
+// @Param        file formData file true "Audio recording"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      413 {object} map[string]string
+// @Failure      415 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /phonetic/admin/minimal-pairs/{id}/audio/{word} [post]
+func (r *Router) UploadMinimalPairAudio(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	word, err := strconv.Atoi(c.Param("word"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word, expected 1 or 2"})
+		return
+	}
+
+	file, err := storage.OpenFormFile(c.Writer, c.Request, storage.KindMinimalPairAudio)
+	if err != nil {
+		c.JSON(storage.StatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	pair, err := r.service.UploadMinimalPairAudio(c.Request.Context(), c.Param("id"), word, userID, file)
+	if err != nil {
+		c.JSON(storage.StatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"minimal_pair": pair})
+}
+
 // GetExercises godoc
 // @Summary      Get phonetic exercises
 // @Description  Get paginated list of phonetic exercises with optional filtering
@@ -4,20 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"time"
 
 	"gorm.io/gorm"
+
+	"github.com/Raylynd6299/babel/internal/shared/storage"
 )
 
 type Service struct {
 	db        *gorm.DB
 	jwtSecret string
+	files     *storage.Files
 }
 
-func NewService(db *gorm.DB, jwtSecret string) *Service {
+func NewService(db *gorm.DB, jwtSecret string, files *storage.Files) *Service {
 	return &Service{
 		db:        db,
 		jwtSecret: jwtSecret,
+		files:     files,
 	}
 }
 
@@ -42,6 +47,49 @@ func (s *Service) GetPhoneme(ctx context.Context, id int) (*Phoneme, error) {
 	return &phoneme, nil
 }
 
+// UploadPhonemeAudio stores a reference recording for a phoneme and links it
+// from the phoneme. The previous recording is cleaned up as an orphan.
+func (s *Service) UploadPhonemeAudio(ctx context.Context, phonemeID int, userID string, file io.Reader) (*Phoneme, error) {
+	var phoneme Phoneme
+	if err := s.db.Where("id = ?", phonemeID).First(&phoneme).Error; err != nil {
+		return nil, errors.New("phoneme not found")
+	}
+
+	upload, err := s.files.Save(ctx, storage.KindPhonemeAudio, userID, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(&phoneme).Update("audio_url", upload.URL).Error; err != nil {
+		return nil, err
+	}
+	return &phoneme, nil
+}
+
+// UploadMinimalPairAudio stores the recording of the first or second word of
+// a minimal pair
+func (s *Service) UploadMinimalPairAudio(ctx context.Context, pairID string, word int, userID string, file io.Reader) (*MinimalPair, error) {
+	column := map[int]string{1: "audio1_url", 2: "audio2_url"}[word]
+	if column == "" {
+		return nil, errors.New("word must be 1 or 2")
+	}
+
+	var pair MinimalPair
+	if err := s.db.Where("id = ?", pairID).First(&pair).Error; err != nil {
+		return nil, errors.New("minimal pair not found")
+	}
+
+	upload, err := s.files.Save(ctx, storage.KindMinimalPairAudio, userID, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(&pair).Update(column, upload.URL).Error; err != nil {
+		return nil, err
+	}
+	return &pair, nil
+}
+
 // User progress tracking
 func (s *Service) GetUserProgress(ctx context.Context, userID string, languageID int) ([]PhoneticProgressResponse, error) {
 	var progress []UserPhoneticProgress
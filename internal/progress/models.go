@@ -95,7 +95,8 @@ type GoalsResponse struct {
 	DailyGoalMinutes       int     `json:"daily_goal_minutes"`
 	WeeklyGoalHours        int     `json:"weekly_goal_hours"`
 	MonthlyGoalHours       int     `json:"monthly_goal_hours"`
-	DailyProgress          int     `json:"daily_progress_minutes"`
+	DailyProgress          int     `json:"daily_progress_minutes"` // Includes vocabulary review time
+	DailyReviewMinutes     int     `json:"daily_review_minutes"`
 	WeeklyProgress         float64 `json:"weekly_progress_hours"`
 	MonthlyProgress        float64 `json:"monthly_progress_hours"`
 	DailyProgressPercent   float64 `json:"daily_progress_percent"`
@@ -174,3 +175,104 @@ type WeeklyProgressSummary struct {
 	Comprehension float64 `json:"comprehension"`
 	MetGoal       bool    `json:"met_goal"`
 }
+
+// Watchlist is a named list of content a user saved to watch later
+type Watchlist struct {
+	ID          string         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      string         `json:"user_id" gorm:"not null;index"`
+	Name        string         `json:"name" gorm:"not null"`
+	Description string         `json:"description"`
+	Position    int            `json:"position"` // Order among the user's watchlists
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index" swaggerignore:"true"`
+}
+
+type WatchlistItem struct {
+	ID          string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WatchlistID string    `json:"watchlist_id" gorm:"type:uuid;not null;uniqueIndex:idx_watchlist_content"`
+	ContentID   string    `json:"content_id" gorm:"not null;uniqueIndex:idx_watchlist_content"`
+	Position    int       `json:"position"` // Order within the watchlist
+	Note        string    `json:"note"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WatchlistSummary is a watchlist with how much content it holds
+type WatchlistSummary struct {
+	Watchlist
+	ItemCount int `json:"item_count"`
+}
+
+// WatchlistEntry is a watchlist item with its content and how far the user
+// got through it
+type WatchlistEntry struct {
+	WatchlistItem
+	Title             string     `json:"title"`
+	ContentType       string     `json:"content_type"`
+	PosterURL         string     `json:"poster_url"`
+	State             string     `json:"state"` // unwatched, in_progress, completed
+	EpisodeCount      int        `json:"episode_count"`
+	EpisodesCompleted int        `json:"episodes_completed"`
+	LastWatchedAt     *time.Time `json:"last_watched_at,omitempty"`
+}
+
+type WatchlistDetail struct {
+	Watchlist
+	Items []WatchlistEntry `json:"items"`
+}
+
+// EpisodeState is where a user is with one episode, derived from their
+// logged progress
+type EpisodeState struct {
+	EpisodeID       string     `json:"episode_id"`
+	ContentID       string     `json:"content_id"`
+	SeasonNumber    int        `json:"season_number"`
+	EpisodeNumber   int        `json:"episode_number"`
+	Title           string     `json:"title"`
+	DurationMinutes int        `json:"duration_minutes"`
+	State           string     `json:"state"` // unwatched, in_progress, completed
+	MinutesWatched  int        `json:"minutes_watched"`
+	LastWatchedAt   *time.Time `json:"last_watched_at,omitempty"`
+}
+
+// ContentEpisodeStates is a user's state of every episode of a content
+type ContentEpisodeStates struct {
+	ContentID   string         `json:"content_id"`
+	State       string         `json:"state"`
+	Completed   int            `json:"completed"`
+	InProgress  int            `json:"in_progress"`
+	Unwatched   int            `json:"unwatched"`
+	NextEpisode *EpisodeState  `json:"next_episode,omitempty"`
+	Episodes    []EpisodeState `json:"episodes"`
+}
+
+// ContinueWatchingItem is the episode to watch next in a show the user has
+// started
+type ContinueWatchingItem struct {
+	ContentID         string       `json:"content_id"`
+	Title             string       `json:"title"`
+	ContentType       string       `json:"content_type"`
+	PosterURL         string       `json:"poster_url"`
+	Episode           EpisodeState `json:"episode"`
+	Resume            bool         `json:"resume"` // The episode was started but not finished
+	EpisodeCount      int          `json:"episode_count"`
+	EpisodesCompleted int          `json:"episodes_completed"`
+	LastWatchedAt     time.Time    `json:"last_watched_at"`
+}
+
+type WatchlistRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+type AddWatchlistItemRequest struct {
+	ContentID string `json:"content_id" validate:"required"`
+	Note      string `json:"note" validate:"max=500"`
+	Position  *int   `json:"position" validate:"omitempty,min=0"` // Insert before this position; appended when omitted
+}
+
+// ReorderRequest lists every watchlist, or every item of a watchlist, in
+// their new order
+type ReorderRequest struct {
+	IDs []string `json:"ids" validate:"required,min=1,max=500,dive,required"`
+}
@@ -75,6 +75,21 @@ func NewRouter(service *Service) *gin.Engine {
 		// Reports
 		protected.GET("/weekly-report", progressRouter.GetWeeklyReport)
 		protected.GET("/monthly-report", progressRouter.GetMonthlyReport)
+
+		// Watchlists
+		protected.GET("/watchlists", progressRouter.GetWatchlists)
+		protected.POST("/watchlists", progressRouter.CreateWatchlist)
+		protected.PUT("/watchlists/order", progressRouter.ReorderWatchlists)
+		protected.GET("/watchlists/:id", progressRouter.GetWatchlist)
+		protected.PUT("/watchlists/:id", progressRouter.UpdateWatchlist)
+		protected.DELETE("/watchlists/:id", progressRouter.DeleteWatchlist)
+		protected.POST("/watchlists/:id/items", progressRouter.AddWatchlistItem)
+		protected.PUT("/watchlists/:id/items/order", progressRouter.ReorderWatchlistItems)
+		protected.DELETE("/watchlists/:id/items/:item_id", progressRouter.RemoveWatchlistItem)
+
+		// Series tracking
+		protected.GET("/content/:content_id/episodes", progressRouter.GetEpisodeStates)
+		protected.GET("/continue-watching", progressRouter.GetContinueWatching)
 	}
 
 	return router
@@ -594,6 +609,375 @@ func (r *Router) GetMonthlyReport(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"monthly_report": report})
 }
 
+// GetWatchlists godoc
+// @Summary      Get watchlists
+// @Description  Get the user's watchlists in their order with how many items each holds
+// @Tags         watchlists
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /progress/watchlists [get]
+func (r *Router) GetWatchlists(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	watchlists, err := r.service.GetWatchlists(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"watchlists": watchlists})
+}
+
+// CreateWatchlist godoc
+// @Summary      Create watchlist
+// @Description  Create a named watchlist, placed after the user's other watchlists
+// @Tags         watchlists
+// @Accept       json
+// @Produce      json
+// @Param        request body WatchlistRequest true "Watchlist"
+// @Success      201 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /progress/watchlists [post]
+func (r *Router) CreateWatchlist(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req WatchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	watchlist, err := r.service.CreateWatchlist(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"watchlist": watchlist})
+}
+
+// ReorderWatchlists godoc
+// @Summary      Reorder watchlists
+// @Description  Put the user's watchlists in a new order. The IDs must list every watchlist once.
+// @Tags         watchlists
+// @Accept       json
+// @Produce      json
+// @Param        request body ReorderRequest true "Watchlist IDs in their new order"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /progress/watchlists/order [put]
+func (r *Router) ReorderWatchlists(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.service.ReorderWatchlists(c.Request.Context(), userID, req.IDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Watchlists reordered successfully"})
+}
+
+// GetWatchlist godoc
+// @Summary      Get watchlist
+// @Description  Get a watchlist with its content in order and whether the user has watched each (unwatched, in_progress, completed)
+// @Tags         watchlists
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Watchlist ID"
+// @Success      200 {object} WatchlistDetail
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /progress/watchlists/{id} [get]
+func (r *Router) GetWatchlist(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	watchlist, err := r.service.GetWatchlist(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"watchlist": watchlist})
+}
+
+// UpdateWatchlist godoc
+// @Summary      Update watchlist
+// @Description  Rename a watchlist or change its description
+// @Tags         watchlists
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Watchlist ID"
+// @Param        request body WatchlistRequest true "Watchlist"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /progress/watchlists/{id} [put]
+func (r *Router) UpdateWatchlist(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req WatchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	watchlist, err := r.service.UpdateWatchlist(c.Request.Context(), userID, c.Param("id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"watchlist": watchlist})
+}
+
+// DeleteWatchlist godoc
+// @Summary      Delete watchlist
+// @Description  Delete a watchlist and its items
+// @Tags         watchlists
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Watchlist ID"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /progress/watchlists/{id} [delete]
+func (r *Router) DeleteWatchlist(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := r.service.DeleteWatchlist(c.Request.Context(), userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Watchlist deleted successfully"})
+}
+
+// AddWatchlistItem godoc
+// @Summary      Add content to watchlist
+// @Description  Save content to a watchlist, at the end or before the given position
+// @Tags         watchlists
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Watchlist ID"
+// @Param        request body AddWatchlistItemRequest true "Content to add"
+// @Success      201 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /progress/watchlists/{id}/items [post]
+func (r *Router) AddWatchlistItem(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req AddWatchlistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := r.service.AddWatchlistItem(c.Request.Context(), userID, c.Param("id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"item": item})
+}
+
+// ReorderWatchlistItems godoc
+// @Summary      Reorder watchlist items
+// @Description  Put a watchlist's items in a new order. The IDs must list every item once.
+// @Tags         watchlists
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Watchlist ID"
+// @Param        request body ReorderRequest true "Item IDs in their new order"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /progress/watchlists/{id}/items/order [put]
+func (r *Router) ReorderWatchlistItems(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.service.ReorderWatchlistItems(c.Request.Context(), userID, c.Param("id"), req.IDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Watchlist reordered successfully"})
+}
+
+// RemoveWatchlistItem godoc
+// @Summary      Remove content from watchlist
+// @Description  Remove an item from a watchlist
+// @Tags         watchlists
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Watchlist ID"
+// @Param        item_id path string true "Watchlist item ID"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /progress/watchlists/{id}/items/{item_id} [delete]
+func (r *Router) RemoveWatchlistItem(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := r.service.RemoveWatchlistItem(c.Request.Context(), userID, c.Param("id"), c.Param("item_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item removed successfully"})
+}
+
+// GetEpisodeStates godoc
+// @Summary      Get episode states
+// @Description  Get whether the user has watched each episode of a content (unwatched, in_progress, completed), derived from logged progress, and the episode to watch next. An episode is completed when a session was marked completed or 90% of its length was logged.
+// @Tags         series
+// @Accept       json
+// @Produce      json
+// @Param        content_id path string true "Content ID"
+// @Success      200 {object} ContentEpisodeStates
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /progress/content/{content_id}/episodes [get]
+func (r *Router) GetEpisodeStates(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	states, err := r.service.GetEpisodeStates(c.Request.Context(), userID, c.Param("content_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, states)
+}
+
+// GetContinueWatching godoc
+// @Summary      Continue watching
+// @Description  Get the episode to watch next in each show the user has started and not finished, most recently watched first: the last episode if it was left unfinished, otherwise the next unwatched one
+// @Tags         series
+// @Accept       json
+// @Produce      json
+// @Param        limit query int false "Number of shows to return (max 50)" default(10)
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /progress/continue-watching [get]
+func (r *Router) GetContinueWatching(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit := 10
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 50 {
+			limit = parsed
+		}
+	}
+
+	items, err := r.service.GetContinueWatching(c.Request.Context(), userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"continue_watching": items})
+}
+
 // Middleware
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -476,6 +476,7 @@ func (s *Service) GetGoals(ctx context.Context, userID string, languageID int) (
 		WeeklyGoalHours:        weeklyGoal,
 		MonthlyGoalHours:       monthlyGoal,
 		DailyProgress:          dailyProgress,
+		DailyReviewMinutes:     s.getDailyReviewMinutes(userID, languageID, now),
 		WeeklyProgress:         weeklyProgress,
 		MonthlyProgress:        monthlyProgress,
 		DailyProgressPercent:   float64(dailyProgress) / float64(dailyGoal) * 100,
@@ -615,7 +616,30 @@ func (s *Service) getDailyProgress(userID string, languageID int, date time.Time
 		s.db.Raw(query, userID, dateStr).Scan(&minutes)
 	}
 
-	return minutes
+	return minutes + s.getDailyReviewMinutes(userID, languageID, date)
+}
+
+// getDailyReviewMinutes returns the time spent in vocabulary review sessions
+// started on the given day. Only time spent answering is counted, not pauses.
+func (s *Service) getDailyReviewMinutes(userID string, languageID int, date time.Time) int {
+	dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return s.getReviewMinutes(userID, languageID, dayStart, dayStart.AddDate(0, 0, 1))
+}
+
+// getReviewMinutes returns the time spent in vocabulary review sessions
+// started in [from, to)
+func (s *Service) getReviewMinutes(userID string, languageID int, from, to time.Time) int {
+	var seconds int
+
+	query := s.db.Table("review_sessions").
+		Select("COALESCE(SUM(active_seconds), 0)").
+		Where("user_id = ? AND started_at >= ? AND started_at < ?", userID, from, to)
+	if languageID > 0 {
+		query = query.Where("language_id = ?", languageID)
+	}
+	query.Scan(&seconds)
+
+	return seconds / 60
 }
 
 func (s *Service) getWeeklyProgressHours(userID string, languageID int, weekStart time.Time) float64 {
@@ -635,6 +659,8 @@ func (s *Service) getWeeklyProgressHours(userID string, languageID int, weekStar
 		s.db.Raw(query, userID, weekStart, weekEnd).Scan(&minutes)
 	}
 
+	minutes += s.getReviewMinutes(userID, languageID, weekStart, weekStart.AddDate(0, 0, 7))
+
 	return float64(minutes) / 60
 }
 
@@ -655,6 +681,8 @@ func (s *Service) getMonthlyProgressHours(userID string, languageID int, monthSt
 		s.db.Raw(query, userID, monthStart, monthEnd).Scan(&minutes)
 	}
 
+	minutes += s.getReviewMinutes(userID, languageID, monthStart, monthStart.AddDate(0, 1, 0))
+
 	return float64(minutes) / 60
 }
 
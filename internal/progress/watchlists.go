@@ -0,0 +1,557 @@
+package progress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Watch states of episodes and content
+const (
+	WatchStateUnwatched  = "unwatched"
+	WatchStateInProgress = "in_progress"
+	WatchStateCompleted  = "completed"
+)
+
+const (
+	maxWatchlists     = 50
+	maxWatchlistItems = 500
+	// An episode counts as completed once this share of it was logged, even
+	// if no session was marked completed
+	completedShare = 0.9
+	// Shows looked at for continue watching, most recently watched first
+	maxStartedShows = 200
+)
+
+// watchedContent is the part of a content watchlists and continue watching
+// show
+type watchedContent struct {
+	ID          string
+	Title       string
+	ContentType string
+	PosterURL   string
+}
+
+// contentActivity is a user's progress on a content as a whole
+type contentActivity struct {
+	ContentID     string
+	Completed     bool
+	LastWatchedAt *time.Time
+}
+
+// GetWatchlists lists the user's watchlists in their order
+func (s *Service) GetWatchlists(ctx context.Context, userID string) ([]WatchlistSummary, error) {
+	watchlists := []WatchlistSummary{}
+	err := s.db.Model(&Watchlist{}).
+		Select("watchlists.*, COUNT(watchlist_items.id) AS item_count").
+		Joins("LEFT JOIN watchlist_items ON watchlist_items.watchlist_id = watchlists.id").
+		Where("watchlists.user_id = ?", userID).
+		Group("watchlists.id").
+		Order("watchlists.position ASC, watchlists.created_at ASC").
+		Scan(&watchlists).Error
+	return watchlists, err
+}
+
+func (s *Service) CreateWatchlist(ctx context.Context, userID string, req WatchlistRequest) (*Watchlist, error) {
+	var count int64
+	if err := s.db.Model(&Watchlist{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= maxWatchlists {
+		return nil, fmt.Errorf("a user can have at most %d watchlists", maxWatchlists)
+	}
+
+	var position int
+	err := s.db.Model(&Watchlist{}).Where("user_id = ?", userID).
+		Select("COALESCE(MAX(position), -1) + 1").Scan(&position).Error
+	if err != nil {
+		return nil, err
+	}
+
+	watchlist := Watchlist{
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+		Position:    position,
+	}
+	if err := s.db.Create(&watchlist).Error; err != nil {
+		return nil, err
+	}
+	return &watchlist, nil
+}
+
+func (s *Service) UpdateWatchlist(ctx context.Context, userID, watchlistID string, req WatchlistRequest) (*Watchlist, error) {
+	watchlist, err := s.ownedWatchlist(userID, watchlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	watchlist.Name = req.Name
+	watchlist.Description = req.Description
+	if err := s.db.Save(watchlist).Error; err != nil {
+		return nil, err
+	}
+	return watchlist, nil
+}
+
+func (s *Service) DeleteWatchlist(ctx context.Context, userID, watchlistID string) error {
+	watchlist, err := s.ownedWatchlist(userID, watchlistID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("watchlist_id = ?", watchlist.ID).Delete(&WatchlistItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(watchlist).Error
+	})
+}
+
+// ReorderWatchlists puts the user's watchlists in the order of ids, which
+// must list each of them once
+func (s *Service) ReorderWatchlists(ctx context.Context, userID string, ids []string) error {
+	var current []string
+	if err := s.db.Model(&Watchlist{}).Where("user_id = ?", userID).Pluck("CAST(id AS text)", &current).Error; err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return applyOrder(tx, &Watchlist{}, current, ids)
+	})
+}
+
+// GetWatchlist returns a watchlist with its content in order and how far
+// the user got through each
+func (s *Service) GetWatchlist(ctx context.Context, userID, watchlistID string) (*WatchlistDetail, error) {
+	watchlist, err := s.ownedWatchlist(userID, watchlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []WatchlistItem
+	if err := s.db.Where("watchlist_id = ?", watchlist.ID).Order("position ASC, created_at ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	contentIDs := make([]string, len(items))
+	for i, item := range items {
+		contentIDs[i] = item.ContentID
+	}
+	contents, err := s.loadWatchedContent(contentIDs)
+	if err != nil {
+		return nil, err
+	}
+	episodes, err := s.loadEpisodeStates(userID, contentIDs)
+	if err != nil {
+		return nil, err
+	}
+	activity, err := s.loadContentActivity(userID, contentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &WatchlistDetail{Watchlist: *watchlist, Items: make([]WatchlistEntry, 0, len(items))}
+	for _, item := range items {
+		content, ok := contents[item.ContentID]
+		if !ok {
+			// Deleted from the catalogue
+			continue
+		}
+		states := episodes[item.ContentID]
+		completed, _ := countEpisodeStates(states)
+		detail.Items = append(detail.Items, WatchlistEntry{
+			WatchlistItem:     item,
+			Title:             content.Title,
+			ContentType:       content.ContentType,
+			PosterURL:         content.PosterURL,
+			State:             contentState(states, activity[item.ContentID]),
+			EpisodeCount:      len(states),
+			EpisodesCompleted: completed,
+			LastWatchedAt:     activity[item.ContentID].LastWatchedAt,
+		})
+	}
+	return detail, nil
+}
+
+// AddWatchlistItem saves content to a watchlist, at the end unless a
+// position is given
+func (s *Service) AddWatchlistItem(ctx context.Context, userID, watchlistID string, req AddWatchlistItemRequest) (*WatchlistItem, error) {
+	watchlist, err := s.ownedWatchlist(userID, watchlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := s.loadWatchedContent([]string{req.ContentID})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := contents[req.ContentID]; !ok {
+		return nil, errors.New("content not found")
+	}
+
+	item := WatchlistItem{WatchlistID: watchlist.ID, ContentID: req.ContentID, Note: req.Note}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&WatchlistItem{}).Where("watchlist_id = ?", watchlist.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= maxWatchlistItems {
+			return fmt.Errorf("a watchlist can hold at most %d items", maxWatchlistItems)
+		}
+
+		var existing int64
+		if err := tx.Model(&WatchlistItem{}).Where("watchlist_id = ? AND content_id = ?", watchlist.ID, req.ContentID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errors.New("content is already in this watchlist")
+		}
+
+		item.Position = int(count)
+		if req.Position != nil && *req.Position < item.Position {
+			item.Position = *req.Position
+			err := tx.Model(&WatchlistItem{}).
+				Where("watchlist_id = ? AND position >= ?", watchlist.ID, item.Position).
+				UpdateColumn("position", gorm.Expr("position + 1")).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Create(&item).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *Service) RemoveWatchlistItem(ctx context.Context, userID, watchlistID, itemID string) error {
+	watchlist, err := s.ownedWatchlist(userID, watchlistID)
+	if err != nil {
+		return err
+	}
+
+	var item WatchlistItem
+	if err := s.db.Where("id = ? AND watchlist_id = ?", itemID, watchlist.ID).First(&item).Error; err != nil {
+		return errors.New("watchlist item not found")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+		return tx.Model(&WatchlistItem{}).
+			Where("watchlist_id = ? AND position > ?", watchlist.ID, item.Position).
+			UpdateColumn("position", gorm.Expr("position - 1")).Error
+	})
+}
+
+// ReorderWatchlistItems puts a watchlist's items in the order of ids, which
+// must list each of them once
+func (s *Service) ReorderWatchlistItems(ctx context.Context, userID, watchlistID string, ids []string) error {
+	watchlist, err := s.ownedWatchlist(userID, watchlistID)
+	if err != nil {
+		return err
+	}
+
+	var current []string
+	if err := s.db.Model(&WatchlistItem{}).Where("watchlist_id = ?", watchlist.ID).Pluck("CAST(id AS text)", &current).Error; err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return applyOrder(tx, &WatchlistItem{}, current, ids)
+	})
+}
+
+// GetEpisodeStates returns the user's state of every episode of a content
+// and the episode to watch next
+func (s *Service) GetEpisodeStates(ctx context.Context, userID, contentID string) (*ContentEpisodeStates, error) {
+	contents, err := s.loadWatchedContent([]string{contentID})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := contents[contentID]; !ok {
+		return nil, errors.New("content not found")
+	}
+
+	episodes, err := s.loadEpisodeStates(userID, []string{contentID})
+	if err != nil {
+		return nil, err
+	}
+	activity, err := s.loadContentActivity(userID, []string{contentID})
+	if err != nil {
+		return nil, err
+	}
+
+	states := episodes[contentID]
+	if states == nil {
+		states = []EpisodeState{}
+	}
+	completed, inProgress := countEpisodeStates(states)
+	result := &ContentEpisodeStates{
+		ContentID:  contentID,
+		State:      contentState(states, activity[contentID]),
+		Completed:  completed,
+		InProgress: inProgress,
+		Unwatched:  len(states) - completed - inProgress,
+		Episodes:   states,
+	}
+	if next, _ := nextEpisode(states); next != nil {
+		result.NextEpisode = next
+	}
+	return result, nil
+}
+
+// GetContinueWatching returns the episode to watch next in each show the
+// user has started and not finished, most recently watched show first
+func (s *Service) GetContinueWatching(ctx context.Context, userID string, limit int) ([]ContinueWatchingItem, error) {
+	var started []struct {
+		ContentID     string
+		LastWatchedAt time.Time
+	}
+	err := s.db.Model(&UserProgress{}).
+		Select("content_id, MAX(watched_at) AS last_watched_at").
+		Where("user_id = ? AND episode_id IS NOT NULL AND episode_id <> ''", userID).
+		Group("content_id").
+		Order("last_watched_at DESC").
+		Limit(maxStartedShows).
+		Scan(&started).Error
+	if err != nil {
+		return nil, err
+	}
+
+	contentIDs := make([]string, len(started))
+	for i, show := range started {
+		contentIDs[i] = show.ContentID
+	}
+	contents, err := s.loadWatchedContent(contentIDs)
+	if err != nil {
+		return nil, err
+	}
+	episodes, err := s.loadEpisodeStates(userID, contentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []ContinueWatchingItem{}
+	for _, show := range started {
+		content, ok := contents[show.ContentID]
+		if !ok {
+			continue
+		}
+		states := episodes[show.ContentID]
+		next, resume := nextEpisode(states)
+		if next == nil {
+			// Finished, or the episodes were removed
+			continue
+		}
+
+		completed, _ := countEpisodeStates(states)
+		items = append(items, ContinueWatchingItem{
+			ContentID:         show.ContentID,
+			Title:             content.Title,
+			ContentType:       content.ContentType,
+			PosterURL:         content.PosterURL,
+			Episode:           *next,
+			Resume:            resume,
+			EpisodeCount:      len(states),
+			EpisodesCompleted: completed,
+			LastWatchedAt:     show.LastWatchedAt,
+		})
+		if len(items) == limit {
+			break
+		}
+	}
+	return items, nil
+}
+
+func (s *Service) ownedWatchlist(userID, watchlistID string) (*Watchlist, error) {
+	var watchlist Watchlist
+	if err := s.db.Where("id = ? AND user_id = ?", watchlistID, userID).First(&watchlist).Error; err != nil {
+		return nil, errors.New("watchlist not found")
+	}
+	return &watchlist, nil
+}
+
+// applyOrder sets the position of each row to its index in ids, after
+// checking ids lists every current row exactly once
+func applyOrder(tx *gorm.DB, model interface{}, current, ids []string) error {
+	if len(ids) != len(current) {
+		return fmt.Errorf("expected %d ids, got %d", len(current), len(ids))
+	}
+	unplaced := make(map[string]bool, len(current))
+	for _, id := range current {
+		unplaced[id] = true
+	}
+	for _, id := range ids {
+		if !unplaced[id] {
+			return fmt.Errorf("unknown or repeated id %s", id)
+		}
+		delete(unplaced, id)
+	}
+
+	for position, id := range ids {
+		if err := tx.Model(model).Where("id = ?", id).UpdateColumn("position", position).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadWatchedContent reads the catalogue entries of contentIDs, leaving out
+// deleted ones
+func (s *Service) loadWatchedContent(contentIDs []string) (map[string]watchedContent, error) {
+	contents := make(map[string]watchedContent, len(contentIDs))
+	if len(contentIDs) == 0 {
+		return contents, nil
+	}
+
+	var rows []watchedContent
+	err := s.db.Table("contents").
+		Select("CAST(id AS text) AS id, title, content_type, poster_url").
+		Where("CAST(id AS text) IN ? AND deleted_at IS NULL", contentIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		contents[row.ID] = row
+	}
+	return contents, nil
+}
+
+// loadEpisodeStates derives the user's state of each episode of contentIDs
+// from their logged progress, in season and episode order
+func (s *Service) loadEpisodeStates(userID string, contentIDs []string) (map[string][]EpisodeState, error) {
+	states := make(map[string][]EpisodeState, len(contentIDs))
+	if len(contentIDs) == 0 {
+		return states, nil
+	}
+
+	var rows []struct {
+		EpisodeState
+		Completed bool
+	}
+	err := s.db.Raw(`
+        SELECT CAST(e.id AS text) AS episode_id, e.content_id, e.season_number, e.episode_number, e.title, e.duration_minutes,
+            COALESCE(SUM(up.duration_minutes), 0) AS minutes_watched,
+            COALESCE(BOOL_OR(up.completed), false) AS completed,
+            MAX(up.watched_at) AS last_watched_at
+        FROM content_episodes e
+        LEFT JOIN user_progress up ON up.episode_id = CAST(e.id AS text) AND up.user_id = @user
+        WHERE e.content_id IN @contents AND e.deleted_at IS NULL
+        GROUP BY e.id, e.content_id, e.season_number, e.episode_number, e.title, e.duration_minutes
+        ORDER BY e.season_number ASC, e.episode_number ASC`,
+		map[string]interface{}{"user": userID, "contents": contentIDs}).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		state := row.EpisodeState
+		state.State = episodeState(state.MinutesWatched, state.DurationMinutes, row.Completed)
+		states[state.ContentID] = append(states[state.ContentID], state)
+	}
+	return states, nil
+}
+
+// loadContentActivity reads whether the user completed each of contentIDs
+// and when they last watched it
+func (s *Service) loadContentActivity(userID string, contentIDs []string) (map[string]contentActivity, error) {
+	activity := make(map[string]contentActivity, len(contentIDs))
+	if len(contentIDs) == 0 {
+		return activity, nil
+	}
+
+	var rows []contentActivity
+	err := s.db.Model(&UserProgress{}).
+		Select("content_id, BOOL_OR(completed) AS completed, MAX(watched_at) AS last_watched_at").
+		Where("user_id = ? AND content_id IN ?", userID, contentIDs).
+		Group("content_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		activity[row.ContentID] = row
+	}
+	return activity, nil
+}
+
+// episodeState classifies an episode by the minutes logged against it and
+// whether a session was marked completed
+func episodeState(minutesWatched, durationMinutes int, completed bool) string {
+	switch {
+	case completed, durationMinutes > 0 && float64(minutesWatched) >= completedShare*float64(durationMinutes):
+		return WatchStateCompleted
+	case minutesWatched > 0:
+		return WatchStateInProgress
+	default:
+		return WatchStateUnwatched
+	}
+}
+
+// contentState classifies a content by its episodes, or by progress logged
+// against the content as a whole when it has none
+func contentState(episodes []EpisodeState, activity contentActivity) string {
+	if len(episodes) == 0 {
+		switch {
+		case activity.Completed:
+			return WatchStateCompleted
+		case activity.LastWatchedAt != nil:
+			return WatchStateInProgress
+		default:
+			return WatchStateUnwatched
+		}
+	}
+
+	completed, inProgress := countEpisodeStates(episodes)
+	switch {
+	case completed == len(episodes):
+		return WatchStateCompleted
+	case completed > 0 || inProgress > 0 || activity.LastWatchedAt != nil:
+		return WatchStateInProgress
+	default:
+		return WatchStateUnwatched
+	}
+}
+
+func countEpisodeStates(episodes []EpisodeState) (completed, inProgress int) {
+	for _, episode := range episodes {
+		switch episode.State {
+		case WatchStateCompleted:
+			completed++
+		case WatchStateInProgress:
+			inProgress++
+		}
+	}
+	return completed, inProgress
+}
+
+// nextEpisode picks the episode to continue a show with from its episodes in
+// order: the most recently watched one if it was left unfinished, otherwise
+// the first unfinished episode after it, wrapping round to gaps earlier in
+// the show. It returns nil once every episode is completed.
+func nextEpisode(episodes []EpisodeState) (next *EpisodeState, resume bool) {
+	last := -1
+	for i, episode := range episodes {
+		if episode.LastWatchedAt != nil && (last < 0 || episode.LastWatchedAt.After(*episodes[last].LastWatchedAt)) {
+			last = i
+		}
+	}
+	if last >= 0 && episodes[last].State == WatchStateInProgress {
+		return &episodes[last], true
+	}
+
+	for offset := 1; offset <= len(episodes); offset++ {
+		i := (last + offset) % len(episodes)
+		if episodes[i].State != WatchStateCompleted {
+			return &episodes[i], episodes[i].State == WatchStateInProgress
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,252 @@
+package progress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockService returns a service backed by sqlmock
+func newMockService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	require.NoError(t, err)
+
+	return &Service{db: db}, mock
+}
+
+func TestEpisodeState(t *testing.T) {
+	tests := []struct {
+		name      string
+		minutes   int
+		duration  int
+		completed bool
+		want      string
+	}{
+		{"Unwatched", 0, 40, false, WatchStateUnwatched},
+		{"Started", 10, 40, false, WatchStateInProgress},
+		{"AlmostDone", 35, 40, false, WatchStateInProgress},
+		{"NinetyPercentLogged", 36, 40, false, WatchStateCompleted},
+		{"MarkedCompleted", 5, 40, true, WatchStateCompleted},
+		{"UnknownDuration", 120, 0, false, WatchStateInProgress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, episodeState(tt.minutes, tt.duration, tt.completed))
+		})
+	}
+}
+
+func TestContentState(t *testing.T) {
+	watchedAt := time.Now()
+	episodes := func(states ...string) []EpisodeState {
+		result := make([]EpisodeState, len(states))
+		for i, state := range states {
+			result[i].State = state
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		episodes []EpisodeState
+		activity contentActivity
+		want     string
+	}{
+		{"MovieUnwatched", nil, contentActivity{}, WatchStateUnwatched},
+		{"MovieStarted", nil, contentActivity{LastWatchedAt: &watchedAt}, WatchStateInProgress},
+		{"MovieCompleted", nil, contentActivity{Completed: true, LastWatchedAt: &watchedAt}, WatchStateCompleted},
+		{"ShowUnwatched", episodes(WatchStateUnwatched, WatchStateUnwatched), contentActivity{}, WatchStateUnwatched},
+		{"ShowStarted", episodes(WatchStateCompleted, WatchStateUnwatched), contentActivity{}, WatchStateInProgress},
+		{"ShowWithContentLevelProgress", episodes(WatchStateUnwatched), contentActivity{LastWatchedAt: &watchedAt}, WatchStateInProgress},
+		// Marking the content completed doesn't finish a show with episodes left
+		{"ShowMarkedCompleted", episodes(WatchStateCompleted, WatchStateInProgress), contentActivity{Completed: true}, WatchStateInProgress},
+		{"ShowCompleted", episodes(WatchStateCompleted, WatchStateCompleted), contentActivity{}, WatchStateCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, contentState(tt.episodes, tt.activity))
+		})
+	}
+}
+
+func TestNextEpisode(t *testing.T) {
+	at := func(minutes int) *time.Time {
+		watchedAt := time.Date(2024, 5, 1, 20, minutes, 0, 0, time.UTC)
+		return &watchedAt
+	}
+	episode := func(id, state string, watchedAt *time.Time) EpisodeState {
+		return EpisodeState{EpisodeID: id, State: state, LastWatchedAt: watchedAt}
+	}
+
+	tests := []struct {
+		name       string
+		episodes   []EpisodeState
+		wantID     string
+		wantResume bool
+	}{
+		{
+			name:     "NotStarted",
+			episodes: []EpisodeState{episode("e1", WatchStateUnwatched, nil), episode("e2", WatchStateUnwatched, nil)},
+			wantID:   "e1",
+		},
+		{
+			name: "ResumesLastWatchedUnfinished",
+			episodes: []EpisodeState{
+				episode("e1", WatchStateCompleted, at(1)),
+				episode("e2", WatchStateInProgress, at(3)),
+				episode("e3", WatchStateInProgress, at(2)),
+			},
+			wantID:     "e2",
+			wantResume: true,
+		},
+		{
+			name: "FollowsLastWatched",
+			episodes: []EpisodeState{
+				episode("e1", WatchStateUnwatched, nil),
+				episode("e2", WatchStateCompleted, at(5)),
+				episode("e3", WatchStateUnwatched, nil),
+			},
+			wantID: "e3",
+		},
+		{
+			name: "SkipsCompletedAfterLastWatched",
+			episodes: []EpisodeState{
+				episode("e1", WatchStateCompleted, at(1)),
+				episode("e2", WatchStateCompleted, at(2)),
+				episode("e3", WatchStateCompleted, at(0)), // Watched ahead earlier
+				episode("e4", WatchStateUnwatched, nil),
+			},
+			wantID: "e4",
+		},
+		{
+			name: "WrapsRoundToEarlierGap",
+			episodes: []EpisodeState{
+				episode("e1", WatchStateInProgress, at(1)),
+				episode("e2", WatchStateCompleted, at(2)),
+				episode("e3", WatchStateCompleted, at(3)),
+			},
+			wantID:     "e1",
+			wantResume: true,
+		},
+		{
+			name:     "Finished",
+			episodes: []EpisodeState{episode("e1", WatchStateCompleted, at(1)), episode("e2", WatchStateCompleted, at(2))},
+		},
+		{
+			name: "NoEpisodes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, resume := nextEpisode(tt.episodes)
+			if tt.wantID == "" {
+				assert.Nil(t, next)
+				return
+			}
+			require.NotNil(t, next)
+			assert.Equal(t, tt.wantID, next.EpisodeID)
+			assert.Equal(t, tt.wantResume, resume)
+		})
+	}
+}
+
+func TestCountEpisodeStates(t *testing.T) {
+	completed, inProgress := countEpisodeStates([]EpisodeState{
+		{State: WatchStateCompleted},
+		{State: WatchStateInProgress},
+		{State: WatchStateCompleted},
+		{State: WatchStateUnwatched},
+	})
+	assert.Equal(t, 2, completed)
+	assert.Equal(t, 1, inProgress)
+}
+
+func TestApplyOrderRejectsMismatchedIDs(t *testing.T) {
+	current := []string{"a", "b", "c"}
+
+	assert.EqualError(t, applyOrder(nil, &Watchlist{}, current, []string{"a", "b"}), "expected 3 ids, got 2")
+	assert.EqualError(t, applyOrder(nil, &Watchlist{}, current, []string{"a", "b", "b"}), "unknown or repeated id b")
+	assert.EqualError(t, applyOrder(nil, &Watchlist{}, current, []string{"a", "b", "z"}), "unknown or repeated id z")
+}
+
+func TestGetContinueWatching(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2024, 5, 1, hour, 0, 0, 0, time.UTC)
+	}
+
+	expectQueries := func(mock sqlmock.Sqlmock) {
+		// Started shows, most recently watched first
+		mock.ExpectQuery(`SELECT content_id, MAX\(watched_at\) AS last_watched_at FROM "user_progresses" .* ORDER BY last_watched_at DESC LIMIT \$2`).
+			WithArgs("user-1", maxStartedShows).
+			WillReturnRows(sqlmock.NewRows([]string{"content_id", "last_watched_at"}).
+				AddRow("show-a", at(22)).
+				AddRow("show-finished", at(21)).
+				AddRow("show-deleted", at(20)).
+				AddRow("show-b", at(19)))
+		mock.ExpectQuery(`FROM "contents"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content_type", "poster_url"}).
+				AddRow("show-a", "Show A", "series", "").
+				AddRow("show-finished", "Finished", "series", "").
+				AddRow("show-b", "Show B", "series", ""))
+
+		episodes := sqlmock.NewRows([]string{"episode_id", "content_id", "season_number", "episode_number", "title",
+			"duration_minutes", "minutes_watched", "completed", "last_watched_at"})
+		episodes.AddRow("a1", "show-a", 1, 1, "A1", 40, 40, true, at(21))
+		episodes.AddRow("a2", "show-a", 1, 2, "A2", 40, 12, false, at(22))
+		episodes.AddRow("f1", "show-finished", 1, 1, "F1", 30, 30, true, at(21))
+		episodes.AddRow("b1", "show-b", 1, 1, "B1", 20, 20, false, at(19))
+		episodes.AddRow("b2", "show-b", 1, 2, "B2", 20, 0, false, nil)
+		mock.ExpectQuery(`FROM content_episodes e`).WillReturnRows(episodes)
+	}
+
+	t.Run("NextEpisodePerShow", func(t *testing.T) {
+		service, mock := newMockService(t)
+		expectQueries(mock)
+
+		items, err := service.GetContinueWatching(context.Background(), "user-1", 10)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+
+		// Finished and deleted shows are left out, the rest keep their order
+		assert.Equal(t, "show-a", items[0].ContentID)
+		assert.Equal(t, "a2", items[0].Episode.EpisodeID)
+		assert.True(t, items[0].Resume)
+		assert.Equal(t, 1, items[0].EpisodesCompleted)
+		assert.Equal(t, at(22), items[0].LastWatchedAt)
+
+		assert.Equal(t, "show-b", items[1].ContentID)
+		assert.Equal(t, "b2", items[1].Episode.EpisodeID) // b1 counts as completed at 100%
+		assert.False(t, items[1].Resume)
+		assert.Equal(t, 2, items[1].EpisodeCount)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		service, mock := newMockService(t)
+		expectQueries(mock)
+
+		items, err := service.GetContinueWatching(context.Background(), "user-1", 1)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "show-a", items[0].ContentID)
+	})
+}
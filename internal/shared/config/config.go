@@ -34,14 +34,30 @@ type Config struct {
 	PasswordSaltRounds int
 
 	// File Storage
-	MinIOEndpoint   string
-	MinIOAccessKey  string
-	MinIOSecretKey  string
-	MinIOBucketName string
+	StorageBackend      string // s3 or local
+	StorageLocalPath    string
+	PublicURL           string // Base URL clients reach the API gateway on, used in file links
+	MinIOEndpoint       string
+	MinIOPublicEndpoint string
+	MinIOAccessKey      string
+	MinIOSecretKey      string
+	MinIOBucketName     string
+	MinIORegion         string
+	MinIOUseSSL         bool
+
+	// Catalogue Metadata
+	MetadataProvider    string // tmdb, fixture or none
+	MetadataLanguage    string // Language of imported titles and overviews, e.g. en-US
+	MetadataFixturePath string
+	TMDBAPIKey          string
+	TMDBBaseURL         string
 
 	// Rate Limiting
 	RateLimit RateLimitSettings
 
+	// Background Jobs
+	JobWorkers int
+
 	// Email
 	SMTPHost     string
 	SMTPPort     int
@@ -86,16 +102,30 @@ func Load() *Config {
 		JWTExpiration:      getEnvIntOrDefault("JWT_EXPIRATION", 3600),
 		PasswordSaltRounds: getEnvIntOrDefault("PASSWORD_SALT_ROUNDS", 12),
 
-		MinIOEndpoint:   getEnvOrDefault("MINIO_ENDPOINT", "localhost:9000"),
-		MinIOAccessKey:  getEnvOrDefault("MINIO_ACCESS_KEY", "minioadmin"),
-		MinIOSecretKey:  getEnvOrDefault("MINIO_SECRET_KEY", "minioadmin"),
-		MinIOBucketName: getEnvOrDefault("MINIO_BUCKET_NAME", "language-learning"),
+		StorageBackend:      getEnvOrDefault("STORAGE_BACKEND", "s3"),
+		StorageLocalPath:    getEnvOrDefault("STORAGE_LOCAL_PATH", "./uploads"),
+		PublicURL:           getEnvOrDefault("PUBLIC_URL", "http://localhost:8080"),
+		MinIOEndpoint:       getEnvOrDefault("MINIO_ENDPOINT", "localhost:9000"),
+		MinIOPublicEndpoint: getEnvOrDefault("MINIO_PUBLIC_ENDPOINT", ""),
+		MinIOAccessKey:      getEnvOrDefault("MINIO_ACCESS_KEY", "minioadmin"),
+		MinIOSecretKey:      getEnvOrDefault("MINIO_SECRET_KEY", "minioadmin"),
+		MinIOBucketName:     getEnvOrDefault("MINIO_BUCKET_NAME", "language-learning"),
+		MinIORegion:         getEnvOrDefault("MINIO_REGION", "us-east-1"),
+		MinIOUseSSL:         getEnvBoolOrDefault("MINIO_USE_SSL", false),
+
+		MetadataProvider:    getEnvOrDefault("METADATA_PROVIDER", "none"),
+		MetadataLanguage:    getEnvOrDefault("METADATA_LANGUAGE", "en-US"),
+		MetadataFixturePath: getEnvOrDefault("METADATA_FIXTURE_PATH", "./metadata-fixtures"),
+		TMDBAPIKey:          getEnvOrDefault("TMDB_API_KEY", ""),
+		TMDBBaseURL:         getEnvOrDefault("TMDB_BASE_URL", "https://api.themoviedb.org/3"),
 
 		RateLimit: RateLimitSettings{
 			RequestsPerSecond: getEnvIntOrDefault("RATE_LIMIT_RPS", 100),
 			Burst:             getEnvIntOrDefault("RATE_LIMIT_BURST", 200),
 		},
 
+		JobWorkers: getEnvIntOrDefault("JOB_WORKERS", 4),
+
 		SMTPHost:     getEnvOrDefault("SMTP_HOST", ""),
 		SMTPPort:     getEnvIntOrDefault("SMTP_PORT", 587),
 		SMTPUsername: getEnvOrDefault("SMTP_USERNAME", ""),
@@ -124,3 +154,12 @@ func getEnvIntOrDefault(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvBoolOrDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"gorm.io/gorm"
+)
+
+// How long presigned links stay valid
+const PresignExpiry = 15 * time.Minute
+
+// Room for multipart boundaries and headers on top of the file itself
+const multipartOverhead = 64 << 10
+
+var (
+	ErrFileTooLarge    = errors.New("file too large")
+	ErrUnsupportedType = errors.New("unsupported file type")
+)
+
+// Upload records a stored file so its links can be resolved and orphans
+// cleaned up
+type Upload struct {
+	ID          string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Key         string    `json:"key" gorm:"not null;uniqueIndex"`
+	Kind        string    `json:"kind" gorm:"not null;index"`
+	ContentType string    `json:"content_type" gorm:"not null"`
+	Size        int64     `json:"size"`
+	UploadedBy  string    `json:"uploaded_by" gorm:"index"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Computed fields
+	URL string `json:"url" gorm:"-"`
+}
+
+// Kind describes what may be uploaded for a purpose and where it is kept
+type Kind struct {
+	Name         string
+	Prefix       string
+	MaxSize      int64 // Bytes
+	ContentTypes []string
+}
+
+var imageTypes = []string{"image/jpeg", "image/png", "image/webp", "image/gif"}
+
+var audioTypes = []string{"audio/mpeg", "audio/ogg", "audio/wav", "audio/flac", "audio/aac", "audio/mp4", "audio/x-m4a", "audio/webm"}
+
+var (
+	KindPoster           = Kind{Name: "poster", Prefix: "posters", MaxSize: 5 << 20, ContentTypes: imageTypes}
+	KindAvatar           = Kind{Name: "avatar", Prefix: "avatars", MaxSize: 2 << 20, ContentTypes: imageTypes}
+	KindPhonemeAudio     = Kind{Name: "phoneme_audio", Prefix: "audio/phonemes", MaxSize: 10 << 20, ContentTypes: audioTypes}
+	KindMinimalPairAudio = Kind{Name: "minimal_pair_audio", Prefix: "audio/minimal-pairs", MaxSize: 10 << 20, ContentTypes: audioTypes}
+)
+
+// references are the columns that may link to uploads. An upload none of
+// them mention is an orphan.
+var references = []struct {
+	table  string
+	column string
+}{
+	{"contents", "poster_url"},
+	{"phonemes", "audio_url"},
+	{"minimal_pairs", "audio1_url"},
+	{"minimal_pairs", "audio2_url"},
+	{"user_profiles", "avatar_url"},
+}
+
+// detect sniffs the content type from the file's bytes. The file name and
+// the Content-Type the client sent are not trusted.
+func (k Kind) detect(data []byte) (string, string, error) {
+	detected := mimetype.Detect(data)
+	for _, allowed := range k.ContentTypes {
+		if detected.Is(allowed) {
+			return allowed, detected.Extension(), nil
+		}
+	}
+	return "", "", fmt.Errorf("%w %s, expected one of %s", ErrUnsupportedType, detected.String(), strings.Join(k.ContentTypes, ", "))
+}
+
+// StatusCode maps upload errors to HTTP statuses
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// OpenFormFile caps the request body at what the kind allows and opens the
+// "file" field of a multipart upload
+func OpenFormFile(w http.ResponseWriter, r *http.Request, kind Kind) (multipart.File, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, kind.MaxSize+multipartOverhead)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) || strings.Contains(err.Error(), "request body too large") {
+			return nil, fmt.Errorf("%w: %s uploads are limited to %d MB", ErrFileTooLarge, kind.Name, kind.MaxSize>>20)
+		}
+		return nil, errors.New("multipart field \"file\" required")
+	}
+	return file, nil
+}
+
+// Files stores uploads, tracks them in the uploads table and builds the
+// links saved on records
+type Files struct {
+	db        *gorm.DB
+	store     Storage
+	publicURL string
+	now       func() time.Time
+}
+
+func NewFiles(db *gorm.DB, store Storage, publicURL string) *Files {
+	return &Files{
+		db:        db,
+		store:     store,
+		publicURL: strings.TrimRight(publicURL, "/"),
+		now:       time.Now,
+	}
+}
+
+// Storage returns the backend the files are kept in
+func (f *Files) Storage() Storage {
+	return f.store
+}
+
+// Save checks an upload's size and sniffed type against its kind, stores it
+// under a fresh key and records it
+func (f *Files) Save(ctx context.Context, kind Kind, userID string, r io.Reader) (*Upload, error) {
+	data, err := io.ReadAll(io.LimitReader(r, kind.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > kind.MaxSize {
+		return nil, fmt.Errorf("%w: %s uploads are limited to %d MB", ErrFileTooLarge, kind.Name, kind.MaxSize>>20)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	contentType, extension, err := kind.detect(data)
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	key := path.Join(kind.Prefix, f.now().UTC().Format("2006/01"), hex.EncodeToString(id)+extension)
+
+	if err := f.store.Put(ctx, key, data, contentType); err != nil {
+		return nil, err
+	}
+
+	upload := Upload{
+		Key:         key,
+		Kind:        kind.Name,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		UploadedBy:  userID,
+	}
+	if err := f.db.Create(&upload).Error; err != nil {
+		f.store.Delete(ctx, key)
+		return nil, err
+	}
+
+	upload.URL = f.URL(key)
+	return &upload, nil
+}
+
+// URL is the stable link to a stored file. It redirects to a presigned URL
+// each time it is followed.
+func (f *Files) URL(key string) string {
+	return f.publicURL + FilesPath + key
+}
+
+// Presign returns a short-lived download URL for a recorded upload
+func (f *Files) Presign(ctx context.Context, key string) (string, time.Time, error) {
+	var count int64
+	if err := f.db.Model(&Upload{}).Where("key = ?", key).Count(&count).Error; err != nil {
+		return "", time.Time{}, err
+	}
+	if count == 0 {
+		return "", time.Time{}, ErrNotFound
+	}
+
+	url, err := f.store.PresignGet(ctx, key, PresignExpiry)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return url, f.now().Add(PresignExpiry), nil
+}
+
+// CleanupOrphans deletes uploads older than the grace period that no record
+// links to, such as replaced posters or avatars and uploads never saved on a
+// record. It returns how many were removed.
+func (f *Files) CleanupOrphans(ctx context.Context, gracePeriod time.Duration) (int, error) {
+	query := f.db.Model(&Upload{}).Where("created_at < ?", f.now().Add(-gracePeriod))
+	for _, ref := range references {
+		if !f.db.Migrator().HasTable(ref.table) {
+			continue
+		}
+		query = query.Where(fmt.Sprintf(
+			"NOT EXISTS (SELECT 1 FROM %s r WHERE strpos(r.%s, CAST(? AS text) || uploads.key) > 0)",
+			ref.table, ref.column), FilesPath)
+	}
+	query = query.Session(&gorm.Session{})
+
+	removed := 0
+	for {
+		var orphans []Upload
+		if err := query.Order("created_at ASC").Limit(100).Find(&orphans).Error; err != nil {
+			return removed, err
+		}
+
+		for _, orphan := range orphans {
+			if err := f.store.Delete(ctx, orphan.Key); err != nil {
+				return removed, err
+			}
+			if err := f.db.Delete(&orphan).Error; err != nil {
+				return removed, err
+			}
+			removed++
+		}
+
+		if len(orphans) < 100 {
+			return removed, nil
+		}
+	}
+}
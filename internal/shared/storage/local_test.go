@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalPresignedLinks(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir(), "http://localhost:8080/", []byte("secret"))
+	assert.NoError(t, err)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	assert.NoError(t, store.Put(ctx, "posters/2024/03/a.png", []byte("poster"), "image/png"))
+
+	link, err := store.PresignGet(ctx, "posters/2024/03/a.png", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(link, "http://localhost:8080/api/v1/files/posters/2024/03/a.png?"))
+
+	parsed, err := url.Parse(link)
+	assert.NoError(t, err)
+	expires, signature := parsed.Query().Get("expires"), parsed.Query().Get("signature")
+
+	path, err := store.VerifiedPath("posters/2024/03/a.png", expires, signature)
+	assert.NoError(t, err)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "poster", string(data))
+
+	// The signature covers the key and the expiry
+	_, err = store.VerifiedPath("posters/2024/03/b.png", expires, signature)
+	assert.Error(t, err)
+	_, err = store.VerifiedPath("posters/2024/03/a.png", expires+"0", signature)
+	assert.Error(t, err)
+
+	now = now.Add(2 * time.Minute)
+	_, err = store.VerifiedPath("posters/2024/03/a.png", expires, signature)
+	assert.Error(t, err)
+
+	assert.NoError(t, store.Delete(ctx, "posters/2024/03/a.png"))
+	assert.NoError(t, store.Delete(ctx, "posters/2024/03/a.png"))
+}
+
+func TestLocalRejectsEscapingKeys(t *testing.T) {
+	store, err := NewLocal(t.TempDir(), "http://localhost:8080", []byte("secret"))
+	assert.NoError(t, err)
+
+	assert.Error(t, store.Put(context.Background(), "../outside.txt", []byte("x"), "text/plain"))
+	assert.Error(t, store.Put(context.Background(), "", []byte("x"), "text/plain"))
+}
+
+func TestKindDetect(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	contentType, extension, err := KindPoster.detect(png)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	assert.Equal(t, ".png", extension)
+
+	// Sniffed from the bytes, whatever the upload claims to be
+	_, _, err = KindPoster.detect([]byte("<html><script>alert(1)</script></html>"))
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+	_, _, err = KindPhonemeAudio.detect(png)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	wav := append([]byte("RIFF\x24\x00\x00\x00WAVEfmt "), make([]byte, 32)...)
+	contentType, _, err = KindPhonemeAudio.detect(wav)
+	assert.NoError(t, err)
+	assert.Equal(t, "audio/wav", contentType)
+}
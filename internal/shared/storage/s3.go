@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Config points the S3 backend at a bucket. MinIO and other
+// S3-compatible servers work with path-style addressing.
+type S3Config struct {
+	Endpoint string // host:port, or a URL with scheme
+	// PublicEndpoint is where clients reach the server when it differs from
+	// Endpoint, e.g. outside the Docker network. Presigned URLs use it.
+	PublicEndpoint string
+	AccessKey      string
+	SecretKey      string
+	Bucket         string
+	Region         string
+	UseSSL         bool
+}
+
+// S3 stores objects in an S3-compatible bucket, signing requests with
+// Signature Version 4
+type S3 struct {
+	endpoint *url.URL
+	public   *url.URL
+	bucket   string
+	signer   sigV4Signer
+	client   *http.Client
+	now      func() time.Time
+}
+
+// s3Error is the XML error body S3 returns
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("storage bucket name required")
+	}
+
+	endpoint, err := parseEndpoint(cfg.Endpoint, cfg.UseSSL)
+	if err != nil {
+		return nil, err
+	}
+	public := endpoint
+	if cfg.PublicEndpoint != "" {
+		if public, err = parseEndpoint(cfg.PublicEndpoint, cfg.UseSSL); err != nil {
+			return nil, err
+		}
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3{
+		endpoint: endpoint,
+		public:   public,
+		bucket:   cfg.Bucket,
+		signer:   sigV4Signer{accessKey: cfg.AccessKey, secretKey: cfg.SecretKey, region: region},
+		client:   &http.Client{Timeout: 60 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+// EnsureBucket creates the bucket when it does not exist yet
+func (s *S3) EnsureBucket(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodHead, s.bucketURL(), nil, "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		resp, err = s.do(ctx, http.MethodPut, s.bucketURL(), nil, "")
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return responseError("create bucket", resp)
+		}
+		return nil
+	default:
+		return fmt.Errorf("check bucket: unexpected status %d", resp.StatusCode)
+	}
+}
+
+func (s *S3) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, s.objectURL(key), data, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError("put "+key, resp)
+	}
+	return nil
+}
+
+// Delete removes an object. Deleting a missing object succeeds.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.objectURL(key), nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return responseError("delete "+key, resp)
+	}
+}
+
+func (s *S3) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	u := s.objectURL(key)
+	u.Scheme, u.Host = s.public.Scheme, s.public.Host
+	return s.signer.presign(http.MethodGet, u, expiry, s.now()).String(), nil
+}
+
+func (s *S3) do(ctx context.Context, method string, u *url.URL, body []byte, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	s.signer.sign(req, hashHex(body), s.now())
+	return s.client.Do(req)
+}
+
+func parseEndpoint(endpoint string, useSSL bool) (*url.URL, error) {
+	raw := endpoint
+	if !strings.Contains(raw, "://") {
+		scheme := "http"
+		if useSSL {
+			scheme = "https"
+		}
+		raw = scheme + "://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid storage endpoint %q", endpoint)
+	}
+	return &url.URL{Scheme: u.Scheme, Host: u.Host}, nil
+}
+
+func (s *S3) bucketURL() *url.URL {
+	u := *s.endpoint
+	u.Path = "/" + s.bucket
+	return &u
+}
+
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	u.Path = "/" + s.bucket + "/" + strings.TrimPrefix(key, "/")
+	// Send the path exactly as it is signed
+	u.RawPath = canonicalURI(u.Path)
+	return &u
+}
+
+func responseError(operation string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	var parsed s3Error
+	if xml.Unmarshal(body, &parsed) == nil && parsed.Code != "" {
+		return fmt.Errorf("storage %s: %s: %s", operation, parsed.Code, parsed.Message)
+	}
+	return fmt.Errorf("storage %s: unexpected status %d", operation, resp.StatusCode)
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4, as used by S3 and MinIO
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+	shortDateFormat = "20060102"
+	s3Service       = "s3"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty body
+var emptyPayloadHash = hashHex(nil)
+
+type sigV4Signer struct {
+	accessKey string
+	secretKey string
+	region    string
+}
+
+// sign adds the date, payload hash and Authorization headers to a request.
+// Every header already set on the request is signed along with Host.
+func (s sigV4Signer) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": requestHost(req)}
+	for name, values := range req.Header {
+		trimmed := make([]string, len(values))
+		for i, value := range values {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(headers)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	signature := s.signature(canonicalRequest, now)
+	req.Header.Set("Authorization", sigV4Algorithm+
+		" Credential="+s.accessKey+"/"+s.scope(now)+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+}
+
+// presign returns u with query string authentication valid for expiry.
+// Only the Host header is signed and the payload is left unsigned.
+func (s sigV4Signer) presign(method string, u *url.URL, expiry time.Duration, now time.Time) *url.URL {
+	now = now.UTC()
+	signed := *u
+
+	query := signed.Query()
+	query.Set("X-Amz-Algorithm", sigV4Algorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(map[string]string{"host": signed.Host})
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalURI(signed.Path),
+		canonicalQuery(query),
+		canonicalHeaders,
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	signed.RawQuery = canonicalQuery(query) + "&X-Amz-Signature=" + s.signature(canonicalRequest, now)
+	return &signed
+}
+
+func (s sigV4Signer) scope(now time.Time) string {
+	return now.Format(shortDateFormat) + "/" + s.region + "/" + s3Service + "/aws4_request"
+}
+
+func (s sigV4Signer) signature(canonicalRequest string, now time.Time) string {
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		now.Format(amzDateFormat),
+		s.scope(now),
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format(shortDateFormat))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalizeHeaders returns the sorted "name:value" lines and the
+// semicolon-separated list of signed header names
+func canonicalizeHeaders(headers map[string]string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	return canonical.String(), strings.Join(names, ";")
+}
+
+// canonicalURI encodes each path segment, keeping the slashes
+func canonicalURI(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(query))
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything except the unreserved characters
+func uriEncode(value string) string {
+	var encoded strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			encoded.WriteByte(c)
+			continue
+		}
+		encoded.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+	}
+	return encoded.String()
+}
+
+func requestHost(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+	return req.URL.Host
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,66 @@
+// Package storage keeps user and catalogue uploads (posters, avatars and
+// pronunciation audio) in an S3-compatible bucket or on the local disk.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Raylynd6299/babel/internal/shared/config"
+)
+
+// Backends selectable with STORAGE_BACKEND
+const (
+	BackendS3    = "s3"
+	BackendLocal = "local"
+)
+
+// FilesPath is where the services serve stored files. Links saved on
+// records point here and redirect to a presigned URL.
+const FilesPath = "/api/v1/files/"
+
+var ErrNotFound = errors.New("file not found")
+
+// Storage keeps objects under slash-separated keys such as
+// "posters/2024/03/<id>.jpg"
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	// PresignGet returns a URL anyone can download the object from until
+	// the expiry passes
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// New builds the backend selected in the configuration
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageBackend {
+	case BackendLocal:
+		return NewLocal(cfg.StorageLocalPath, cfg.PublicURL, []byte(cfg.JWTSecret))
+	case BackendS3, "":
+		store, err := NewS3(S3Config{
+			Endpoint:       cfg.MinIOEndpoint,
+			PublicEndpoint: cfg.MinIOPublicEndpoint,
+			AccessKey:      cfg.MinIOAccessKey,
+			SecretKey:      cfg.MinIOSecretKey,
+			Bucket:         cfg.MinIOBucketName,
+			Region:         cfg.MinIORegion,
+			UseSSL:         cfg.MinIOUseSSL,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// The bucket can be created later; uploads fail until it exists
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := store.EnsureBucket(ctx); err != nil {
+			log.Printf("Warning: storage bucket %s not ready: %v", cfg.MinIOBucketName, err)
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/Raylynd6299/babel/internal/shared/storage"
 	polyfyjwt "github.com/Raylynd6299/babel/pkg/jwt"
 
 	_ "github.com/Raylynd6299/babel/cmd/social-service/docs"
@@ -67,6 +68,7 @@ func NewRouter(service *Service) *gin.Engine {
 		// Profile management
 		protected.GET("/profile", socialRouter.GetMyProfile)
 		protected.PUT("/profile", socialRouter.UpdateProfile)
+		protected.POST("/profile/avatar", socialRouter.UploadAvatar)
 		protected.GET("/stats", socialRouter.GetMyStats)
 
 		// Follow system
@@ -441,6 +443,43 @@ func (r *Router) UpdateProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"profile": profile})
 }
 
+// UploadAvatar godoc
+// @Summary      Upload my avatar
+// @Description  Upload a profile picture (JPEG, PNG, WebP or GIF, up to 2 MB). The type is detected from the file itself.
+// @Tags         profiles
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file formData file true "Avatar image"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      413 {object} map[string]string
+// @Failure      415 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /social/profile/avatar [post]
+func (r *Router) UploadAvatar(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	file, err := storage.OpenFormFile(c.Writer, c.Request, storage.KindAvatar)
+	if err != nil {
+		c.JSON(storage.StatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	profile, err := r.service.UploadAvatar(c.Request.Context(), userID, file)
+	if err != nil {
+		c.JSON(storage.StatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
 // GetMyStats godoc
 // @Summary      Get my social statistics
 // @Description  Get current user's comprehensive social statistics and metrics
@@ -5,22 +5,26 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"gorm.io/gorm"
 
 	"github.com/Raylynd6299/babel/internal/content"
+	"github.com/Raylynd6299/babel/internal/shared/storage"
 )
 
 type Service struct {
 	db        *gorm.DB
 	jwtSecret string
+	files     *storage.Files
 }
 
-func NewService(db *gorm.DB, jwtSecret string) *Service {
+func NewService(db *gorm.DB, jwtSecret string, files *storage.Files) *Service {
 	return &Service{
 		db:        db,
 		jwtSecret: jwtSecret,
+		files:     files,
 	}
 }
 
@@ -114,6 +118,34 @@ func (s *Service) UpdateProfile(ctx context.Context, userID string, req UpdatePr
 	return &profile, err
 }
 
+// UploadAvatar stores a profile picture and links it from the user's profile.
+// The previous picture is cleaned up as an orphan.
+func (s *Service) UploadAvatar(ctx context.Context, userID string, file io.Reader) (*UserProfile, error) {
+	var profile UserProfile
+	err := s.db.Where("user_id = ?", userID).First(&profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		profile = UserProfile{UserID: userID}
+	} else if err != nil {
+		return nil, err
+	}
+
+	upload, err := s.files.Save(ctx, storage.KindAvatar, userID, file)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.AvatarURL = upload.URL
+	if profile.CreatedAt.IsZero() {
+		err = s.db.Create(&profile).Error
+	} else {
+		err = s.db.Model(&profile).Update("avatar_url", upload.URL).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
 // Follow system
 func (s *Service) FollowUser(ctx context.Context, followerID, followingID string) error {
 	if followerID == followingID {
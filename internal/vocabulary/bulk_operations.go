@@ -11,17 +11,28 @@ import (
 
 // VocabularyList represents a collection of vocabulary words
 type VocabularyList struct {
-	ID          string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID      string    `json:"user_id" gorm:"not null"`
-	Name        string    `json:"name" gorm:"not null"`
-	Description string    `json:"description"`
-	LanguageID  int       `json:"language_id" gorm:"not null"`
-	IsPublic    bool      `json:"is_public" gorm:"default:false"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      string `json:"user_id" gorm:"not null"`
+	Name        string `json:"name" gorm:"not null"`
+	Description string `json:"description"`
+	LanguageID  int    `json:"language_id" gorm:"not null"`
+	IsPublic    bool   `json:"is_public" gorm:"default:false"`
+	CardTypes   string `json:"card_types" gorm:"default:'recognition'"` // Enabled card templates, comma-separated
+	Tags        string `json:"tags"`                                    // Discovery tags, comma-separated
+
+	// Sharing
+	ForkedFromID    *string `json:"forked_from_id,omitempty" gorm:"type:uuid"`
+	SubscriberCount int     `json:"subscriber_count" gorm:"default:0"`
+	ForkCount       int     `json:"fork_count" gorm:"default:0"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 
 	// Relations
 	Items []VocabularyListItem `json:"items,omitempty" gorm:"foreignKey:ListID"`
+
+	// Computed fields
+	Role string `json:"role,omitempty" gorm:"-"` // The requesting user's role: owner, editor, viewer
 }
 
 // VocabularyListItem represents a vocabulary word in a list
@@ -38,29 +49,39 @@ type VocabularyListItem struct {
 
 // Request/Response types
 type CreateVocabularyListRequest struct {
-	Name        string `json:"name" validate:"required,min=1,max=255"`
-	Description string `json:"description" validate:"max=1000"`
-	LanguageID  int    `json:"language_id" validate:"required"`
-	IsPublic    bool   `json:"is_public"`
+	Name        string   `json:"name" validate:"required,min=1,max=255"`
+	Description string   `json:"description" validate:"max=1000"`
+	LanguageID  int      `json:"language_id" validate:"required"`
+	IsPublic    bool     `json:"is_public"`
+	CardTypes   []string `json:"card_types" validate:"omitempty,dive,oneof=recognition production cloze listening"`
+	Tags        []string `json:"tags" validate:"omitempty,max=10,dive,min=1,max=50"`
 }
 
 type UpdateVocabularyListRequest struct {
-	Name        string `json:"name" validate:"omitempty,min=1,max=255"`
-	Description string `json:"description" validate:"omitempty,max=1000"`
-	IsPublic    *bool  `json:"is_public"`
+	Name        string   `json:"name" validate:"omitempty,min=1,max=255"`
+	Description string   `json:"description" validate:"omitempty,max=1000"`
+	IsPublic    *bool    `json:"is_public"`
+	CardTypes   []string `json:"card_types" validate:"omitempty,dive,oneof=recognition production cloze listening"`
+	Tags        []string `json:"tags" validate:"omitempty,max=10,dive,min=1,max=50"`
 }
 
+// Bulk operations apply to the given vocabulary IDs, or to every entry
+// matching a smart filter when filter_id is set, as long as it matches no
+// more than maxBulkFilterTargets entries
 type BulkAddVocabularyRequest struct {
-	VocabularyIDs []string `json:"vocabulary_ids" validate:"required,min=1,max=100"`
+	VocabularyIDs []string `json:"vocabulary_ids" validate:"required_without=FilterID,omitempty,min=1,max=100"`
+	FilterID      string   `json:"filter_id" validate:"omitempty,uuid"`
 	ListID        string   `json:"list_id"`
 }
 
 type BulkDeleteVocabularyRequest struct {
-	VocabularyIDs []string `json:"vocabulary_ids" validate:"required,min=1,max=100"`
+	VocabularyIDs []string `json:"vocabulary_ids" validate:"required_without=FilterID,omitempty,min=1,max=100"`
+	FilterID      string   `json:"filter_id" validate:"omitempty,uuid"`
 }
 
 type BulkResetProgressRequest struct {
-	VocabularyIDs []string `json:"vocabulary_ids" validate:"required,min=1,max=100"`
+	VocabularyIDs []string `json:"vocabulary_ids" validate:"required_without=FilterID,omitempty,min=1,max=100"`
+	FilterID      string   `json:"filter_id" validate:"omitempty,uuid"`
 	ResetType     string   `json:"reset_type" validate:"required,oneof=all progress reviews"`
 }
 
@@ -72,20 +93,43 @@ type BulkOperationResult struct {
 }
 
 // Vocabulary Lists Service Methods
+
+// GetVocabularyLists returns the lists the user owns or has been added to
+// as an editor or viewer
 func (s *Service) GetVocabularyLists(ctx context.Context, userID string, languageID int) ([]VocabularyList, error) {
 	var lists []VocabularyList
 
-	query := s.db.Where("user_id = ?", userID)
+	memberOf := s.db.Model(&VocabularyListMember{}).Select("list_id").Where("user_id = ?", userID)
+	query := s.db.Where("(user_id = ? OR id IN (?))", userID, memberOf)
 	if languageID > 0 {
 		query = query.Where("language_id = ?", languageID)
 	}
 
-	err := query.Preload("Items").
+	err := query.Preload("Items", orderedListItems).
 		Preload("Items.Vocabulary").
 		Order("created_at DESC").
 		Find(&lists).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var memberships []VocabularyListMember
+	if err := s.db.Where("user_id = ?", userID).Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+	roles := make(map[string]string, len(memberships))
+	for _, membership := range memberships {
+		roles[membership.ListID] = membership.Role
+	}
+	for i := range lists {
+		if lists[i].UserID == userID {
+			lists[i].Role = ListRoleOwner
+		} else {
+			lists[i].Role = roles[lists[i].ID]
+		}
+	}
 
-	return lists, err
+	return lists, nil
 }
 
 func (s *Service) CreateVocabularyList(ctx context.Context, userID string, req CreateVocabularyListRequest) (*VocabularyList, error) {
@@ -96,41 +140,49 @@ func (s *Service) CreateVocabularyList(ctx context.Context, userID string, req C
 		LanguageID:  req.LanguageID,
 		IsPublic:    req.IsPublic,
 	}
+	list.SetCardTypes(req.CardTypes)
+	list.SetTags(req.Tags)
 
 	if err := s.db.Create(&list).Error; err != nil {
 		return nil, err
 	}
 
+	list.Role = ListRoleOwner
 	return &list, nil
 }
 
+// GetVocabularyList returns a list with its items in order to anyone with
+// at least viewer access
 func (s *Service) GetVocabularyList(ctx context.Context, userID, listID string) (*VocabularyList, error) {
-	var list VocabularyList
+	list, err := s.listAccess(listID, userID)
+	if err != nil {
+		return nil, err
+	}
 
-	err := s.db.Where("id = ? AND (user_id = ? OR is_public = ?)", listID, userID, true).
-		Preload("Items").
+	role := list.Role
+	err = s.db.Preload("Items", orderedListItems).
 		Preload("Items.Vocabulary").
-		First(&list).Error
-
+		Where("id = ?", list.ID).
+		First(list).Error
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("vocabulary list not found")
-		}
 		return nil, err
 	}
+	list.Role = role
 
-	return &list, nil
+	return list, nil
 }
 
+// UpdateVocabularyList changes a list's metadata. Editors may rename and
+// retag a list; visibility and card templates are left to the owner.
 func (s *Service) UpdateVocabularyList(ctx context.Context, userID, listID string, req UpdateVocabularyListRequest) (*VocabularyList, error) {
-	var list VocabularyList
-	err := s.db.Where("id = ? AND user_id = ?", listID, userID).First(&list).Error
+	listRef, err := s.requireListRole(listID, userID, ListRoleEditor)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("vocabulary list not found")
-		}
 		return nil, err
 	}
+	list := *listRef
+	if list.Role != ListRoleOwner && (req.IsPublic != nil || req.CardTypes != nil) {
+		return nil, errors.New("only the list owner can change visibility or card types")
+	}
 
 	// Update fields
 	if req.Name != "" {
@@ -142,13 +194,30 @@ func (s *Service) UpdateVocabularyList(ctx context.Context, userID, listID strin
 	if req.IsPublic != nil {
 		list.IsPublic = *req.IsPublic
 	}
+	cardTypesChanged := false
+	if req.CardTypes != nil {
+		previous := list.CardTypes
+		list.SetCardTypes(req.CardTypes)
+		cardTypesChanged = list.CardTypes != previous
+	}
+	if req.Tags != nil {
+		list.SetTags(req.Tags)
+	}
 
 	if err := s.db.Save(&list).Error; err != nil {
 		return nil, err
 	}
 
+	if cardTypesChanged {
+		if err := s.refreshListCards(list.UserID, list.ID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Reload with items
-	s.db.Preload("Items").Preload("Items.Vocabulary").Where("id = ?", list.ID).First(&list)
+	role := list.Role
+	s.db.Preload("Items", orderedListItems).Preload("Items.Vocabulary").Where("id = ?", list.ID).First(&list)
+	list.Role = role
 	return &list, nil
 }
 
@@ -162,21 +231,49 @@ func (s *Service) DeleteVocabularyList(ctx context.Context, userID, listID strin
 		return err
 	}
 
+	var vocabularyIDs []string
+	s.db.Model(&VocabularyListItem{}).Where("list_id = ?", listID).Pluck("vocabulary_id", &vocabularyIDs)
+
 	// Delete list items first
 	if err := s.db.Where("list_id = ?", listID).Delete(&VocabularyListItem{}).Error; err != nil {
 		return err
 	}
 
+	// Subscribers keep the words they already received
+	if err := s.db.Where("list_id = ?", listID).Delete(&VocabularyListSubscription{}).Error; err != nil {
+		return err
+	}
+
+	if err := s.db.Where("list_id = ?", listID).Delete(&VocabularyListMember{}).Error; err != nil {
+		return err
+	}
+
+	if err := s.db.Where("list_id = ?", listID).Delete(&VocabularyListGroup{}).Error; err != nil {
+		return err
+	}
+
 	// Delete the list
-	return s.db.Delete(&list).Error
+	if err := s.db.Delete(&list).Error; err != nil {
+		return err
+	}
+
+	// Templates enabled only by this list no longer apply
+	for _, vocabularyID := range vocabularyIDs {
+		if err := s.refreshCards(userID, vocabularyID); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
+// AddVocabularyToList appends a word from the user's collection to a list
+// they own or edit. The owner receives the word at once and the list's
+// subscribers through a background job.
 func (s *Service) AddVocabularyToList(ctx context.Context, userID, listID, vocabularyID string) error {
-	// Verify list ownership
-	var list VocabularyList
-	err := s.db.Where("id = ? AND user_id = ?", listID, userID).First(&list).Error
+	list, err := s.requireListRole(listID, userID, ListRoleEditor)
 	if err != nil {
-		return errors.New("vocabulary list not found")
+		return err
 	}
 
 	// Verify vocabulary exists and user has access
@@ -195,7 +292,7 @@ func (s *Service) AddVocabularyToList(ctx context.Context, userID, listID, vocab
 
 	// Get next order
 	var maxOrder int
-	s.db.Model(&VocabularyListItem{}).Where("list_id = ?", listID).Select("COALESCE(MAX(order), 0)").Scan(&maxOrder)
+	s.db.Model(&VocabularyListItem{}).Where("list_id = ?", listID).Select(`COALESCE(MAX("order"), 0)`).Scan(&maxOrder)
 
 	// Add to list
 	item := VocabularyListItem{
@@ -204,15 +301,32 @@ func (s *Service) AddVocabularyToList(ctx context.Context, userID, listID, vocab
 		Order:        maxOrder + 1,
 	}
 
-	return s.db.Create(&item).Error
+	if err := s.db.Create(&item).Error; err != nil {
+		return err
+	}
+
+	// Words added by an editor reach the owner's deck too
+	if list.UserID != userID {
+		if _, err := s.addToDeck(s.db, list.UserID, vocabularyID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.refreshCards(list.UserID, vocabularyID); err != nil {
+		return err
+	}
+
+	s.db.Model(list).UpdateColumn("updated_at", time.Now())
+
+	return s.queueListDelivery(userID, list, vocabularyID)
 }
 
+// RemoveVocabularyFromList takes a word off a list the user owns or edits.
+// Learners keep the word in their own vocabulary.
 func (s *Service) RemoveVocabularyFromList(ctx context.Context, userID, listID, vocabularyID string) error {
-	// Verify list ownership
-	var list VocabularyList
-	err := s.db.Where("id = ? AND user_id = ?", listID, userID).First(&list).Error
+	list, err := s.requireListRole(listID, userID, ListRoleEditor)
 	if err != nil {
-		return errors.New("vocabulary list not found")
+		return err
 	}
 
 	// Remove from list
@@ -224,19 +338,26 @@ func (s *Service) RemoveVocabularyFromList(ctx context.Context, userID, listID,
 		return errors.New("vocabulary not found in list")
 	}
 
-	return nil
+	s.db.Model(list).UpdateColumn("updated_at", time.Now())
+
+	return s.refreshCards(list.UserID, vocabularyID)
 }
 
 // Bulk Operations Service Methods
 func (s *Service) BulkAddVocabulary(ctx context.Context, userID string, req BulkAddVocabularyRequest) (*BulkOperationResult, error) {
+	vocabularyIDs, err := s.bulkTargets(userID, req.VocabularyIDs, req.FilterID)
+	if err != nil {
+		return nil, err
+	}
+
 	result := &BulkOperationResult{
-		Total:  len(req.VocabularyIDs),
+		Total:  len(vocabularyIDs),
 		Errors: make([]string, 0),
 	}
 
 	if req.ListID != "" {
 		// Add to specific list
-		for _, vocabID := range req.VocabularyIDs {
+		for _, vocabID := range vocabularyIDs {
 			err := s.AddVocabularyToList(ctx, userID, req.ListID, vocabID)
 			if err != nil {
 				result.Failed++
@@ -254,12 +375,17 @@ func (s *Service) BulkAddVocabulary(ctx context.Context, userID string, req Bulk
 }
 
 func (s *Service) BulkDeleteVocabulary(ctx context.Context, userID string, req BulkDeleteVocabularyRequest) (*BulkOperationResult, error) {
+	vocabularyIDs, err := s.bulkTargets(userID, req.VocabularyIDs, req.FilterID)
+	if err != nil {
+		return nil, err
+	}
+
 	result := &BulkOperationResult{
-		Total:  len(req.VocabularyIDs),
+		Total:  len(vocabularyIDs),
 		Errors: make([]string, 0),
 	}
 
-	for _, vocabID := range req.VocabularyIDs {
+	for _, vocabID := range vocabularyIDs {
 		err := s.DeleteVocabulary(ctx, userID, vocabID)
 		if err != nil {
 			result.Failed++
@@ -273,12 +399,17 @@ func (s *Service) BulkDeleteVocabulary(ctx context.Context, userID string, req B
 }
 
 func (s *Service) BulkResetProgress(ctx context.Context, userID string, req BulkResetProgressRequest) (*BulkOperationResult, error) {
+	vocabularyIDs, err := s.bulkTargets(userID, req.VocabularyIDs, req.FilterID)
+	if err != nil {
+		return nil, err
+	}
+
 	result := &BulkOperationResult{
-		Total:  len(req.VocabularyIDs),
+		Total:  len(vocabularyIDs),
 		Errors: make([]string, 0),
 	}
 
-	for _, vocabID := range req.VocabularyIDs {
+	for _, vocabID := range vocabularyIDs {
 		var userVocab UserVocabulary
 		err := s.db.Where("user_id = ? AND vocabulary_id = ?", userID, vocabID).First(&userVocab).Error
 		if err != nil {
@@ -294,6 +425,8 @@ func (s *Service) BulkResetProgress(ctx context.Context, userID string, req Bulk
 			userVocab.ReviewCount = 0
 			userVocab.CorrectCount = 0
 			userVocab.LastReviewedAt = nil
+			userVocab.FirstReviewedAt = nil
+			userVocab.LapseCount = 0
 			userVocab.NextReviewAt = nil
 			userVocab.EaseFactor = s.srsConfig.MaxEaseFactor
 			userVocab.IntervalDays = 1
@@ -306,6 +439,7 @@ func (s *Service) BulkResetProgress(ctx context.Context, userID string, req Bulk
 		case "reviews":
 			userVocab.ReviewCount = 0
 			userVocab.CorrectCount = 0
+			userVocab.LapseCount = 0
 			userVocab.LastReviewedAt = nil
 		default:
 			result.Failed++
@@ -316,6 +450,13 @@ func (s *Service) BulkResetProgress(ctx context.Context, userID string, req Bulk
 		if err := s.db.Save(&userVocab).Error; err != nil {
 			result.Failed++
 			result.Errors = append(result.Errors, fmt.Sprintf("Failed to reset %s: %v", vocabID, err))
+			continue
+		}
+
+		// Extra card types are reset the same way as the recognition card
+		if err := s.resetCards(userVocab.ID, req.ResetType); err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Failed to reset cards of %s: %v", vocabID, err))
 		} else {
 			result.Processed++
 		}
@@ -323,3 +464,33 @@ func (s *Service) BulkResetProgress(ctx context.Context, userID string, req Bulk
 
 	return result, nil
 }
+
+// resetCards applies a progress reset to the extra cards of an entry
+func (s *Service) resetCards(userVocabularyID string, resetType string) error {
+	updates := map[string]interface{}{}
+
+	switch resetType {
+	case "all":
+		updates["mastery_level"] = 0
+		updates["review_count"] = 0
+		updates["correct_count"] = 0
+		updates["last_reviewed_at"] = nil
+		updates["first_reviewed_at"] = nil
+		updates["lapse_count"] = 0
+		updates["next_review_at"] = nil
+		updates["ease_factor"] = s.srsConfig.MaxEaseFactor
+		updates["interval_days"] = 1
+	case "progress":
+		updates["mastery_level"] = 0
+		updates["ease_factor"] = s.srsConfig.MaxEaseFactor
+		updates["interval_days"] = 1
+		updates["next_review_at"] = time.Now().Add(time.Hour * 24)
+	case "reviews":
+		updates["review_count"] = 0
+		updates["correct_count"] = 0
+		updates["lapse_count"] = 0
+		updates["last_reviewed_at"] = nil
+	}
+
+	return s.db.Model(&VocabularyCard{}).Where("user_vocabulary_id = ?", userVocabularyID).Updates(updates).Error
+}
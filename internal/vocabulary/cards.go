@@ -0,0 +1,299 @@
+package vocabulary
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// Card templates generated from a vocabulary entry
+const (
+	CardTypeRecognition = "recognition" // word -> translation
+	CardTypeProduction  = "production"  // translation -> word
+	CardTypeCloze       = "cloze"       // sentence with the word blanked out
+	CardTypeListening   = "listening"   // audio -> word
+)
+
+// AllCardTypes lists every card template in display order
+var AllCardTypes = []string{CardTypeRecognition, CardTypeProduction, CardTypeCloze, CardTypeListening}
+
+const clozeBlank = "[...]"
+
+// VocabularyCard is an additional card template for a user's vocabulary
+// entry, with its own SRS state. The recognition card is the SRS state on
+// UserVocabulary itself.
+type VocabularyCard struct {
+	ID               string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserVocabularyID string    `json:"user_vocabulary_id" gorm:"not null;uniqueIndex:idx_vocabulary_card_type"`
+	UserID           string    `json:"user_id" gorm:"not null;index"`
+	CardType         string    `json:"card_type" gorm:"not null;uniqueIndex:idx_vocabulary_card_type"`
+	Active           bool      `json:"active" gorm:"default:true"` // False when no list enables the template
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	SRSState
+
+	// Relations
+	UserVocabulary UserVocabulary `json:"-" gorm:"foreignKey:UserVocabularyID"`
+}
+
+// ReviewCard is a card ready to be shown to the learner
+type ReviewCard struct {
+	CardID       string `json:"card_id"`
+	CardType     string `json:"card_type"`
+	VocabularyID string `json:"vocabulary_id"`
+	Front        string `json:"front"`
+	Back         string `json:"back"`
+	AudioURL     string `json:"audio_url,omitempty"`
+	SRSState
+
+	UserVocabulary UserVocabulary `json:"user_vocabulary"`
+}
+
+// GetCardTypes converts the comma-separated string to a slice
+func (l *VocabularyList) GetCardTypes() []string {
+	if l.CardTypes == "" {
+		return []string{CardTypeRecognition}
+	}
+
+	types := make([]string, 0, len(AllCardTypes))
+	for _, part := range strings.Split(l.CardTypes, ",") {
+		if cardType := strings.TrimSpace(part); isCardType(cardType) {
+			types = append(types, cardType)
+		}
+	}
+
+	if len(types) == 0 {
+		return []string{CardTypeRecognition}
+	}
+
+	return types
+}
+
+// SetCardTypes converts a slice to the comma-separated string. Recognition is
+// always enabled.
+func (l *VocabularyList) SetCardTypes(types []string) {
+	enabled := map[string]bool{CardTypeRecognition: true}
+	for _, cardType := range types {
+		enabled[cardType] = true
+	}
+
+	ordered := make([]string, 0, len(enabled))
+	for _, cardType := range AllCardTypes {
+		if enabled[cardType] {
+			ordered = append(ordered, cardType)
+		}
+	}
+
+	l.CardTypes = strings.Join(ordered, ",")
+}
+
+func isCardType(cardType string) bool {
+	for _, t := range AllCardTypes {
+		if t == cardType {
+			return true
+		}
+	}
+	return false
+}
+
+// buildCardFaces renders the front and back of a card. ok is false when the
+// entry lacks what the template needs, e.g. a sentence containing the word
+// for a cloze card.
+func buildCardFaces(cardType string, userVocab *UserVocabulary) (front, back string, ok bool) {
+	word := userVocab.Vocabulary.Word
+	translation := userVocab.DisplayTranslation()
+
+	switch cardType {
+	case CardTypeRecognition:
+		return word, translation, true
+	case CardTypeProduction:
+		if translation == "" {
+			return "", "", false
+		}
+		return translation, word, true
+	case CardTypeCloze:
+		for _, sentence := range []string{userVocab.ContextSentence, userVocab.Vocabulary.ExampleSentence} {
+			if cloze, found := clozeSentence(sentence, word); found {
+				return cloze, word, true
+			}
+		}
+		return "", "", false
+	case CardTypeListening:
+		// Clients synthesise speech when the entry has no recording
+		return "", word + " — " + translation, true
+	}
+
+	return "", "", false
+}
+
+// clozeSentence blanks out every occurrence of word in sentence, ignoring
+// case. Only whole words are blanked, so "cat" leaves "category" alone.
+func clozeSentence(sentence, word string) (string, bool) {
+	if sentence == "" || strings.TrimSpace(word) == "" {
+		return "", false
+	}
+
+	pattern, err := regexp.Compile("(?i)" + regexp.QuoteMeta(strings.TrimSpace(word)))
+	if err != nil {
+		return "", false
+	}
+
+	var cloze strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringIndex(sentence, -1) {
+		before, _ := utf8.DecodeLastRuneInString(sentence[:match[0]])
+		after, _ := utf8.DecodeRuneInString(sentence[match[1]:])
+		if isWordRune(before) || isWordRune(after) {
+			continue
+		}
+		cloze.WriteString(sentence[last:match[0]])
+		cloze.WriteString(clozeBlank)
+		last = match[1]
+	}
+	if last == 0 {
+		return "", false
+	}
+
+	cloze.WriteString(sentence[last:])
+	return cloze.String(), true
+}
+
+// isWordRune reports whether r continues a word, in any script
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r)
+}
+
+func newReviewCard(cardID, cardType string, state SRSState, userVocab UserVocabulary) ReviewCard {
+	front, back, _ := buildCardFaces(cardType, &userVocab)
+	return ReviewCard{
+		CardID:         cardID,
+		CardType:       cardType,
+		VocabularyID:   userVocab.VocabularyID,
+		Front:          front,
+		Back:           back,
+		AudioURL:       userVocab.Vocabulary.AudioURL,
+		SRSState:       state,
+		UserVocabulary: userVocab,
+	}
+}
+
+// refreshCards creates or (de)activates the extra cards of a user's entry
+// according to the templates enabled on the user's lists that contain it
+func (s *Service) refreshCards(userID, vocabularyID string) error {
+	var userVocab UserVocabulary
+	err := s.db.Preload("Vocabulary").Where("user_id = ? AND vocabulary_id = ?", userID, vocabularyID).First(&userVocab).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var lists []VocabularyList
+	err = s.db.Joins("JOIN vocabulary_list_items ON vocabulary_list_items.list_id = vocabulary_lists.id").
+		Where("vocabulary_lists.user_id = ? AND vocabulary_list_items.vocabulary_id = ?", userID, vocabularyID).
+		Find(&lists).Error
+	if err != nil {
+		return err
+	}
+
+	enabled := make(map[string]bool)
+	for _, list := range lists {
+		for _, cardType := range list.GetCardTypes() {
+			enabled[cardType] = true
+		}
+	}
+
+	var existing []VocabularyCard
+	if err := s.db.Where("user_vocabulary_id = ?", userVocab.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+	byType := make(map[string]VocabularyCard, len(existing))
+	for _, card := range existing {
+		byType[card.CardType] = card
+	}
+
+	for _, cardType := range AllCardTypes[1:] {
+		_, _, eligible := buildCardFaces(cardType, &userVocab)
+		active := enabled[cardType] && eligible
+		card, exists := byType[cardType]
+
+		switch {
+		case !exists && active:
+			card = VocabularyCard{
+				UserVocabularyID: userVocab.ID,
+				UserID:           userID,
+				CardType:         cardType,
+				Active:           true,
+				SRSState: SRSState{
+					EaseFactor:   s.srsConfig.MaxEaseFactor,
+					IntervalDays: 1,
+				},
+			}
+			if err := s.db.Create(&card).Error; err != nil {
+				return err
+			}
+		case exists && card.Active != active:
+			if err := s.db.Model(&card).Update("active", active).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// refreshListCards refreshes the cards of every entry in a list
+func (s *Service) refreshListCards(userID, listID string) error {
+	var vocabularyIDs []string
+	if err := s.db.Model(&VocabularyListItem{}).Where("list_id = ?", listID).Pluck("vocabulary_id", &vocabularyIDs).Error; err != nil {
+		return err
+	}
+
+	for _, vocabularyID := range vocabularyIDs {
+		if err := s.refreshCards(userID, vocabularyID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sortCardsByDue orders cards by next review, cards never scheduled last
+func sortCardsByDue(cards []ReviewCard) {
+	sort.SliceStable(cards, func(i, j int) bool {
+		a, b := cards[i].NextReviewAt, cards[j].NextReviewAt
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return a.Before(*b)
+	})
+}
+
+// GetVocabularyCards returns every card of a user's vocabulary entry
+func (s *Service) GetVocabularyCards(ctx context.Context, userID, vocabularyID string) ([]ReviewCard, error) {
+	var userVocab UserVocabulary
+	err := s.db.Preload("Vocabulary").Where("user_id = ? AND vocabulary_id = ?", userID, vocabularyID).First(&userVocab).Error
+	if err != nil {
+		return nil, errors.New("vocabulary not found")
+	}
+
+	var cards []VocabularyCard
+	if err := s.db.Where("user_vocabulary_id = ? AND active = ?", userVocab.ID, true).Find(&cards).Error; err != nil {
+		return nil, err
+	}
+
+	result := []ReviewCard{newReviewCard(userVocab.ID, CardTypeRecognition, userVocab.SRSState, userVocab)}
+	for _, card := range cards {
+		result = append(result, newReviewCard(card.ID, card.CardType, card.SRSState, userVocab))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package vocabulary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildCardFaces(t *testing.T) {
+	userVocab := &UserVocabulary{
+		Translation:     "to run",
+		ContextSentence: "Corre todos los días.",
+		Vocabulary: Vocabulary{
+			Word:            "correr",
+			Translation:     "run",
+			ExampleSentence: "Me gusta correr por la mañana.",
+		},
+	}
+
+	t.Run("Recognition", func(t *testing.T) {
+		front, back, ok := buildCardFaces(CardTypeRecognition, userVocab)
+		assert.True(t, ok)
+		assert.Equal(t, "correr", front)
+		assert.Equal(t, "to run", back)
+	})
+
+	t.Run("Production", func(t *testing.T) {
+		front, back, ok := buildCardFaces(CardTypeProduction, userVocab)
+		assert.True(t, ok)
+		assert.Equal(t, "to run", front)
+		assert.Equal(t, "correr", back)
+	})
+
+	t.Run("ClozeFallsBackToExampleSentence", func(t *testing.T) {
+		front, back, ok := buildCardFaces(CardTypeCloze, userVocab)
+		assert.True(t, ok)
+		assert.Equal(t, "Me gusta [...] por la mañana.", front)
+		assert.Equal(t, "correr", back)
+	})
+
+	t.Run("ClozeWithoutSentence", func(t *testing.T) {
+		_, _, ok := buildCardFaces(CardTypeCloze, &UserVocabulary{Vocabulary: Vocabulary{Word: "correr"}})
+		assert.False(t, ok)
+	})
+}
+
+func TestClozeSentence(t *testing.T) {
+	cloze, ok := clozeSentence("Casa blanca, casa grande.", "casa")
+	assert.True(t, ok)
+	assert.Equal(t, "[...] blanca, [...] grande.", cloze)
+
+	_, ok = clozeSentence("Nothing here.", "casa")
+	assert.False(t, ok)
+
+	t.Run("WholeWordsOnly", func(t *testing.T) {
+		cloze, ok := clozeSentence("The cat, not the category: CAT cat.", "cat")
+		assert.True(t, ok)
+		assert.Equal(t, "The [...], not the category: [...] [...].", cloze)
+
+		_, ok = clozeSentence("Concatenate them.", "cat")
+		assert.False(t, ok)
+
+		cloze, ok = clozeSentence("¿Está bien? Estábamos bien.", "está")
+		assert.True(t, ok)
+		assert.Equal(t, "¿[...] bien? Estábamos bien.", cloze)
+	})
+}
+
+func TestVocabularyListCardTypes(t *testing.T) {
+	list := &VocabularyList{}
+	assert.Equal(t, []string{CardTypeRecognition}, list.GetCardTypes())
+
+	list.SetCardTypes([]string{CardTypeCloze, CardTypeProduction, CardTypeCloze})
+	assert.Equal(t, "recognition,production,cloze", list.CardTypes)
+	assert.Equal(t, []string{CardTypeRecognition, CardTypeProduction, CardTypeCloze}, list.GetCardTypes())
+}
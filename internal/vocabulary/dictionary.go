@@ -0,0 +1,209 @@
+package vocabulary
+
+import (
+	"log"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// NormalizeLemma returns the dictionary key for a word: NFKC-normalised,
+// case-folded, with surrounding punctuation and repeated whitespace removed
+func NormalizeLemma(word string) string {
+	normalized := norm.NFKC.String(word)
+	normalized = strings.Join(strings.Fields(normalized), " ")
+	normalized = strings.TrimFunc(normalized, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	})
+	normalized = cases.Fold().String(normalized)
+
+	return norm.NFC.String(normalized)
+}
+
+// normalizeSense trims the optional sense discriminator
+func normalizeSense(sense string) string {
+	return strings.TrimSpace(strings.ToLower(sense))
+}
+
+// RenameLegacyVocabularyTables moves tables created before the models had
+// explicit table names to the names used by the queries in this package. It
+// must run before AutoMigrate.
+func RenameLegacyVocabularyTables(db *gorm.DB) error {
+	renames := map[string]string{
+		"vocabularies":      "vocabulary",
+		"user_vocabularies": "user_vocabulary",
+	}
+
+	for legacy, current := range renames {
+		if db.Migrator().HasTable(legacy) && !db.Migrator().HasTable(current) {
+			log.Printf("Renaming legacy table %s to %s", legacy, current)
+			if err := db.Migrator().RenameTable(legacy, current); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// MigrateDictionary turns the vocabulary table into a canonical dictionary.
+// It backfills lemmas, copies each user's translation onto their
+// user_vocabulary row, merges duplicate entries and remaps user_vocabulary
+// and vocabulary_list_items to the surviving entry. It is safe to run on
+// every start.
+func MigrateDictionary(db *gorm.DB) error {
+	if err := backfillLemmas(db); err != nil {
+		return err
+	}
+
+	// Keep the translation each user saw before entries are shared
+	if err := db.Exec(`
+        UPDATE user_vocabulary uv
+        SET translation = v.translation, definition = v.definition
+        FROM vocabulary v
+        WHERE uv.vocabulary_id = v.id AND uv.translation = '' AND uv.definition = ''
+    `).Error; err != nil {
+		return err
+	}
+
+	var groups []struct {
+		LanguageID int
+		Lemma      string
+		Sense      string
+	}
+	err := db.Raw(`
+        SELECT language_id, lemma, sense
+        FROM vocabulary
+        GROUP BY language_id, lemma, sense
+        HAVING COUNT(*) > 1
+    `).Scan(&groups).Error
+	if err != nil {
+		return err
+	}
+
+	if len(groups) > 0 {
+		log.Printf("Merging %d duplicated vocabulary entries", len(groups))
+	}
+
+	for _, group := range groups {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return mergeDictionaryGroup(tx, group.LanguageID, group.Lemma, group.Sense)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	indexes := []string{
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_vocabulary_dictionary_key ON vocabulary(language_id, lemma, sense)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_user_vocabulary_user_entry ON user_vocabulary(user_id, vocabulary_id)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_vocabulary_list_items_entry ON vocabulary_list_items(list_id, vocabulary_id)",
+	}
+	for _, indexSQL := range indexes {
+		if err := db.Exec(indexSQL).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backfillLemmas computes the lemma of rows created before the column existed
+func backfillLemmas(db *gorm.DB) error {
+	var rows []Vocabulary
+	return db.Select("id", "word", "sense").
+		Where("lemma = '' OR lemma IS NULL").
+		FindInBatches(&rows, 500, func(tx *gorm.DB, batch int) error {
+			for _, row := range rows {
+				err := db.Model(&Vocabulary{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+					"lemma": NormalizeLemma(row.Word),
+					"sense": normalizeSense(row.Sense),
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// mergeDictionaryGroup keeps the oldest entry of a duplicate group and points
+// every reference at it
+func mergeDictionaryGroup(tx *gorm.DB, languageID int, lemma, sense string) error {
+	var entries []Vocabulary
+	err := tx.Where("language_id = ? AND lemma = ? AND sense = ?", languageID, lemma, sense).
+		Order("created_at ASC, id ASC").
+		Find(&entries).Error
+	if err != nil || len(entries) < 2 {
+		return err
+	}
+
+	canonical := entries[0]
+	duplicateIDs := make([]string, 0, len(entries)-1)
+	for _, entry := range entries[1:] {
+		duplicateIDs = append(duplicateIDs, entry.ID)
+
+		// Fill gaps in the canonical entry from its duplicates
+		if canonical.PhoneticTranscription == "" {
+			canonical.PhoneticTranscription = entry.PhoneticTranscription
+		}
+		if canonical.Definition == "" {
+			canonical.Definition = entry.Definition
+		}
+		if canonical.ExampleSentence == "" {
+			canonical.ExampleSentence = entry.ExampleSentence
+		}
+	}
+	allIDs := append([]string{canonical.ID}, duplicateIDs...)
+
+	if err := tx.Save(&canonical).Error; err != nil {
+		return err
+	}
+
+	// A user holding several duplicates keeps the most reviewed card
+	if err := tx.Exec(`
+        DELETE FROM user_vocabulary uv
+        USING (
+            SELECT id, ROW_NUMBER() OVER (
+                PARTITION BY user_id
+                ORDER BY review_count DESC, added_at ASC, id ASC
+            ) AS rank
+            FROM user_vocabulary
+            WHERE vocabulary_id IN ?
+        ) ranked
+        WHERE uv.id = ranked.id AND ranked.rank > 1
+    `, allIDs).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Exec("UPDATE user_vocabulary SET vocabulary_id = ?, updated_at = NOW() WHERE vocabulary_id IN ?",
+		canonical.ID, duplicateIDs).Error; err != nil {
+		return err
+	}
+
+	// Same for lists that contain several duplicates
+	if err := tx.Exec(`
+        DELETE FROM vocabulary_list_items li
+        USING (
+            SELECT id, ROW_NUMBER() OVER (
+                PARTITION BY list_id
+                ORDER BY "order" ASC, id ASC
+            ) AS rank
+            FROM vocabulary_list_items
+            WHERE vocabulary_id IN ?
+        ) ranked
+        WHERE li.id = ranked.id AND ranked.rank > 1
+    `, allIDs).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Exec("UPDATE vocabulary_list_items SET vocabulary_id = ? WHERE vocabulary_id IN ?",
+		canonical.ID, duplicateIDs).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("id IN ?", duplicateIDs).Delete(&Vocabulary{}).Error
+}
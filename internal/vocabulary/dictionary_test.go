@@ -0,0 +1,36 @@
+package vocabulary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeLemma(t *testing.T) {
+	t.Run("CaseFolding", func(t *testing.T) {
+		assert.Equal(t, "casa", NormalizeLemma("Casa"))
+		assert.Equal(t, "strasse", NormalizeLemma("STRASSE"))
+		assert.Equal(t, NormalizeLemma("Straße"), NormalizeLemma("STRASSE"))
+		assert.Equal(t, "привет", NormalizeLemma("Привет"))
+	})
+
+	t.Run("UnicodeNormalization", func(t *testing.T) {
+		composed := "caf\u00e9"
+		decomposed := "cafe\u0301"
+		assert.Equal(t, NormalizeLemma(composed), NormalizeLemma(decomposed))
+
+		// Full-width forms collapse to their ASCII equivalents
+		assert.Equal(t, "abc", NormalizeLemma("ＡＢＣ"))
+	})
+
+	t.Run("WhitespaceAndPunctuation", func(t *testing.T) {
+		assert.Equal(t, "qué", NormalizeLemma("  ¿Qué? "))
+		assert.Equal(t, "por favor", NormalizeLemma("por   favor"))
+		assert.Equal(t, "", NormalizeLemma(" ... "))
+	})
+
+	t.Run("KeepsScripts", func(t *testing.T) {
+		assert.Equal(t, "日本語", NormalizeLemma("日本語"))
+		assert.Equal(t, "한국어", NormalizeLemma("한국어"))
+	})
+}
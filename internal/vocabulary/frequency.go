@@ -0,0 +1,374 @@
+package vocabulary
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WordFrequency is one entry of a language's frequency list. Lists are ranked
+// by how often words occur in typical text, most frequent first.
+type WordFrequency struct {
+	LanguageID int       `json:"language_id" gorm:"primaryKey;autoIncrement:false"`
+	Lemma      string    `json:"lemma" gorm:"primaryKey"`
+	Word       string    `json:"word" gorm:"not null"`
+	Rank       int       `json:"rank" gorm:"not null;index"`
+	Count      int64     `json:"count"` // Occurrences in the source corpus, 0 when the list only gives ranks
+	Source     string    `json:"source"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName keeps the frequency list table name stable
+func (WordFrequency) TableName() string {
+	return "word_frequencies"
+}
+
+type ImportFrequencyListRequest struct {
+	LanguageID int    `json:"language_id" validate:"required"`
+	Source     string `json:"source" validate:"max=255"`
+	// One word per line, most frequent first, optionally followed by its
+	// count and separated by a comma, tab or space ("de 123456")
+	Data string `json:"data" validate:"required"`
+}
+
+// CoverageEstimate is the share of running text made up of words the user
+// knows, estimated from the language's frequency list
+type CoverageEstimate struct {
+	LanguageID                int     `json:"language_id"`
+	ListSize                  int64   `json:"list_size"`
+	KnownWords                int64   `json:"known_words"`    // Words in the list the user has mastered
+	LearningWords             int64   `json:"learning_words"` // Words in the list the user is still learning
+	Coverage                  float64 `json:"coverage"`       // Percentage of running text covered by known words
+	CoverageIncludingLearning float64 `json:"coverage_including_learning"`
+}
+
+// UsefulWord is a frequent word the user does not have yet
+type UsefulWord struct {
+	Word         string  `json:"word"`
+	Lemma        string  `json:"lemma"`
+	Rank         int     `json:"rank"`
+	CoverageGain float64 `json:"coverage_gain"` // Percentage points of running text the word adds
+	VocabularyID string  `json:"vocabulary_id,omitempty"`
+	Translation  string  `json:"translation,omitempty"`
+}
+
+// frequencyWeightSQL is the share of running text a list entry stands for.
+// Counts are used when the list has them; otherwise Zipf's law makes a word's
+// frequency inversely proportional to its rank.
+const frequencyWeightSQL = "CASE WHEN word_frequencies.count > 0 THEN CAST(word_frequencies.count AS double precision) ELSE 1.0 / word_frequencies.rank END"
+
+// parseFrequencyList reads a frequency list, ranking words by line order.
+// Spellings sharing a lemma are merged into the first. Counts are dropped
+// unless every line has one, so weights are never mixed.
+func parseFrequencyList(languageID int, source, data string) ([]WordFrequency, error) {
+	entries := make([]WordFrequency, 0)
+	byLemma := make(map[string]int)
+	allCounted := true
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var fields []string
+		switch {
+		case strings.Contains(text, "\t"):
+			fields = strings.Split(text, "\t")
+		case strings.Contains(text, ","):
+			fields = strings.Split(text, ",")
+		default:
+			fields = strings.Fields(text)
+		}
+
+		word := strings.TrimSpace(fields[0])
+		var count int64
+		if len(fields) > 1 {
+			parsed, err := strconv.ParseInt(strings.TrimSpace(fields[len(fields)-1]), 10, 64)
+			if err != nil {
+				if len(entries) == 0 {
+					continue // Header row
+				}
+				return nil, fmt.Errorf("line %d: invalid count %q", line, fields[len(fields)-1])
+			}
+			count = parsed
+		}
+
+		lemma := NormalizeLemma(word)
+		if lemma == "" {
+			continue
+		}
+		if count <= 0 {
+			allCounted = false
+		}
+
+		if i, ok := byLemma[lemma]; ok {
+			entries[i].Count += count
+			continue
+		}
+
+		byLemma[lemma] = len(entries)
+		entries = append(entries, WordFrequency{
+			LanguageID: languageID,
+			Lemma:      lemma,
+			Word:       word,
+			Rank:       len(entries) + 1,
+			Count:      count,
+			Source:     source,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !allCounted {
+		for i := range entries {
+			entries[i].Count = 0
+		}
+	}
+
+	return entries, nil
+}
+
+// mergeFrequencyLemmas files each entry under lemmas[i]. Entries sharing a
+// lemma are merged into the highest-ranked one, keeping the spelling of the
+// lemma's own entry when the list has one, and the list is ranked again.
+func mergeFrequencyLemmas(entries []WordFrequency, lemmas []string) []WordFrequency {
+	merged := make([]WordFrequency, 0, len(entries))
+	byLemma := make(map[string]int)
+
+	for i, entry := range entries {
+		lemma := lemmas[i]
+		if j, ok := byLemma[lemma]; ok {
+			merged[j].Count += entry.Count
+			if entry.Lemma == lemma {
+				merged[j].Word = entry.Word
+			}
+			continue
+		}
+
+		if entry.Lemma != lemma {
+			entry.Word = lemmaDisplayWord(lemma, entry.Word)
+		}
+		entry.Lemma = lemma
+		entry.Rank = len(merged) + 1
+		byLemma[lemma] = len(merged)
+		merged = append(merged, entry)
+	}
+
+	return merged
+}
+
+// lemmatizeFrequencyList files list entries under their lemmas the way
+// AddVocabulary files words, so the list joins the dictionary on lemma.
+// Guesses are confirmed by the ranks of the list itself.
+func (s *Service) lemmatizeFrequencyList(languageID int, entries []WordFrequency) ([]WordFrequency, error) {
+	code := s.languageCode(languageID)
+	if lemmatizerFor(code) == nil {
+		return entries, nil
+	}
+
+	ranks := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		ranks[entry.Lemma] = entry.Rank
+	}
+
+	lemmas := make([]string, len(entries))
+	guesses := make(map[int][]string)
+	forms := make([]string, 0)
+	for i, entry := range entries {
+		lemmas[i] = entry.Lemma
+		_, candidates, exact := lemmaCandidates(code, entry.Lemma)
+		switch {
+		case len(candidates) == 0:
+		case exact:
+			lemmas[i] = candidates[0]
+		default:
+			guesses[i] = candidates
+			forms = append(append(forms, entry.Lemma), candidates...)
+		}
+	}
+
+	inDictionary, err := s.dictionaryLemmas(languageID, forms)
+	if err != nil {
+		return nil, err
+	}
+	for i, candidates := range guesses {
+		lemmas[i] = pickLemma(entries[i].Lemma, candidates, ranks, inDictionary)
+	}
+
+	return mergeFrequencyLemmas(entries, lemmas), nil
+}
+
+// ImportFrequencyList replaces a language's frequency list, filed by lemma,
+// and re-ranks the dictionary entries of that language
+func (s *Service) ImportFrequencyList(ctx context.Context, req ImportFrequencyListRequest) (*ImportResult, error) {
+	entries, err := parseFrequencyList(req.LanguageID, req.Source, req.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("frequency list is empty")
+	}
+	listed := len(entries)
+	entries, err = s.lemmatizeFrequencyList(req.LanguageID, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("language_id = ?", req.LanguageID).Delete(&WordFrequency{}).Error; err != nil {
+			return err
+		}
+		if err := tx.CreateInBatches(&entries, 1000).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&Vocabulary{}).Where("language_id = ?", req.LanguageID).Update("frequency_rank", 0).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+            UPDATE vocabulary SET frequency_rank = word_frequencies.rank
+            FROM word_frequencies
+            WHERE word_frequencies.language_id = vocabulary.language_id
+              AND word_frequencies.lemma = vocabulary.lemma
+              AND vocabulary.language_id = ?`, req.LanguageID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImportResult{Total: listed, Imported: len(entries)}, nil
+}
+
+// frequencyRank returns the rank of a lemma in the language's frequency list,
+// or 0 when it is not listed
+func (s *Service) frequencyRank(languageID int, lemma string) int {
+	var rank int
+	s.db.Model(&WordFrequency{}).Select("rank").Where("language_id = ? AND lemma = ?", languageID, lemma).Scan(&rank)
+	return rank
+}
+
+// GetCoverage estimates how much of typical running text the user's
+// vocabulary covers
+func (s *Service) GetCoverage(ctx context.Context, userID string, languageID int) (*CoverageEstimate, error) {
+	var row struct {
+		ListSize      int64
+		KnownWords    int64
+		LearningWords int64
+		Total         float64
+		Known         float64
+		Learning      float64
+	}
+
+	err := s.db.Raw(`
+        SELECT
+            COUNT(*) AS list_size,
+            COUNT(*) FILTER (WHERE deck.mastery_level >= @mastered) AS known_words,
+            COUNT(*) FILTER (WHERE deck.mastery_level < @mastered) AS learning_words,
+            COALESCE(SUM(`+frequencyWeightSQL+`), 0) AS total,
+            COALESCE(SUM(`+frequencyWeightSQL+`) FILTER (WHERE deck.mastery_level >= @mastered), 0) AS known,
+            COALESCE(SUM(`+frequencyWeightSQL+`) FILTER (WHERE deck.mastery_level < @mastered), 0) AS learning
+        FROM word_frequencies
+        LEFT JOIN (
+            SELECT vocabulary.lemma, MAX(user_vocabulary.mastery_level) AS mastery_level
+            FROM user_vocabulary
+            JOIN vocabulary ON user_vocabulary.vocabulary_id = vocabulary.id
+            WHERE user_vocabulary.user_id = @user AND vocabulary.language_id = @language
+            GROUP BY vocabulary.lemma
+        ) deck ON deck.lemma = word_frequencies.lemma
+        WHERE word_frequencies.language_id = @language`,
+		map[string]interface{}{"user": userID, "language": languageID, "mastered": learningMasteryLevel},
+	).Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	if row.ListSize == 0 {
+		return nil, errors.New("no frequency list for this language")
+	}
+
+	return &CoverageEstimate{
+		LanguageID:                languageID,
+		ListSize:                  row.ListSize,
+		KnownWords:                row.KnownWords,
+		LearningWords:             row.LearningWords,
+		Coverage:                  percentage(row.Known, row.Total),
+		CoverageIncludingLearning: percentage(row.Known+row.Learning, row.Total),
+	}, nil
+}
+
+// GetUsefulWords returns the most frequent words the user does not have yet
+func (s *Service) GetUsefulWords(ctx context.Context, userID string, languageID, limit int) ([]UsefulWord, error) {
+	var total float64
+	err := s.db.Model(&WordFrequency{}).
+		Select("COALESCE(SUM("+frequencyWeightSQL+"), 0)").
+		Where("language_id = ?", languageID).
+		Scan(&total).Error
+	if err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return nil, errors.New("no frequency list for this language")
+	}
+
+	var rows []struct {
+		Word         string
+		Lemma        string
+		Rank         int
+		Weight       float64
+		VocabularyID string
+		Translation  string
+	}
+	err = s.db.Table("word_frequencies").
+		Select("word_frequencies.word, word_frequencies.lemma, word_frequencies.rank, "+frequencyWeightSQL+" AS weight, "+
+			"COALESCE(CAST(vocabulary.id AS text), '') AS vocabulary_id, COALESCE(vocabulary.translation, '') AS translation").
+		Joins("LEFT JOIN vocabulary ON vocabulary.language_id = word_frequencies.language_id AND vocabulary.lemma = word_frequencies.lemma AND vocabulary.sense = ''").
+		Where("word_frequencies.language_id = ?", languageID).
+		Where(`NOT EXISTS (
+            SELECT 1 FROM user_vocabulary
+            JOIN vocabulary known ON user_vocabulary.vocabulary_id = known.id
+            WHERE user_vocabulary.user_id = ? AND known.language_id = word_frequencies.language_id AND known.lemma = word_frequencies.lemma
+        )`, userID).
+		Order("word_frequencies.rank ASC").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	words := make([]UsefulWord, 0, len(rows))
+	for _, row := range rows {
+		words = append(words, UsefulWord{
+			Word:         row.Word,
+			Lemma:        row.Lemma,
+			Rank:         row.Rank,
+			CoverageGain: roundTo(row.Weight/total*100, 4),
+			VocabularyID: row.VocabularyID,
+			Translation:  row.Translation,
+		})
+	}
+
+	return words, nil
+}
+
+// percentage returns part as a percentage of total rounded to two decimals
+func percentage(part, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return roundTo(part/total*100, 2)
+}
+
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
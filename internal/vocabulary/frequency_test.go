@@ -0,0 +1,59 @@
+package vocabulary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFrequencyList(t *testing.T) {
+	entries, err := parseFrequencyList(2, "subtitles", "word,count\nde,500\nla 300\nDe,20\n\nque\t250\n")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "de", entries[0].Lemma)
+	assert.Equal(t, 1, entries[0].Rank)
+	assert.Equal(t, int64(520), entries[0].Count)
+	assert.Equal(t, "que", entries[2].Lemma)
+	assert.Equal(t, 3, entries[2].Rank)
+
+	t.Run("RanksOnly", func(t *testing.T) {
+		entries, err := parseFrequencyList(2, "", "the 100\nof\nand 80")
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		for _, entry := range entries {
+			assert.Zero(t, entry.Count)
+		}
+	})
+
+	t.Run("InvalidCount", func(t *testing.T) {
+		_, err := parseFrequencyList(2, "", "the,100\nof,many")
+		assert.Error(t, err)
+	})
+}
+
+func TestMergeFrequencyLemmas(t *testing.T) {
+	entries := []WordFrequency{
+		{Lemma: "de", Word: "de", Rank: 1, Count: 500},
+		{Lemma: "perros", Word: "perros", Rank: 2, Count: 300},
+		{Lemma: "casa", Word: "casa", Rank: 3, Count: 200},
+		{Lemma: "perro", Word: "Perro", Rank: 4, Count: 100},
+		{Lemma: "fue", Word: "Fue", Rank: 5, Count: 50},
+	}
+	lemmas := []string{"de", "perro", "casa", "perro", "ir"}
+
+	merged := mergeFrequencyLemmas(entries, lemmas)
+	require.Len(t, merged, 4)
+
+	// Inflections join the first entry of their lemma, which takes the
+	// lemma's own spelling
+	assert.Equal(t, WordFrequency{Lemma: "perro", Word: "Perro", Rank: 2, Count: 400}, merged[1])
+	assert.Equal(t, 3, merged[2].Rank)
+	assert.Equal(t, WordFrequency{Lemma: "ir", Word: "Ir", Rank: 4, Count: 50}, merged[3])
+}
+
+func TestPercentage(t *testing.T) {
+	assert.Equal(t, 85.71, percentage(6, 7))
+	assert.Zero(t, percentage(1, 0))
+}
@@ -0,0 +1,503 @@
+package vocabulary
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Job types and statuses
+const (
+	JobTypeImport       = "import"
+	JobTypeExport       = "export"
+	JobTypeListDelivery = "list_delivery" // Queued when a word is added to a list with subscribers
+
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+const (
+	jobProgressBatchSize = 100
+	jobExportPageSize    = 500
+	jobPollInterval      = 5 * time.Second
+	jobHeartbeatInterval = time.Minute
+	jobStaleAfter        = 10 * time.Minute
+)
+
+// errJobNotFinished is returned when the result of a job is requested before
+// the job has completed
+var errJobNotFinished = errors.New("job has not finished yet")
+
+// Job represents an asynchronous import or export of a user's vocabulary, or
+// the delivery of a word added to a list to the list's subscribers
+type Job struct {
+	ID          string     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      string     `json:"user_id" gorm:"not null;index"`
+	Type        string     `json:"type" gorm:"not null"` // import, export, list_delivery
+	Status      string     `json:"status" gorm:"not null;default:'pending';index"`
+	LanguageID  int        `json:"language_id" gorm:"not null"`
+	Format      string     `json:"format" gorm:"not null"`
+	FilterID    string     `json:"filter_id,omitempty"` // Smart filter limiting an export
+	Payload     string     `json:"-" gorm:"type:text"`  // Import data and options
+	Total       int        `json:"total" gorm:"default:0"`
+	Processed   int        `json:"processed" gorm:"default:0"`
+	Result      string     `json:"-" gorm:"type:text"` // Export file or import summary
+	Error       string     `json:"error,omitempty"`
+	WorkerID    string     `json:"-" gorm:"index"` // Worker process holding the job while running
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+type CreateJobRequest struct {
+	Type       string        `json:"type" validate:"required,oneof=import export"`
+	LanguageID int           `json:"language_id" validate:"required"`
+	Format     string        `json:"format" validate:"required,oneof=csv json anki"`
+	FilterID   string        `json:"filter_id" validate:"omitempty,uuid"` // Export only entries matching this smart filter
+	Data       string        `json:"data"`
+	Options    ImportOptions `json:"options"`
+}
+
+type JobStatusResponse struct {
+	Job
+	Progress     float64       `json:"progress"` // percentage
+	ImportResult *ImportResult `json:"import_result,omitempty"`
+	DownloadURL  string        `json:"download_url,omitempty"`
+}
+
+// importJobPayload is the persisted input of an import job
+type importJobPayload struct {
+	Data    string        `json:"data"`
+	Options ImportOptions `json:"options"`
+}
+
+// jobWorkers holds the in-process worker pool state
+type jobWorkers struct {
+	id   string // Identifies this process as the owner of the jobs it runs
+	wake chan struct{}
+	once sync.Once
+}
+
+// CreateJob stores a new job and wakes the worker pool
+func (s *Service) CreateJob(ctx context.Context, userID string, req CreateJobRequest) (*Job, error) {
+	job := Job{
+		UserID:     userID,
+		Type:       req.Type,
+		Status:     JobStatusPending,
+		LanguageID: req.LanguageID,
+		Format:     req.Format,
+		FilterID:   req.FilterID,
+	}
+
+	switch req.Type {
+	case JobTypeImport:
+		if req.Data == "" {
+			return nil, errors.New("data is required for import jobs")
+		}
+		payload, err := json.Marshal(importJobPayload{Data: req.Data, Options: req.Options})
+		if err != nil {
+			return nil, err
+		}
+		job.Payload = string(payload)
+	case JobTypeExport:
+		if req.Format != "json" && req.Format != "csv" {
+			return nil, errors.New("unsupported export format")
+		}
+		if req.FilterID != "" {
+			if _, err := s.loadSmartFilter(userID, req.FilterID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	s.notifyJobWorkers()
+	return &job, nil
+}
+
+// GetJob returns a job owned by the user together with its progress
+func (s *Service) GetJob(ctx context.Context, userID, jobID string) (*JobStatusResponse, error) {
+	var job Job
+	err := s.db.Where("id = ? AND user_id = ?", jobID, userID).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("job not found")
+		}
+		return nil, err
+	}
+
+	return newJobStatusResponse(job), nil
+}
+
+// GetJobs returns the user's most recent jobs
+func (s *Service) GetJobs(ctx context.Context, userID string, limit, offset int) ([]JobStatusResponse, int64, error) {
+	var jobs []Job
+	var total int64
+
+	query := s.db.Model(&Job{}).Where("user_id = ?", userID)
+	query.Count(&total)
+
+	err := query.Omit("payload", "result").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&jobs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]JobStatusResponse, 0, len(jobs))
+	for _, job := range jobs {
+		responses = append(responses, *newJobStatusResponse(job))
+	}
+
+	return responses, total, nil
+}
+
+// GetJobResult returns the downloadable output of a finished export job
+func (s *Service) GetJobResult(ctx context.Context, userID, jobID string) (*Job, error) {
+	var job Job
+	err := s.db.Where("id = ? AND user_id = ?", jobID, userID).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("job not found")
+		}
+		return nil, err
+	}
+
+	if job.Type == JobTypeListDelivery {
+		return nil, errors.New("job has no downloadable result")
+	}
+	if job.Status != JobStatusCompleted {
+		return nil, errJobNotFinished
+	}
+
+	return &job, nil
+}
+
+// StartJobWorkers launches the worker pool. Jobs left pending, or running
+// without a heartbeat for jobStaleAfter, are picked up again; jobs still
+// held by another live instance are left alone.
+func (s *Service) StartJobWorkers(ctx context.Context, workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	s.jobs.once.Do(func() {
+		s.jobs.id = newJobWorkerID()
+		s.jobs.wake = make(chan struct{}, workers)
+
+		if err := s.requeueStaleJobs(jobStaleAfter); err != nil {
+			log.Printf("Failed to requeue interrupted jobs: %v", err)
+		}
+
+		for i := 0; i < workers; i++ {
+			go s.runJobWorker(ctx)
+		}
+	})
+}
+
+func (s *Service) notifyJobWorkers() {
+	if s.jobs.wake == nil {
+		return
+	}
+
+	select {
+	case s.jobs.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Service) runJobWorker(ctx context.Context) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			job, err := s.claimNextJob()
+			if err != nil {
+				log.Printf("Failed to claim job: %v", err)
+				break
+			}
+			if job == nil {
+				break
+			}
+			s.processJob(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.jobs.wake:
+		case <-ticker.C:
+			if err := s.requeueStaleJobs(jobStaleAfter); err != nil {
+				log.Printf("Failed to requeue stale jobs: %v", err)
+			}
+			if err := s.subscribeNewGroupMembers(); err != nil {
+				log.Printf("Failed to subscribe new study group members: %v", err)
+			}
+		}
+	}
+}
+
+// requeueStaleJobs moves running jobs whose worker has not sent a heartbeat
+// within staleAfter back to pending
+func (s *Service) requeueStaleJobs(staleAfter time.Duration) error {
+	return s.db.Model(&Job{}).
+		Where("status = ? AND updated_at <= ?", JobStatusRunning, time.Now().Add(-staleAfter)).
+		Updates(map[string]interface{}{
+			"status":    JobStatusPending,
+			"worker_id": "",
+		}).Error
+}
+
+// claimNextJob atomically marks the oldest pending job as running and owned
+// by this process
+func (s *Service) claimNextJob() (*Job, error) {
+	var jobs []Job
+	err := s.db.Raw(`
+        UPDATE jobs SET status = ?, worker_id = ?, updated_at = NOW(), started_at = COALESCE(started_at, NOW())
+        WHERE id = (
+            SELECT id FROM jobs
+            WHERE status = ?
+            ORDER BY created_at ASC
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING *
+    `, JobStatusRunning, s.jobs.id, JobStatusPending).Scan(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+
+	return &jobs[0], nil
+}
+
+// ownedJob scopes a query to a job while this process still holds it, so a
+// worker that lost the job to a requeue can't overwrite the new owner's state
+func (s *Service) ownedJob(jobID string) *gorm.DB {
+	return s.db.Model(&Job{}).Where("id = ? AND worker_id = ?", jobID, s.jobs.id)
+}
+
+// renewJobLease refreshes the heartbeat of a running job and reports whether
+// this process still holds it
+func (s *Service) renewJobLease(jobID string) (bool, error) {
+	result := s.ownedJob(jobID).
+		Where("status = ?", JobStatusRunning).
+		Update("updated_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// keepJobLease sends heartbeats until ctx is done and cancels the job once
+// another worker has taken it over
+func (s *Service) keepJobLease(ctx context.Context, cancel context.CancelFunc, jobID string) {
+	ticker := time.NewTicker(jobHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			owned, err := s.renewJobLease(jobID)
+			if err != nil {
+				log.Printf("Failed to renew lease on job %s: %v", jobID, err)
+				continue
+			}
+			if !owned {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (s *Service) processJob(ctx context.Context, job *Job) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.keepJobLease(jobCtx, cancel, job.ID)
+
+	var err error
+
+	switch job.Type {
+	case JobTypeImport:
+		err = s.runImportJob(jobCtx, job)
+	case JobTypeExport:
+		err = s.runExportJob(jobCtx, job)
+	case JobTypeListDelivery:
+		err = s.runListDeliveryJob(jobCtx, job)
+	default:
+		err = fmt.Errorf("unknown job type: %s", job.Type)
+	}
+
+	// Shutting down: hand the job back so it resumes on the next start
+	if ctx.Err() != nil {
+		s.ownedJob(job.ID).Updates(map[string]interface{}{
+			"status":    JobStatusPending,
+			"worker_id": "",
+		})
+		return
+	}
+
+	// The job was requeued and claimed by another worker
+	if jobCtx.Err() != nil {
+		log.Printf("Job %s was taken over by another worker", job.ID)
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":       JobStatusCompleted,
+		"completed_at": now,
+	}
+	if err != nil {
+		updates["status"] = JobStatusFailed
+		updates["error"] = err.Error()
+	}
+
+	if err := s.ownedJob(job.ID).Updates(updates).Error; err != nil {
+		log.Printf("Failed to finish job %s: %v", job.ID, err)
+	}
+}
+
+// runImportJob imports the payload row by row, persisting progress so that an
+// interrupted job resumes where it stopped
+func (s *Service) runImportJob(ctx context.Context, job *Job) error {
+	var payload importJobPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return fmt.Errorf("invalid job payload: %v", err)
+	}
+
+	items, err := parseImportData(job.Format, payload.Data)
+	if err != nil {
+		return err
+	}
+
+	result := &ImportResult{Errors: make([]string, 0)}
+	if job.Result != "" {
+		json.Unmarshal([]byte(job.Result), result)
+	}
+	result.Total = len(items)
+
+	if err := s.saveImportProgress(job.ID, job.Processed, result); err != nil {
+		return err
+	}
+
+	for i := job.Processed; i < len(items); i++ {
+		if ctx.Err() != nil {
+			return s.saveImportProgress(job.ID, i, result)
+		}
+
+		s.importVocabularyItem(ctx, job.UserID, job.LanguageID, items[i], payload.Options, result)
+
+		processed := i + 1
+		if processed%jobProgressBatchSize == 0 || processed == len(items) {
+			if err := s.saveImportProgress(job.ID, processed, result); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) saveImportProgress(jobID string, processed int, result *ImportResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	return s.ownedJob(jobID).Updates(map[string]interface{}{
+		"total":     result.Total,
+		"processed": processed,
+		"result":    string(data),
+	}).Error
+}
+
+// runExportJob pages through the user's whole vocabulary and stores the file
+func (s *Service) runExportJob(ctx context.Context, job *Job) error {
+	criteria, languageID, err := s.exportScope(job.UserID, job.LanguageID, job.FilterID)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	countQuery := s.userVocabularyQuery(job.UserID, languageID)
+	if criteria != nil {
+		countQuery = applySmartFilter(countQuery, *criteria, time.Now(), "user_vocabulary")
+	}
+	countQuery.Model(&UserVocabulary{}).Count(&total)
+	s.ownedJob(job.ID).Updates(map[string]interface{}{
+		"total":     total,
+		"processed": 0,
+	})
+
+	vocab, err := s.getAllUserVocabulary(ctx, job.UserID, languageID, criteria, func(loaded int) {
+		s.ownedJob(job.ID).Update("processed", loaded)
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := s.formatExport(vocab, job.Format)
+	if err != nil {
+		return err
+	}
+
+	return s.ownedJob(job.ID).Update("result", data).Error
+}
+
+func newJobStatusResponse(job Job) *JobStatusResponse {
+	response := &JobStatusResponse{Job: job}
+
+	if job.Total > 0 {
+		response.Progress = float64(job.Processed) / float64(job.Total) * 100
+	} else if job.Status == JobStatusCompleted {
+		response.Progress = 100
+	}
+
+	if job.Type == JobTypeImport && job.Result != "" {
+		var result ImportResult
+		if err := json.Unmarshal([]byte(job.Result), &result); err == nil {
+			response.ImportResult = &result
+		}
+	}
+
+	if job.Type == JobTypeExport && job.Status == JobStatusCompleted {
+		response.DownloadURL = fmt.Sprintf("/api/v1/vocabulary/jobs/%s/download", job.ID)
+	}
+
+	return response
+}
+
+// newJobWorkerID returns a random identifier for this process's worker pool
+func newJobWorkerID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("worker-%d", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(b)
+}
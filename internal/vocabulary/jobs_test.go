@@ -0,0 +1,222 @@
+package vocabulary
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockService returns a service backed by sqlmock, running jobs as the
+// worker pool "worker-a"
+func newMockService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	require.NoError(t, err)
+
+	return &Service{db: db, jobs: jobWorkers{id: "worker-a"}}, mock
+}
+
+// timeNear matches a time argument within a second of want
+type timeNear struct {
+	want time.Time
+}
+
+func (m timeNear) Match(v driver.Value) bool {
+	got, ok := v.(time.Time)
+	if !ok {
+		return false
+	}
+	diff := got.Sub(m.want)
+	return diff > -time.Second && diff < time.Second
+}
+
+func TestClaimNextJob(t *testing.T) {
+	claimQuery := regexp.QuoteMeta("UPDATE jobs SET status = $1, worker_id = $2") +
+		`(?s).*` + regexp.QuoteMeta("FOR UPDATE SKIP LOCKED") + `.*RETURNING \*`
+
+	t.Run("ClaimsOldestPendingJob", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(claimQuery).
+			WithArgs(JobStatusRunning, "worker-a", JobStatusPending).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type", "status", "worker_id", "processed"}).
+				AddRow("job-1", JobTypeImport, JobStatusRunning, "worker-a", 200))
+
+		job, err := service.claimNextJob()
+		require.NoError(t, err)
+		require.NotNil(t, job)
+		assert.Equal(t, "job-1", job.ID)
+		assert.Equal(t, "worker-a", job.WorkerID)
+		assert.Equal(t, 200, job.Processed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NoPendingJobs", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(claimQuery).
+			WithArgs(JobStatusRunning, "worker-a", JobStatusPending).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		job, err := service.claimNextJob()
+		assert.NoError(t, err)
+		assert.Nil(t, job)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(claimQuery).WillReturnError(errors.New("connection reset"))
+
+		job, err := service.claimNextJob()
+		assert.Error(t, err)
+		assert.Nil(t, job)
+	})
+}
+
+func TestRequeueStaleJobs(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "jobs" SET "status"=$1,"worker_id"=$2,"updated_at"=$3 WHERE status = $4 AND updated_at <= $5`)).
+		WithArgs(JobStatusPending, "", sqlmock.AnyArg(), JobStatusRunning, timeNear{time.Now().Add(-jobStaleAfter)}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Only jobs without a heartbeat for jobStaleAfter go back to pending, so
+	// jobs still running on another instance are left alone
+	assert.NoError(t, service.requeueStaleJobs(jobStaleAfter))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRenewJobLease(t *testing.T) {
+	leaseQuery := regexp.QuoteMeta(`UPDATE "jobs" SET "updated_at"=$1 WHERE (id = $2 AND worker_id = $3) AND status = $4`)
+
+	t.Run("StillOwned", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectExec(leaseQuery).
+			WithArgs(sqlmock.AnyArg(), "job-1", "worker-a", JobStatusRunning).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		owned, err := service.renewJobLease("job-1")
+		assert.NoError(t, err)
+		assert.True(t, owned)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("TakenOver", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectExec(leaseQuery).
+			WithArgs(sqlmock.AnyArg(), "job-1", "worker-a", JobStatusRunning).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		owned, err := service.renewJobLease("job-1")
+		assert.NoError(t, err)
+		assert.False(t, owned)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestProcessJobFinishesOnlyOwnedJobs(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "jobs" SET "completed_at"=$1,"error"=$2,"status"=$3,"updated_at"=$4 WHERE id = $5 AND worker_id = $6`)).
+		WithArgs(sqlmock.AnyArg(), "unknown job type: bogus", JobStatusFailed, sqlmock.AnyArg(), "job-1", "worker-a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service.processJob(context.Background(), &Job{ID: "job-1", Type: "bogus"})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetJobResultNotFinished(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "jobs" WHERE id = $1 AND user_id = $2`)).
+		WithArgs("job-1", "user-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "status"}).
+			AddRow("job-1", "user-1", JobStatusRunning))
+
+	job, err := service.GetJobResult(context.Background(), "user-1", "job-1")
+	assert.Nil(t, job)
+	assert.ErrorIs(t, err, errJobNotFinished)
+}
+
+func TestParseImportData(t *testing.T) {
+	t.Run("CSV", func(t *testing.T) {
+		data := "Word, Translation, Definition, Example\n" +
+			"perro, dog, a domestic animal, El perro ladra\n" +
+			"\n" +
+			"gato, cat\n" +
+			"incomplete\n"
+
+		items, err := parseImportData("csv", data)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, AddVocabularyRequest{
+			Word:            "perro",
+			Translation:     "dog",
+			Definition:      "a domestic animal",
+			ExampleSentence: "El perro ladra",
+		}, items[0])
+		assert.Equal(t, AddVocabularyRequest{Word: "gato", Translation: "cat"}, items[1])
+	})
+
+	t.Run("CSVHeaderAliases", func(t *testing.T) {
+		items, err := parseImportData("csv", "back,front\nhouse,casa")
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "casa", items[0].Word)
+		assert.Equal(t, "house", items[0].Translation)
+	})
+
+	t.Run("CSVMissingColumns", func(t *testing.T) {
+		_, err := parseImportData("csv", "word,definition\nperro,animal")
+		assert.Error(t, err)
+
+		_, err = parseImportData("csv", "word,translation")
+		assert.Error(t, err)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		items, err := parseImportData("json", `[{"word":"perro","translation":"dog"}]`)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "perro", items[0].Word)
+
+		_, err = parseImportData("json", "not json")
+		assert.Error(t, err)
+	})
+
+	t.Run("UnsupportedFormats", func(t *testing.T) {
+		_, err := parseImportData("anki", "")
+		assert.Error(t, err)
+
+		_, err = parseImportData("xml", "")
+		assert.Error(t, err)
+	})
+}
+
+func TestNewJobStatusResponse(t *testing.T) {
+	running := newJobStatusResponse(Job{Type: JobTypeImport, Status: JobStatusRunning, Total: 400, Processed: 100})
+	assert.Equal(t, 25.0, running.Progress)
+	assert.Empty(t, running.DownloadURL)
+
+	emptyExport := newJobStatusResponse(Job{ID: "job-1", Type: JobTypeExport, Status: JobStatusCompleted})
+	assert.Equal(t, 100.0, emptyExport.Progress)
+	assert.Equal(t, "/api/v1/vocabulary/jobs/job-1/download", emptyExport.DownloadURL)
+
+	imported := newJobStatusResponse(Job{Type: JobTypeImport, Status: JobStatusCompleted, Result: `{"total":2,"imported":1}`})
+	require.NotNil(t, imported.ImportResult)
+	assert.Equal(t, 2, imported.ImportResult.Total)
+}
@@ -0,0 +1,139 @@
+package vocabulary
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Leech handling
+const (
+	LeechTag = "leech"
+
+	LeechActionSuspend = "suspend" // tag and suspend the card
+	LeechActionTag     = "tag"     // only tag the entry
+
+	defaultLeechThreshold = 8
+)
+
+type CardActionRequest struct {
+	CardIDs []string `json:"card_ids" validate:"required,min=1,max=1000,dive,uuid"`
+}
+
+// isLeech reports whether a card that just lapsed should be flagged. Like
+// Anki, a card is flagged when it reaches the threshold and again every half
+// threshold after that, so unsuspended leeches resurface if they keep failing.
+func isLeech(lapses, threshold int) bool {
+	if threshold <= 0 || lapses < threshold {
+		return false
+	}
+
+	step := threshold / 2
+	if step < 1 {
+		step = 1
+	}
+
+	return (lapses-threshold)%step == 0
+}
+
+// handleLapse tags the entry and, depending on the user's leech action,
+// suspends the card once it turns into a leech. It reports whether the entry's
+// tags changed.
+func (s *Service) handleLapse(userID string, userVocab *UserVocabulary, state *SRSState) bool {
+	config, err := s.getUserSRSConfig(context.Background(), userID)
+	if err != nil {
+		config = &s.srsConfig // Fallback to default
+	}
+
+	if !isLeech(state.LapseCount, config.LeechThreshold) {
+		return false
+	}
+
+	if config.LeechAction != LeechActionTag {
+		state.Suspended = true
+	}
+
+	if userVocab.HasTag(LeechTag) {
+		return false
+	}
+
+	userVocab.SetTags(append(userVocab.GetTags(), LeechTag))
+	return true
+}
+
+// GetLeeches returns the user's cards that lapsed at least as often as the
+// leech threshold, most lapses first, whether or not they are suspended. There
+// are none when the user disabled leech detection.
+func (s *Service) GetLeeches(ctx context.Context, userID string, languageID int) ([]ReviewCard, error) {
+	config, err := s.getUserSRSConfig(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := config.LeechThreshold
+	if threshold <= 0 {
+		return []ReviewCard{}, nil
+	}
+
+	leeches, err := s.findCards(userID, QueueOptions{LanguageID: languageID}, func(query *gorm.DB, table string) *gorm.DB {
+		return query.Where(table+".lapse_count >= ?", threshold)
+	}, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(leeches, func(i, j int) bool {
+		return leeches[i].LapseCount > leeches[j].LapseCount
+	})
+
+	return leeches, nil
+}
+
+// SuspendCards suspends or unsuspends recognition and extra cards by card ID
+func (s *Service) SuspendCards(ctx context.Context, userID string, cardIDs []string, suspended bool) (*BulkOperationResult, error) {
+	return s.updateCards(userID, cardIDs, map[string]interface{}{"suspended": suspended})
+}
+
+// BuryCards hides cards from queues until the start of the user's next day
+func (s *Service) BuryCards(ctx context.Context, userID string, cardIDs []string) (*BulkOperationResult, error) {
+	location := s.resolveTimezone(userID, "")
+	now := time.Now().In(location)
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, location)
+
+	return s.updateCards(userID, cardIDs, map[string]interface{}{"buried_until": tomorrow})
+}
+
+// updateCards applies updates to the user's cards whichever table holds them
+func (s *Service) updateCards(userID string, cardIDs []string, updates map[string]interface{}) (*BulkOperationResult, error) {
+	result := &BulkOperationResult{
+		Total:  len(cardIDs),
+		Errors: make([]string, 0),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		recognition := tx.Model(&UserVocabulary{}).Where("id IN ? AND user_id = ?", cardIDs, userID).Updates(updates)
+		if recognition.Error != nil {
+			return recognition.Error
+		}
+
+		extra := tx.Model(&VocabularyCard{}).Where("id IN ? AND user_id = ?", cardIDs, userID).Updates(updates)
+		if extra.Error != nil {
+			return extra.Error
+		}
+
+		result.Processed = int(recognition.RowsAffected + extra.RowsAffected)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Failed = result.Total - result.Processed
+	if result.Failed > 0 {
+		result.Errors = append(result.Errors, "some cards were not found")
+	}
+
+	return result, nil
+}
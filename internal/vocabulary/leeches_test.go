@@ -0,0 +1,55 @@
+package vocabulary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLeech(t *testing.T) {
+	assert.False(t, isLeech(7, 8))
+	assert.True(t, isLeech(8, 8))
+	assert.False(t, isLeech(9, 8))
+	assert.True(t, isLeech(12, 8))
+	assert.True(t, isLeech(16, 8))
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert.False(t, isLeech(100, 0))
+	})
+
+	t.Run("SmallThreshold", func(t *testing.T) {
+		assert.True(t, isLeech(1, 1))
+		assert.True(t, isLeech(2, 1))
+	})
+}
+
+func TestUserVocabularyTags(t *testing.T) {
+	userVocab := &UserVocabulary{}
+	userVocab.SetTags([]string{" Verbs ", "travel", "verbs", "", "a,b"})
+
+	assert.Equal(t, "verbs,travel,a b", userVocab.Tags)
+	assert.Equal(t, []string{"verbs", "travel", "a b"}, userVocab.GetTags())
+	assert.True(t, userVocab.HasTag("VERBS"))
+	assert.False(t, userVocab.HasTag(LeechTag))
+}
+
+func TestSRSConfigRequestLeechThreshold(t *testing.T) {
+	current := &SRSConfig{LeechThreshold: 5, LeechAction: LeechActionTag}
+	req := UpdateSRSConfigRequest{MinEaseFactor: 1.3, MaxEaseFactor: 2.5, GraduationSteps: []int{1, 6}}
+
+	t.Run("KeptWhenOmitted", func(t *testing.T) {
+		config := req.toSRSConfig(current)
+		assert.Equal(t, 5, config.LeechThreshold)
+		assert.Equal(t, LeechActionTag, config.LeechAction)
+	})
+
+	t.Run("ZeroDisables", func(t *testing.T) {
+		disabled := 0
+		req := req
+		req.LeechThreshold = &disabled
+
+		config := req.toSRSConfig(current)
+		assert.Equal(t, 0, config.LeechThreshold)
+		assert.False(t, isLeech(100, config.LeechThreshold))
+	})
+}
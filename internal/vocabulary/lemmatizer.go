@@ -0,0 +1,513 @@
+package vocabulary
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Lemmatizer reduces inflected forms of a language to their dictionary form
+type Lemmatizer interface {
+	// Lemmas returns the possible dictionary forms of a word normalised with
+	// NormalizeLemma, most likely first, without the word itself. Exact
+	// reports that the first one is known for certain, e.g. from a table of
+	// irregular forms, rather than guessed by suffix rules.
+	Lemmas(word string) (candidates []string, exact bool)
+}
+
+// lemmatizers holds the lemmatiser of each language code
+var lemmatizers = map[string]Lemmatizer{
+	"es": spanishLemmatizer,
+	"fr": frenchLemmatizer,
+	"de": germanLemmatizer,
+	"it": italianLemmatizer,
+	"pt": portugueseLemmatizer,
+}
+
+// RegisterLemmatizer sets the lemmatiser of a language, replacing the
+// built-in one. It is meant to be called at start-up.
+func RegisterLemmatizer(languageCode string, lemmatizer Lemmatizer) {
+	lemmatizers[languageCode] = lemmatizer
+}
+
+// lemmatizerFor returns the lemmatiser of a language, or nil when it has none
+func lemmatizerFor(languageCode string) Lemmatizer {
+	return lemmatizers[languageCode]
+}
+
+// suffixRule maps words ending in suffix (and starting with prefix, if set)
+// to candidate lemmas by swapping the affixes for each of endings
+type suffixRule struct {
+	prefix  string
+	suffix  string
+	endings []string
+	minStem int // Shortest stem in runes the rule applies to, defaults to 3
+}
+
+// ruleLemmatizer looks words up in a table of irregular forms and otherwise
+// guesses lemmas from suffix rules. Guesses are only used once confirmed by
+// the frequency list or dictionary (see pickLemma), so the rules may
+// over-generate.
+type ruleLemmatizer struct {
+	irregular map[string]string
+	rules     []suffixRule
+	variants  func(candidate string) []string // Extra spellings of a guess, e.g. without umlauts
+}
+
+func (l *ruleLemmatizer) Lemmas(word string) ([]string, bool) {
+	if lemma, ok := l.irregular[word]; ok {
+		if lemma == word {
+			return nil, true
+		}
+		return []string{lemma}, true
+	}
+
+	candidates := make([]string, 0)
+	seen := map[string]bool{word: true}
+	add := func(candidate string) {
+		if !seen[candidate] {
+			seen[candidate] = true
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	for _, rule := range l.rules {
+		if !strings.HasSuffix(word, rule.suffix) || !strings.HasPrefix(word, rule.prefix) {
+			continue
+		}
+		stem := strings.TrimPrefix(strings.TrimSuffix(word, rule.suffix), rule.prefix)
+		minStem := rule.minStem
+		if minStem == 0 {
+			minStem = 3
+		}
+		if utf8.RuneCountInString(stem) < minStem {
+			continue
+		}
+
+		for _, ending := range rule.endings {
+			candidate := stem + ending
+			add(candidate)
+			if l.variants != nil {
+				for _, variant := range l.variants(candidate) {
+					add(variant)
+				}
+			}
+		}
+	}
+
+	return candidates, false
+}
+
+// irregularForms inverts a table of lemmas and their irregular forms
+func irregularForms(table map[string][]string) map[string]string {
+	forms := make(map[string]string)
+	for lemma, inflections := range table {
+		forms[lemma] = lemma
+		for _, form := range inflections {
+			if _, ok := forms[form]; !ok {
+				forms[form] = lemma
+			}
+		}
+	}
+	return forms
+}
+
+var spanishLemmatizer = &ruleLemmatizer{
+	irregular: irregularForms(map[string][]string{
+		"ser":    {"soy", "eres", "es", "somos", "sois", "son", "era", "eras", "éramos", "eran", "fui", "fuiste", "fue", "fuimos", "fueron", "sido", "siendo", "sea", "sean", "seré", "será"},
+		"estar":  {"estoy", "estás", "está", "estamos", "están", "estaba", "estuve", "estuvo", "estuvieron", "esté"},
+		"ir":     {"voy", "vas", "va", "vamos", "vais", "van", "iba", "ibas", "íbamos", "iban", "ido", "yendo", "vaya"},
+		"haber":  {"he", "has", "ha", "hemos", "han", "había", "habían", "hubo", "hay", "haya"},
+		"tener":  {"tengo", "tienes", "tiene", "tienen", "tuve", "tuvo", "tuvieron", "tendré", "tenga"},
+		"hacer":  {"hago", "haces", "hace", "hacen", "hice", "hizo", "hicieron", "hecho", "haré", "haga"},
+		"decir":  {"digo", "dices", "dice", "dicen", "dije", "dijo", "dijeron", "dicho", "diciendo", "diré", "diga"},
+		"poder":  {"puedo", "puedes", "puede", "pueden", "pude", "pudo", "pudieron", "podré", "pueda", "pudiendo"},
+		"querer": {"quiero", "quieres", "quiere", "quieren", "quise", "quiso", "quisieron", "querré", "quiera"},
+		"saber":  {"sé", "sabes", "sabe", "saben", "supe", "supo", "supieron", "sabré", "sepa"},
+		"venir":  {"vengo", "vienes", "viene", "vienen", "vine", "vino", "vinieron", "viniendo", "vendré", "venga"},
+		"poner":  {"pongo", "pones", "pone", "ponen", "puse", "puso", "pusieron", "puesto", "pondré", "ponga"},
+		"salir":  {"salgo", "saldré", "salga"},
+		"dar":    {"doy", "das", "da", "dan", "di", "dio", "dieron", "dé"},
+		"ver":    {"veo", "ves", "ve", "ven", "vi", "vio", "vieron", "visto", "veía"},
+		"dormir": {"duermo", "duermes", "duerme", "duermen", "durmió", "durmieron", "durmiendo"},
+	}),
+	rules: []suffixRule{
+		{suffix: "iendo", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "yendo", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "ando", endings: []string{"ar"}, minStem: 2},
+		{suffix: "ábamos", endings: []string{"ar"}, minStem: 2},
+		{suffix: "íamos", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "aron", endings: []string{"ar"}, minStem: 2},
+		{suffix: "ieron", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "aban", endings: []string{"ar"}, minStem: 2},
+		{suffix: "abas", endings: []string{"ar"}, minStem: 2},
+		{suffix: "aba", endings: []string{"ar"}, minStem: 2},
+		{suffix: "ían", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "ías", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "ía", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "ados", endings: []string{"ar"}, minStem: 2},
+		{suffix: "adas", endings: []string{"ar"}, minStem: 2},
+		{suffix: "ado", endings: []string{"ar"}, minStem: 2},
+		{suffix: "ada", endings: []string{"ar"}, minStem: 2},
+		{suffix: "idos", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "idas", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "ido", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "ida", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "amos", endings: []string{"ar"}, minStem: 2},
+		{suffix: "emos", endings: []string{"er"}, minStem: 2},
+		{suffix: "imos", endings: []string{"ir"}, minStem: 2},
+		{suffix: "ió", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "ó", endings: []string{"ar"}, minStem: 2},
+		{suffix: "é", endings: []string{"ar"}, minStem: 2},
+		{suffix: "an", endings: []string{"ar"}, minStem: 2},
+		{suffix: "en", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "as", endings: []string{"a", "ar"}, minStem: 2},
+		{suffix: "es", endings: []string{"", "e", "er", "ir"}, minStem: 2},
+		{suffix: "ones", endings: []string{"ón"}, minStem: 2},
+		{suffix: "os", endings: []string{"o"}, minStem: 2},
+		{suffix: "a", endings: []string{"ar", "o"}, minStem: 2},
+		{suffix: "e", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "o", endings: []string{"ar", "er", "ir"}, minStem: 2},
+		{suffix: "s", endings: []string{""}},
+	},
+}
+
+var frenchLemmatizer = &ruleLemmatizer{
+	irregular: irregularForms(map[string][]string{
+		"être":    {"suis", "es", "est", "sommes", "êtes", "sont", "été", "étais", "était", "étaient", "serai", "sera", "sois", "soit", "fut"},
+		"avoir":   {"ai", "as", "a", "avons", "avez", "ont", "eu", "avais", "avait", "avaient", "aurai", "aura", "aie", "ait"},
+		"aller":   {"vais", "vas", "va", "allons", "allez", "vont", "allé", "allée", "allait", "irai", "ira", "aille"},
+		"faire":   {"fais", "fait", "faisons", "faites", "font", "faisait", "ferai", "fera", "fasse"},
+		"pouvoir": {"peux", "peut", "pouvons", "pouvez", "peuvent", "pu", "pouvait", "pourrai", "pourra", "puisse"},
+		"vouloir": {"veux", "veut", "voulons", "voulez", "veulent", "voulu", "voulait", "voudrai", "voudrais"},
+		"savoir":  {"sais", "sait", "savons", "savez", "savent", "su", "savait", "saurai", "sache"},
+		"dire":    {"dis", "dit", "disons", "dites", "disent", "disait"},
+		"venir":   {"viens", "vient", "venons", "venez", "viennent", "venu", "venue", "venait", "viendrai"},
+		"prendre": {"prends", "prend", "prenons", "prenez", "prennent", "pris", "prise", "prenait"},
+		"voir":    {"vois", "voit", "voyons", "voyez", "voient", "vu", "vue", "voyait", "verrai"},
+		"devoir":  {"dois", "doit", "devons", "devez", "doivent", "dû", "devait", "devrai"},
+	}),
+	rules: []suffixRule{
+		{suffix: "issant", endings: []string{"ir"}, minStem: 2},
+		{suffix: "ant", endings: []string{"er", "ir", "re"}, minStem: 2},
+		{suffix: "aient", endings: []string{"er", "ir", "re"}, minStem: 2},
+		{suffix: "ais", endings: []string{"er", "ir", "re"}, minStem: 2},
+		{suffix: "ait", endings: []string{"er", "ir", "re"}, minStem: 2},
+		{suffix: "issons", endings: []string{"ir"}, minStem: 2},
+		{suffix: "issez", endings: []string{"ir"}, minStem: 2},
+		{suffix: "issent", endings: []string{"ir"}, minStem: 2},
+		{suffix: "ons", endings: []string{"er", "re"}, minStem: 2},
+		{suffix: "ez", endings: []string{"er", "re"}, minStem: 2},
+		{suffix: "ent", endings: []string{"er", "re"}, minStem: 2},
+		{suffix: "erai", endings: []string{"er"}, minStem: 2},
+		{suffix: "era", endings: []string{"er"}, minStem: 2},
+		{suffix: "ées", endings: []string{"er"}, minStem: 2},
+		{suffix: "ée", endings: []string{"er"}, minStem: 2},
+		{suffix: "és", endings: []string{"er"}, minStem: 2},
+		{suffix: "é", endings: []string{"er"}, minStem: 2},
+		{suffix: "aux", endings: []string{"al"}, minStem: 2},
+		{suffix: "es", endings: []string{"er", "e", ""}, minStem: 2},
+		{suffix: "e", endings: []string{"er", ""}, minStem: 3},
+		{suffix: "is", endings: []string{"ir"}, minStem: 2},
+		{suffix: "it", endings: []string{"ir"}, minStem: 2},
+		{suffix: "s", endings: []string{""}},
+		{suffix: "x", endings: []string{""}},
+	},
+}
+
+var germanLemmatizer = &ruleLemmatizer{
+	irregular: irregularForms(map[string][]string{
+		"sein":     {"bin", "bist", "ist", "sind", "seid", "war", "warst", "waren", "wart", "gewesen", "wäre"},
+		"haben":    {"habe", "hast", "hat", "habt", "hatte", "hattest", "hatten", "gehabt", "hätte"},
+		"werden":   {"werde", "wirst", "wird", "werdet", "wurde", "wurden", "geworden", "würde"},
+		"gehen":    {"gehe", "gehst", "geht", "ging", "gingen", "gegangen"},
+		"kommen":   {"komme", "kommst", "kommt", "kam", "kamen", "gekommen"},
+		"sehen":    {"sehe", "siehst", "sieht", "sah", "sahen", "gesehen"},
+		"geben":    {"gebe", "gibst", "gibt", "gab", "gaben", "gegeben"},
+		"nehmen":   {"nehme", "nimmst", "nimmt", "nahm", "nahmen", "genommen"},
+		"essen":    {"esse", "isst", "aß", "aßen", "gegessen"},
+		"fahren":   {"fahre", "fährst", "fährt", "fuhr", "fuhren", "gefahren"},
+		"laufen":   {"laufe", "läufst", "läuft", "lief", "liefen", "gelaufen"},
+		"können":   {"kann", "kannst", "könnt", "konnte", "konnten", "gekonnt"},
+		"müssen":   {"muss", "musst", "müsst", "musste", "mussten"},
+		"wollen":   {"will", "willst", "wollt", "wollte", "wollten"},
+		"wissen":   {"weiß", "weißt", "wisst", "wusste", "wussten", "gewusst"},
+		"sprechen": {"spreche", "sprichst", "spricht", "sprach", "sprachen", "gesprochen"},
+	}),
+	rules: []suffixRule{
+		{prefix: "ge", suffix: "t", endings: []string{"en", "n"}, minStem: 2},
+		{prefix: "ge", suffix: "en", endings: []string{"en"}, minStem: 2},
+		{suffix: "test", endings: []string{"en"}, minStem: 2},
+		{suffix: "ten", endings: []string{"en"}, minStem: 2},
+		{suffix: "te", endings: []string{"en"}, minStem: 2},
+		{suffix: "est", endings: []string{"en"}, minStem: 2},
+		{suffix: "st", endings: []string{"en"}, minStem: 2},
+		{suffix: "et", endings: []string{"en"}, minStem: 2},
+		{suffix: "t", endings: []string{"en"}, minStem: 2},
+		{suffix: "ern", endings: []string{""}, minStem: 2},
+		{suffix: "er", endings: []string{""}, minStem: 3},
+		{suffix: "en", endings: []string{"", "e"}, minStem: 3},
+		{suffix: "e", endings: []string{"en", ""}, minStem: 2},
+		{suffix: "n", endings: []string{""}, minStem: 3},
+		{suffix: "s", endings: []string{""}, minStem: 3},
+	},
+	variants: func(candidate string) []string {
+		// Plurals such as Häuser and Äpfel umlaut the stem
+		plain := strings.NewReplacer("ä", "a", "ö", "o", "ü", "u").Replace(candidate)
+		if plain == candidate {
+			return nil
+		}
+		return []string{plain}
+	},
+}
+
+var italianLemmatizer = &ruleLemmatizer{
+	irregular: irregularForms(map[string][]string{
+		"essere": {"sono", "sei", "è", "siamo", "siete", "ero", "era", "erano", "fui", "fu", "stato", "stata", "sarò", "sarà", "sia"},
+		"avere":  {"ho", "hai", "ha", "abbiamo", "avete", "hanno", "avevo", "aveva", "ebbi", "ebbe", "avuto", "avrò", "abbia"},
+		"andare": {"vado", "vai", "va", "andiamo", "andate", "vanno", "andato", "andrò", "vada"},
+		"fare":   {"faccio", "fai", "fa", "facciamo", "fate", "fanno", "fatto", "facevo", "faceva", "farò", "feci", "fece"},
+		"dire":   {"dico", "dici", "dice", "diciamo", "dite", "dicono", "detto", "diceva", "dissi", "disse"},
+		"potere": {"posso", "puoi", "può", "possiamo", "potete", "possono", "potuto", "potrò"},
+		"volere": {"voglio", "vuoi", "vuole", "vogliamo", "volete", "vogliono", "voluto", "vorrei"},
+		"sapere": {"so", "sai", "sa", "sappiamo", "sapete", "sanno", "saputo", "seppi"},
+		"venire": {"vengo", "vieni", "viene", "veniamo", "venite", "vengono", "venuto", "verrò", "venni", "venne"},
+		"stare":  {"sto", "stai", "sta", "stiamo", "state", "stanno", "stavo", "stava"},
+		"dare":   {"do", "dai", "dà", "diamo", "date", "danno", "dato", "diedi", "diede"},
+	}),
+	rules: []suffixRule{
+		{suffix: "ando", endings: []string{"are"}, minStem: 2},
+		{suffix: "endo", endings: []string{"ere", "ire"}, minStem: 2},
+		{suffix: "avano", endings: []string{"are"}, minStem: 2},
+		{suffix: "evano", endings: []string{"ere"}, minStem: 2},
+		{suffix: "ivano", endings: []string{"ire"}, minStem: 2},
+		{suffix: "ava", endings: []string{"are"}, minStem: 2},
+		{suffix: "eva", endings: []string{"ere"}, minStem: 2},
+		{suffix: "iva", endings: []string{"ire"}, minStem: 2},
+		{suffix: "iamo", endings: []string{"are", "ere", "ire"}, minStem: 2},
+		{suffix: "ano", endings: []string{"are"}, minStem: 2},
+		{suffix: "ono", endings: []string{"ere", "ire"}, minStem: 2},
+		{suffix: "ato", endings: []string{"are"}, minStem: 2},
+		{suffix: "ata", endings: []string{"are"}, minStem: 2},
+		{suffix: "ati", endings: []string{"are"}, minStem: 2},
+		{suffix: "ate", endings: []string{"are"}, minStem: 2},
+		{suffix: "uto", endings: []string{"ere"}, minStem: 2},
+		{suffix: "uta", endings: []string{"ere"}, minStem: 2},
+		{suffix: "ito", endings: []string{"ire"}, minStem: 2},
+		{suffix: "ita", endings: []string{"ire"}, minStem: 2},
+		{suffix: "ò", endings: []string{"are"}, minStem: 2},
+		{suffix: "ì", endings: []string{"ire"}, minStem: 2},
+		{suffix: "a", endings: []string{"are", "o"}, minStem: 2},
+		{suffix: "i", endings: []string{"o", "e", "are", "ere", "ire"}, minStem: 2},
+		{suffix: "e", endings: []string{"a", "ere", "ire"}, minStem: 2},
+		{suffix: "o", endings: []string{"are", "ere", "ire"}, minStem: 2},
+	},
+}
+
+var portugueseLemmatizer = &ruleLemmatizer{
+	irregular: irregularForms(map[string][]string{
+		"ser":    {"sou", "és", "é", "somos", "são", "era", "eras", "eram", "fui", "foi", "fomos", "foram", "sido", "seja", "será"},
+		"estar":  {"estou", "estás", "está", "estamos", "estão", "estava", "estive", "esteve", "estiveram", "esteja"},
+		"ir":     {"vou", "vais", "vai", "vamos", "vão", "ia", "iam", "indo", "vá"},
+		"ter":    {"tenho", "tens", "tem", "temos", "têm", "tinha", "tinham", "tive", "teve", "tiveram", "tido", "tenha"},
+		"fazer":  {"faço", "fazes", "faz", "fazemos", "fazem", "fiz", "fez", "fizeram", "feito", "farei", "faça"},
+		"dizer":  {"digo", "dizes", "diz", "dizemos", "dizem", "disse", "disseram", "dito", "direi", "diga"},
+		"poder":  {"posso", "podes", "pode", "podemos", "podem", "pude", "pôde", "puderam", "possa"},
+		"querer": {"quero", "queres", "quer", "queremos", "querem", "quis", "quiseram", "queira"},
+		"saber":  {"sei", "sabes", "sabe", "sabemos", "sabem", "soube", "souberam", "saiba"},
+		"ver":    {"vejo", "vês", "vê", "vemos", "veem", "vi", "viu", "viram", "visto", "veja"},
+		"vir":    {"venho", "vens", "vem", "vêm", "vim", "veio", "vieram", "vindo", "venha"},
+		"dar":    {"dou", "dás", "dá", "damos", "dão", "dei", "deu", "deram", "dado", "dê"},
+	}),
+	rules: []suffixRule{
+		{suffix: "ando", endings: []string{"ar"}, minStem: 2},
+		{suffix: "endo", endings: []string{"er"}, minStem: 2},
+		{suffix: "indo", endings: []string{"ir"}, minStem: 2},
+		{suffix: "aram", endings: []string{"ar"}, minStem: 2},
+		{suffix: "eram", endings: []string{"er"}, minStem: 2},
+		{suffix: "iram", endings: []string{"ir"}, minStem: 2},
+		{suffix: "avam", endings: []string{"ar"}, minStem: 2},
+		{suffix: "ava", endings: []string{"ar"}, minStem: 2},
+		{suffix: "iam", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "ia", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "ados", endings: []string{"ar"}, minStem: 2},
+		{suffix: "adas", endings: []string{"ar"}, minStem: 2},
+		{suffix: "ado", endings: []string{"ar"}, minStem: 2},
+		{suffix: "ada", endings: []string{"ar"}, minStem: 2},
+		{suffix: "idos", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "idas", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "ido", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "ida", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "amos", endings: []string{"ar"}, minStem: 2},
+		{suffix: "emos", endings: []string{"er"}, minStem: 2},
+		{suffix: "imos", endings: []string{"ir"}, minStem: 2},
+		{suffix: "ou", endings: []string{"ar"}, minStem: 2},
+		{suffix: "eu", endings: []string{"er"}, minStem: 2},
+		{suffix: "iu", endings: []string{"ir"}, minStem: 2},
+		{suffix: "am", endings: []string{"ar"}, minStem: 2},
+		{suffix: "em", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "ões", endings: []string{"ão"}, minStem: 2},
+		{suffix: "ães", endings: []string{"ão"}, minStem: 2},
+		{suffix: "ns", endings: []string{"m"}, minStem: 2},
+		{suffix: "is", endings: []string{"l"}, minStem: 2},
+		{suffix: "es", endings: []string{"er", "ir", ""}, minStem: 2},
+		{suffix: "as", endings: []string{"ar", "a", "o"}, minStem: 2},
+		{suffix: "os", endings: []string{"o"}, minStem: 2},
+		{suffix: "a", endings: []string{"ar", "o"}, minStem: 2},
+		{suffix: "e", endings: []string{"er", "ir"}, minStem: 2},
+		{suffix: "o", endings: []string{"ar", "er", "ir"}, minStem: 2},
+		{suffix: "s", endings: []string{""}},
+	},
+}
+
+// lemmaCandidates returns the normalised word followed by the lemmas its
+// language's lemmatiser suggests. Phrases are left whole.
+func lemmaCandidates(languageCode, word string) (normalized string, candidates []string, exact bool) {
+	normalized = NormalizeLemma(word)
+	lemmatizer := lemmatizerFor(languageCode)
+	if lemmatizer == nil || normalized == "" || strings.ContainsFunc(normalized, unicode.IsSpace) {
+		return normalized, nil, false
+	}
+	candidates, exact = lemmatizer.Lemmas(normalized)
+	return normalized, candidates, exact
+}
+
+// lemmaDisplayWord spells a lemma for display the way the word was typed:
+// capitalised if the word was, as German nouns are
+func lemmaDisplayWord(lemma, word string) string {
+	first, _ := utf8.DecodeRuneInString(strings.TrimSpace(word))
+	if !unicode.IsUpper(first) {
+		return lemma
+	}
+	r, size := utf8.DecodeRuneInString(lemma)
+	return string(unicode.ToUpper(r)) + lemma[size:]
+}
+
+// pickLemma chooses the lemma of a word among the lemmatiser's guesses. A
+// guess found in the frequency list must be at least as frequent as the word
+// itself, since a dictionary form usually outranks each of its inflections
+// and this keeps base forms such as "casa" from becoming "casar"; the most
+// frequent such guess wins. Without frequency data a guess already in the
+// dictionary is used, unless the word is in it too.
+func pickLemma(word string, candidates []string, ranks map[string]int, inDictionary map[string]bool) string {
+	best, bestRank := "", 0
+	for _, candidate := range candidates {
+		rank := ranks[candidate]
+		if rank == 0 || (ranks[word] > 0 && rank > ranks[word]) {
+			continue
+		}
+		if best == "" || rank < bestRank {
+			best, bestRank = candidate, rank
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	if ranks[word] > 0 || inDictionary[word] {
+		return word
+	}
+	for _, candidate := range candidates {
+		if inDictionary[candidate] {
+			return candidate
+		}
+	}
+	return word
+}
+
+// resolveLemma returns the lemma a word is filed under in a language: the
+// lemmatiser's answer when it is certain, otherwise a guess confirmed by the
+// frequency list or dictionary, otherwise the word itself
+func (s *Service) resolveLemma(languageID int, word string) string {
+	return s.resolveLemmas(languageID, []string{word})[NormalizeLemma(word)]
+}
+
+// resolveLemmas resolves many words of a language at once, as resolveLemma
+// does, looking the guesses up in one go. The result is keyed by the words
+// normalised with NormalizeLemma.
+func (s *Service) resolveLemmas(languageID int, words []string) map[string]string {
+	code := s.languageCode(languageID)
+	lemmas := make(map[string]string, len(words))
+	guesses := make(map[string][]string)
+	forms := make([]string, 0)
+
+	for _, word := range words {
+		normalized, candidates, exact := lemmaCandidates(code, word)
+		if _, ok := lemmas[normalized]; ok {
+			continue
+		}
+		lemmas[normalized] = normalized
+		switch {
+		case len(candidates) == 0:
+		case exact:
+			lemmas[normalized] = candidates[0]
+		default:
+			guesses[normalized] = candidates
+			forms = append(append(forms, normalized), candidates...)
+		}
+	}
+	if len(guesses) == 0 {
+		return lemmas
+	}
+
+	ranks, err := s.frequencyRanks(languageID, forms)
+	if err != nil {
+		return lemmas
+	}
+	inDictionary, err := s.dictionaryLemmas(languageID, forms)
+	if err != nil {
+		return lemmas
+	}
+
+	for word, candidates := range guesses {
+		lemmas[word] = pickLemma(word, candidates, ranks, inDictionary)
+	}
+	return lemmas
+}
+
+// lemmaLookupBatch bounds the lemmas looked up per query
+const lemmaLookupBatch = 1000
+
+// frequencyRanks returns the frequency list rank of those of lemmas that are
+// listed
+func (s *Service) frequencyRanks(languageID int, lemmas []string) (map[string]int, error) {
+	ranks := make(map[string]int)
+	for start := 0; start < len(lemmas); start += lemmaLookupBatch {
+		var rows []struct {
+			Lemma string
+			Rank  int
+		}
+		batch := lemmas[start:min(start+lemmaLookupBatch, len(lemmas))]
+		if err := s.db.Model(&WordFrequency{}).Select("lemma, rank").Where("language_id = ? AND lemma IN ?", languageID, batch).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			ranks[row.Lemma] = row.Rank
+		}
+	}
+	return ranks, nil
+}
+
+// dictionaryLemmas reports which of lemmas the shared dictionary has
+func (s *Service) dictionaryLemmas(languageID int, lemmas []string) (map[string]bool, error) {
+	inDictionary := make(map[string]bool)
+	for start := 0; start < len(lemmas); start += lemmaLookupBatch {
+		var entries []string
+		batch := lemmas[start:min(start+lemmaLookupBatch, len(lemmas))]
+		if err := s.db.Model(&Vocabulary{}).Where("language_id = ? AND lemma IN ?", languageID, batch).Pluck("lemma", &entries).Error; err != nil {
+			return nil, err
+		}
+		for _, lemma := range entries {
+			inDictionary[lemma] = true
+		}
+	}
+	return inDictionary, nil
+}
+
+// requestLemma returns the lemma a word being added is filed under
+func (s *Service) requestLemma(languageID int, req AddVocabularyRequest) string {
+	if req.KeepInflection {
+		return NormalizeLemma(req.Word)
+	}
+	return s.resolveLemma(languageID, req.Word)
+}
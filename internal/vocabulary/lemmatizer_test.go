@@ -0,0 +1,101 @@
+package vocabulary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLemmaCandidates(t *testing.T) {
+	tests := []struct {
+		language string
+		word     string
+		lemma    string
+		exact    bool
+	}{
+		{"es", "corriendo", "correr", false},
+		{"es", "corrió", "correr", false},
+		{"es", "hablaron", "hablar", false},
+		{"es", "canciones", "canción", false},
+		{"es", "fue", "ser", true},
+		{"fr", "parlons", "parler", false},
+		{"fr", "chevaux", "cheval", false},
+		{"fr", "sont", "être", true},
+		{"de", "gemacht", "machen", false},
+		{"de", "Häuser", "haus", false},
+		{"de", "ging", "gehen", true},
+		{"it", "parlando", "parlare", false},
+		{"it", "libri", "libro", false},
+		{"it", "hanno", "avere", true},
+		{"pt", "falou", "falar", false},
+		{"pt", "canções", "canção", false},
+		{"pt", "tenho", "ter", true},
+	}
+
+	for _, tt := range tests {
+		_, candidates, exact := lemmaCandidates(tt.language, tt.word)
+		assert.Contains(t, candidates, tt.lemma, "%s %s", tt.language, tt.word)
+		assert.Equal(t, tt.exact, exact, "%s %s", tt.language, tt.word)
+	}
+
+	// Languages without a lemmatiser, phrases and lemmas of irregular verbs
+	_, candidates, _ := lemmaCandidates("en", "running")
+	assert.Empty(t, candidates)
+	_, candidates, _ = lemmaCandidates("es", "de nada")
+	assert.Empty(t, candidates)
+	_, candidates, exact := lemmaCandidates("es", "ser")
+	assert.Empty(t, candidates)
+	assert.True(t, exact)
+
+	// Short stems are left alone
+	_, candidates, _ = lemmaCandidates("es", "mes")
+	assert.NotContains(t, candidates, "me")
+}
+
+func TestPickLemma(t *testing.T) {
+	ranks := map[string]int{"casa": 200, "caso": 300, "casar": 5000, "correr": 3000, "corrió": 8000, "habla": 600, "hablar": 400}
+
+	// A more frequent guess wins over the word
+	assert.Equal(t, "correr", pickLemma("corrió", []string{"correr", "corrir"}, ranks, nil))
+	assert.Equal(t, "hablar", pickLemma("hablas", []string{"habla", "hablar"}, ranks, nil))
+	// Base forms outrank their guesses and stay
+	assert.Equal(t, "casa", pickLemma("casa", []string{"casar", "caso"}, ranks, nil))
+	// Unknown guesses are ignored
+	assert.Equal(t, "café", pickLemma("café", []string{"cafar"}, ranks, nil))
+
+	// Without frequency data the dictionary confirms guesses
+	dictionary := map[string]bool{"correr": true}
+	assert.Equal(t, "correr", pickLemma("corriendo", []string{"correr", "corrir"}, nil, dictionary))
+	dictionary["corriendo"] = true
+	assert.Equal(t, "corriendo", pickLemma("corriendo", []string{"correr", "corrir"}, nil, dictionary))
+}
+
+func TestIrregularFormsAreUnambiguous(t *testing.T) {
+	for code, lemmatizer := range lemmatizers {
+		rules, ok := lemmatizer.(*ruleLemmatizer)
+		if !ok {
+			continue
+		}
+		for form, lemma := range rules.irregular {
+			candidates, exact := rules.Lemmas(form)
+			assert.True(t, exact, "%s %s", code, form)
+			if form != lemma {
+				assert.Equal(t, []string{lemma}, candidates, "%s %s", code, form)
+			}
+		}
+	}
+}
+
+func TestLemmaDisplayWord(t *testing.T) {
+	assert.Equal(t, "Haus", lemmaDisplayWord("haus", "Häuser"))
+	assert.Equal(t, "correr", lemmaDisplayWord("correr", "corriendo"))
+}
+
+func TestUserVocabularyForms(t *testing.T) {
+	uv := &UserVocabulary{}
+	assert.True(t, uv.AddForm("corrió"))
+	assert.True(t, uv.AddForm("corriendo"))
+	assert.False(t, uv.AddForm("Corrió"))
+	assert.False(t, uv.AddForm(" "))
+	assert.Equal(t, []string{"corrió", "corriendo"}, uv.GetForms())
+}
@@ -0,0 +1,683 @@
+package vocabulary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// List roles, from most to least privileged. The owner is the list's UserID;
+// editors and viewers are VocabularyListMember rows.
+const (
+	ListRoleOwner  = "owner"
+	ListRoleEditor = "editor"
+	ListRoleViewer = "viewer"
+)
+
+var listRoleRank = map[string]int{
+	ListRoleViewer: 1,
+	ListRoleEditor: 2,
+	ListRoleOwner:  3,
+}
+
+// Study group roles from the social service allowed to attach lists
+var groupAdminRoles = []string{"admin", "moderator"}
+
+// VocabularyListMember gives another user editor or viewer access to a list
+type VocabularyListMember struct {
+	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ListID    string    `json:"list_id" gorm:"not null;uniqueIndex:idx_list_member"`
+	UserID    string    `json:"user_id" gorm:"not null;uniqueIndex:idx_list_member;index"`
+	Role      string    `json:"role" gorm:"not null;default:'viewer'"` // editor, viewer
+	AddedBy   string    `json:"added_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Computed fields
+	Username string `json:"username,omitempty" gorm:"-"`
+}
+
+// VocabularyListGroup attaches a list to a study group from the social
+// service. The group's active members are subscribed to the list, including
+// those who join after it was attached.
+type VocabularyListGroup struct {
+	ID              string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ListID          string    `json:"list_id" gorm:"not null;uniqueIndex:idx_list_group"`
+	GroupID         string    `json:"group_id" gorm:"not null;uniqueIndex:idx_list_group;index"`
+	AttachedBy      string    `json:"attached_by" gorm:"not null"`
+	MembersSyncedAt time.Time `json:"-" gorm:"not null;default:CURRENT_TIMESTAMP"` // Memberships changed after this are not subscribed yet
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type SetListMemberRequest struct {
+	Role string `json:"role" validate:"required,oneof=editor viewer"`
+}
+
+type AddListItemRequest struct {
+	VocabularyID string `json:"vocabulary_id" validate:"required,uuid"`
+}
+
+// ReorderVocabularyListRequest lists every word of the list in its new order
+type ReorderVocabularyListRequest struct {
+	VocabularyIDs []string `json:"vocabulary_ids" validate:"required,min=1,dive,required"`
+}
+
+type AttachListToGroupRequest struct {
+	GroupID string `json:"group_id" validate:"required,uuid"`
+}
+
+// GroupAttachResult reports how many group members were subscribed and how
+// many words they received
+type GroupAttachResult struct {
+	Attachment *VocabularyListGroup `json:"attachment"`
+	Subscribed int                  `json:"subscribed"`
+	Added      int                  `json:"added"`
+	Skipped    int                  `json:"skipped"`
+}
+
+// ListLearnerProgress is one learner's mastery of a list's words
+type ListLearnerProgress struct {
+	UserID         string     `json:"user_id"`
+	Username       string     `json:"username"`
+	Owner          bool       `json:"owner"`
+	GroupID        *string    `json:"group_id,omitempty"` // Study group the subscription came from
+	WordsInDeck    int        `json:"words_in_deck"`
+	NewWords       int        `json:"new_words"`
+	LearningWords  int        `json:"learning_words"`
+	MatureWords    int        `json:"mature_words"`
+	DueWords       int        `json:"due_words"`
+	AverageMastery float64    `json:"average_mastery"`
+	Completion     float64    `json:"completion"` // Percentage of the list's words that are mature
+	LastReviewedAt *time.Time `json:"last_reviewed_at,omitempty"`
+}
+
+// VocabularyListProgress shows how the owner and every subscriber are doing
+// on a list
+type VocabularyListProgress struct {
+	ListID            string                `json:"list_id"`
+	WordCount         int                   `json:"word_count"`
+	Learners          []ListLearnerProgress `json:"learners"`
+	AverageCompletion float64               `json:"average_completion"`
+	GeneratedAt       time.Time             `json:"generated_at"`
+}
+
+// listRoleAtLeast reports whether role grants at least the required access
+func listRoleAtLeast(role, required string) bool {
+	return role != "" && listRoleRank[role] >= listRoleRank[required]
+}
+
+// orderedListItems preloads list items in their list order
+func orderedListItems(db *gorm.DB) *gorm.DB {
+	return db.Order(`"order" ASC, created_at ASC`)
+}
+
+// listItemPositions checks that ids names every word of the list exactly
+// once and returns each word's new 1-based position
+func listItemPositions(items []VocabularyListItem, ids []string) (map[string]int, error) {
+	if len(ids) != len(items) {
+		return nil, fmt.Errorf("order must list all %d words of the list", len(items))
+	}
+
+	inList := make(map[string]bool, len(items))
+	for _, item := range items {
+		inList[item.VocabularyID] = true
+	}
+
+	positions := make(map[string]int, len(ids))
+	for i, id := range ids {
+		if !inList[id] {
+			return nil, fmt.Errorf("vocabulary %s is not in the list", id)
+		}
+		if _, seen := positions[id]; seen {
+			return nil, fmt.Errorf("vocabulary %s is listed more than once", id)
+		}
+		positions[id] = i + 1
+	}
+
+	return positions, nil
+}
+
+// summarizeListProgress fills in completion figures and orders learners from
+// most to least complete
+func summarizeListProgress(progress *VocabularyListProgress) {
+	total := 0.0
+	for i := range progress.Learners {
+		learner := &progress.Learners[i]
+		learner.Completion = percentage(float64(learner.MatureWords), float64(progress.WordCount))
+		total += learner.Completion
+	}
+	if len(progress.Learners) > 0 {
+		progress.AverageCompletion = roundTo(total/float64(len(progress.Learners)), 2)
+	}
+
+	sort.SliceStable(progress.Learners, func(i, j int) bool {
+		a, b := progress.Learners[i], progress.Learners[j]
+		if a.Completion != b.Completion {
+			return a.Completion > b.Completion
+		}
+		return a.Username < b.Username
+	})
+}
+
+// listAccess loads a list with the user's role on it. Public lists and lists
+// attached to one of the user's study groups can be viewed by anyone who can
+// see them.
+func (s *Service) listAccess(listID, userID string) (*VocabularyList, error) {
+	var list VocabularyList
+	if err := s.db.Where("id = ?", listID).First(&list).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("vocabulary list not found")
+		}
+		return nil, err
+	}
+
+	role, err := s.listRole(&list, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("vocabulary list not found")
+	}
+
+	list.Role = role
+	return &list, nil
+}
+
+// requireListRole loads a list the user holds at least the given role on
+func (s *Service) requireListRole(listID, userID, required string) (*VocabularyList, error) {
+	list, err := s.listAccess(listID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !listRoleAtLeast(list.Role, required) {
+		return nil, fmt.Errorf("%s access to this list required", required)
+	}
+	return list, nil
+}
+
+func (s *Service) listRole(list *VocabularyList, userID string) (string, error) {
+	if list.UserID == userID {
+		return ListRoleOwner, nil
+	}
+
+	var member VocabularyListMember
+	err := s.db.Where("list_id = ? AND user_id = ?", list.ID, userID).First(&member).Error
+	if err == nil {
+		return member.Role, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	if list.IsPublic {
+		return ListRoleViewer, nil
+	}
+
+	var groups int64
+	err = s.db.Model(&VocabularyListGroup{}).
+		Joins("JOIN group_memberships gm ON gm.group_id = vocabulary_list_groups.group_id").
+		Where("vocabulary_list_groups.list_id = ? AND gm.user_id = ? AND gm.status = ? AND gm.deleted_at IS NULL", list.ID, userID, "active").
+		Count(&groups).Error
+	if err != nil {
+		return "", err
+	}
+	if groups > 0 {
+		return ListRoleViewer, nil
+	}
+
+	return "", nil
+}
+
+// GetVocabularyListMembers returns the owner and every editor and viewer of
+// a list
+func (s *Service) GetVocabularyListMembers(ctx context.Context, userID, listID string) ([]VocabularyListMember, error) {
+	list, err := s.requireListRole(listID, userID, ListRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []VocabularyListMember
+	if err := s.db.Where("list_id = ?", list.ID).Order("created_at ASC").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	members = append([]VocabularyListMember{{
+		ListID:    list.ID,
+		UserID:    list.UserID,
+		Role:      ListRoleOwner,
+		CreatedAt: list.CreatedAt,
+		UpdatedAt: list.CreatedAt,
+	}}, members...)
+
+	userIDs := make([]string, len(members))
+	for i, member := range members {
+		userIDs[i] = member.UserID
+	}
+	usernames, err := s.usernames(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		members[i].Username = usernames[members[i].UserID]
+	}
+
+	return members, nil
+}
+
+// SetVocabularyListMember adds a user to a list as an editor or viewer, or
+// changes their role. Only the owner manages members.
+func (s *Service) SetVocabularyListMember(ctx context.Context, userID, listID, memberID string, req SetListMemberRequest) (*VocabularyListMember, error) {
+	list, err := s.requireListRole(listID, userID, ListRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	if memberID == list.UserID {
+		return nil, errors.New("the owner cannot be added as a member")
+	}
+
+	var users int64
+	if err := s.db.Table("users").Where("id = ?", memberID).Count(&users).Error; err != nil {
+		return nil, err
+	}
+	if users == 0 {
+		return nil, errors.New("user not found")
+	}
+
+	member := VocabularyListMember{ListID: list.ID, UserID: memberID, Role: req.Role, AddedBy: userID}
+	err = s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "list_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(&member).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Where("list_id = ? AND user_id = ?", list.ID, memberID).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveVocabularyListMember revokes a member's access. Owners remove anyone;
+// members may remove themselves.
+func (s *Service) RemoveVocabularyListMember(ctx context.Context, userID, listID, memberID string) error {
+	required := ListRoleOwner
+	if memberID == userID {
+		required = ListRoleViewer
+	}
+	list, err := s.requireListRole(listID, userID, required)
+	if err != nil {
+		return err
+	}
+
+	result := s.db.Where("list_id = ? AND user_id = ?", list.ID, memberID).Delete(&VocabularyListMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("member not found")
+	}
+	return nil
+}
+
+// ReorderVocabularyList sets the order of a list's words
+func (s *Service) ReorderVocabularyList(ctx context.Context, userID, listID string, req ReorderVocabularyListRequest) ([]VocabularyListItem, error) {
+	list, err := s.requireListRole(listID, userID, ListRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []VocabularyListItem
+	if err := s.db.Where("list_id = ?", list.ID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	positions, err := listItemPositions(items, req.VocabularyIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range items {
+			position := positions[item.VocabularyID]
+			if item.Order == position {
+				continue
+			}
+			if err := tx.Model(&VocabularyListItem{}).Where("id = ?", item.ID).UpdateColumn("order", position).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(list).UpdateColumn("updated_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items = nil
+	err = orderedListItems(s.db.Preload("Vocabulary").Where("list_id = ?", list.ID)).Find(&items).Error
+	return items, err
+}
+
+// GetVocabularyListProgress reports the mastery of the owner and every
+// subscriber, including study group members, on a list's words. Only the
+// owner and editors see it.
+func (s *Service) GetVocabularyListProgress(ctx context.Context, userID, listID string) (*VocabularyListProgress, error) {
+	list, err := s.requireListRole(listID, userID, ListRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	var wordCount int64
+	if err := s.db.Model(&VocabularyListItem{}).Where("list_id = ?", list.ID).Count(&wordCount).Error; err != nil {
+		return nil, err
+	}
+
+	var subscriptions []VocabularyListSubscription
+	if err := s.db.Where("list_id = ?", list.ID).Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+
+	learnerIDs := []string{list.UserID}
+	groups := make(map[string]*string, len(subscriptions))
+	for _, subscription := range subscriptions {
+		learnerIDs = append(learnerIDs, subscription.UserID)
+		groups[subscription.UserID] = subscription.GroupID
+	}
+
+	var rows []struct {
+		UserID         string
+		WordsInDeck    int
+		NewWords       int
+		LearningWords  int
+		MatureWords    int
+		DueWords       int
+		AverageMastery float64
+		LastReviewedAt *time.Time
+	}
+	err = s.db.Table("user_vocabulary uv").
+		Select(`uv.user_id,
+			COUNT(*) AS words_in_deck,
+			SUM(CASE WHEN uv.review_count = 0 THEN 1 ELSE 0 END) AS new_words,
+			SUM(CASE WHEN uv.review_count > 0 AND uv.mastery_level < ? THEN 1 ELSE 0 END) AS learning_words,
+			SUM(CASE WHEN uv.interval_days >= ? THEN 1 ELSE 0 END) AS mature_words,
+			SUM(CASE WHEN uv.next_review_at IS NULL OR uv.next_review_at <= ? THEN 1 ELSE 0 END) AS due_words,
+			AVG(uv.mastery_level) AS average_mastery,
+			MAX(uv.last_reviewed_at) AS last_reviewed_at`, learningMasteryLevel, matureIntervalDays, time.Now()).
+		Joins("JOIN vocabulary_list_items li ON li.vocabulary_id = uv.vocabulary_id").
+		Where("li.list_id = ? AND uv.user_id IN ?", list.ID, learnerIDs).
+		Group("uv.user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	usernames, err := s.usernames(learnerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &VocabularyListProgress{
+		ListID:      list.ID,
+		WordCount:   int(wordCount),
+		Learners:    make([]ListLearnerProgress, 0, len(learnerIDs)),
+		GeneratedAt: time.Now(),
+	}
+	byUser := make(map[string]int, len(learnerIDs))
+	for _, learnerID := range learnerIDs {
+		byUser[learnerID] = len(progress.Learners)
+		progress.Learners = append(progress.Learners, ListLearnerProgress{
+			UserID:   learnerID,
+			Username: usernames[learnerID],
+			Owner:    learnerID == list.UserID,
+			GroupID:  groups[learnerID],
+		})
+	}
+	for _, row := range rows {
+		learner := &progress.Learners[byUser[row.UserID]]
+		learner.WordsInDeck = row.WordsInDeck
+		learner.NewWords = row.NewWords
+		learner.LearningWords = row.LearningWords
+		learner.MatureWords = row.MatureWords
+		learner.DueWords = row.DueWords
+		learner.AverageMastery = roundTo(row.AverageMastery, 2)
+		learner.LastReviewedAt = row.LastReviewedAt
+	}
+
+	summarizeListProgress(progress)
+	return progress, nil
+}
+
+// AttachListToGroup lets a study group admin share a list with their group.
+// Every active member is subscribed and receives the list's words; members
+// who join later are subscribed by subscribeNewGroupMembers.
+func (s *Service) AttachListToGroup(ctx context.Context, userID, listID string, req AttachListToGroupRequest) (*GroupAttachResult, error) {
+	list, err := s.requireListRole(listID, userID, ListRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireGroupAdmin(req.GroupID, userID); err != nil {
+		return nil, err
+	}
+
+	// Memberships changing while the list is attached are picked up by the
+	// next sync
+	syncedAt := time.Now()
+	var memberIDs []string
+	err = s.db.Table("group_memberships").
+		Where("group_id = ? AND status = ? AND deleted_at IS NULL", req.GroupID, "active").
+		Pluck("user_id", &memberIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var vocabularyIDs []string
+	if err := s.db.Model(&VocabularyListItem{}).Where("list_id = ?", list.ID).Pluck("vocabulary_id", &vocabularyIDs).Error; err != nil {
+		return nil, err
+	}
+
+	attachment := VocabularyListGroup{ListID: list.ID, GroupID: req.GroupID, AttachedBy: userID, MembersSyncedAt: syncedAt}
+	result := &GroupAttachResult{Attachment: &attachment}
+	copied := &ListCopyResult{}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&attachment)
+		if created.Error != nil {
+			return created.Error
+		}
+		if created.RowsAffected == 0 {
+			return errors.New("list already attached to this group")
+		}
+
+		result.Subscribed, err = s.subscribeGroupMembers(tx, list, req.GroupID, memberIDs, vocabularyIDs, copied)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Added = copied.Added
+	result.Skipped = copied.Skipped
+	return result, nil
+}
+
+// subscribeGroupMembers subscribes the members of a study group, other than
+// the list's owner, to a list attached to the group and returns how many
+// were newly subscribed
+func (s *Service) subscribeGroupMembers(tx *gorm.DB, list *VocabularyList, groupID string, memberIDs, vocabularyIDs []string, result *ListCopyResult) (int, error) {
+	subscribed := 0
+	for _, memberID := range memberIDs {
+		if memberID == list.UserID {
+			continue
+		}
+		created, err := s.subscribeUser(tx, list, memberID, &groupID, vocabularyIDs, result)
+		if err != nil {
+			return subscribed, err
+		}
+		if created {
+			subscribed++
+		}
+	}
+	return subscribed, nil
+}
+
+// subscribeNewGroupMembers subscribes members who joined a study group, or
+// whose membership became active, after the group's lists were last synced.
+// Members who unsubscribed since are left alone.
+func (s *Service) subscribeNewGroupMembers() error {
+	changed := s.db.Table("group_memberships gm").
+		Select("1").
+		Where("gm.group_id = vocabulary_list_groups.group_id AND gm.status = ? AND gm.deleted_at IS NULL AND gm.updated_at > vocabulary_list_groups.members_synced_at", "active")
+
+	var attachments []VocabularyListGroup
+	if err := s.db.Where("EXISTS (?)", changed).Find(&attachments).Error; err != nil {
+		return err
+	}
+
+	for i := range attachments {
+		if err := s.syncGroupAttachment(&attachments[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncGroupAttachment subscribes the members of an attachment's group whose
+// membership changed since it was last synced
+func (s *Service) syncGroupAttachment(attachment *VocabularyListGroup) error {
+	var list VocabularyList
+	if err := s.db.Where("id = ?", attachment.ListID).First(&list).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	syncedAt := time.Now()
+	var memberIDs []string
+	err := s.db.Table("group_memberships").
+		Where("group_id = ? AND status = ? AND deleted_at IS NULL AND updated_at > ?", attachment.GroupID, "active", attachment.MembersSyncedAt).
+		Pluck("user_id", &memberIDs).Error
+	if err != nil {
+		return err
+	}
+
+	var vocabularyIDs []string
+	if err := s.db.Model(&VocabularyListItem{}).Where("list_id = ?", list.ID).Pluck("vocabulary_id", &vocabularyIDs).Error; err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.subscribeGroupMembers(tx, &list, attachment.GroupID, memberIDs, vocabularyIDs, &ListCopyResult{}); err != nil {
+			return err
+		}
+		return tx.Model(attachment).UpdateColumn("members_synced_at", syncedAt).Error
+	})
+}
+
+// DetachListFromGroup removes a list from a study group. The subscriptions
+// the attachment created end, unless the member gets the list through
+// another attached group; words already added are kept.
+func (s *Service) DetachListFromGroup(ctx context.Context, userID, listID, groupID string) error {
+	var list VocabularyList
+	if err := s.db.Where("id = ?", listID).First(&list).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("vocabulary list not found")
+		}
+		return err
+	}
+	if list.UserID != userID {
+		if err := s.requireGroupAdmin(groupID, userID); err != nil {
+			return err
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("list_id = ? AND group_id = ?", listID, groupID).Delete(&VocabularyListGroup{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("list is not attached to this group")
+		}
+
+		otherGroups := tx.Table("vocabulary_list_groups lg").
+			Select("gm.user_id").
+			Joins("JOIN group_memberships gm ON gm.group_id = lg.group_id").
+			Where("lg.list_id = ? AND gm.status = ? AND gm.deleted_at IS NULL", listID, "active")
+		removed := tx.Where("list_id = ? AND group_id = ? AND user_id NOT IN (?)", listID, groupID, otherGroups).
+			Delete(&VocabularyListSubscription{})
+		if removed.Error != nil {
+			return removed.Error
+		}
+		if removed.RowsAffected == 0 {
+			return nil
+		}
+
+		return tx.Model(&list).UpdateColumn("subscriber_count",
+			gorm.Expr("GREATEST(subscriber_count - ?, 0)", removed.RowsAffected)).Error
+	})
+}
+
+// GetGroupVocabularyLists returns the lists attached to a study group the
+// user is an active member of
+func (s *Service) GetGroupVocabularyLists(ctx context.Context, userID, groupID string) ([]PublicVocabularyList, error) {
+	var memberships int64
+	err := s.db.Table("group_memberships").
+		Where("group_id = ? AND user_id = ? AND status = ? AND deleted_at IS NULL", groupID, userID, "active").
+		Count(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	if memberships == 0 {
+		return nil, errors.New("study group not found")
+	}
+
+	var lists []VocabularyList
+	err = s.db.Joins("JOIN vocabulary_list_groups ON vocabulary_list_groups.list_id = vocabulary_lists.id").
+		Where("vocabulary_list_groups.group_id = ?", groupID).
+		Order("vocabulary_list_groups.created_at DESC").
+		Find(&lists).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return s.publicListViews(userID, lists)
+}
+
+// requireGroupAdmin checks the user administers the study group in the
+// social service
+func (s *Service) requireGroupAdmin(groupID, userID string) error {
+	var admins int64
+	err := s.db.Table("group_memberships gm").
+		Joins("JOIN study_groups sg ON sg.id = gm.group_id AND sg.deleted_at IS NULL").
+		Where("gm.group_id = ? AND gm.user_id = ? AND gm.status = ? AND gm.role IN ? AND gm.deleted_at IS NULL",
+			groupID, userID, "active", groupAdminRoles).
+		Count(&admins).Error
+	if err != nil {
+		return err
+	}
+	if admins == 0 {
+		return errors.New("only study group admins can manage the group's lists")
+	}
+	return nil
+}
+
+// usernames looks up the usernames of the given users
+func (s *Service) usernames(userIDs []string) (map[string]string, error) {
+	var users []struct {
+		ID       string
+		Username string
+	}
+	if err := s.db.Table("users").Select("id, username").Where("id IN ?", userIDs).Scan(&users).Error; err != nil {
+		return nil, err
+	}
+
+	usernames := make(map[string]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+	return usernames, nil
+}
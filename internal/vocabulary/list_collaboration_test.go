@@ -0,0 +1,85 @@
+package vocabulary
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListRoleAtLeast(t *testing.T) {
+	assert.True(t, listRoleAtLeast(ListRoleOwner, ListRoleEditor))
+	assert.True(t, listRoleAtLeast(ListRoleEditor, ListRoleEditor))
+	assert.True(t, listRoleAtLeast(ListRoleViewer, ListRoleViewer))
+	assert.False(t, listRoleAtLeast(ListRoleViewer, ListRoleEditor))
+	assert.False(t, listRoleAtLeast(ListRoleEditor, ListRoleOwner))
+	assert.False(t, listRoleAtLeast("", ListRoleViewer))
+}
+
+func TestListItemPositions(t *testing.T) {
+	items := []VocabularyListItem{{VocabularyID: "a", Order: 1}, {VocabularyID: "b", Order: 2}, {VocabularyID: "c", Order: 3}}
+
+	positions, err := listItemPositions(items, []string{"c", "a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"c": 1, "a": 2, "b": 3}, positions)
+
+	_, err = listItemPositions(items, []string{"c", "a"})
+	assert.Error(t, err)
+	_, err = listItemPositions(items, []string{"c", "a", "a"})
+	assert.Error(t, err)
+	_, err = listItemPositions(items, []string{"c", "a", "d"})
+	assert.Error(t, err)
+}
+
+func TestSummarizeListProgress(t *testing.T) {
+	progress := &VocabularyListProgress{
+		WordCount: 40,
+		Learners: []ListLearnerProgress{
+			{Username: "ana", MatureWords: 10},
+			{Username: "ben", MatureWords: 30},
+			{Username: "cai"},
+		},
+	}
+
+	summarizeListProgress(progress)
+	assert.Equal(t, "ben", progress.Learners[0].Username)
+	assert.Equal(t, 75.0, progress.Learners[0].Completion)
+	assert.Equal(t, 25.0, progress.Learners[1].Completion)
+	assert.Equal(t, 0.0, progress.Learners[2].Completion)
+	assert.Equal(t, 33.33, progress.AverageCompletion)
+
+	empty := &VocabularyListProgress{}
+	summarizeListProgress(empty)
+	assert.Equal(t, 0.0, empty.AverageCompletion)
+}
+
+func TestSyncGroupAttachment(t *testing.T) {
+	service, mock := newMockService(t)
+	lastSync := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	attachment := &VocabularyListGroup{ID: "attachment-1", ListID: "list-1", GroupID: "group-1", MembersSyncedAt: lastSync}
+
+	mock.ExpectQuery(`SELECT \* FROM "vocabulary_lists" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow("list-1", "owner"))
+	mock.ExpectQuery(`SELECT "user_id" FROM "group_memberships" WHERE .*updated_at > \$3`).
+		WithArgs("group-1", "active", lastSync).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("owner").AddRow("newcomer"))
+	mock.ExpectQuery(`SELECT "vocabulary_id" FROM "vocabulary_list_items"`).
+		WillReturnRows(sqlmock.NewRows([]string{"vocabulary_id"}).AddRow("vocab-1"))
+	mock.ExpectBegin()
+	// Only the member who joined since is subscribed, never the owner
+	mock.ExpectQuery(`INSERT INTO "vocabulary_list_subscriptions" .* ON CONFLICT DO NOTHING`).
+		WithArgs("list-1", "newcomer", "group-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("subscription-1"))
+	expectAddToDeck(mock, "newcomer", "vocab-1", true)
+	mock.ExpectExec(`UPDATE "vocabulary_lists" SET "subscriber_count"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "vocabulary_list_groups" SET "members_synced_at"=\$1 WHERE "id" = \$2`).
+		WithArgs(sqlmock.AnyArg(), "attachment-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, service.syncGroupAttachment(attachment))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
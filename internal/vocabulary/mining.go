@@ -0,0 +1,455 @@
+package vocabulary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"unicode"
+)
+
+const defaultMiningMinLength = 2
+
+// MiningOptions tune which transcript words are suggested
+type MiningOptions struct {
+	MinLength int // Shortest word in characters, ignored for ideographs
+	Limit     int
+}
+
+// MinedWord is a word of an episode transcript the user does not know yet
+type MinedWord struct {
+	Word            string `json:"word"` // Most frequent spelling in the transcript
+	Lemma           string `json:"lemma"`
+	Frequency       int    `json:"frequency"`
+	ContextSentence string `json:"context_sentence"`
+	StartMs         int    `json:"start_ms"`
+	EndMs           int    `json:"end_ms"`
+
+	// Set when the shared dictionary already has the word
+	VocabularyID string `json:"vocabulary_id,omitempty"`
+	Translation  string `json:"translation,omitempty"`
+}
+
+// EpisodeMiningResult lists the unknown words of an episode, most frequent
+// first
+type EpisodeMiningResult struct {
+	EpisodeID    string      `json:"episode_id"`
+	ContentID    string      `json:"content_id"`
+	LanguageID   int         `json:"language_id"`
+	TotalTokens  int         `json:"total_tokens"`
+	UniqueWords  int         `json:"unique_words"`
+	KnownWords   int         `json:"known_words"`
+	UnknownWords int         `json:"unknown_words"`
+	Words        []MinedWord `json:"words"`
+}
+
+type MinedWordInput struct {
+	Word            string   `json:"word" validate:"required,min=1,max=255"`
+	Sense           string   `json:"sense" validate:"max=100"`
+	Translation     string   `json:"translation" validate:"max=255"` // Defaults to the dictionary translation
+	Definition      string   `json:"definition"`
+	ContextSentence string   `json:"context_sentence"` // Defaults to the first transcript line containing the word
+	PersonalNote    string   `json:"personal_note"`
+	Tags            []string `json:"tags" validate:"omitempty,max=20,dive,min=1,max=50"`
+}
+
+type AddMinedWordsRequest struct {
+	Words []MinedWordInput `json:"words" validate:"required,min=1,max=100,dive"`
+}
+
+// episodeSource identifies the content and language of an episode
+type episodeSource struct {
+	EpisodeID  string
+	ContentID  string
+	LanguageID int
+}
+
+type transcriptLine struct {
+	Text    string
+	StartMs int
+	EndMs   int
+}
+
+// tokenize splits transcript text into words of letters and combining marks.
+// Hyphens and, in English, apostrophes between letters are kept inside words. Languages written without spaces yield whole
+// runs, so their transcripts should be segmented upstream.
+func tokenize(languageCode, text string) []string {
+	runes := []rune(text)
+	tokens := make([]string, 0)
+	start := -1
+
+	isWordRune := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsMark(r)
+	}
+	isJoiner := func(r rune) bool {
+		switch r {
+		case '-', '‐':
+			return true
+		case '\'', '’':
+			return languageCode == "en"
+		}
+		return false
+	}
+
+	for i, r := range runes {
+		switch {
+		case isWordRune(r):
+			if start < 0 {
+				start = i
+			}
+		case start >= 0 && isJoiner(r) && i+1 < len(runes) && isWordRune(runes[i+1]):
+			// Part of the word
+		default:
+			if start >= 0 {
+				tokens = append(tokens, string(runes[start:i]))
+				start = -1
+			}
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, string(runes[start:]))
+	}
+
+	return tokens
+}
+
+// isIdeographic reports whether a word is written in a script where single
+// characters are words
+func isIdeographic(word string) bool {
+	for _, r := range word {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			return true
+		}
+	}
+	return false
+}
+
+// transcriptWords returns each word of a transcript once
+func transcriptWords(languageCode string, lines []transcriptLine) []string {
+	words := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		for _, token := range tokenize(languageCode, line.Text) {
+			if !seen[token] {
+				seen[token] = true
+				words = append(words, token)
+			}
+		}
+	}
+	return words
+}
+
+// mineTranscript counts the words of a transcript that are not in known,
+// keeping the first line each word appears in as its context. Words are
+// grouped under their entry in lemmas, keyed by normalised spelling, so
+// inflections count as one word; a word is known when its lemma or the
+// spelling itself is in known.
+func mineTranscript(languageCode string, lines []transcriptLine, lemmas map[string]string, known map[string]bool, minLength int) (words []MinedWord, totalTokens, uniqueWords, knownWords int) {
+	type candidate struct {
+		word     MinedWord
+		first    int
+		spelling map[string]int
+	}
+
+	candidates := make(map[string]*candidate)
+	seen := make(map[string]bool)
+	order := 0
+
+	for _, line := range lines {
+		for _, token := range tokenize(languageCode, line.Text) {
+			normalized := NormalizeLemma(token)
+			if normalized == "" {
+				continue
+			}
+			if len([]rune(normalized)) < minLength && !isIdeographic(normalized) {
+				continue
+			}
+			lemma := normalized
+			if resolved, ok := lemmas[normalized]; ok {
+				lemma = resolved
+			}
+
+			totalTokens++
+			isKnown := known[lemma] || known[normalized]
+			if !seen[lemma] {
+				seen[lemma] = true
+				if isKnown {
+					knownWords++
+				}
+			}
+			if isKnown {
+				continue
+			}
+
+			c, ok := candidates[lemma]
+			if !ok {
+				c = &candidate{
+					word: MinedWord{
+						Lemma:           lemma,
+						ContextSentence: line.Text,
+						StartMs:         line.StartMs,
+						EndMs:           line.EndMs,
+					},
+					first:    order,
+					spelling: make(map[string]int),
+				}
+				candidates[lemma] = c
+				order++
+			}
+			c.word.Frequency++
+			c.spelling[token]++
+		}
+	}
+
+	ranked := make([]*candidate, 0, len(candidates))
+	for _, c := range candidates {
+		// Prefer the most common spelling, lower case on ties
+		best := ""
+		for spelling, count := range c.spelling {
+			if best == "" || count > c.spelling[best] || (count == c.spelling[best] && spelling > best) {
+				best = spelling
+			}
+		}
+		c.word.Word = best
+		ranked = append(ranked, c)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].word.Frequency != ranked[j].word.Frequency {
+			return ranked[i].word.Frequency > ranked[j].word.Frequency
+		}
+		return ranked[i].first < ranked[j].first
+	})
+
+	words = make([]MinedWord, len(ranked))
+	for i, c := range ranked {
+		words[i] = c.word
+	}
+
+	return words, totalTokens, len(seen), knownWords
+}
+
+// loadEpisodeSource finds the content and language of an episode
+func (s *Service) loadEpisodeSource(episodeID string) (*episodeSource, error) {
+	var source episodeSource
+	err := s.db.Table("content_episodes").
+		Select("content_episodes.id AS episode_id, content_episodes.content_id, contents.language_id").
+		Joins("JOIN contents ON contents.id = content_episodes.content_id").
+		Where("content_episodes.id = ? AND content_episodes.deleted_at IS NULL AND contents.deleted_at IS NULL", episodeID).
+		Scan(&source).Error
+	if err != nil {
+		return nil, err
+	}
+	if source.EpisodeID == "" {
+		return nil, errors.New("episode not found")
+	}
+	return &source, nil
+}
+
+// loadTranscript returns the transcript lines of an episode in playback
+// order. Episodes without a transcript use their subtitles in the language
+// of the content.
+func (s *Service) loadTranscript(source *episodeSource) ([]transcriptLine, error) {
+	var lines []transcriptLine
+	err := s.db.Table("transcript_segments").
+		Select("text, start_ms, end_ms").
+		Where("episode_id = ? AND track_id IS NULL", source.EpisodeID).
+		Order("position ASC").
+		Scan(&lines).Error
+	if err != nil || len(lines) > 0 {
+		return lines, err
+	}
+
+	err = s.db.Table("transcript_segments").
+		Select("transcript_segments.text, transcript_segments.start_ms, transcript_segments.end_ms").
+		Joins("JOIN subtitle_tracks ON subtitle_tracks.id = transcript_segments.track_id").
+		Where("subtitle_tracks.episode_id = ? AND subtitle_tracks.language_id = ?", source.EpisodeID, source.LanguageID).
+		Order("transcript_segments.position ASC").
+		Scan(&lines).Error
+	return lines, err
+}
+
+// knownLemmas returns the lemmas of the user's vocabulary in a language and
+// the inflected forms the words were added as, normalised
+func (s *Service) knownLemmas(userID string, languageID int) (map[string]bool, error) {
+	var rows []struct {
+		Lemma string
+		Forms string
+	}
+	err := s.db.Table("user_vocabulary").
+		Select("vocabulary.lemma, user_vocabulary.forms").
+		Joins("JOIN vocabulary ON user_vocabulary.vocabulary_id = vocabulary.id").
+		Where("user_vocabulary.user_id = ? AND vocabulary.language_id = ?", userID, languageID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		known[row.Lemma] = true
+		entry := UserVocabulary{Forms: row.Forms}
+		for _, form := range entry.GetForms() {
+			known[NormalizeLemma(form)] = true
+		}
+	}
+	return known, nil
+}
+
+// MineEpisode returns the words of an episode's transcript the user does not
+// have in their vocabulary, ranked by how often they occur
+func (s *Service) MineEpisode(ctx context.Context, userID, episodeID string, opts MiningOptions) (*EpisodeMiningResult, error) {
+	source, err := s.loadEpisodeSource(episodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := s.loadTranscript(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("episode has no transcript or subtitles in its language")
+	}
+
+	known, err := s.knownLemmas(userID, source.LanguageID)
+	if err != nil {
+		return nil, err
+	}
+
+	minLength := opts.MinLength
+	if minLength <= 0 {
+		minLength = defaultMiningMinLength
+	}
+
+	code := s.languageCode(source.LanguageID)
+	lemmas := s.resolveLemmas(source.LanguageID, transcriptWords(code, lines))
+	words, totalTokens, uniqueWords, knownWords := mineTranscript(code, lines, lemmas, known, minLength)
+
+	result := &EpisodeMiningResult{
+		EpisodeID:    source.EpisodeID,
+		ContentID:    source.ContentID,
+		LanguageID:   source.LanguageID,
+		TotalTokens:  totalTokens,
+		UniqueWords:  uniqueWords,
+		KnownWords:   knownWords,
+		UnknownWords: len(words),
+	}
+
+	if opts.Limit > 0 && len(words) > opts.Limit {
+		words = words[:opts.Limit]
+	}
+	if err := s.attachDictionaryEntries(source.LanguageID, words); err != nil {
+		return nil, err
+	}
+	result.Words = words
+
+	return result, nil
+}
+
+// attachDictionaryEntries fills in the shared dictionary entry of mined words
+// that other users have already added
+func (s *Service) attachDictionaryEntries(languageID int, words []MinedWord) error {
+	if len(words) == 0 {
+		return nil
+	}
+
+	lemmas := make([]string, len(words))
+	for i, word := range words {
+		lemmas[i] = word.Lemma
+	}
+
+	var entries []Vocabulary
+	err := s.db.Where("language_id = ? AND lemma IN ? AND sense = ''", languageID, lemmas).Find(&entries).Error
+	if err != nil {
+		return err
+	}
+
+	byLemma := make(map[string]Vocabulary, len(entries))
+	for _, entry := range entries {
+		byLemma[entry.Lemma] = entry
+	}
+
+	for i := range words {
+		if entry, ok := byLemma[words[i].Lemma]; ok {
+			words[i].VocabularyID = entry.ID
+			words[i].Translation = entry.Translation
+		}
+	}
+
+	return nil
+}
+
+// AddMinedWords adds words picked from an episode to the user's vocabulary,
+// recording the episode and its content as the source
+func (s *Service) AddMinedWords(ctx context.Context, userID, episodeID string, req AddMinedWordsRequest) (*BulkOperationResult, []UserVocabulary, error) {
+	source, err := s.loadEpisodeSource(episodeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines, err := s.loadTranscript(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	code := s.languageCode(source.LanguageID)
+	lemmas := s.resolveLemmas(source.LanguageID, transcriptWords(code, lines))
+	mined, _, _, _ := mineTranscript(code, lines, lemmas, nil, 1)
+	if err := s.attachDictionaryEntries(source.LanguageID, mined); err != nil {
+		return nil, nil, err
+	}
+	byLemma := make(map[string]MinedWord, len(mined))
+	for _, word := range mined {
+		byLemma[word.Lemma] = word
+	}
+
+	result := &BulkOperationResult{
+		Total:  len(req.Words),
+		Errors: make([]string, 0),
+	}
+	added := make([]UserVocabulary, 0, len(req.Words))
+
+	for _, input := range req.Words {
+		lemma := NormalizeLemma(input.Word)
+		if resolved, ok := lemmas[lemma]; ok {
+			lemma = resolved
+		}
+		minedWord := byLemma[lemma]
+
+		addReq := AddVocabularyRequest{
+			Word:            input.Word,
+			Sense:           input.Sense,
+			Translation:     input.Translation,
+			Definition:      input.Definition,
+			ContextSentence: input.ContextSentence,
+			PersonalNote:    input.PersonalNote,
+			SourceContentID: source.ContentID,
+			SourceEpisodeID: source.EpisodeID,
+			Tags:            input.Tags,
+		}
+		if addReq.Translation == "" {
+			addReq.Translation = minedWord.Translation
+		}
+		if addReq.ContextSentence == "" {
+			addReq.ContextSentence = minedWord.ContextSentence
+		}
+
+		if addReq.Translation == "" {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Failed to add %s: translation is required", input.Word))
+			continue
+		}
+
+		userVocab, err := s.AddVocabulary(ctx, userID, source.LanguageID, addReq)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("Failed to add %s: %v", input.Word, err))
+			continue
+		}
+
+		result.Processed++
+		added = append(added, *userVocab)
+	}
+
+	return result, added, nil
+}
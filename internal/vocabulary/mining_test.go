@@ -0,0 +1,69 @@
+package vocabulary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"Don't", "go", "to", "the", "well-known", "café"}, tokenize("en", "Don't go to the well-known café!"))
+	assert.Equal(t, []string{"l", "homme", "est", "là"}, tokenize("fr", "l'homme est là"))
+	assert.Empty(t, tokenize("en", "123 -- ..."))
+}
+
+func TestMineTranscript(t *testing.T) {
+	lines := []transcriptLine{
+		{Text: "Hola, ¿cómo estás?", StartMs: 0, EndMs: 1500},
+		{Text: "Estás muy bien, hola hola.", StartMs: 1500, EndMs: 3000},
+		{Text: "Y tú", StartMs: 3000, EndMs: 4000},
+	}
+	known := map[string]bool{"muy": true}
+
+	words, total, unique, knownWords := mineTranscript("es", lines, nil, known, 2)
+
+	assert.Equal(t, 9, total) // "Y" is too short
+	assert.Equal(t, 6, unique)
+	assert.Equal(t, 1, knownWords)
+
+	require.Len(t, words, 5)
+	assert.Equal(t, "hola", words[0].Lemma)
+	assert.Equal(t, 3, words[0].Frequency)
+	assert.Equal(t, "hola", words[0].Word)
+	assert.Equal(t, "Hola, ¿cómo estás?", words[0].ContextSentence)
+
+	assert.Equal(t, "estás", words[1].Lemma)
+	assert.Equal(t, 2, words[1].Frequency)
+
+	// Ties keep transcript order
+	assert.Equal(t, []string{"cómo", "bien", "tú"}, []string{words[2].Lemma, words[3].Lemma, words[4].Lemma})
+	assert.Equal(t, 1500, words[3].StartMs)
+}
+
+func TestMineTranscriptGroupsInflections(t *testing.T) {
+	lines := []transcriptLine{
+		{Text: "Los perros corren"},
+		{Text: "El perro come"},
+	}
+	lemmas := map[string]string{"perros": "perro", "corren": "correr", "come": "comer"}
+	// "correr" is in the deck and "los" was added as a form of another entry
+	known := map[string]bool{"correr": true, "los": true}
+
+	words, total, unique, knownWords := mineTranscript("es", lines, lemmas, known, 2)
+
+	assert.Equal(t, 6, total)
+	assert.Equal(t, 5, unique)
+	assert.Equal(t, 2, knownWords)
+
+	require.Len(t, words, 3)
+	assert.Equal(t, "perro", words[0].Lemma)
+	assert.Equal(t, 2, words[0].Frequency)
+	assert.Equal(t, "Los perros corren", words[0].ContextSentence)
+	assert.Equal(t, []string{"el", "comer"}, []string{words[1].Lemma, words[2].Lemma})
+}
+
+func TestTranscriptWords(t *testing.T) {
+	lines := []transcriptLine{{Text: "hola hola"}, {Text: "Hola amigo"}}
+	assert.Equal(t, []string{"hola", "Hola", "amigo"}, transcriptWords("es", lines))
+}
@@ -10,40 +10,92 @@ import (
 	"gorm.io/gorm"
 )
 
+// Vocabulary is a canonical dictionary entry shared by every user, keyed by
+// language, normalised lemma and sense
 type Vocabulary struct {
 	ID                    string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	Word                  string    `json:"word" gorm:"not null"`
+	Lemma                 string    `json:"lemma" gorm:"not null;default:''"` // Dictionary form of Word, see resolveLemma
+	Sense                 string    `json:"sense" gorm:"not null;default:''"` // Optional meaning discriminator
 	LanguageID            int       `json:"language_id" gorm:"not null"`
 	Translation           string    `json:"translation"`
 	PhoneticTranscription string    `json:"phonetic_transcription"`
+	Romanization          string    `json:"romanization" gorm:"not null;default:''"` // Romaji/pinyin search key for ja and zh
 	Definition            string    `json:"definition"`
 	ExampleSentence       string    `json:"example_sentence"`
+	AudioURL              string    `json:"audio_url"`
 	FrequencyRank         int       `json:"frequency_rank"`
 	DifficultyLevel       string    `json:"difficulty_level"`
 	CreatedBy             string    `json:"created_by"`
 	CreatedAt             time.Time `json:"created_at"`
 }
 
-type UserVocabulary struct {
-	ID              string     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID          string     `json:"user_id" gorm:"not null"`
-	VocabularyID    string     `json:"vocabulary_id" gorm:"not null"`
-	AddedAt         time.Time  `json:"added_at" gorm:"default:CURRENT_TIMESTAMP"`
-	ContextSentence string     `json:"context_sentence"`
-	PersonalNote    string     `json:"personal_note"`
-	SourceContentID *string    `json:"source_content_id"`
+// SRSState is the scheduling state of a single card
+type SRSState struct {
 	MasteryLevel    int        `json:"mastery_level" gorm:"default:0;check:mastery_level >= 0 AND mastery_level <= 10"`
 	NextReviewAt    *time.Time `json:"next_review_at"`
 	ReviewCount     int        `json:"review_count" gorm:"default:0"`
 	CorrectCount    int        `json:"correct_count" gorm:"default:0"`
 	LastReviewedAt  *time.Time `json:"last_reviewed_at"`
+	FirstReviewedAt *time.Time `json:"first_reviewed_at"`              // When the card left the new queue
+	LapseCount      int        `json:"lapse_count" gorm:"default:0"`   // Times the card was forgotten after graduating
+	Suspended       bool       `json:"suspended" gorm:"default:false"` // Excluded from queues until unsuspended
+	BuriedUntil     *time.Time `json:"buried_until"`                   // Hidden from queues until this time
 	EaseFactor      float64    `json:"ease_factor" gorm:"default:2.50"`
 	IntervalDays    int        `json:"interval_days" gorm:"default:1"`
+}
+
+// UserVocabulary is a user's entry for a dictionary word, holding their own
+// translation and notes. Its SRS state is the recognition card; other card
+// types are stored as VocabularyCard rows.
+type UserVocabulary struct {
+	ID              string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID          string    `json:"user_id" gorm:"not null"`
+	VocabularyID    string    `json:"vocabulary_id" gorm:"not null"`
+	AddedAt         time.Time `json:"added_at" gorm:"default:CURRENT_TIMESTAMP"`
+	Translation     string    `json:"translation" gorm:"not null;default:''"`
+	Definition      string    `json:"definition" gorm:"not null;default:''"`
+	ContextSentence string    `json:"context_sentence"`
+	PersonalNote    string    `json:"personal_note"`
+	SourceContentID *string   `json:"source_content_id"`
+	SourceEpisodeID *string   `json:"source_episode_id"`
+	Tags            string    `json:"tags" gorm:"not null;default:''"`  // Stored as comma-separated string
+	Forms           string    `json:"forms" gorm:"not null;default:''"` // Inflected forms the word was added as, comma-separated
+	UpdatedAt       time.Time `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP;index"`
+	SRSState
 
 	// Relations
 	Vocabulary Vocabulary `json:"vocabulary,omitempty" gorm:"foreignKey:VocabularyID"`
 }
 
+// TableName matches the table name used by the raw queries in this package
+func (Vocabulary) TableName() string {
+	return "vocabulary"
+}
+
+// TableName matches the table name used by the raw queries in this package
+func (UserVocabulary) TableName() string {
+	return "user_vocabulary"
+}
+
+// DisplayTranslation returns the user's translation, falling back to the
+// dictionary entry
+func (u *UserVocabulary) DisplayTranslation() string {
+	if u.Translation != "" {
+		return u.Translation
+	}
+	return u.Vocabulary.Translation
+}
+
+// DisplayDefinition returns the user's definition, falling back to the
+// dictionary entry
+func (u *UserVocabulary) DisplayDefinition() string {
+	if u.Definition != "" {
+		return u.Definition
+	}
+	return u.Vocabulary.Definition
+}
+
 type UserSRSConfig struct {
 	ID               string         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	UserID           string         `json:"user_id" gorm:"not null;uniqueIndex"`
@@ -55,6 +107,8 @@ type UserSRSConfig struct {
 	GraduationSteps  string         `json:"-" gorm:"default:'1,6'"` // Stored as comma-separated string
 	NewWordsPerDay   int            `json:"new_words_per_day" gorm:"default:20"`
 	MaxReviewsPerDay int            `json:"max_reviews_per_day" gorm:"default:200"`
+	LeechThreshold   int            `json:"leech_threshold" gorm:"default:8"`      // Lapses before a card is a leech, 0 disables
+	LeechAction      string         `json:"leech_action" gorm:"default:'suspend'"` // suspend, tag
 	CreatedAt        time.Time      `json:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
@@ -70,10 +124,13 @@ type UpdateSRSConfigRequest struct {
 	GraduationSteps  []int   `json:"graduation_steps" validate:"min=1,max=10,dive,min=1,max=30"`
 	NewWordsPerDay   int     `json:"new_words_per_day" validate:"min=1,max=100"`
 	MaxReviewsPerDay int     `json:"max_reviews_per_day" validate:"min=10,max=1000"`
+	LeechThreshold   *int    `json:"leech_threshold" validate:"omitempty,min=0,max=50"`   // Kept when omitted, 0 disables
+	LeechAction      string  `json:"leech_action" validate:"omitempty,oneof=suspend tag"` // Kept when omitted
 }
 
 type ReviewRequest struct {
 	VocabularyID string `json:"vocabulary_id" validate:"required"`
+	CardType     string `json:"card_type" validate:"omitempty,oneof=recognition production cloze listening"`
 	Correct      bool   `json:"correct"`
 	ResponseTime int    `json:"response_time"` // milliseconds
 }
@@ -97,6 +154,8 @@ type SRSConfigPreset struct {
 	GraduationSteps  []int   `json:"graduation_steps"`
 	NewWordsPerDay   int     `json:"new_words_per_day"`
 	MaxReviewsPerDay int     `json:"max_reviews_per_day"`
+	LeechThreshold   int     `json:"leech_threshold"`
+	LeechAction      string  `json:"leech_action"`
 }
 
 // SRSStatistics represents statistics about the user's SRS performance
@@ -106,7 +165,7 @@ type SRSStatistics struct {
 	TotalReviews     int64   `json:"total_reviews"`
 	StreakDays       int     `json:"streak_days"`
 	ReviewsPerDay    float64 `json:"reviews_per_day"`
-	TimeToMaturity   float64 `json:"time_to_maturity_days"`
+	TimeToMaturity   float64 `json:"time_to_maturity_days"` // 0 when cards never mature under the config
 }
 
 // Update the original SRSConfig to include new fields
@@ -119,6 +178,8 @@ type SRSConfig struct {
 	GraduationSteps  []int   `json:"graduation_steps"`
 	NewWordsPerDay   int     `json:"new_words_per_day"`   // NEW
 	MaxReviewsPerDay int     `json:"max_reviews_per_day"` // NEW
+	LeechThreshold   int     `json:"leech_threshold"`
+	LeechAction      string  `json:"leech_action"`
 }
 type VocabularyStats struct {
 	TotalWords    int64   `json:"total_words"`
@@ -128,28 +189,41 @@ type VocabularyStats struct {
 	MatureWords   int64   `json:"mature_words"`
 	ReviewsToday  int64   `json:"reviews_today"`
 	AccuracyRate  float64 `json:"accuracy_rate"`
+	Suspended     int64   `json:"suspended"` // Not counted in the figures above
 }
 
 // Request
 type AddVocabularyRequest struct {
-	Word                  string `json:"word" validate:"required,min=1,max=255"`
-	Translation           string `json:"translation" validate:"required,min=1,max=255"`
-	PhoneticTranscription string `json:"phonetic_transcription"`
-	Definition            string `json:"definition"`
-	ExampleSentence       string `json:"example_sentence"`
-	ContextSentence       string `json:"context_sentence"`
-	PersonalNote          string `json:"personal_note"`
-	SourceContentID       string `json:"source_content_id"`
-	DifficultyLevel       string `json:"difficulty_level"`
+	Word                  string   `json:"word" validate:"required,min=1,max=255"`
+	Sense                 string   `json:"sense" validate:"max=100"`
+	Translation           string   `json:"translation" validate:"required,min=1,max=255"`
+	PhoneticTranscription string   `json:"phonetic_transcription"`
+	Romanization          string   `json:"romanization" validate:"max=255"` // Romaji or pinyin, derived from kana or pinyin readings when empty
+	Definition            string   `json:"definition"`
+	ExampleSentence       string   `json:"example_sentence"`
+	AudioURL              string   `json:"audio_url" validate:"omitempty,url"`
+	ContextSentence       string   `json:"context_sentence"`
+	PersonalNote          string   `json:"personal_note"`
+	SourceContentID       string   `json:"source_content_id"`
+	SourceEpisodeID       string   `json:"source_episode_id"`
+	DifficultyLevel       string   `json:"difficulty_level"`
+	Tags                  []string `json:"tags" validate:"omitempty,max=20,dive,min=1,max=50"`
+	// Add the word as typed instead of filing it under its lemma
+	KeepInflection bool `json:"keep_inflection"`
+	// When the user already has the lemma, record the form on that card
+	// instead of failing as a duplicate
+	LinkInflection bool `json:"link_inflection"`
 }
 
 type UpdateVocabularyRequest struct {
-	Translation           string `json:"translation" validate:"omitempty,min=1,max=255"`
-	PhoneticTranscription string `json:"phonetic_transcription" validate:"omitempty,max=255"`
-	Definition            string `json:"definition" validate:"omitempty"`
-	ExampleSentence       string `json:"example_sentence" validate:"omitempty"`
-	ContextSentence       string `json:"context_sentence" validate:"omitempty"`
-	PersonalNote          string `json:"personal_note" validate:"omitempty"`
+	Translation           string   `json:"translation" validate:"omitempty,min=1,max=255"`
+	PhoneticTranscription string   `json:"phonetic_transcription" validate:"omitempty,max=255"`
+	Romanization          string   `json:"romanization" validate:"omitempty,max=255"`
+	Definition            string   `json:"definition" validate:"omitempty"`
+	ExampleSentence       string   `json:"example_sentence" validate:"omitempty"`
+	ContextSentence       string   `json:"context_sentence" validate:"omitempty"`
+	PersonalNote          string   `json:"personal_note" validate:"omitempty"`
+	Tags                  []string `json:"tags" validate:"omitempty,max=20,dive,min=1,max=50"` // Replaces the tags when present
 }
 
 type BatchReviewRequest struct {
@@ -164,6 +238,7 @@ type BatchReviewResult struct {
 
 type ReviewVocabularyResult struct {
 	VocabularyID string `json:"vocabulary_id"`
+	CardType     string `json:"card_type,omitempty"`
 	Success      bool   `json:"success"`
 	NextReview   string `json:"next_review"`
 	Error        string `json:"error,omitempty"`
@@ -173,7 +248,7 @@ type VocabularyFilter struct {
 	LanguageID    int    `json:"language_id" validate:"required"`
 	MasteryLevels []int  `json:"mastery_levels"`
 	SearchQuery   string `json:"search_query"`
-	SortBy        string `json:"sort_by"`        // added_at, mastery_level, word, next_review
+	SortBy        string `json:"sort_by"`        // relevance, added_at, mastery_level, word, next_review
 	SortDirection string `json:"sort_direction"` // asc, desc
 	Limit         int    `json:"limit" validate:"min=1,max=100"`
 	Offset        int    `json:"offset" validate:"min=0"`
@@ -253,6 +328,74 @@ func (u *UserSRSConfig) SetGraduationSteps(steps []int) {
 	u.GraduationSteps = strings.Join(strSteps, ",")
 }
 
+// normalizeTag lower-cases a tag and strips the separator
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(strings.ReplaceAll(tag, ",", " ")))
+}
+
+// GetTags converts the comma-separated string to a slice
+func (u *UserVocabulary) GetTags() []string {
+	tags := make([]string, 0)
+	for _, part := range strings.Split(u.Tags, ",") {
+		if tag := strings.TrimSpace(part); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// SetTags normalises and de-duplicates tags into the comma-separated string
+func (u *UserVocabulary) SetTags(tags []string) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	u.Tags = strings.Join(normalized, ",")
+}
+
+// GetForms converts the comma-separated forms to a slice
+func (u *UserVocabulary) GetForms() []string {
+	forms := make([]string, 0)
+	for _, part := range strings.Split(u.Forms, ",") {
+		if form := strings.TrimSpace(part); form != "" {
+			forms = append(forms, form)
+		}
+	}
+	return forms
+}
+
+// AddForm records an inflected form of the word, reporting whether it was new
+func (u *UserVocabulary) AddForm(form string) bool {
+	form = strings.TrimSpace(strings.ReplaceAll(form, ",", " "))
+	if form == "" {
+		return false
+	}
+	forms := u.GetForms()
+	for _, existing := range forms {
+		if NormalizeLemma(existing) == NormalizeLemma(form) {
+			return false
+		}
+	}
+	u.Forms = strings.Join(append(forms, form), ",")
+	return true
+}
+
+// HasTag reports whether the entry carries the tag
+func (u *UserVocabulary) HasTag(tag string) bool {
+	tag = normalizeTag(tag)
+	for _, t := range u.GetTags() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // ToSRSConfig converts UserSRSConfig to SRSConfig
 func (u *UserSRSConfig) ToSRSConfig() SRSConfig {
 	return SRSConfig{
@@ -264,6 +407,8 @@ func (u *UserSRSConfig) ToSRSConfig() SRSConfig {
 		GraduationSteps:  u.GetGraduationSteps(),
 		NewWordsPerDay:   u.NewWordsPerDay,
 		MaxReviewsPerDay: u.MaxReviewsPerDay,
+		LeechThreshold:   u.LeechThreshold,
+		LeechAction:      u.LeechAction,
 	}
 }
 
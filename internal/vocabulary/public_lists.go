@@ -0,0 +1,517 @@
+package vocabulary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sort orders for public list discovery
+const (
+	PublicListSortPopular = "popular"
+	PublicListSortRecent  = "recent"
+	PublicListSortName    = "name"
+)
+
+// VocabularyListSubscription makes later additions to a list flow into the
+// subscriber's vocabulary
+type VocabularyListSubscription struct {
+	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ListID    string    `json:"list_id" gorm:"not null;uniqueIndex:idx_list_subscription"`
+	UserID    string    `json:"user_id" gorm:"not null;uniqueIndex:idx_list_subscription;index"`
+	GroupID   *string   `json:"group_id,omitempty" gorm:"type:uuid;index"` // Set when created by attaching the list to a study group
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PublicListFilter narrows public list discovery
+type PublicListFilter struct {
+	LanguageID int    `json:"language_id"`
+	Tag        string `json:"tag"`
+	Query      string `json:"query"`   // Matched against name and description
+	SortBy     string `json:"sort_by"` // popular, recent, name
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+}
+
+// PublicVocabularyList is the read-only view of a public list. It never
+// includes the author's personal notes or SRS state.
+type PublicVocabularyList struct {
+	ID              string           `json:"id"`
+	Name            string           `json:"name"`
+	Description     string           `json:"description"`
+	LanguageID      int              `json:"language_id"`
+	Tags            []string         `json:"tags"`
+	AuthorID        string           `json:"author_id"`
+	AuthorUsername  string           `json:"author_username"`
+	WordCount       int              `json:"word_count"`
+	SubscriberCount int              `json:"subscriber_count"`
+	ForkCount       int              `json:"fork_count"`
+	Subscribed      bool             `json:"subscribed"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	Items           []PublicListItem `json:"items,omitempty"`
+}
+
+type PublicListItem struct {
+	Order      int        `json:"order"`
+	Vocabulary Vocabulary `json:"vocabulary"`
+}
+
+type ForkVocabularyListRequest struct {
+	Name string `json:"name" validate:"omitempty,min=1,max=255"` // Defaults to the original name
+}
+
+// ListCopyResult reports how many of a list's words were added to the user's
+// vocabulary and how many were already there
+type ListCopyResult struct {
+	List    *VocabularyList `json:"list,omitempty"`
+	Added   int             `json:"added"`
+	Skipped int             `json:"skipped"`
+}
+
+// GetTags converts the comma-separated string to a slice
+func (l *VocabularyList) GetTags() []string {
+	return (&UserVocabulary{Tags: l.Tags}).GetTags()
+}
+
+// SetTags normalises the tags and stores them comma-separated
+func (l *VocabularyList) SetTags(tags []string) {
+	l.Tags = strings.Join(normalizeTags(tags), ",")
+}
+
+// GetPublicVocabularyLists browses other users' public lists
+func (s *Service) GetPublicVocabularyLists(ctx context.Context, userID string, filter PublicListFilter) ([]PublicVocabularyList, int64, error) {
+	query := s.db.Model(&VocabularyList{}).Where("vocabulary_lists.is_public = ?", true)
+	if filter.LanguageID > 0 {
+		query = query.Where("vocabulary_lists.language_id = ?", filter.LanguageID)
+	}
+	if tag := normalizeTag(filter.Tag); tag != "" {
+		query = query.Where("strpos(',' || vocabulary_lists.tags || ',', ?) > 0", ","+tag+",")
+	}
+	if term := strings.TrimSpace(filter.Query); term != "" {
+		pattern := "%" + term + "%"
+		query = query.Where("vocabulary_lists.name ILIKE ? OR vocabulary_lists.description ILIKE ?", pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	switch filter.SortBy {
+	case PublicListSortRecent:
+		query = query.Order("vocabulary_lists.updated_at DESC")
+	case PublicListSortName:
+		query = query.Order("vocabulary_lists.name ASC")
+	default:
+		query = query.Order("vocabulary_lists.subscriber_count + vocabulary_lists.fork_count DESC").
+			Order("vocabulary_lists.updated_at DESC")
+	}
+
+	var lists []VocabularyList
+	if err := query.Limit(filter.Limit).Offset(filter.Offset).Find(&lists).Error; err != nil {
+		return nil, 0, err
+	}
+
+	views, err := s.publicListViews(userID, lists)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return views, total, nil
+}
+
+// GetPublicVocabularyList returns the read-only view of a public list with
+// its words
+func (s *Service) GetPublicVocabularyList(ctx context.Context, userID, listID string) (*PublicVocabularyList, error) {
+	list, err := s.loadPublicList(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	views, err := s.publicListViews(userID, []VocabularyList{*list})
+	if err != nil {
+		return nil, err
+	}
+	view := views[0]
+
+	var items []VocabularyListItem
+	err = s.db.Preload("Vocabulary").
+		Where("list_id = ?", list.ID).
+		Order(`"order" ASC, created_at ASC`).
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	view.Items = make([]PublicListItem, 0, len(items))
+	for _, item := range items {
+		view.Items = append(view.Items, PublicListItem{Order: item.Order, Vocabulary: item.Vocabulary})
+	}
+
+	return &view, nil
+}
+
+// ForkVocabularyList copies a public list into a new list owned by the user
+// and adds its words to the user's vocabulary with fresh SRS state. The fork
+// does not follow later changes to the original.
+func (s *Service) ForkVocabularyList(ctx context.Context, userID, listID string, req ForkVocabularyListRequest) (*ListCopyResult, error) {
+	original, err := s.loadPublicList(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = original.Name
+	}
+
+	fork := VocabularyList{
+		UserID:       userID,
+		Name:         name,
+		Description:  original.Description,
+		LanguageID:   original.LanguageID,
+		CardTypes:    original.CardTypes,
+		Tags:         original.Tags,
+		ForkedFromID: &original.ID,
+	}
+
+	var items []VocabularyListItem
+	if err := s.db.Where("list_id = ?", original.ID).Order(`"order" ASC, created_at ASC`).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	result := &ListCopyResult{}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&fork).Error; err != nil {
+			return err
+		}
+
+		for i, item := range items {
+			added, err := s.addToDeck(tx, userID, item.VocabularyID)
+			if err != nil {
+				return err
+			}
+			if added {
+				result.Added++
+			} else {
+				result.Skipped++
+			}
+
+			copied := VocabularyListItem{ListID: fork.ID, VocabularyID: item.VocabularyID, Order: i + 1}
+			if err := tx.Create(&copied).Error; err != nil {
+				return err
+			}
+		}
+
+		if userID != original.UserID {
+			return tx.Model(original).UpdateColumn("fork_count", gorm.Expr("fork_count + 1")).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshListCards(userID, fork.ID); err != nil {
+		return nil, err
+	}
+
+	s.db.Preload("Items", orderedListItems).Preload("Items.Vocabulary").Where("id = ?", fork.ID).First(&fork)
+	fork.Role = ListRoleOwner
+	result.List = &fork
+
+	return result, nil
+}
+
+// SubscribeToVocabularyList adds the words of a list the user can view to
+// their vocabulary and keeps adding the words its editors add later
+func (s *Service) SubscribeToVocabularyList(ctx context.Context, userID, listID string) (*ListCopyResult, error) {
+	list, err := s.listAccess(listID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if list.UserID == userID {
+		return nil, errors.New("cannot subscribe to your own list")
+	}
+
+	var existing int64
+	s.db.Model(&VocabularyListSubscription{}).Where("list_id = ? AND user_id = ?", listID, userID).Count(&existing)
+	if existing > 0 {
+		return nil, errors.New("already subscribed to this list")
+	}
+
+	var vocabularyIDs []string
+	if err := s.db.Model(&VocabularyListItem{}).Where("list_id = ?", listID).Pluck("vocabulary_id", &vocabularyIDs).Error; err != nil {
+		return nil, err
+	}
+
+	result := &ListCopyResult{}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		_, err := s.subscribeUser(tx, list, userID, nil, vocabularyIDs, result)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UnsubscribeFromVocabularyList stops later additions from flowing into the
+// user's vocabulary. Words already added are kept.
+func (s *Service) UnsubscribeFromVocabularyList(ctx context.Context, userID, listID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("list_id = ? AND user_id = ?", listID, userID).Delete(&VocabularyListSubscription{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("not subscribed to this list")
+		}
+
+		return tx.Model(&VocabularyList{}).
+			Where("id = ? AND subscriber_count > 0", listID).
+			UpdateColumn("subscriber_count", gorm.Expr("subscriber_count - 1")).Error
+	})
+}
+
+// GetSubscribedVocabularyLists returns the public lists the user follows
+func (s *Service) GetSubscribedVocabularyLists(ctx context.Context, userID string) ([]PublicVocabularyList, error) {
+	var lists []VocabularyList
+	err := s.db.Joins("JOIN vocabulary_list_subscriptions ON vocabulary_list_subscriptions.list_id = vocabulary_lists.id").
+		Where("vocabulary_list_subscriptions.user_id = ? AND vocabulary_lists.is_public = ?", userID, true).
+		Order("vocabulary_list_subscriptions.created_at DESC").
+		Find(&lists).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return s.publicListViews(userID, lists)
+}
+
+// subscribeUser subscribes a user to a list and copies its words into their
+// vocabulary, reporting false when they were already subscribed
+func (s *Service) subscribeUser(tx *gorm.DB, list *VocabularyList, userID string, groupID *string, vocabularyIDs []string, result *ListCopyResult) (bool, error) {
+	subscription := VocabularyListSubscription{ListID: list.ID, UserID: userID, GroupID: groupID}
+	created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&subscription)
+	if created.Error != nil {
+		return false, created.Error
+	}
+	if created.RowsAffected == 0 {
+		return false, nil
+	}
+
+	for _, vocabularyID := range vocabularyIDs {
+		added, err := s.addToDeck(tx, userID, vocabularyID)
+		if err != nil {
+			return false, err
+		}
+		if added {
+			result.Added++
+		} else {
+			result.Skipped++
+		}
+	}
+
+	return true, tx.Model(list).UpdateColumn("subscriber_count", gorm.Expr("subscriber_count + 1")).Error
+}
+
+// listDeliveryPayload is the persisted input of a list delivery job
+type listDeliveryPayload struct {
+	ListID       string `json:"list_id"`
+	VocabularyID string `json:"vocabulary_id"`
+}
+
+// queueListDelivery schedules a word newly added to a list for delivery to
+// the list's subscribers, so popular lists don't hold up the request
+func (s *Service) queueListDelivery(userID string, list *VocabularyList, vocabularyID string) error {
+	var subscribers int64
+	if err := s.db.Model(&VocabularyListSubscription{}).Where("list_id = ?", list.ID).Count(&subscribers).Error; err != nil {
+		return err
+	}
+	if subscribers == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(listDeliveryPayload{ListID: list.ID, VocabularyID: vocabularyID})
+	if err != nil {
+		return err
+	}
+
+	job := Job{
+		UserID:     userID,
+		Type:       JobTypeListDelivery,
+		Status:     JobStatusPending,
+		LanguageID: list.LanguageID,
+		Format:     "json",
+		Payload:    string(payload),
+		Total:      int(subscribers),
+	}
+	if err := s.db.Create(&job).Error; err != nil {
+		return err
+	}
+
+	s.notifyJobWorkers()
+	return nil
+}
+
+// runListDeliveryJob adds the word of a delivery job to the vocabulary of
+// every subscriber who does not have it yet. addToDeck skips subscribers who
+// already received it, so an interrupted job simply starts over.
+func (s *Service) runListDeliveryJob(ctx context.Context, job *Job) error {
+	var payload listDeliveryPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return fmt.Errorf("invalid job payload: %v", err)
+	}
+
+	var subscriberIDs []string
+	err := s.db.Model(&VocabularyListSubscription{}).
+		Where("list_id = ?", payload.ListID).
+		Order("created_at ASC").
+		Pluck("user_id", &subscriberIDs).Error
+	if err != nil {
+		return err
+	}
+
+	if err := s.ownedJob(job.ID).Updates(map[string]interface{}{
+		"total":     len(subscriberIDs),
+		"processed": 0,
+	}).Error; err != nil {
+		return err
+	}
+
+	for i, subscriberID := range subscriberIDs {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if _, err := s.addToDeck(s.db, subscriberID, payload.VocabularyID); err != nil {
+			return fmt.Errorf("failed to deliver word to subscriber %s: %w", subscriberID, err)
+		}
+
+		processed := i + 1
+		if processed%jobProgressBatchSize == 0 || processed == len(subscriberIDs) {
+			if err := s.ownedJob(job.ID).Update("processed", processed).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// addToDeck adds a dictionary entry to a user's vocabulary with fresh SRS
+// state, reporting false when the user already has it
+func (s *Service) addToDeck(tx *gorm.DB, userID, vocabularyID string) (bool, error) {
+	var count int64
+	if err := tx.Model(&UserVocabulary{}).Where("user_id = ? AND vocabulary_id = ?", userID, vocabularyID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	nextReview := time.Now().Add(time.Hour * 24) // Review tomorrow
+	userVocab := UserVocabulary{
+		UserID:       userID,
+		VocabularyID: vocabularyID,
+		SRSState: SRSState{
+			NextReviewAt: &nextReview,
+			EaseFactor:   s.srsConfig.MaxEaseFactor,
+			IntervalDays: 1,
+		},
+	}
+
+	if err := tx.Create(&userVocab).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// loadPublicList finds a list shared by its author
+func (s *Service) loadPublicList(listID string) (*VocabularyList, error) {
+	var list VocabularyList
+	err := s.db.Where("id = ? AND is_public = ?", listID, true).First(&list).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("public vocabulary list not found")
+		}
+		return nil, err
+	}
+	return &list, nil
+}
+
+// publicListViews adds author, word count and subscription status to lists
+func (s *Service) publicListViews(userID string, lists []VocabularyList) ([]PublicVocabularyList, error) {
+	views := make([]PublicVocabularyList, 0, len(lists))
+	if len(lists) == 0 {
+		return views, nil
+	}
+
+	listIDs := make([]string, len(lists))
+	authorIDs := make([]string, len(lists))
+	for i, list := range lists {
+		listIDs[i] = list.ID
+		authorIDs[i] = list.UserID
+	}
+
+	var counts []struct {
+		ListID string
+		Count  int
+	}
+	err := s.db.Model(&VocabularyListItem{}).
+		Select("list_id, COUNT(*) AS count").
+		Where("list_id IN ?", listIDs).
+		Group("list_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	wordCounts := make(map[string]int, len(counts))
+	for _, count := range counts {
+		wordCounts[count.ListID] = count.Count
+	}
+
+	usernames, err := s.usernames(authorIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var subscribedIDs []string
+	err = s.db.Model(&VocabularyListSubscription{}).
+		Where("user_id = ? AND list_id IN ?", userID, listIDs).
+		Pluck("list_id", &subscribedIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	subscribed := make(map[string]bool, len(subscribedIDs))
+	for _, id := range subscribedIDs {
+		subscribed[id] = true
+	}
+
+	for _, list := range lists {
+		views = append(views, PublicVocabularyList{
+			ID:              list.ID,
+			Name:            list.Name,
+			Description:     list.Description,
+			LanguageID:      list.LanguageID,
+			Tags:            list.GetTags(),
+			AuthorID:        list.UserID,
+			AuthorUsername:  usernames[list.UserID],
+			WordCount:       wordCounts[list.ID],
+			SubscriberCount: list.SubscriberCount,
+			ForkCount:       list.ForkCount,
+			Subscribed:      subscribed[list.ID],
+			UpdatedAt:       list.UpdatedAt,
+		})
+	}
+
+	return views, nil
+}
@@ -0,0 +1,208 @@
+package vocabulary
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVocabularyListTags(t *testing.T) {
+	list := &VocabularyList{}
+	assert.Empty(t, list.GetTags())
+
+	list.SetTags([]string{"Week 1", "week 1", " A2 "})
+	assert.Equal(t, "week 1,a2", list.Tags)
+	assert.Equal(t, []string{"week 1", "a2"}, list.GetTags())
+}
+
+// expectAddToDeck expects addToDeck for a user who already has the word or
+// gets a new entry
+func expectAddToDeck(mock sqlmock.Sqlmock, userID, vocabularyID string, has bool) {
+	count := 0
+	if has {
+		count = 1
+	}
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "user_vocabulary" WHERE user_id = \$1 AND vocabulary_id = \$2`).
+		WithArgs(userID, vocabularyID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+	if !has {
+		mock.ExpectQuery(`INSERT INTO "user_vocabulary"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("uv-" + userID))
+	}
+}
+
+// deliveryPayload matches the payload column of a queued list delivery
+type deliveryPayload struct {
+	want listDeliveryPayload
+}
+
+func (m deliveryPayload) Match(v driver.Value) bool {
+	data, ok := v.(string)
+	if !ok {
+		return false
+	}
+	var got listDeliveryPayload
+	return json.Unmarshal([]byte(data), &got) == nil && got == m.want
+}
+
+func TestForkVocabularyList(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectQuery(`SELECT \* FROM "vocabulary_lists" WHERE id = \$1 AND is_public = \$2`).
+		WithArgs("list-1", true, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "language_id", "card_types", "is_public"}).
+			AddRow("list-1", "author", "Verbs", 1, "recognition,production", true))
+	mock.ExpectQuery(`SELECT \* FROM "vocabulary_list_items" WHERE list_id = \$1`).
+		WithArgs("list-1").
+		WillReturnRows(sqlmock.NewRows([]string{"list_id", "vocabulary_id", "order"}).
+			AddRow("list-1", "vocab-1", 1).
+			AddRow("list-1", "vocab-2", 2))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "vocabulary_lists"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("fork-1"))
+	expectAddToDeck(mock, "user-1", "vocab-1", false)
+	mock.ExpectQuery(`INSERT INTO "vocabulary_list_items"`).
+		WithArgs("fork-1", "vocab-1", 1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("item-1"))
+	expectAddToDeck(mock, "user-1", "vocab-2", true)
+	mock.ExpectQuery(`INSERT INTO "vocabulary_list_items"`).
+		WithArgs("fork-1", "vocab-2", 2, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("item-2"))
+	mock.ExpectExec(`UPDATE "vocabulary_lists" SET "fork_count"=fork_count \+ 1 WHERE "id" = \$1`).
+		WithArgs("list-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectQuery(`SELECT "vocabulary_id" FROM "vocabulary_list_items" WHERE list_id = \$1`).
+		WithArgs("fork-1").
+		WillReturnRows(sqlmock.NewRows([]string{"vocabulary_id"}))
+	mock.ExpectQuery(`SELECT \* FROM "vocabulary_lists" WHERE id = \$1`).
+		WithArgs("fork-1", "fork-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name"}).AddRow("fork-1", "user-1", "Verbs"))
+	mock.ExpectQuery(`SELECT \* FROM "vocabulary_list_items" WHERE "vocabulary_list_items"."list_id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	result, err := service.ForkVocabularyList(context.Background(), "user-1", "list-1", ForkVocabularyListRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Added)
+	assert.Equal(t, 1, result.Skipped)
+	require.NotNil(t, result.List)
+	assert.Equal(t, "fork-1", result.List.ID)
+	assert.Equal(t, ListRoleOwner, result.List.Role)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscribeUser(t *testing.T) {
+	list := &VocabularyList{ID: "list-1", UserID: "author"}
+
+	t.Run("CopiesWords", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`INSERT INTO "vocabulary_list_subscriptions" .* ON CONFLICT DO NOTHING`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("subscription-1"))
+		expectAddToDeck(mock, "user-1", "vocab-1", false)
+		expectAddToDeck(mock, "user-1", "vocab-2", true)
+		mock.ExpectExec(`UPDATE "vocabulary_lists" SET "subscriber_count"=subscriber_count \+ 1 WHERE "id" = \$1`).
+			WithArgs("list-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		result := &ListCopyResult{}
+		subscribed, err := service.subscribeUser(service.db, list, "user-1", nil, []string{"vocab-1", "vocab-2"}, result)
+		require.NoError(t, err)
+		assert.True(t, subscribed)
+		assert.Equal(t, 1, result.Added)
+		assert.Equal(t, 1, result.Skipped)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadySubscribed", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`INSERT INTO "vocabulary_list_subscriptions" .* ON CONFLICT DO NOTHING`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		result := &ListCopyResult{}
+		subscribed, err := service.subscribeUser(service.db, list, "user-1", nil, []string{"vocab-1"}, result)
+		require.NoError(t, err)
+		assert.False(t, subscribed)
+		assert.Equal(t, ListCopyResult{}, *result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestQueueListDelivery(t *testing.T) {
+	list := &VocabularyList{ID: "list-1", UserID: "author", LanguageID: 3}
+	countQuery := `SELECT count\(\*\) FROM "vocabulary_list_subscriptions" WHERE list_id = \$1`
+
+	t.Run("NoSubscribers", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(countQuery).
+			WithArgs("list-1").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		assert.NoError(t, service.queueListDelivery("author", list, "vocab-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("QueuesJob", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(countQuery).
+			WithArgs("list-1").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(250))
+		mock.ExpectQuery(`INSERT INTO "jobs" \("user_id","type","status","language_id","format","filter_id","payload","total",`).
+			WithArgs("author", JobTypeListDelivery, JobStatusPending, 3, "json", "",
+				deliveryPayload{listDeliveryPayload{ListID: "list-1", VocabularyID: "vocab-1"}}, 250,
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("job-1"))
+
+		// Adding the word returns without waiting for the subscribers
+		assert.NoError(t, service.queueListDelivery("author", list, "vocab-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRunListDeliveryJob(t *testing.T) {
+	payload, err := json.Marshal(listDeliveryPayload{ListID: "list-1", VocabularyID: "vocab-1"})
+	require.NoError(t, err)
+	job := &Job{ID: "job-1", Type: JobTypeListDelivery, Payload: string(payload)}
+
+	t.Run("DeliversToSubscribersMissingTheWord", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`SELECT "user_id" FROM "vocabulary_list_subscriptions" WHERE list_id = \$1 ORDER BY created_at ASC`).
+			WithArgs("list-1").
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user-1").AddRow("user-2"))
+		mock.ExpectExec(`UPDATE "jobs" SET "processed"=\$1,"total"=\$2,"updated_at"=\$3 WHERE id = \$4 AND worker_id = \$5`).
+			WithArgs(0, 2, sqlmock.AnyArg(), "job-1", "worker-a").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectAddToDeck(mock, "user-1", "vocab-1", true)
+		expectAddToDeck(mock, "user-2", "vocab-1", false)
+		mock.ExpectExec(`UPDATE "jobs" SET "processed"=\$1,"updated_at"=\$2 WHERE id = \$3 AND worker_id = \$4`).
+			WithArgs(2, sqlmock.AnyArg(), "job-1", "worker-a").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, service.runListDeliveryJob(context.Background(), job))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("StopsWhenCancelled", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`SELECT "user_id" FROM "vocabulary_list_subscriptions"`).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user-1"))
+		mock.ExpectExec(`UPDATE "jobs"`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.NoError(t, service.runListDeliveryJob(ctx, job))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidPayload", func(t *testing.T) {
+		service, _ := newMockService(t)
+		assert.Error(t, service.runListDeliveryJob(context.Background(), &Job{ID: "job-2", Payload: "{"}))
+	})
+}
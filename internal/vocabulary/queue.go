@@ -0,0 +1,341 @@
+package vocabulary
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Queue interleaving strategies
+const (
+	QueueStrategySequential  = "sequential"  // learning, then reviews, then new cards
+	QueueStrategyInterleaved = "interleaved" // learning, then new cards spread evenly among reviews
+	QueueStrategyNewFirst    = "new_first"   // learning, then new cards, then reviews
+)
+
+// learningMasteryLevel is the mastery level at which a card graduates from
+// learning steps to regular reviews
+const learningMasteryLevel = 2
+
+// QueueOptions scopes and shapes a daily review queue
+type QueueOptions struct {
+	LanguageID     int    `json:"language_id"`
+	Strategy       string `json:"strategy"`
+	ListID         string `json:"list_id"`
+	Tag            string `json:"tag"`
+	FilterID       string `json:"filter_id"` // Smart filter scoping the queue
+	CardType       string `json:"card_type"`
+	Timezone       string `json:"timezone"`
+	StudyAheadDays int    `json:"study_ahead_days"` // Also include reviews due within this many days
+	Limit          int    `json:"limit"`
+
+	criteria *SmartFilterCriteria // Loaded from FilterID
+}
+
+// ReviewQueue is today's study session
+type ReviewQueue struct {
+	Date      string       `json:"date"`
+	Timezone  string       `json:"timezone"`
+	Strategy  string       `json:"strategy"`
+	Cards     []ReviewCard `json:"cards"`
+	Counts    QueueCounts  `json:"counts"`
+	Remaining QueueCounts  `json:"remaining_today"` // Daily allowance left before this session
+}
+
+type QueueCounts struct {
+	Learning int `json:"learning"`
+	Review   int `json:"review"`
+	New      int `json:"new"`
+}
+
+// cardCondition narrows a card query; table is the table holding the SRS state
+type cardCondition func(query *gorm.DB, table string) *gorm.DB
+
+// BuildReviewQueue computes today's session in the user's timezone: learning
+// cards first, then due reviews up to MaxReviewsPerDay, then new cards up to
+// NewWordsPerDay, ordered by the requested strategy
+func (s *Service) BuildReviewQueue(ctx context.Context, userID string, opts QueueOptions) (*ReviewQueue, error) {
+	config, err := s.getUserSRSConfig(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.FilterID != "" {
+		filter, err := s.loadSmartFilter(userID, opts.FilterID)
+		if err != nil {
+			return nil, err
+		}
+		criteria := filter.GetCriteria()
+		opts.criteria = &criteria
+		if opts.LanguageID == 0 {
+			opts.LanguageID = filter.LanguageID
+		}
+	}
+
+	location := s.resolveTimezone(userID, opts.Timezone)
+	now := time.Now().In(location)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	dueBy := startOfDay.AddDate(0, 0, 1+opts.StudyAheadDays)
+
+	// Allowance left after what was already studied today
+	reviewedToday, err := s.countCards(userID, opts, func(query *gorm.DB, table string) *gorm.DB {
+		return query.Where(table+".last_reviewed_at >= ?", startOfDay).
+			Where("("+table+".first_reviewed_at IS NULL OR "+table+".first_reviewed_at < ?)", startOfDay)
+	})
+	if err != nil {
+		return nil, err
+	}
+	introducedToday, err := s.countCards(userID, opts, func(query *gorm.DB, table string) *gorm.DB {
+		return query.Where(table+".first_reviewed_at >= ?", startOfDay)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Suspended and buried cards are never offered
+	available := func(condition cardCondition) cardCondition {
+		return func(query *gorm.DB, table string) *gorm.DB {
+			query = query.Where(table+".suspended = ?", false).
+				Where("("+table+".buried_until IS NULL OR "+table+".buried_until <= ?)", now)
+			return condition(query, table)
+		}
+	}
+
+	remaining := QueueCounts{
+		Review: maxInt(config.MaxReviewsPerDay-int(reviewedToday), 0),
+		New:    maxInt(config.NewWordsPerDay-int(introducedToday), 0),
+	}
+
+	learning, err := s.findCards(userID, opts, available(func(query *gorm.DB, table string) *gorm.DB {
+		return query.Where(table+".review_count > 0 AND "+table+".mastery_level < ?", learningMasteryLevel).
+			Where(table+".next_review_at <= ?", dueBy)
+	}), -1)
+	if err != nil {
+		return nil, err
+	}
+	sortCardsByDue(learning)
+
+	reviews, err := s.findCards(userID, opts, available(func(query *gorm.DB, table string) *gorm.DB {
+		return query.Where(table+".review_count > 0 AND "+table+".mastery_level >= ?", learningMasteryLevel).
+			Where(table+".next_review_at <= ?", dueBy)
+	}), remaining.Review)
+	if err != nil {
+		return nil, err
+	}
+	sortCardsByDue(reviews)
+
+	newCards, err := s.findCards(userID, opts, available(func(query *gorm.DB, table string) *gorm.DB {
+		return query.Where(table + ".review_count = 0")
+	}), remaining.New)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(newCards, func(i, j int) bool {
+		return newCards[i].UserVocabulary.AddedAt.Before(newCards[j].UserVocabulary.AddedAt)
+	})
+
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = QueueStrategySequential
+	}
+
+	cards := buildQueue(learning, reviews, newCards, remaining, strategy)
+	if opts.Limit > 0 && len(cards) > opts.Limit {
+		cards = cards[:opts.Limit]
+	}
+
+	queue := &ReviewQueue{
+		Date:      startOfDay.Format("2006-01-02"),
+		Timezone:  location.String(),
+		Strategy:  strategy,
+		Cards:     cards,
+		Remaining: remaining,
+	}
+	for _, card := range cards {
+		switch {
+		case card.ReviewCount == 0:
+			queue.Counts.New++
+		case card.MasteryLevel < learningMasteryLevel:
+			queue.Counts.Learning++
+		default:
+			queue.Counts.Review++
+		}
+	}
+
+	return queue, nil
+}
+
+// buildQueue caps reviews and new cards and orders the session. Learning cards
+// are never capped and always come first.
+func buildQueue(learning, reviews, newCards []ReviewCard, limits QueueCounts, strategy string) []ReviewCard {
+	if len(reviews) > limits.Review {
+		reviews = reviews[:limits.Review]
+	}
+	if len(newCards) > limits.New {
+		newCards = newCards[:limits.New]
+	}
+
+	queue := make([]ReviewCard, 0, len(learning)+len(reviews)+len(newCards))
+	queue = append(queue, learning...)
+
+	switch strategy {
+	case QueueStrategyNewFirst:
+		queue = append(queue, newCards...)
+		queue = append(queue, reviews...)
+	case QueueStrategyInterleaved:
+		queue = append(queue, interleaveCards(reviews, newCards)...)
+	default:
+		queue = append(queue, reviews...)
+		queue = append(queue, newCards...)
+	}
+
+	return queue
+}
+
+// interleaveCards spreads the new cards evenly among the reviews
+func interleaveCards(reviews, newCards []ReviewCard) []ReviewCard {
+	if len(newCards) == 0 {
+		return reviews
+	}
+	if len(reviews) == 0 {
+		return newCards
+	}
+
+	merged := make([]ReviewCard, 0, len(reviews)+len(newCards))
+	gap := float64(len(reviews)+len(newCards)) / float64(len(newCards))
+	nextNew := gap / 2
+	r, n := 0, 0
+
+	for i := 0; i < len(reviews)+len(newCards); i++ {
+		if n < len(newCards) && (float64(i) >= nextNew || r >= len(reviews)) {
+			merged = append(merged, newCards[n])
+			n++
+			nextNew += gap
+		} else {
+			merged = append(merged, reviews[r])
+			r++
+		}
+	}
+
+	return merged
+}
+
+// findCards loads recognition cards and extra cards matching the queue scope
+// and condition, at most limit of each. A negative limit loads every match.
+func (s *Service) findCards(userID string, opts QueueOptions, condition cardCondition, limit int) ([]ReviewCard, error) {
+	cards := make([]ReviewCard, 0)
+	if limit == 0 {
+		return cards, nil
+	}
+
+	if opts.CardType == "" || opts.CardType == CardTypeRecognition {
+		query := condition(s.scopeCards(s.userVocabularyQuery(userID, opts.LanguageID), opts, "user_vocabulary"), "user_vocabulary")
+		if limit > 0 {
+			query = query.Order("user_vocabulary.next_review_at ASC").Limit(limit)
+		}
+
+		var vocab []UserVocabulary
+		if err := query.Find(&vocab).Error; err != nil {
+			return nil, err
+		}
+		for _, uv := range vocab {
+			cards = append(cards, newReviewCard(uv.ID, CardTypeRecognition, uv.SRSState, uv))
+		}
+	}
+
+	if opts.CardType != CardTypeRecognition {
+		query := condition(s.scopeCards(s.extraCardsQuery(userID, opts.LanguageID), opts, "vocabulary_cards"), "vocabulary_cards")
+		if opts.CardType != "" {
+			query = query.Where("vocabulary_cards.card_type = ?", opts.CardType)
+		}
+		if limit > 0 {
+			query = query.Order("vocabulary_cards.next_review_at ASC").Limit(limit)
+		}
+
+		var extra []VocabularyCard
+		if err := query.Find(&extra).Error; err != nil {
+			return nil, err
+		}
+		for _, card := range extra {
+			cards = append(cards, newReviewCard(card.ID, card.CardType, card.SRSState, card.UserVocabulary))
+		}
+	}
+
+	return cards, nil
+}
+
+// countCards counts recognition cards and extra cards matching the scope
+func (s *Service) countCards(userID string, opts QueueOptions, condition cardCondition) (int64, error) {
+	var recognition, extra int64
+
+	if opts.CardType == "" || opts.CardType == CardTypeRecognition {
+		query := condition(s.scopeCards(s.userVocabularyQuery(userID, opts.LanguageID), opts, "user_vocabulary"), "user_vocabulary")
+		if err := query.Model(&UserVocabulary{}).Count(&recognition).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	if opts.CardType != CardTypeRecognition {
+		query := condition(s.scopeCards(s.extraCardsQuery(userID, opts.LanguageID), opts, "vocabulary_cards"), "vocabulary_cards")
+		if opts.CardType != "" {
+			query = query.Where("vocabulary_cards.card_type = ?", opts.CardType)
+		}
+		if err := query.Model(&VocabularyCard{}).Count(&extra).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	return recognition + extra, nil
+}
+
+// extraCardsQuery scopes active non-recognition cards to a user and language
+func (s *Service) extraCardsQuery(userID string, languageID int) *gorm.DB {
+	return s.db.Preload("UserVocabulary.Vocabulary").
+		Joins("JOIN user_vocabulary ON user_vocabulary.id = vocabulary_cards.user_vocabulary_id").
+		Joins("JOIN vocabulary ON user_vocabulary.vocabulary_id = vocabulary.id").
+		Where("vocabulary_cards.user_id = ? AND vocabulary.language_id = ? AND vocabulary_cards.active = ?", userID, languageID, true)
+}
+
+// scopeCards applies the list, tag and smart filters of a queue to cards
+// whose SRS state is held in table
+func (s *Service) scopeCards(query *gorm.DB, opts QueueOptions, table string) *gorm.DB {
+	if opts.criteria != nil {
+		query = applySmartFilter(query, *opts.criteria, time.Now(), table)
+	}
+	if opts.ListID != "" {
+		query = query.Where("user_vocabulary.vocabulary_id IN (?)",
+			s.db.Model(&VocabularyListItem{}).Select("vocabulary_id").Where("list_id = ?", opts.ListID))
+	}
+	if tag := normalizeTag(opts.Tag); tag != "" {
+		query = query.Where("strpos(',' || user_vocabulary.tags || ',', ?) > 0", ","+tag+",")
+	}
+	return query
+}
+
+// resolveTimezone prefers the requested zone, then the user's account zone
+func (s *Service) resolveTimezone(userID, requested string) *time.Location {
+	if requested != "" {
+		if location, err := time.LoadLocation(requested); err == nil {
+			return location
+		}
+	}
+
+	var timezone string
+	s.db.Table("users").Select("timezone").Where("id = ?", userID).Scan(&timezone)
+	if timezone != "" {
+		if location, err := time.LoadLocation(timezone); err == nil {
+			return location
+		}
+	}
+
+	return time.UTC
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,49 @@
+package vocabulary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func queueCards(prefix string, n int) []ReviewCard {
+	cards := make([]ReviewCard, n)
+	for i := range cards {
+		cards[i] = ReviewCard{CardID: prefix + string(rune('a'+i))}
+	}
+	return cards
+}
+
+func cardIDs(cards []ReviewCard) []string {
+	ids := make([]string, len(cards))
+	for i, card := range cards {
+		ids[i] = card.CardID
+	}
+	return ids
+}
+
+func TestBuildQueue(t *testing.T) {
+	learning := queueCards("l", 1)
+	reviews := queueCards("r", 4)
+	newCards := queueCards("n", 3)
+
+	t.Run("Sequential", func(t *testing.T) {
+		queue := buildQueue(learning, reviews, newCards, QueueCounts{Review: 3, New: 2}, QueueStrategySequential)
+		assert.Equal(t, []string{"la", "ra", "rb", "rc", "na", "nb"}, cardIDs(queue))
+	})
+
+	t.Run("NewFirst", func(t *testing.T) {
+		queue := buildQueue(learning, reviews, newCards, QueueCounts{Review: 2, New: 1}, QueueStrategyNewFirst)
+		assert.Equal(t, []string{"la", "na", "ra", "rb"}, cardIDs(queue))
+	})
+
+	t.Run("Interleaved", func(t *testing.T) {
+		queue := buildQueue(learning, reviews, newCards, QueueCounts{Review: 4, New: 2}, QueueStrategyInterleaved)
+		assert.Equal(t, []string{"la", "ra", "rb", "na", "rc", "rd", "nb"}, cardIDs(queue))
+	})
+
+	t.Run("LearningIsNeverCapped", func(t *testing.T) {
+		queue := buildQueue(queueCards("l", 3), reviews, newCards, QueueCounts{}, QueueStrategySequential)
+		assert.Equal(t, []string{"la", "lb", "lc"}, cardIDs(queue))
+	})
+}
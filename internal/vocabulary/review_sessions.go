@@ -0,0 +1,526 @@
+package vocabulary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Review session states
+const (
+	ReviewSessionActive   = "active"
+	ReviewSessionPaused   = "paused"
+	ReviewSessionFinished = "finished"
+
+	// Gaps between answers longer than this are not counted as study time,
+	// so a session left open does not inflate daily goals
+	reviewSessionIdleCap = 5 * time.Minute
+)
+
+// srsStateColumns are the columns of SRSState, restored together on undo
+var srsStateColumns = []string{
+	"mastery_level", "next_review_at", "review_count", "correct_count",
+	"last_reviewed_at", "first_reviewed_at", "lapse_count", "suspended",
+	"buried_until", "ease_factor", "interval_days",
+}
+
+// ReviewSession is a server-side review session over a snapshot of the
+// queue taken when it started, so it can be resumed on another device
+type ReviewSession struct {
+	ID             string     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID         string     `json:"user_id" gorm:"not null;index"`
+	LanguageID     int        `json:"language_id" gorm:"not null"`
+	Status         string     `json:"status" gorm:"not null;default:'active';index"`
+	Strategy       string     `json:"strategy"`
+	Cards          string     `json:"-" gorm:"type:text"`        // []SessionCard as JSON
+	Position       int        `json:"position" gorm:"default:0"` // Index of the next card to answer
+	ActiveSeconds  int        `json:"active_seconds" gorm:"default:0"`
+	StartedAt      time.Time  `json:"started_at"`
+	LastActivityAt time.Time  `json:"last_activity_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ReviewSessionAnswer records an answer and the card state before it, which
+// undo restores
+type ReviewSessionAnswer struct {
+	ID            string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SessionID     string    `json:"session_id" gorm:"type:uuid;not null;index"`
+	Position      int       `json:"position" gorm:"not null"`
+	CardID        string    `json:"card_id" gorm:"not null"`
+	VocabularyID  string    `json:"vocabulary_id" gorm:"not null"`
+	CardType      string    `json:"card_type" gorm:"not null"`
+	Correct       bool      `json:"correct"`
+	ResponseTime  int       `json:"response_time"`      // milliseconds
+	Graduated     bool      `json:"graduated"`          // Left the learning steps with this answer
+	Lapsed        bool      `json:"lapsed"`             // Forgotten after graduating
+	PreviousState string    `json:"-" gorm:"type:text"` // SRSState as JSON
+	PreviousTags  string    `json:"-"`
+	AnsweredAt    time.Time `json:"answered_at"`
+}
+
+// SessionCard identifies a card of the session snapshot
+type SessionCard struct {
+	CardID       string `json:"card_id"`
+	VocabularyID string `json:"vocabulary_id"`
+	CardType     string `json:"card_type"`
+}
+
+type StartReviewSessionRequest struct {
+	LanguageID     int    `json:"language_id" validate:"required"`
+	Strategy       string `json:"strategy" validate:"omitempty,oneof=sequential interleaved new_first"`
+	ListID         string `json:"list_id" validate:"omitempty,uuid"`
+	Tag            string `json:"tag" validate:"max=50"`
+	FilterID       string `json:"filter_id" validate:"omitempty,uuid"`
+	CardType       string `json:"card_type" validate:"omitempty,oneof=recognition production cloze listening"`
+	Timezone       string `json:"timezone"`
+	StudyAheadDays int    `json:"study_ahead_days" validate:"min=0,max=30"`
+	Limit          int    `json:"limit" validate:"min=0,max=1000"`
+}
+
+type AnswerReviewRequest struct {
+	CardID       string `json:"card_id"` // Optional guard: must be the session's current card
+	Correct      bool   `json:"correct"`
+	ResponseTime int    `json:"response_time" validate:"min=0"` // milliseconds
+}
+
+// ReviewSessionState is a session with its progress and the card to answer next
+type ReviewSessionState struct {
+	ReviewSession
+	TotalCards  int         `json:"total_cards"`
+	Remaining   int         `json:"remaining"`
+	CurrentCard *ReviewCard `json:"current_card"` // nil once every card is answered
+	CanUndo     bool        `json:"can_undo"`
+}
+
+// ReviewSessionSummary reports a finished session
+type ReviewSessionSummary struct {
+	SessionID         string  `json:"session_id"`
+	DurationSeconds   int     `json:"duration_seconds"`
+	TotalCards        int     `json:"total_cards"`
+	Answered          int     `json:"answered"`
+	Correct           int     `json:"correct"`
+	Accuracy          float64 `json:"accuracy"` // Percentage of answers that were correct
+	Graduated         int     `json:"graduated"`
+	Lapsed            int     `json:"lapsed"`
+	AverageResponseMs int     `json:"average_response_ms"`
+}
+
+// GetCards decodes the session snapshot
+func (rs *ReviewSession) GetCards() []SessionCard {
+	var cards []SessionCard
+	if rs.Cards != "" {
+		json.Unmarshal([]byte(rs.Cards), &cards)
+	}
+	return cards
+}
+
+// SetCards encodes the session snapshot
+func (rs *ReviewSession) SetCards(cards []SessionCard) {
+	data, _ := json.Marshal(cards)
+	rs.Cards = string(data)
+}
+
+// touch adds the time since the last activity to the session's study time,
+// capped so idle gaps are not counted
+func (rs *ReviewSession) touch(now time.Time) {
+	elapsed := now.Sub(rs.LastActivityAt)
+	if elapsed > reviewSessionIdleCap {
+		elapsed = reviewSessionIdleCap
+	}
+	if elapsed > 0 {
+		rs.ActiveSeconds += int(elapsed.Seconds())
+	}
+	rs.LastActivityAt = now
+}
+
+// StartReviewSession snapshots today's queue into a new session
+func (s *Service) StartReviewSession(ctx context.Context, userID string, req StartReviewSessionRequest) (*ReviewSessionState, error) {
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			return nil, errors.New("invalid timezone")
+		}
+	}
+
+	strategy := req.Strategy
+	if strategy == "" {
+		strategy = QueueStrategySequential
+	}
+
+	queue, err := s.BuildReviewQueue(ctx, userID, QueueOptions{
+		LanguageID:     req.LanguageID,
+		Strategy:       strategy,
+		ListID:         req.ListID,
+		Tag:            req.Tag,
+		FilterID:       req.FilterID,
+		CardType:       req.CardType,
+		Timezone:       req.Timezone,
+		StudyAheadDays: req.StudyAheadDays,
+		Limit:          req.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cards := make([]SessionCard, 0, len(queue.Cards))
+	for _, card := range queue.Cards {
+		cards = append(cards, SessionCard{CardID: card.CardID, VocabularyID: card.VocabularyID, CardType: card.CardType})
+	}
+
+	now := time.Now()
+	session := ReviewSession{
+		UserID:         userID,
+		LanguageID:     req.LanguageID,
+		Status:         ReviewSessionActive,
+		Strategy:       strategy,
+		StartedAt:      now,
+		LastActivityAt: now,
+	}
+	session.SetCards(cards)
+
+	if err := s.db.Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	return s.reviewSessionState(ctx, &session)
+}
+
+// GetReviewSessions returns the user's sessions, most recent first
+func (s *Service) GetReviewSessions(ctx context.Context, userID, status string, limit int) ([]ReviewSession, error) {
+	var sessions []ReviewSession
+	query := s.db.Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	err := query.Order("last_activity_at DESC").Limit(limit).Find(&sessions).Error
+	return sessions, err
+}
+
+// GetReviewSession returns a session with its current card
+func (s *Service) GetReviewSession(ctx context.Context, userID, sessionID string) (*ReviewSessionState, error) {
+	session, err := s.loadReviewSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.reviewSessionState(ctx, session)
+}
+
+// AnswerReviewSession reviews the session's current card and moves on
+func (s *Service) AnswerReviewSession(ctx context.Context, userID, sessionID string, req AnswerReviewRequest) (*ReviewSessionState, error) {
+	session, err := s.loadReviewSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != ReviewSessionActive {
+		return nil, errors.New("review session is not active")
+	}
+
+	cards := session.GetCards()
+	if session.Position >= len(cards) {
+		return nil, errors.New("every card in this session has been answered")
+	}
+
+	current := cards[session.Position]
+	if req.CardID != "" && req.CardID != current.CardID {
+		return nil, errors.New("card is not the current card of this session")
+	}
+
+	previous, tags, err := s.loadCardState(userID, current)
+	if err != nil {
+		return nil, err
+	}
+	previousState, _ := json.Marshal(previous)
+
+	reviewed, err := s.ReviewVocabulary(ctx, userID, ReviewRequest{
+		VocabularyID: current.VocabularyID,
+		CardType:     current.CardType,
+		Correct:      req.Correct,
+		ResponseTime: req.ResponseTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	answer := ReviewSessionAnswer{
+		SessionID:     session.ID,
+		Position:      session.Position,
+		CardID:        current.CardID,
+		VocabularyID:  current.VocabularyID,
+		CardType:      current.CardType,
+		Correct:       req.Correct,
+		ResponseTime:  req.ResponseTime,
+		Graduated:     previous.MasteryLevel < learningMasteryLevel && reviewed.MasteryLevel >= learningMasteryLevel,
+		Lapsed:        reviewed.LapseCount > previous.LapseCount,
+		PreviousState: string(previousState),
+		PreviousTags:  tags,
+		AnsweredAt:    now,
+	}
+
+	session.Position++
+	session.touch(now)
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&answer).Error; err != nil {
+			return err
+		}
+		return tx.Save(session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.reviewSessionState(ctx, session)
+}
+
+// UndoReviewSessionAnswer reverts the session's last answer, restoring the
+// card's SRS state and tags from before it
+func (s *Service) UndoReviewSessionAnswer(ctx context.Context, userID, sessionID string) (*ReviewSessionState, error) {
+	session, err := s.loadReviewSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != ReviewSessionActive {
+		return nil, errors.New("review session is not active")
+	}
+
+	var answer ReviewSessionAnswer
+	err = s.db.Where("session_id = ?", session.ID).Order("position DESC").First(&answer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("nothing to undo")
+		}
+		return nil, err
+	}
+
+	var previous SRSState
+	if err := json.Unmarshal([]byte(answer.PreviousState), &previous); err != nil {
+		return nil, err
+	}
+
+	session.Position = answer.Position
+	session.touch(time.Now())
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if answer.CardType == CardTypeRecognition {
+			err := tx.Model(&UserVocabulary{}).
+				Where("id = ? AND user_id = ?", answer.CardID, userID).
+				Select(append(srsStateColumns, "tags")).
+				Updates(&UserVocabulary{SRSState: previous, Tags: answer.PreviousTags}).Error
+			if err != nil {
+				return err
+			}
+		} else {
+			err := tx.Model(&VocabularyCard{}).
+				Where("id = ? AND user_id = ?", answer.CardID, userID).
+				Select(srsStateColumns).
+				Updates(&VocabularyCard{SRSState: previous}).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&UserVocabulary{}).
+				Where("user_id = ? AND vocabulary_id = ?", userID, answer.VocabularyID).
+				Update("tags", answer.PreviousTags).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// The undone review no longer counts towards the scheduler history
+		err := tx.Where("id = (?)", tx.Model(&ReviewLog{}).Select("id").
+			Where("user_id = ? AND card_id = ?", userID, answer.CardID).
+			Order("reviewed_at DESC").Limit(1),
+		).Delete(&ReviewLog{}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&answer).Error; err != nil {
+			return err
+		}
+		return tx.Save(session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.reviewSessionState(ctx, session)
+}
+
+// PauseReviewSession stops the session clock until it is resumed
+func (s *Service) PauseReviewSession(ctx context.Context, userID, sessionID string) (*ReviewSessionState, error) {
+	session, err := s.loadReviewSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != ReviewSessionActive {
+		return nil, errors.New("review session is not active")
+	}
+
+	session.touch(time.Now())
+	session.Status = ReviewSessionPaused
+
+	if err := s.db.Save(session).Error; err != nil {
+		return nil, err
+	}
+
+	return s.reviewSessionState(ctx, session)
+}
+
+// ResumeReviewSession continues a paused session, possibly on another device
+func (s *Service) ResumeReviewSession(ctx context.Context, userID, sessionID string) (*ReviewSessionState, error) {
+	session, err := s.loadReviewSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == ReviewSessionFinished {
+		return nil, errors.New("review session is finished")
+	}
+
+	if session.Status == ReviewSessionPaused {
+		// Time spent paused is not study time
+		session.LastActivityAt = time.Now()
+		session.Status = ReviewSessionActive
+
+		if err := s.db.Save(session).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return s.reviewSessionState(ctx, session)
+}
+
+// FinishReviewSession closes the session and summarises it. Its study time
+// then counts towards the user's progress goals.
+func (s *Service) FinishReviewSession(ctx context.Context, userID, sessionID string) (*ReviewSessionSummary, error) {
+	session, err := s.loadReviewSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Status != ReviewSessionFinished {
+		now := time.Now()
+		if session.Status == ReviewSessionActive {
+			session.touch(now)
+		}
+		session.Status = ReviewSessionFinished
+		session.FinishedAt = &now
+
+		if err := s.db.Save(session).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return s.reviewSessionSummary(session)
+}
+
+// reviewSessionSummary aggregates the answers of a session
+func (s *Service) reviewSessionSummary(session *ReviewSession) (*ReviewSessionSummary, error) {
+	var totals struct {
+		Answered     int
+		Correct      int
+		Graduated    int
+		Lapsed       int
+		ResponseTime float64
+	}
+	err := s.db.Model(&ReviewSessionAnswer{}).
+		Select(`COUNT(*) AS answered,
+            COUNT(*) FILTER (WHERE correct) AS correct,
+            COUNT(*) FILTER (WHERE graduated) AS graduated,
+            COUNT(*) FILTER (WHERE lapsed) AS lapsed,
+            COALESCE(AVG(response_time) FILTER (WHERE response_time > 0), 0) AS response_time`).
+		Where("session_id = ?", session.ID).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ReviewSessionSummary{
+		SessionID:         session.ID,
+		DurationSeconds:   session.ActiveSeconds,
+		TotalCards:        len(session.GetCards()),
+		Answered:          totals.Answered,
+		Correct:           totals.Correct,
+		Graduated:         totals.Graduated,
+		Lapsed:            totals.Lapsed,
+		AverageResponseMs: int(totals.ResponseTime),
+	}
+	if totals.Answered > 0 {
+		summary.Accuracy = percentage(float64(totals.Correct), float64(totals.Answered))
+	}
+
+	return summary, nil
+}
+
+// loadCardState returns a card's SRS state and its entry's tags
+func (s *Service) loadCardState(userID string, card SessionCard) (SRSState, string, error) {
+	var userVocab UserVocabulary
+	err := s.db.Where("user_id = ? AND vocabulary_id = ?", userID, card.VocabularyID).First(&userVocab).Error
+	if err != nil {
+		return SRSState{}, "", errors.New("vocabulary not found")
+	}
+
+	if card.CardType == CardTypeRecognition {
+		return userVocab.SRSState, userVocab.Tags, nil
+	}
+
+	var extra VocabularyCard
+	if err := s.db.Where("id = ? AND user_id = ?", card.CardID, userID).First(&extra).Error; err != nil {
+		return SRSState{}, "", errors.New("card not found")
+	}
+
+	return extra.SRSState, userVocab.Tags, nil
+}
+
+// loadReviewSession finds one of the user's sessions
+func (s *Service) loadReviewSession(userID, sessionID string) (*ReviewSession, error) {
+	var session ReviewSession
+	err := s.db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("review session not found")
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// reviewSessionState renders the session's current card
+func (s *Service) reviewSessionState(ctx context.Context, session *ReviewSession) (*ReviewSessionState, error) {
+	cards := session.GetCards()
+	state := &ReviewSessionState{
+		ReviewSession: *session,
+		TotalCards:    len(cards),
+		Remaining:     len(cards) - session.Position,
+		CanUndo:       session.Position > 0 && session.Status == ReviewSessionActive,
+	}
+
+	// Skip cards deleted since the session started
+	for session.Status != ReviewSessionFinished && session.Position < len(cards) {
+		current := cards[session.Position]
+		entryCards, err := s.GetVocabularyCards(ctx, session.UserID, current.VocabularyID)
+		if err == nil {
+			for i := range entryCards {
+				if entryCards[i].CardID == current.CardID {
+					state.CurrentCard = &entryCards[i]
+					return state, nil
+				}
+			}
+		}
+
+		session.Position++
+		if err := s.db.Model(session).Update("position", session.Position).Error; err != nil {
+			return nil, err
+		}
+		state.Position = session.Position
+		state.Remaining--
+	}
+
+	return state, nil
+}
@@ -0,0 +1,33 @@
+package vocabulary
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewSessionTouch(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	session := &ReviewSession{LastActivityAt: start}
+
+	session.touch(start.Add(40 * time.Second))
+	assert.Equal(t, 40, session.ActiveSeconds)
+
+	// Idle gaps are capped
+	session.touch(start.Add(2 * time.Hour))
+	assert.Equal(t, 40+int(reviewSessionIdleCap.Seconds()), session.ActiveSeconds)
+	assert.Equal(t, start.Add(2*time.Hour), session.LastActivityAt)
+}
+
+func TestReviewSessionCards(t *testing.T) {
+	session := &ReviewSession{}
+	assert.Empty(t, session.GetCards())
+
+	cards := []SessionCard{
+		{CardID: "a", VocabularyID: "v1", CardType: CardTypeRecognition},
+		{CardID: "b", VocabularyID: "v1", CardType: CardTypeCloze},
+	}
+	session.SetCards(cards)
+	assert.Equal(t, cards, session.GetCards())
+}
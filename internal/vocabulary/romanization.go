@@ -0,0 +1,205 @@
+package vocabulary
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Languages whose entries get a latin romanization for search
+const (
+	languageJapanese = "ja"
+	languageChinese  = "zh"
+)
+
+// hiraganaRomaji maps hiragana to Hepburn romaji. Katakana is shifted onto
+// hiragana before lookup.
+var hiraganaRomaji = map[string]string{
+	"あ": "a", "い": "i", "う": "u", "え": "e", "お": "o",
+	"か": "ka", "き": "ki", "く": "ku", "け": "ke", "こ": "ko",
+	"さ": "sa", "し": "shi", "す": "su", "せ": "se", "そ": "so",
+	"た": "ta", "ち": "chi", "つ": "tsu", "て": "te", "と": "to",
+	"な": "na", "に": "ni", "ぬ": "nu", "ね": "ne", "の": "no",
+	"は": "ha", "ひ": "hi", "ふ": "fu", "へ": "he", "ほ": "ho",
+	"ま": "ma", "み": "mi", "む": "mu", "め": "me", "も": "mo",
+	"や": "ya", "ゆ": "yu", "よ": "yo",
+	"ら": "ra", "り": "ri", "る": "ru", "れ": "re", "ろ": "ro",
+	"わ": "wa", "ゐ": "i", "ゑ": "e", "を": "o", "ん": "n",
+	"が": "ga", "ぎ": "gi", "ぐ": "gu", "げ": "ge", "ご": "go",
+	"ざ": "za", "じ": "ji", "ず": "zu", "ぜ": "ze", "ぞ": "zo",
+	"だ": "da", "ぢ": "ji", "づ": "zu", "で": "de", "ど": "do",
+	"ば": "ba", "び": "bi", "ぶ": "bu", "べ": "be", "ぼ": "bo",
+	"ぱ": "pa", "ぴ": "pi", "ぷ": "pu", "ぺ": "pe", "ぽ": "po",
+	"ぁ": "a", "ぃ": "i", "ぅ": "u", "ぇ": "e", "ぉ": "o",
+	"ゃ": "ya", "ゅ": "yu", "ょ": "yo", "ゎ": "wa", "ゔ": "vu",
+
+	"きゃ": "kya", "きゅ": "kyu", "きょ": "kyo",
+	"しゃ": "sha", "しゅ": "shu", "しょ": "sho", "しぇ": "she",
+	"ちゃ": "cha", "ちゅ": "chu", "ちょ": "cho", "ちぇ": "che",
+	"にゃ": "nya", "にゅ": "nyu", "にょ": "nyo",
+	"ひゃ": "hya", "ひゅ": "hyu", "ひょ": "hyo",
+	"みゃ": "mya", "みゅ": "myu", "みょ": "myo",
+	"りゃ": "rya", "りゅ": "ryu", "りょ": "ryo",
+	"ぎゃ": "gya", "ぎゅ": "gyu", "ぎょ": "gyo",
+	"じゃ": "ja", "じゅ": "ju", "じょ": "jo", "じぇ": "je",
+	"ぢゃ": "ja", "ぢゅ": "ju", "ぢょ": "jo",
+	"びゃ": "bya", "びゅ": "byu", "びょ": "byo",
+	"ぴゃ": "pya", "ぴゅ": "pyu", "ぴょ": "pyo",
+	"ふぁ": "fa", "ふぃ": "fi", "ふぇ": "fe", "ふぉ": "fo",
+	"てぃ": "ti", "でぃ": "di", "とぅ": "tu", "どぅ": "du",
+	"ゔぁ": "va", "ゔぃ": "vi", "ゔぇ": "ve", "ゔぉ": "vo",
+	"うぃ": "wi", "うぇ": "we", "うぉ": "wo",
+}
+
+// toHiragana shifts katakana onto the matching hiragana
+func toHiragana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - 0x60
+	}
+	return r
+}
+
+func isKana(r rune) bool {
+	return unicode.In(r, unicode.Hiragana, unicode.Katakana) || r == 'ー'
+}
+
+// containsKana reports whether s contains at least one kana character
+func containsKana(s string) bool {
+	for _, r := range s {
+		if isKana(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKanaOnly reports whether every letter of s is kana
+func isKanaOnly(s string) bool {
+	found := false
+	for _, r := range s {
+		switch {
+		case isKana(r):
+			found = true
+		case unicode.IsLetter(r):
+			return false
+		}
+	}
+	return found
+}
+
+// kanaToRomaji transliterates hiragana and katakana to Hepburn romaji, leaving
+// other characters untouched. The sokuon doubles the next consonant and the
+// long vowel mark repeats the previous vowel.
+func kanaToRomaji(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = toHiragana(r)
+	}
+
+	var out strings.Builder
+	doubleNext := false
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if r == 'っ' {
+			doubleNext = true
+			continue
+		}
+
+		if r == 'ー' {
+			if written := out.String(); written != "" {
+				if last := written[len(written)-1]; strings.ContainsRune("aeiou", rune(last)) {
+					out.WriteByte(last)
+				}
+			}
+			continue
+		}
+
+		romaji := ""
+		if i+1 < len(runes) {
+			if pair, ok := hiraganaRomaji[string(runes[i:i+2])]; ok {
+				romaji = pair
+				i++
+			}
+		}
+		if romaji == "" {
+			if single, ok := hiraganaRomaji[string(r)]; ok {
+				romaji = single
+			}
+		}
+
+		if romaji == "" {
+			doubleNext = false
+			out.WriteRune(r)
+			continue
+		}
+
+		if doubleNext {
+			if strings.HasPrefix(romaji, "ch") {
+				out.WriteByte('t')
+			} else if !strings.ContainsRune("aeiou", rune(romaji[0])) {
+				out.WriteByte(romaji[0])
+			}
+			doubleNext = false
+		}
+
+		out.WriteString(romaji)
+	}
+
+	return out.String()
+}
+
+// romanizationKey reduces a romanization to the form used for matching:
+// lower-case latin letters only, so "Nǐ hǎo", "ni3 hao3" and "nihao" agree
+func romanizationKey(s string) string {
+	var out strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		if r >= 'a' && r <= 'z' {
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+// isLatin reports whether every letter of s is latin
+func isLatin(s string) bool {
+	found := false
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			if !unicode.Is(unicode.Latin, r) {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// romanize returns the search key of a Japanese or Chinese entry: romaji
+// derived from kana in the word or its reading, or the pinyin given as
+// phonetic transcription. provided overrides both. Other languages have none.
+func romanize(languageCode, word, phonetic, provided string) string {
+	if provided != "" {
+		return romanizationKey(kanaToRomaji(provided))
+	}
+
+	switch languageCode {
+	case languageJapanese:
+		switch {
+		case isKanaOnly(word):
+			return romanizationKey(kanaToRomaji(word))
+		case containsKana(phonetic):
+			return romanizationKey(kanaToRomaji(phonetic))
+		case isLatin(phonetic):
+			return romanizationKey(phonetic)
+		}
+	case languageChinese:
+		if isLatin(phonetic) {
+			return romanizationKey(phonetic)
+		}
+	}
+
+	return ""
+}
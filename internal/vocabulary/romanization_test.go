@@ -0,0 +1,41 @@
+package vocabulary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKanaToRomaji(t *testing.T) {
+	cases := map[string]string{
+		"ねこ":    "neko",
+		"きょう":   "kyou",
+		"がっこう":  "gakkou",
+		"まっちゃ":  "matcha",
+		"コーヒー":  "koohii",
+		"とうきょう": "toukyou",
+		"しんぶん":  "shinbun",
+		"猫のミルク": "猫nomiruku",
+		"hello": "hello",
+	}
+
+	for kana, romaji := range cases {
+		assert.Equal(t, romaji, kanaToRomaji(kana), kana)
+	}
+}
+
+func TestRomanizationKey(t *testing.T) {
+	assert.Equal(t, "nihao", romanizationKey("Nǐ hǎo"))
+	assert.Equal(t, "nihao", romanizationKey("ni3 hao3"))
+	assert.Equal(t, "lu", romanizationKey("lǜ"))
+}
+
+func TestRomanize(t *testing.T) {
+	assert.Equal(t, "neko", romanize(languageJapanese, "ねこ", "", ""))
+	assert.Equal(t, "neko", romanize(languageJapanese, "猫", "ねこ", ""))
+	assert.Equal(t, "neko", romanize(languageJapanese, "猫", "neko", ""))
+	assert.Equal(t, "", romanize(languageJapanese, "猫", "", ""))
+	assert.Equal(t, "maomi", romanize(languageChinese, "猫咪", "māo mī", ""))
+	assert.Equal(t, "", romanize("es", "gato", "ˈɡato", ""))
+	assert.Equal(t, "xiexie", romanize(languageChinese, "谢谢", "", "xiè xie"))
+}
@@ -1,6 +1,7 @@
 package vocabulary
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -59,16 +60,54 @@ func NewRouter(service *Service) *gin.Engine {
 		protected.GET("/", vocabularyRouter.GetUserVocabulary)
 		protected.PUT("/:id", vocabularyRouter.UpdateVocabulary)
 		protected.DELETE("/:id", vocabularyRouter.DeleteVocabulary)
+		protected.GET("/:id/cards", vocabularyRouter.GetVocabularyCards)
+		protected.PUT("/:id/tags", vocabularyRouter.SetVocabularyTags)
+
+		// Tags and smart filters
+		protected.GET("/tags", vocabularyRouter.GetTags)
+		protected.POST("/bulk-tag", vocabularyRouter.BulkTagVocabulary)
+		protected.GET("/filters", vocabularyRouter.GetSmartFilters)
+		protected.POST("/filters", vocabularyRouter.CreateSmartFilter)
+		protected.GET("/filters/:filter_id", vocabularyRouter.GetSmartFilter)
+		protected.PUT("/filters/:filter_id", vocabularyRouter.UpdateSmartFilter)
+		protected.DELETE("/filters/:filter_id", vocabularyRouter.DeleteSmartFilter)
+		protected.GET("/filters/:filter_id/vocabulary", vocabularyRouter.EvaluateSmartFilter)
 
 		// Review system
 		protected.GET("/reviews", vocabularyRouter.GetVocabularyForReview)
 		protected.POST("/reviews", vocabularyRouter.ReviewVocabulary)
+		protected.GET("/reviews/queue", vocabularyRouter.GetReviewQueue)
+
+		// Review sessions
+		protected.POST("/reviews/sessions", vocabularyRouter.StartReviewSession)
+		protected.GET("/reviews/sessions", vocabularyRouter.GetReviewSessions)
+		protected.GET("/reviews/sessions/:session_id", vocabularyRouter.GetReviewSession)
+		protected.POST("/reviews/sessions/:session_id/answer", vocabularyRouter.AnswerReviewSession)
+		protected.POST("/reviews/sessions/:session_id/undo", vocabularyRouter.UndoReviewSessionAnswer)
+		protected.POST("/reviews/sessions/:session_id/pause", vocabularyRouter.PauseReviewSession)
+		protected.POST("/reviews/sessions/:session_id/resume", vocabularyRouter.ResumeReviewSession)
+		protected.POST("/reviews/sessions/:session_id/finish", vocabularyRouter.FinishReviewSession)
+
+		// Leeches and card suspension
+		protected.GET("/leeches", vocabularyRouter.GetLeeches)
+		protected.POST("/cards/suspend", vocabularyRouter.SuspendCards)
+		protected.POST("/cards/unsuspend", vocabularyRouter.UnsuspendCards)
+		protected.POST("/cards/bury", vocabularyRouter.BuryCards)
 		protected.POST("/reviews/batch", vocabularyRouter.BatchReviewVocabulary)
+		protected.POST("/reviews/sync", vocabularyRouter.SyncReviews)
 
 		// Statistics and analytics
 		protected.GET("/stats", vocabularyRouter.GetVocabularyStats)
 		protected.GET("/progress", vocabularyRouter.GetVocabularyProgress)
 
+		// Vocabulary mining from episode transcripts
+		protected.GET("/mining/episodes/:episode_id", vocabularyRouter.MineEpisode)
+		protected.POST("/mining/episodes/:episode_id", vocabularyRouter.AddMinedWords)
+
+		// Word frequency and coverage
+		protected.GET("/coverage", vocabularyRouter.GetCoverage)
+		protected.GET("/useful-words", vocabularyRouter.GetUsefulWords)
+
 		// Search and filter
 		protected.GET("/search", vocabularyRouter.SearchVocabulary)
 		protected.GET("/filter", vocabularyRouter.FilterVocabulary)
@@ -77,10 +116,18 @@ func NewRouter(service *Service) *gin.Engine {
 		protected.POST("/import", vocabularyRouter.ImportVocabulary)
 		protected.GET("/export", vocabularyRouter.ExportVocabulary)
 
+		// Background import/export jobs
+		protected.POST("/jobs", vocabularyRouter.CreateJob)
+		protected.GET("/jobs", vocabularyRouter.GetJobs)
+		protected.GET("/jobs/:job_id", vocabularyRouter.GetJob)
+		protected.GET("/jobs/:job_id/download", vocabularyRouter.DownloadJobResult)
+
 		// SRS Configuration
 		protected.GET("/srs-config", vocabularyRouter.GetSRSConfig)
 		protected.PUT("/srs-config", vocabularyRouter.UpdateSRSConfig)
 		protected.PUT("/srs-config/preset/:preset", vocabularyRouter.ApplySRSPreset)
+		protected.POST("/srs-config/simulate", vocabularyRouter.SimulateSRS)
+		protected.POST("/srs-config/optimize", vocabularyRouter.OptimizeSRS)
 
 		// Vocabulary lists and collections
 		protected.GET("/lists", vocabularyRouter.GetVocabularyLists)
@@ -89,12 +136,39 @@ func NewRouter(service *Service) *gin.Engine {
 		protected.PUT("/lists/:list_id", vocabularyRouter.UpdateVocabularyList)
 		protected.DELETE("/lists/:list_id", vocabularyRouter.DeleteVocabularyList)
 
+		// Public lists
+		protected.GET("/lists/public", vocabularyRouter.GetPublicVocabularyLists)
+		protected.GET("/lists/public/:list_id", vocabularyRouter.GetPublicVocabularyList)
+		protected.GET("/lists/subscriptions", vocabularyRouter.GetSubscribedVocabularyLists)
+		protected.POST("/lists/:list_id/fork", vocabularyRouter.ForkVocabularyList)
+		protected.POST("/lists/:list_id/subscribe", vocabularyRouter.SubscribeToVocabularyList)
+		protected.DELETE("/lists/:list_id/subscribe", vocabularyRouter.UnsubscribeFromVocabularyList)
+
+		// List items and collaboration
+		protected.POST("/lists/:list_id/items", vocabularyRouter.AddVocabularyToList)
+		protected.DELETE("/lists/:list_id/items/:vocabulary_id", vocabularyRouter.RemoveVocabularyFromList)
+		protected.PUT("/lists/:list_id/items/order", vocabularyRouter.ReorderVocabularyList)
+		protected.GET("/lists/:list_id/members", vocabularyRouter.GetVocabularyListMembers)
+		protected.PUT("/lists/:list_id/members/:user_id", vocabularyRouter.SetVocabularyListMember)
+		protected.DELETE("/lists/:list_id/members/:user_id", vocabularyRouter.RemoveVocabularyListMember)
+		protected.GET("/lists/:list_id/progress", vocabularyRouter.GetVocabularyListProgress)
+		protected.POST("/lists/:list_id/groups", vocabularyRouter.AttachListToGroup)
+		protected.DELETE("/lists/:list_id/groups/:group_id", vocabularyRouter.DetachListFromGroup)
+		protected.GET("/groups/:group_id/lists", vocabularyRouter.GetGroupVocabularyLists)
+
 		// Bulk operations
 		protected.POST("/bulk-add", vocabularyRouter.BulkAddVocabulary)
 		protected.POST("/bulk-delete", vocabularyRouter.BulkDeleteVocabulary)
 		protected.POST("/bulk-reset", vocabularyRouter.BulkResetProgress)
 	}
 
+	// Shared reference data
+	admin := v1.Group("/vocabulary/admin")
+	admin.Use(vocabularyRouter.jwtService.AdminMiddleware())
+	{
+		admin.POST("/frequency-lists", vocabularyRouter.ImportFrequencyList)
+	}
+
 	return router
 }
 
@@ -299,9 +373,37 @@ func (r *Router) DeleteVocabulary(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Vocabulary deleted successfully"})
 }
 
+// GetVocabularyCards godoc
+// @Summary      Get vocabulary cards
+// @Description  Get every active card (recognition, production, cloze, listening) of a vocabulary word
+// @Tags         vocabulary
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Vocabulary ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/{id}/cards [get]
+func (r *Router) GetVocabularyCards(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	cards, err := r.service.GetVocabularyCards(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cards": cards})
+}
+
 // GetVocabularyForReview godoc
 // @Summary      Get vocabulary for review
-// @Description  Get vocabulary words that are due for SRS review
+// @Description  Get cards of every enabled card type that are due for SRS review. The cards are returned under "cards" and, for older clients, under the deprecated "vocabulary" key, which will be removed in a future release.
 // @Tags         reviews
 // @Accept       json
 // @Produce      json
@@ -339,18 +441,108 @@ func (r *Router) GetVocabularyForReview(c *gin.Context) {
 		}
 	}
 
-	vocab, err := r.service.GetVocabularyForReview(c.Request.Context(), userID, languageID, limit)
+	cards, err := r.service.GetVocabularyForReview(c.Request.Context(), userID, languageID, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// "vocabulary" is kept for clients written before card types existed
 	c.JSON(http.StatusOK, gin.H{
-		"vocabulary": vocab,
-		"count":      len(vocab),
+		"cards":      cards,
+		"vocabulary": cards,
+		"count":      len(cards),
 	})
 }
 
+// GetReviewQueue godoc
+// @Summary      Get today's review queue
+// @Description  Build today's study session in the user's timezone: learning cards, then due reviews up to the daily review cap, then new cards up to the daily new-word limit
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        language_id query int true "Language ID"
+// @Param        strategy query string false "Ordering strategy (sequential, interleaved, new_first)" default(sequential)
+// @Param        list_id query string false "Only include words from this list"
+// @Param        tag query string false "Only include words with this tag"
+// @Param        filter_id query string false "Only include words matching this smart filter"
+// @Param        card_type query string false "Only include this card type"
+// @Param        study_ahead_days query int false "Also include reviews due within this many days (max 30)" default(0)
+// @Param        timezone query string false "IANA timezone overriding the account timezone"
+// @Param        limit query int false "Maximum number of cards to return (0 for no limit)" default(0)
+// @Success      200 {object} ReviewQueue
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/reviews/queue [get]
+func (r *Router) GetReviewQueue(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	opts := QueueOptions{
+		Strategy: c.DefaultQuery("strategy", QueueStrategySequential),
+		ListID:   c.Query("list_id"),
+		Tag:      c.Query("tag"),
+		FilterID: c.Query("filter_id"),
+		CardType: c.Query("card_type"),
+		Timezone: c.Query("timezone"),
+	}
+
+	if lang := c.Query("language_id"); lang != "" {
+		if id, err := strconv.Atoi(lang); err == nil {
+			opts.LanguageID = id
+		}
+	}
+
+	if opts.LanguageID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "language_id is required"})
+		return
+	}
+
+	switch opts.Strategy {
+	case QueueStrategySequential, QueueStrategyInterleaved, QueueStrategyNewFirst:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "strategy must be one of sequential, interleaved, new_first"})
+		return
+	}
+
+	if opts.CardType != "" && !isCardType(opts.CardType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card_type"})
+		return
+	}
+
+	if opts.Timezone != "" {
+		if _, err := time.LoadLocation(opts.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
+			return
+		}
+	}
+
+	if days := c.Query("study_ahead_days"); days != "" {
+		if parsed, err := strconv.Atoi(days); err == nil && parsed >= 0 && parsed <= 30 {
+			opts.StudyAheadDays = parsed
+		}
+	}
+
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed >= 0 {
+			opts.Limit = parsed
+		}
+	}
+
+	queue, err := r.service.BuildReviewQueue(c.Request.Context(), userID, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
 // ReviewVocabulary godoc
 // @Summary      Review vocabulary
 // @Description  Submit a review for a vocabulary word using SRS algorithm
@@ -381,7 +573,7 @@ func (r *Router) ReviewVocabulary(c *gin.Context) {
 		return
 	}
 
-	userVocab, err := r.service.ReviewVocabulary(c.Request.Context(), userID, req)
+	card, err := r.service.ReviewVocabulary(c.Request.Context(), userID, req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -389,7 +581,8 @@ func (r *Router) ReviewVocabulary(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Review completed successfully",
-		"vocabulary": userVocab,
+		"card":       card,
+		"vocabulary": card.UserVocabulary,
 	})
 }
 
@@ -435,6 +628,51 @@ func (r *Router) BatchReviewVocabulary(c *gin.Context) {
 	})
 }
 
+// SyncReviews godoc
+// @Summary      Sync offline reviews
+// @Description  Replay reviews given offline in the order they were given and return the cards changed since the device's last sync. Each event needs an idempotency key so retried uploads are applied once; when a card was already reviewed later on another device the older review is superseded.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        request body SyncReviewsRequest true "Review events and last sync token"
+// @Success      200 {object} SyncReviewsResponse
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/reviews/sync [post]
+func (r *Router) SyncReviews(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req SyncReviewsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := parseSyncToken(req.SyncToken); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := r.service.SyncReviews(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // GetVocabularyStats godoc
 // @Summary      Get vocabulary statistics
 // @Description  Get comprehensive statistics for user's vocabulary in a specific language
@@ -521,7 +759,7 @@ func (r *Router) GetVocabularyProgress(c *gin.Context) {
 
 // SearchVocabulary godoc
 // @Summary      Search vocabulary
-// @Description  Search vocabulary words by term in word, definition, or notes
+// @Description  Ranked full-text and typo-tolerant search over words, translations, definitions, example sentences and notes. Accents are ignored and Japanese/Chinese words match romaji/pinyin. Snippets highlight matches with <mark>.
 // @Tags         search
 // @Accept       json
 // @Produce      json
@@ -663,6 +901,7 @@ func (r *Router) ImportVocabulary(c *gin.Context) {
 // @Produce      json
 // @Param        language_id query int false "Language ID (optional for all languages)"
 // @Param        format query string false "Export format (json, csv)" default(json)
+// @Param        filter_id query string false "Only export entries matching this smart filter"
 // @Success      200 {string} string "File content"
 // @Failure      401 {object} map[string]string
 // @Failure      500 {object} map[string]string
@@ -687,7 +926,7 @@ func (r *Router) ExportVocabulary(c *gin.Context) {
 		format = "json"
 	}
 
-	data, err := r.service.ExportVocabulary(c.Request.Context(), userID, languageID, format)
+	data, err := r.service.ExportVocabulary(c.Request.Context(), userID, languageID, format, c.Query("filter_id"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -706,6 +945,172 @@ func (r *Router) ExportVocabulary(c *gin.Context) {
 	c.String(http.StatusOK, data)
 }
 
+// CreateJob godoc
+// @Summary      Create import/export job
+// @Description  Queue a vocabulary import or export to run in the background
+// @Tags         import-export
+// @Accept       json
+// @Produce      json
+// @Param        request body CreateJobRequest true "Job data"
+// @Success      202 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/jobs [post]
+func (r *Router) CreateJob(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := r.service.CreateJob(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Job queued successfully",
+		"job_id":  job.ID,
+		"job":     job,
+	})
+}
+
+// GetJobs godoc
+// @Summary      List import/export jobs
+// @Description  Get the user's background import, export and list delivery jobs, newest first
+// @Tags         import-export
+// @Accept       json
+// @Produce      json
+// @Param        limit query int false "Number of items to return (max 100)" default(20)
+// @Param        offset query int false "Number of items to skip" default(0)
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/jobs [get]
+func (r *Router) GetJobs(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	offset := 0
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	jobs, total, err := r.service.GetJobs(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":   jobs,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetJob godoc
+// @Summary      Get job status
+// @Description  Get the status and progress of a background import/export job
+// @Tags         import-export
+// @Accept       json
+// @Produce      json
+// @Param        job_id path string true "Job ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/jobs/{job_id} [get]
+func (r *Router) GetJob(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	job, err := r.service.GetJob(c.Request.Context(), userID, c.Param("job_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+// DownloadJobResult godoc
+// @Summary      Download job result
+// @Description  Download the file produced by a finished export job, or the summary of an import job
+// @Tags         import-export
+// @Produce      json
+// @Param        job_id path string true "Job ID"
+// @Success      200 {string} string "File content"
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/jobs/{job_id}/download [get]
+func (r *Router) DownloadJobResult(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	job, err := r.service.GetJobResult(c.Request.Context(), userID, c.Param("job_id"))
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, errJobNotFinished) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if job.Type == JobTypeImport {
+		c.Header("Content-Type", "application/json")
+		c.String(http.StatusOK, job.Result)
+		return
+	}
+
+	switch job.Format {
+	case "csv":
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", "attachment; filename=vocabulary.csv")
+	case "json":
+		c.Header("Content-Type", "application/json")
+		c.Header("Content-Disposition", "attachment; filename=vocabulary.json")
+	}
+
+	c.String(http.StatusOK, job.Result)
+}
+
 // GetSRSConfig godoc
 // @Summary      Get SRS configuration
 // @Description  Get current Spaced Repetition System configuration for the user
@@ -812,35 +1217,113 @@ func (r *Router) ApplySRSPreset(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "SRS preset applied successfully"})
 }
 
-// GetVocabularyLists godoc
-// @Summary      Get vocabulary lists
-// @Description  Get all vocabulary lists for the user, optionally filtered by language
-// @Tags         lists
+// SimulateSRS godoc
+// @Summary      Simulate SRS configs
+// @Description  Project daily review load, new-card throughput and retention under the current config and compare it with a preset, a custom config or, when neither is given, every preset
+// @Tags         srs
 // @Accept       json
 // @Produce      json
-// @Param        language_id query int false "Language ID (optional for all languages)"
-// @Success      200 {object} map[string]interface{}
+// @Param        request body SimulateSRSRequest true "Simulation settings"
+// @Success      200 {object} SRSSimulationComparison
+// @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
-// @Failure      500 {object} map[string]string
 // @Security     BearerAuth
-// @Router       /vocabulary/lists [get]
-func (r *Router) GetVocabularyLists(c *gin.Context) {
+// @Router       /vocabulary/srs-config/simulate [post]
+func (r *Router) SimulateSRS(c *gin.Context) {
 	userID, exists := polyfyjwt.GetUserIDFromContext(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	languageID := 0
-	if lang := c.Query("language_id"); lang != "" {
-		if id, err := strconv.Atoi(lang); err == nil {
-			languageID = id
-		}
-	}
-
-	lists, err := r.service.GetVocabularyLists(c.Request.Context(), userID, languageID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var req SimulateSRSRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comparison, err := r.service.SimulateSRS(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"simulation": comparison})
+}
+
+// OptimizeSRS godoc
+// @Summary      Optimise SRS parameters
+// @Description  Fit a memory model to the user's review history and suggest scheduler parameters that reach the target retention with the fewest reviews. The suggestion is not applied.
+// @Tags         srs
+// @Accept       json
+// @Produce      json
+// @Param        request body OptimizeSRSRequest true "Optimiser settings"
+// @Success      200 {object} SRSOptimization
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/srs-config/optimize [post]
+func (r *Router) OptimizeSRS(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req OptimizeSRSRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	optimization, err := r.service.OptimizeSRS(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"optimization": optimization})
+}
+
+// GetVocabularyLists godoc
+// @Summary      Get vocabulary lists
+// @Description  Get all vocabulary lists for the user, optionally filtered by language
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        language_id query int false "Language ID (optional for all languages)"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists [get]
+func (r *Router) GetVocabularyLists(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	languageID := 0
+	if lang := c.Query("language_id"); lang != "" {
+		if id, err := strconv.Atoi(lang); err == nil {
+			languageID = id
+		}
+	}
+
+	lists, err := r.service.GetVocabularyLists(c.Request.Context(), userID, languageID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -1149,3 +1632,1505 @@ func CORSMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// GetLeeches godoc
+// @Summary      Get leeches
+// @Description  Get cards that lapsed at least as often as the user's leech threshold, most lapses first. None are returned when the threshold is 0, which disables leech detection.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        language_id query int true "Language ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/leeches [get]
+func (r *Router) GetLeeches(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	languageID := 0
+	if lang := c.Query("language_id"); lang != "" {
+		if id, err := strconv.Atoi(lang); err == nil {
+			languageID = id
+		}
+	}
+
+	if languageID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "language_id is required"})
+		return
+	}
+
+	leeches, err := r.service.GetLeeches(c.Request.Context(), userID, languageID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cards": leeches,
+		"count": len(leeches),
+	})
+}
+
+// SuspendCards godoc
+// @Summary      Suspend cards
+// @Description  Exclude cards from review queues and statistics until they are unsuspended
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        request body CardActionRequest true "Card IDs"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/cards/suspend [post]
+func (r *Router) SuspendCards(c *gin.Context) {
+	r.handleCardAction(c, "Cards suspended", func(userID string, req CardActionRequest) (*BulkOperationResult, error) {
+		return r.service.SuspendCards(c.Request.Context(), userID, req.CardIDs, true)
+	})
+}
+
+// UnsuspendCards godoc
+// @Summary      Unsuspend cards
+// @Description  Return suspended cards to review queues
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        request body CardActionRequest true "Card IDs"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/cards/unsuspend [post]
+func (r *Router) UnsuspendCards(c *gin.Context) {
+	r.handleCardAction(c, "Cards unsuspended", func(userID string, req CardActionRequest) (*BulkOperationResult, error) {
+		return r.service.SuspendCards(c.Request.Context(), userID, req.CardIDs, false)
+	})
+}
+
+// BuryCards godoc
+// @Summary      Bury cards
+// @Description  Hide cards from review queues until the start of the user's next day
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        request body CardActionRequest true "Card IDs"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/cards/bury [post]
+func (r *Router) BuryCards(c *gin.Context) {
+	r.handleCardAction(c, "Cards buried", func(userID string, req CardActionRequest) (*BulkOperationResult, error) {
+		return r.service.BuryCards(c.Request.Context(), userID, req.CardIDs)
+	})
+}
+
+// handleCardAction binds and validates a CardActionRequest and runs action
+func (r *Router) handleCardAction(c *gin.Context, message string, action func(userID string, req CardActionRequest) (*BulkOperationResult, error)) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CardActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := action(userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"result":  result,
+	})
+}
+
+// SetVocabularyTags godoc
+// @Summary      Set vocabulary tags
+// @Description  Replace the tags of a vocabulary word
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Vocabulary ID"
+// @Param        request body SetTagsRequest true "Tags"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/{id}/tags [put]
+func (r *Router) SetVocabularyTags(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req SetTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vocab, err := r.service.SetVocabularyTags(c.Request.Context(), userID, c.Param("id"), req.Tags)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Tags updated successfully",
+		"vocabulary": vocab,
+		"tags":       vocab.GetTags(),
+	})
+}
+
+// GetTags godoc
+// @Summary      Get tags
+// @Description  Get every tag the user uses with the number of words carrying it
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        language_id query int false "Language ID (optional for all languages)"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/tags [get]
+func (r *Router) GetTags(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	languageID := 0
+	if lang := c.Query("language_id"); lang != "" {
+		if id, err := strconv.Atoi(lang); err == nil {
+			languageID = id
+		}
+	}
+
+	tags, err := r.service.GetTags(c.Request.Context(), userID, languageID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// BulkTagVocabulary godoc
+// @Summary      Bulk tag vocabulary
+// @Description  Add and remove tags on multiple vocabulary words, or on every word matching a smart filter
+// @Tags         bulk
+// @Accept       json
+// @Produce      json
+// @Param        request body BulkTagRequest true "Bulk tag data"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/bulk-tag [post]
+func (r *Router) BulkTagVocabulary(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := r.service.BulkTagVocabulary(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Bulk tag operation completed",
+		"result":  result,
+	})
+}
+
+// GetSmartFilters godoc
+// @Summary      Get smart filters
+// @Description  Get the user's saved smart filters with their current match counts
+// @Tags         filters
+// @Accept       json
+// @Produce      json
+// @Param        language_id query int false "Language ID (optional for all languages)"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/filters [get]
+func (r *Router) GetSmartFilters(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	languageID := 0
+	if lang := c.Query("language_id"); lang != "" {
+		if id, err := strconv.Atoi(lang); err == nil {
+			languageID = id
+		}
+	}
+
+	filters, err := r.service.GetSmartFilters(c.Request.Context(), userID, languageID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"filters": filters})
+}
+
+// CreateSmartFilter godoc
+// @Summary      Create smart filter
+// @Description  Save a filter over tags, source content, added date, lapses, due window, difficulty and mastery. It can scope review queues, exports and bulk operations.
+// @Tags         filters
+// @Accept       json
+// @Produce      json
+// @Param        request body CreateSmartFilterRequest true "Filter data"
+// @Success      201 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/filters [post]
+func (r *Router) CreateSmartFilter(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateSmartFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := r.service.CreateSmartFilter(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Smart filter created successfully",
+		"filter":  filter,
+	})
+}
+
+// GetSmartFilter godoc
+// @Summary      Get smart filter
+// @Description  Get a saved smart filter
+// @Tags         filters
+// @Accept       json
+// @Produce      json
+// @Param        filter_id path string true "Filter ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/filters/{filter_id} [get]
+func (r *Router) GetSmartFilter(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	filter, err := r.service.GetSmartFilter(c.Request.Context(), userID, c.Param("filter_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"filter": filter})
+}
+
+// UpdateSmartFilter godoc
+// @Summary      Update smart filter
+// @Description  Update the name, description or criteria of a smart filter
+// @Tags         filters
+// @Accept       json
+// @Produce      json
+// @Param        filter_id path string true "Filter ID"
+// @Param        request body UpdateSmartFilterRequest true "Updated filter data"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/filters/{filter_id} [put]
+func (r *Router) UpdateSmartFilter(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req UpdateSmartFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := r.service.UpdateSmartFilter(c.Request.Context(), userID, c.Param("filter_id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Smart filter updated successfully",
+		"filter":  filter,
+	})
+}
+
+// DeleteSmartFilter godoc
+// @Summary      Delete smart filter
+// @Description  Delete a saved smart filter. The matching words are not affected.
+// @Tags         filters
+// @Accept       json
+// @Produce      json
+// @Param        filter_id path string true "Filter ID"
+// @Success      200 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/filters/{filter_id} [delete]
+func (r *Router) DeleteSmartFilter(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := r.service.DeleteSmartFilter(c.Request.Context(), userID, c.Param("filter_id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Smart filter deleted successfully"})
+}
+
+// EvaluateSmartFilter godoc
+// @Summary      Get smart filter matches
+// @Description  Get the words currently matching a smart filter
+// @Tags         filters
+// @Accept       json
+// @Produce      json
+// @Param        filter_id path string true "Filter ID"
+// @Param        limit query int false "Number of items to return (max 100)" default(20)
+// @Param        offset query int false "Number of items to skip" default(0)
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/filters/{filter_id}/vocabulary [get]
+func (r *Router) EvaluateSmartFilter(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	offset := 0
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	vocab, total, err := r.service.EvaluateSmartFilter(c.Request.Context(), userID, c.Param("filter_id"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"vocabulary": vocab,
+		"total":      total,
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// GetPublicVocabularyLists godoc
+// @Summary      Browse public lists
+// @Description  Discover vocabulary lists shared by other users, by language, tag, text and popularity
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        language_id query int false "Language ID"
+// @Param        tag query string false "Only lists with this tag"
+// @Param        q query string false "Text matched against name and description"
+// @Param        sort_by query string false "Sort order (popular, recent, name)" default(popular)
+// @Param        limit query int false "Number of items to return (max 100)" default(20)
+// @Param        offset query int false "Number of items to skip" default(0)
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/public [get]
+func (r *Router) GetPublicVocabularyLists(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	filter := PublicListFilter{
+		Tag:    c.Query("tag"),
+		Query:  c.Query("q"),
+		SortBy: c.DefaultQuery("sort_by", PublicListSortPopular),
+		Limit:  20,
+	}
+
+	switch filter.SortBy {
+	case PublicListSortPopular, PublicListSortRecent, PublicListSortName:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort_by must be popular, recent or name"})
+		return
+	}
+
+	if lang := c.Query("language_id"); lang != "" {
+		if id, err := strconv.Atoi(lang); err == nil {
+			filter.LanguageID = id
+		}
+	}
+
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			filter.Limit = parsed
+		}
+	}
+
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			filter.Offset = parsed
+		}
+	}
+
+	lists, total, err := r.service.GetPublicVocabularyLists(c.Request.Context(), userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lists":  lists,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+// GetPublicVocabularyList godoc
+// @Summary      Get public list
+// @Description  Read-only view of a public vocabulary list and its words
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        list_id path string true "List ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/public/{list_id} [get]
+func (r *Router) GetPublicVocabularyList(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	list, err := r.service.GetPublicVocabularyList(c.Request.Context(), userID, c.Param("list_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"list": list})
+}
+
+// GetSubscribedVocabularyLists godoc
+// @Summary      Get subscribed lists
+// @Description  Get the public lists the user is subscribed to
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/subscriptions [get]
+func (r *Router) GetSubscribedVocabularyLists(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	lists, err := r.service.GetSubscribedVocabularyLists(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lists": lists})
+}
+
+// ForkVocabularyList godoc
+// @Summary      Fork public list
+// @Description  Copy a public list into a new list of your own and add its words to your vocabulary with fresh SRS state
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        list_id path string true "List ID"
+// @Param        request body ForkVocabularyListRequest false "Fork options"
+// @Success      201 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/{list_id}/fork [post]
+func (r *Router) ForkVocabularyList(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req ForkVocabularyListRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := r.service.ForkVocabularyList(c.Request.Context(), userID, c.Param("list_id"), req)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Vocabulary list forked successfully",
+		"result":  result,
+	})
+}
+
+// SubscribeToVocabularyList godoc
+// @Summary      Subscribe to list
+// @Description  Add the words of a list you can view to your vocabulary, and keep adding the words its editors add later
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        list_id path string true "List ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/{list_id}/subscribe [post]
+func (r *Router) SubscribeToVocabularyList(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	result, err := r.service.SubscribeToVocabularyList(c.Request.Context(), userID, c.Param("list_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Subscribed to vocabulary list",
+		"result":  result,
+	})
+}
+
+// UnsubscribeFromVocabularyList godoc
+// @Summary      Unsubscribe from public list
+// @Description  Stop receiving new words from a public list. Words already added are kept.
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        list_id path string true "List ID"
+// @Success      200 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/{list_id}/subscribe [delete]
+func (r *Router) UnsubscribeFromVocabularyList(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := r.service.UnsubscribeFromVocabularyList(c.Request.Context(), userID, c.Param("list_id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed from vocabulary list"})
+}
+
+// AddVocabularyToList godoc
+// @Summary      Add word to list
+// @Description  Append a word from your vocabulary to a list you own or edit. The owner receives the word at once; subscribers receive it from a background list_delivery job.
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        list_id path string true "List ID"
+// @Param        request body AddListItemRequest true "Word to add"
+// @Success      201 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/{list_id}/items [post]
+func (r *Router) AddVocabularyToList(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req AddListItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.service.AddVocabularyToList(c.Request.Context(), userID, c.Param("list_id"), req.VocabularyID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Vocabulary added to list"})
+}
+
+// RemoveVocabularyFromList godoc
+// @Summary      Remove word from list
+// @Description  Take a word off a list you own or edit. Learners keep the word in their vocabulary.
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        list_id path string true "List ID"
+// @Param        vocabulary_id path string true "Vocabulary ID"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/{list_id}/items/{vocabulary_id} [delete]
+func (r *Router) RemoveVocabularyFromList(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := r.service.RemoveVocabularyFromList(c.Request.Context(), userID, c.Param("list_id"), c.Param("vocabulary_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Vocabulary removed from list"})
+}
+
+// ReorderVocabularyList godoc
+// @Summary      Reorder list
+// @Description  Set the order of a list's words. Every word of the list must appear exactly once.
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        list_id path string true "List ID"
+// @Param        request body ReorderVocabularyListRequest true "Vocabulary IDs in their new order"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/{list_id}/items/order [put]
+func (r *Router) ReorderVocabularyList(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req ReorderVocabularyListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, err := r.service.ReorderVocabularyList(c.Request.Context(), userID, c.Param("list_id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// GetVocabularyListMembers godoc
+// @Summary      Get list members
+// @Description  Get the owner, editors and viewers of a list
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        list_id path string true "List ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/{list_id}/members [get]
+func (r *Router) GetVocabularyListMembers(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	members, err := r.service.GetVocabularyListMembers(c.Request.Context(), userID, c.Param("list_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// SetVocabularyListMember godoc
+// @Summary      Add or update list member
+// @Description  Give a user editor or viewer access to your list, or change their role
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        list_id path string true "List ID"
+// @Param        user_id path string true "User ID"
+// @Param        request body SetListMemberRequest true "Role"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/{list_id}/members/{user_id} [put]
+func (r *Router) SetVocabularyListMember(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req SetListMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := r.service.SetVocabularyListMember(c.Request.Context(), userID, c.Param("list_id"), c.Param("user_id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"member": member})
+}
+
+// RemoveVocabularyListMember godoc
+// @Summary      Remove list member
+// @Description  Revoke a member's access to your list, or leave a list shared with you
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        list_id path string true "List ID"
+// @Param        user_id path string true "User ID"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/{list_id}/members/{user_id} [delete]
+func (r *Router) RemoveVocabularyListMember(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := r.service.RemoveVocabularyListMember(c.Request.Context(), userID, c.Param("list_id"), c.Param("user_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed from list"})
+}
+
+// GetVocabularyListProgress godoc
+// @Summary      Get list progress report
+// @Description  Mastery of the list's words for the owner and every subscriber, including study group members. Owners and editors only.
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        list_id path string true "List ID"
+// @Success      200 {object} VocabularyListProgress
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/{list_id}/progress [get]
+func (r *Router) GetVocabularyListProgress(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	progress, err := r.service.GetVocabularyListProgress(c.Request.Context(), userID, c.Param("list_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// AttachListToGroup godoc
+// @Summary      Attach list to study group
+// @Description  Study group admins share a list with their group. Every active member is subscribed and receives its words.
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        list_id path string true "List ID"
+// @Param        request body AttachListToGroupRequest true "Study group"
+// @Success      201 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/{list_id}/groups [post]
+func (r *Router) AttachListToGroup(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req AttachListToGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := r.service.AttachListToGroup(c.Request.Context(), userID, c.Param("list_id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "List attached to study group",
+		"result":  result,
+	})
+}
+
+// DetachListFromGroup godoc
+// @Summary      Detach list from study group
+// @Description  Remove a list from a study group. Members subscribed through the group stop receiving new words and keep the ones they have.
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        list_id path string true "List ID"
+// @Param        group_id path string true "Study group ID"
+// @Success      200 {object} map[string]string
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/lists/{list_id}/groups/{group_id} [delete]
+func (r *Router) DetachListFromGroup(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := r.service.DetachListFromGroup(c.Request.Context(), userID, c.Param("list_id"), c.Param("group_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "List detached from study group"})
+}
+
+// GetGroupVocabularyLists godoc
+// @Summary      Get study group lists
+// @Description  Get the lists attached to a study group you belong to
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        group_id path string true "Study group ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/groups/{group_id}/lists [get]
+func (r *Router) GetGroupVocabularyLists(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	lists, err := r.service.GetGroupVocabularyLists(c.Request.Context(), userID, c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lists": lists})
+}
+
+// MineEpisode godoc
+// @Summary      Mine episode vocabulary
+// @Description  Tokenise an episode transcript and return the words not yet in the user's vocabulary, most frequent first, with the line they appear in
+// @Tags         mining
+// @Accept       json
+// @Produce      json
+// @Param        episode_id path string true "Episode ID"
+// @Param        min_length query int false "Shortest word in characters" default(2)
+// @Param        limit query int false "Number of words to return (max 500)" default(50)
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/mining/episodes/{episode_id} [get]
+func (r *Router) MineEpisode(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	opts := MiningOptions{Limit: 50}
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 500 {
+			opts.Limit = parsed
+		}
+	}
+	if m := c.Query("min_length"); m != "" {
+		if parsed, err := strconv.Atoi(m); err == nil && parsed > 0 {
+			opts.MinLength = parsed
+		}
+	}
+
+	result, err := r.service.MineEpisode(c.Request.Context(), userID, c.Param("episode_id"), opts)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// AddMinedWords godoc
+// @Summary      Add mined words
+// @Description  Add words picked from an episode in one call. The context sentence defaults to the transcript line and the source content and episode are recorded.
+// @Tags         mining
+// @Accept       json
+// @Produce      json
+// @Param        episode_id path string true "Episode ID"
+// @Param        request body AddMinedWordsRequest true "Words to add"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/mining/episodes/{episode_id} [post]
+func (r *Router) AddMinedWords(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req AddMinedWordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, vocab, err := r.service.AddMinedWords(c.Request.Context(), userID, c.Param("episode_id"), req)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Mined words added",
+		"result":     result,
+		"vocabulary": vocab,
+	})
+}
+
+// ImportFrequencyList godoc
+// @Summary      Import frequency list
+// @Description  Replace a language's word frequency list and re-rank its dictionary entries. One word per line, most frequent first, optionally followed by its count. Inflected forms are filed under their lemma, as words added to the dictionary are, and merged into its highest rank. Requires the admin role, as the list ranks words for every learner.
+// @Tags         frequency
+// @Accept       json
+// @Produce      json
+// @Param        request body ImportFrequencyListRequest true "Frequency list"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/admin/frequency-lists [post]
+func (r *Router) ImportFrequencyList(c *gin.Context) {
+	var req ImportFrequencyListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := r.service.ImportFrequencyList(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Frequency list imported successfully",
+		"result":  result,
+	})
+}
+
+// GetCoverage godoc
+// @Summary      Get text coverage
+// @Description  Estimate the percentage of typical running text covered by the user's known words, from the language's frequency list
+// @Tags         frequency
+// @Accept       json
+// @Produce      json
+// @Param        language_id query int true "Language ID"
+// @Success      200 {object} CoverageEstimate
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/coverage [get]
+func (r *Router) GetCoverage(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	languageID, err := strconv.Atoi(c.Query("language_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid language_id required"})
+		return
+	}
+
+	coverage, err := r.service.GetCoverage(c.Request.Context(), userID, languageID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, coverage)
+}
+
+// GetUsefulWords godoc
+// @Summary      Get next useful words
+// @Description  Get the most frequent words of a language the user does not have yet, with the coverage each adds
+// @Tags         frequency
+// @Accept       json
+// @Produce      json
+// @Param        language_id query int true "Language ID"
+// @Param        limit query int false "Number of words to return (max 100)" default(20)
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/useful-words [get]
+func (r *Router) GetUsefulWords(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	languageID, err := strconv.Atoi(c.Query("language_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid language_id required"})
+		return
+	}
+
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	words, err := r.service.GetUsefulWords(c.Request.Context(), userID, languageID, limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"words": words})
+}
+
+// StartReviewSession godoc
+// @Summary      Start review session
+// @Description  Start a server-side review session over a snapshot of today's queue
+// @Tags         review-sessions
+// @Accept       json
+// @Produce      json
+// @Param        request body StartReviewSessionRequest true "Queue options"
+// @Success      201 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/reviews/sessions [post]
+func (r *Router) StartReviewSession(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req StartReviewSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := r.service.StartReviewSession(c.Request.Context(), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"session": session})
+}
+
+// GetReviewSessions godoc
+// @Summary      Get review sessions
+// @Description  Get the user's review sessions, most recently active first. Use status=paused to find sessions to resume on another device.
+// @Tags         review-sessions
+// @Accept       json
+// @Produce      json
+// @Param        status query string false "Filter by status (active, paused, finished)"
+// @Param        limit query int false "Number of sessions to return (max 100)" default(20)
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/reviews/sessions [get]
+func (r *Router) GetReviewSessions(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", ReviewSessionActive, ReviewSessionPaused, ReviewSessionFinished:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of active, paused, finished"})
+		return
+	}
+
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	sessions, err := r.service.GetReviewSessions(c.Request.Context(), userID, status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// GetReviewSession godoc
+// @Summary      Get review session
+// @Description  Get a review session with its progress and current card
+// @Tags         review-sessions
+// @Accept       json
+// @Produce      json
+// @Param        session_id path string true "Session ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/reviews/sessions/{session_id} [get]
+func (r *Router) GetReviewSession(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	session, err := r.service.GetReviewSession(c.Request.Context(), userID, c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// AnswerReviewSession godoc
+// @Summary      Answer review session card
+// @Description  Review the session's current card and move to the next one
+// @Tags         review-sessions
+// @Accept       json
+// @Produce      json
+// @Param        session_id path string true "Session ID"
+// @Param        request body AnswerReviewRequest true "Answer"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/reviews/sessions/{session_id}/answer [post]
+func (r *Router) AnswerReviewSession(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req AnswerReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := r.service.AnswerReviewSession(c.Request.Context(), userID, c.Param("session_id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// UndoReviewSessionAnswer godoc
+// @Summary      Undo last answer
+// @Description  Revert the session's last answer, restoring the card's previous SRS state
+// @Tags         review-sessions
+// @Accept       json
+// @Produce      json
+// @Param        session_id path string true "Session ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/reviews/sessions/{session_id}/undo [post]
+func (r *Router) UndoReviewSessionAnswer(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	session, err := r.service.UndoReviewSessionAnswer(c.Request.Context(), userID, c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// PauseReviewSession godoc
+// @Summary      Pause review session
+// @Description  Pause a review session. Paused time does not count as study time.
+// @Tags         review-sessions
+// @Accept       json
+// @Produce      json
+// @Param        session_id path string true "Session ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/reviews/sessions/{session_id}/pause [post]
+func (r *Router) PauseReviewSession(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	session, err := r.service.PauseReviewSession(c.Request.Context(), userID, c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// ResumeReviewSession godoc
+// @Summary      Resume review session
+// @Description  Resume a paused review session, on this or another device
+// @Tags         review-sessions
+// @Accept       json
+// @Produce      json
+// @Param        session_id path string true "Session ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/reviews/sessions/{session_id}/resume [post]
+func (r *Router) ResumeReviewSession(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	session, err := r.service.ResumeReviewSession(c.Request.Context(), userID, c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// FinishReviewSession godoc
+// @Summary      Finish review session
+// @Description  Finish a review session and get its summary of time, accuracy and graduated cards. Its time counts towards progress goals.
+// @Tags         review-sessions
+// @Accept       json
+// @Produce      json
+// @Param        session_id path string true "Session ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Security     BearerAuth
+// @Router       /vocabulary/reviews/sessions/{session_id}/finish [post]
+func (r *Router) FinishReviewSession(c *gin.Context) {
+	userID, exists := polyfyjwt.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	summary, err := r.service.FinishReviewSession(c.Request.Context(), userID, c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"summary": summary})
+}
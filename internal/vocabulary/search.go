@@ -0,0 +1,267 @@
+package vocabulary
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// searchConfigs maps language codes to the Postgres text search configuration
+// whose stemmer is used. Languages without a stemmer (ja, ko, zh) use simple
+// and rely on trigram and substring matching.
+var searchConfigs = map[string]string{
+	"en": "english",
+	"es": "spanish",
+	"fr": "french",
+	"de": "german",
+	"it": "italian",
+	"pt": "portuguese",
+	"ru": "russian",
+}
+
+const (
+	searchConfigPrefix   = "vocabulary_"
+	searchSnippetOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=20, MinWords=5, MaxFragments=2, FragmentDelimiter=\" … \""
+)
+
+// VocabularySearchResult is a matching entry with its relevance and a
+// highlighted snippet
+type VocabularySearchResult struct {
+	UserVocabulary
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// searchConfigName returns the unaccenting text search configuration for a
+// language code
+func searchConfigName(languageCode string) string {
+	if config, ok := searchConfigs[languageCode]; ok {
+		return searchConfigPrefix + config
+	}
+	return searchConfigPrefix + "simple"
+}
+
+// MigrateSearch installs the extensions, text search configurations and
+// trigram indexes used by vocabulary search, and backfills romanizations of
+// Japanese and Chinese entries. It is safe to run on every start.
+func MigrateSearch(db *gorm.DB) error {
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS unaccent",
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		// unaccent is only STABLE; an IMMUTABLE wrapper can back indexes
+		`CREATE OR REPLACE FUNCTION vocabulary_unaccent(text) RETURNS text
+            LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+            AS $$ SELECT public.unaccent('public.unaccent'::regdictionary, lower($1)) $$`,
+	}
+
+	configs := map[string]string{"simple": "simple"}
+	for _, config := range searchConfigs {
+		configs[config] = config + "_stem"
+	}
+	for config, dictionary := range configs {
+		statements = append(statements, fmt.Sprintf(`
+            DO $$ BEGIN
+                IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = '%[1]s%[2]s') THEN
+                    CREATE TEXT SEARCH CONFIGURATION %[1]s%[2]s (COPY = %[2]s);
+                    ALTER TEXT SEARCH CONFIGURATION %[1]s%[2]s
+                        ALTER MAPPING FOR hword, hword_part, word WITH unaccent, %[3]s;
+                END IF;
+            END $$`, searchConfigPrefix, config, dictionary))
+	}
+
+	statements = append(statements,
+		"CREATE INDEX IF NOT EXISTS idx_vocabulary_word_trgm ON vocabulary USING gin (vocabulary_unaccent(word) gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_vocabulary_translation_trgm ON vocabulary USING gin (vocabulary_unaccent(translation) gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_vocabulary_romanization_trgm ON vocabulary USING gin (romanization gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_user_vocabulary_translation_trgm ON user_vocabulary USING gin (vocabulary_unaccent(translation) gin_trgm_ops)",
+	)
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return backfillRomanizations(db)
+}
+
+// backfillRomanizations computes the romanization of Japanese and Chinese
+// entries created before the column existed
+func backfillRomanizations(db *gorm.DB) error {
+	var rows []struct {
+		ID                    string
+		Word                  string
+		PhoneticTranscription string
+		Code                  string
+	}
+	err := db.Table("vocabulary").
+		Select("vocabulary.id, vocabulary.word, vocabulary.phonetic_transcription, languages.code").
+		Joins("JOIN languages ON languages.id = vocabulary.language_id").
+		Where("languages.code IN ? AND vocabulary.romanization = ''", []string{languageJapanese, languageChinese}).
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	updated := 0
+	for _, row := range rows {
+		romanization := romanize(row.Code, row.Word, row.PhoneticTranscription, "")
+		if romanization == "" {
+			continue
+		}
+		if err := db.Model(&Vocabulary{}).Where("id = ?", row.ID).Update("romanization", romanization).Error; err != nil {
+			return err
+		}
+		updated++
+	}
+
+	if updated > 0 {
+		log.Printf("Backfilled romanization of %d vocabulary entries", updated)
+	}
+
+	return nil
+}
+
+// languageCode returns the code of a language, or "" when unknown
+func (s *Service) languageCode(languageID int) string {
+	var code string
+	s.db.Table("languages").Select("code").Where("id = ?", languageID).Scan(&code)
+	return code
+}
+
+// searchArgs are the named parameters of searchMatchSQL and searchRankSQL
+func (s *Service) searchArgs(languageID int, query string) map[string]interface{} {
+	return map[string]interface{}{
+		"config": searchConfigName(s.languageCode(languageID)),
+		"query":  query,
+		"term":   strings.ToLower(strings.TrimSpace(query)),
+		"roman":  romanizationKey(kanaToRomaji(query)),
+		"lemmas": s.searchLemmas(languageID, query),
+	}
+}
+
+// searchLemmas returns the query and the lemmas of its words, so inflected
+// forms find the entry they are filed under. Without a language the words
+// are only normalised.
+func (s *Service) searchLemmas(languageID int, query string) []string {
+	lemmas := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(lemma string) {
+		if lemma != "" && !seen[lemma] {
+			seen[lemma] = true
+			lemmas = append(lemmas, lemma)
+		}
+	}
+
+	add(NormalizeLemma(query))
+	for _, word := range strings.Fields(query) {
+		if languageID == 0 {
+			add(NormalizeLemma(word))
+		} else {
+			add(s.resolveLemma(languageID, word))
+		}
+	}
+	if len(lemmas) == 0 {
+		lemmas = append(lemmas, "")
+	}
+
+	return lemmas
+}
+
+// The searchable document: the word and translations rank highest, then
+// definitions, example and context sentences, then personal notes
+const searchDocumentSQL = `(
+    setweight(to_tsvector(CAST(@config AS regconfig), coalesce(vocabulary.word, '')), 'A') ||
+    setweight(to_tsvector(CAST(@config AS regconfig), coalesce(nullif(user_vocabulary.translation, ''), vocabulary.translation, '')), 'A') ||
+    setweight(to_tsvector(CAST(@config AS regconfig), coalesce(nullif(user_vocabulary.definition, ''), vocabulary.definition, '')), 'B') ||
+    setweight(to_tsvector(CAST(@config AS regconfig), coalesce(vocabulary.example_sentence, '') || ' ' || coalesce(user_vocabulary.context_sentence, '')), 'C') ||
+    setweight(to_tsvector(CAST(@config AS regconfig), coalesce(user_vocabulary.personal_note, '')), 'D')
+)`
+
+const searchTextSQL = `concat_ws(' · ', vocabulary.word,
+    coalesce(nullif(user_vocabulary.translation, ''), vocabulary.translation),
+    coalesce(nullif(user_vocabulary.definition, ''), vocabulary.definition),
+    vocabulary.example_sentence, user_vocabulary.context_sentence, user_vocabulary.personal_note)`
+
+const searchQuerySQL = `websearch_to_tsquery(CAST(@config AS regconfig), @query)`
+
+// searchMatchSQL matches full-text hits, typo-tolerant trigram hits on the
+// word and translation, substrings (for CJK input), lemmas of inflected
+// forms and romaji/pinyin
+var searchMatchSQL = `(
+    ` + searchDocumentSQL + ` @@ ` + searchQuerySQL + `
+    OR vocabulary_unaccent(vocabulary.word) % vocabulary_unaccent(@term)
+    OR vocabulary_unaccent(@term) <% vocabulary_unaccent(coalesce(nullif(user_vocabulary.translation, ''), vocabulary.translation, ''))
+    OR strpos(vocabulary_unaccent(vocabulary.word), vocabulary_unaccent(@term)) > 0
+    OR vocabulary.lemma IN @lemmas
+    OR (CAST(@roman AS text) <> '' AND vocabulary.romanization <> '' AND (strpos(vocabulary.romanization, @roman) = 1 OR vocabulary.romanization % @roman))
+)`
+
+// searchRankSQL weighs full-text relevance above fuzzy similarity
+var searchRankSQL = `(
+    ts_rank_cd(` + searchDocumentSQL + `, ` + searchQuerySQL + `) * 2 +
+    GREATEST(
+        similarity(vocabulary_unaccent(vocabulary.word), vocabulary_unaccent(@term)),
+        CASE WHEN vocabulary.lemma IN @lemmas THEN 0.9 ELSE 0 END,
+        word_similarity(vocabulary_unaccent(@term), vocabulary_unaccent(coalesce(nullif(user_vocabulary.translation, ''), vocabulary.translation, ''))),
+        CASE WHEN CAST(@roman AS text) <> '' AND vocabulary.romanization <> '' THEN similarity(vocabulary.romanization, @roman) ELSE 0 END
+    )
+)`
+
+// SearchVocabulary runs a ranked full-text and fuzzy search over the user's
+// vocabulary. A languageID of 0 searches every language without stemming.
+func (s *Service) SearchVocabulary(ctx context.Context, userID string, languageID int, query string, limit int) ([]VocabularySearchResult, error) {
+	args := s.searchArgs(languageID, query)
+
+	matches := s.db.Table("user_vocabulary").
+		Select("user_vocabulary.id AS id, "+searchRankSQL+" AS rank, ts_headline(CAST(@config AS regconfig), "+searchTextSQL+", "+searchQuerySQL+", '"+searchSnippetOptions+"') AS snippet", args).
+		Joins("JOIN vocabulary ON user_vocabulary.vocabulary_id = vocabulary.id").
+		Where("user_vocabulary.user_id = ?", userID).
+		Where(searchMatchSQL, args)
+	if languageID != 0 {
+		matches = matches.Where("vocabulary.language_id = ?", languageID)
+	}
+
+	var hits []struct {
+		ID      string
+		Rank    float64
+		Snippet string
+	}
+	err := s.db.Table("(?) AS hits", matches).
+		Order("rank DESC").
+		Limit(limit).
+		Scan(&hits).Error
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]VocabularySearchResult, 0, len(hits))
+	if len(hits) == 0 {
+		return results, nil
+	}
+
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+
+	var vocab []UserVocabulary
+	if err := s.db.Preload("Vocabulary").Where("id IN ?", ids).Find(&vocab).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]UserVocabulary, len(vocab))
+	for _, uv := range vocab {
+		byID[uv.ID] = uv
+	}
+
+	for _, hit := range hits {
+		if uv, ok := byID[hit.ID]; ok {
+			results = append(results, VocabularySearchResult{UserVocabulary: uv, Rank: hit.Rank, Snippet: hit.Snippet})
+		}
+	}
+
+	return results, nil
+}
@@ -9,12 +9,14 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Service struct {
 	db        *gorm.DB
 	jwtSecret string
 	srsConfig SRSConfig
+	jobs      jobWorkers
 }
 
 func NewService(db *gorm.DB, jwtSecret string) *Service {
@@ -30,44 +32,26 @@ func NewService(db *gorm.DB, jwtSecret string) *Service {
 			GraduationSteps:  []int{1, 6},
 			NewWordsPerDay:   20,
 			MaxReviewsPerDay: 200,
+			LeechThreshold:   defaultLeechThreshold,
+			LeechAction:      LeechActionSuspend,
 		},
 	}
 }
 
 func (s *Service) AddVocabulary(ctx context.Context, userID string, languageID int, req AddVocabularyRequest) (*UserVocabulary, error) {
-	// Check if word already exists in global vocabulary
-	var existingVocab Vocabulary
-	err := s.db.Where("word = ? AND language_id = ?", req.Word, languageID).First(&existingVocab).Error
-
-	var vocabID string
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		// Create new vocabulary entry
-		newVocab := Vocabulary{
-			Word:                  req.Word,
-			LanguageID:            languageID,
-			Translation:           req.Translation,
-			PhoneticTranscription: req.PhoneticTranscription,
-			Definition:            req.Definition,
-			ExampleSentence:       req.ExampleSentence,
-			DifficultyLevel:       req.DifficultyLevel,
-			CreatedBy:             userID,
-		}
-
-		if err := s.db.Create(&newVocab).Error; err != nil {
-			return nil, err
-		}
-		vocabID = newVocab.ID
-	} else if err != nil {
+	vocabID, inflected, err := s.findOrCreateDictionaryEntry(userID, languageID, req)
+	if err != nil {
 		return nil, err
-	} else {
-		vocabID = existingVocab.ID
 	}
 
 	// Check if user already has this word
 	var existingUserVocab UserVocabulary
 	err = s.db.Where("user_id = ? AND vocabulary_id = ?", userID, vocabID).First(&existingUserVocab).Error
 	if err == nil {
-		return nil, errors.New("word already exists in your vocabulary")
+		if !inflected || !req.LinkInflection {
+			return nil, errors.New("word already exists in your vocabulary")
+		}
+		return s.linkInflection(&existingUserVocab, req)
 	}
 
 	// Create user vocabulary entry
@@ -75,12 +59,25 @@ func (s *Service) AddVocabulary(ctx context.Context, userID string, languageID i
 	userVocab := UserVocabulary{
 		UserID:          userID,
 		VocabularyID:    vocabID,
+		Translation:     req.Translation,
+		Definition:      req.Definition,
 		ContextSentence: req.ContextSentence,
 		PersonalNote:    req.PersonalNote,
-		SourceContentID: &req.SourceContentID,
-		NextReviewAt:    &nextReview,
-		EaseFactor:      s.srsConfig.MaxEaseFactor,
-		IntervalDays:    1,
+		SRSState: SRSState{
+			NextReviewAt: &nextReview,
+			EaseFactor:   s.srsConfig.MaxEaseFactor,
+			IntervalDays: 1,
+		},
+	}
+	if req.SourceContentID != "" {
+		userVocab.SourceContentID = &req.SourceContentID
+	}
+	if req.SourceEpisodeID != "" {
+		userVocab.SourceEpisodeID = &req.SourceEpisodeID
+	}
+	userVocab.SetTags(req.Tags)
+	if inflected {
+		userVocab.AddForm(req.Word)
 	}
 
 	if err := s.db.Create(&userVocab).Error; err != nil {
@@ -93,55 +90,224 @@ func (s *Service) AddVocabulary(ctx context.Context, userID string, languageID i
 	return &userVocab, nil
 }
 
-func (s *Service) GetVocabularyForReview(ctx context.Context, userID string, languageID int, limit int) ([]UserVocabulary, error) {
-	var vocab []UserVocabulary
+// linkInflection records an inflected form on the user's card for its lemma,
+// keeping the sentence it was found in when the card has none
+func (s *Service) linkInflection(userVocab *UserVocabulary, req AddVocabularyRequest) (*UserVocabulary, error) {
+	updates := map[string]interface{}{}
+	if userVocab.AddForm(req.Word) {
+		updates["forms"] = userVocab.Forms
+	}
+	if userVocab.ContextSentence == "" && req.ContextSentence != "" {
+		updates["context_sentence"] = req.ContextSentence
+	}
+	if len(updates) > 0 {
+		if err := s.db.Model(userVocab).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
 
-	err := s.db.Preload("Vocabulary").
-		Joins("JOIN vocabulary ON user_vocabulary.vocabulary_id = vocabulary.id").
-		Where("user_vocabulary.user_id = ? AND vocabulary.language_id = ?", userID, languageID).
-		Where("user_vocabulary.next_review_at IS NULL OR user_vocabulary.next_review_at <= ?", time.Now()).
-		Order("user_vocabulary.next_review_at ASC").
-		Limit(limit).
-		Find(&vocab).Error
+	s.db.Preload("Vocabulary").Where("id = ?", userVocab.ID).First(userVocab)
+	return userVocab, nil
+}
+
+// findOrCreateDictionaryEntry returns the canonical entry for the word's
+// lemma, creating it from the request if no user has added it before. It
+// reports whether the word is an inflection filed under a different lemma.
+func (s *Service) findOrCreateDictionaryEntry(userID string, languageID int, req AddVocabularyRequest) (string, bool, error) {
+	normalized := NormalizeLemma(req.Word)
+	if normalized == "" {
+		return "", false, errors.New("word is empty after normalization")
+	}
+	lemma := s.requestLemma(languageID, req)
+	inflected := lemma != normalized
+	if inflected {
+		// Pronunciation and audio describe the form that was typed
+		req.PhoneticTranscription = ""
+		req.AudioURL = ""
+	}
+	sense := normalizeSense(req.Sense)
+	romanization := romanize(s.languageCode(languageID), req.Word, req.PhoneticTranscription, req.Romanization)
 
-	return vocab, err
+	var existingVocab Vocabulary
+	err := s.db.Where("language_id = ? AND lemma = ? AND sense = ?", languageID, lemma, sense).First(&existingVocab).Error
+	if err == nil {
+		// Enrich the shared entry with anything it is missing
+		updates := map[string]interface{}{}
+		if existingVocab.PhoneticTranscription == "" && req.PhoneticTranscription != "" {
+			updates["phonetic_transcription"] = req.PhoneticTranscription
+		}
+		if existingVocab.ExampleSentence == "" && req.ExampleSentence != "" {
+			updates["example_sentence"] = req.ExampleSentence
+		}
+		if existingVocab.AudioURL == "" && req.AudioURL != "" {
+			updates["audio_url"] = req.AudioURL
+		}
+		if existingVocab.Romanization == "" && romanization != "" {
+			updates["romanization"] = romanization
+		}
+		if existingVocab.FrequencyRank == 0 {
+			if rank := s.frequencyRank(languageID, lemma); rank > 0 {
+				updates["frequency_rank"] = rank
+			}
+		}
+		if len(updates) > 0 {
+			s.db.Model(&existingVocab).Updates(updates)
+		}
+		return existingVocab.ID, inflected, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", false, err
+	}
+
+	word := strings.TrimSpace(req.Word)
+	if inflected {
+		word = lemmaDisplayWord(lemma, req.Word)
+	}
+
+	newVocab := Vocabulary{
+		Word:                  word,
+		Lemma:                 lemma,
+		Sense:                 sense,
+		LanguageID:            languageID,
+		Translation:           req.Translation,
+		PhoneticTranscription: req.PhoneticTranscription,
+		Romanization:          romanization,
+		Definition:            req.Definition,
+		ExampleSentence:       req.ExampleSentence,
+		AudioURL:              req.AudioURL,
+		DifficultyLevel:       req.DifficultyLevel,
+		FrequencyRank:         s.frequencyRank(languageID, lemma),
+		CreatedBy:             userID,
+	}
+
+	// Another request may have created the entry concurrently
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&newVocab)
+	if result.Error != nil {
+		return "", false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if err := s.db.Where("language_id = ? AND lemma = ? AND sense = ?", languageID, lemma, sense).First(&existingVocab).Error; err != nil {
+			return "", false, err
+		}
+		return existingVocab.ID, inflected, nil
+	}
+
+	return newVocab.ID, inflected, nil
 }
 
-func (s *Service) ReviewVocabulary(ctx context.Context, userID string, req ReviewRequest) (*UserVocabulary, error) {
+// GetVocabularyForReview returns today's queue of every enabled card type
+// with the default strategy
+func (s *Service) GetVocabularyForReview(ctx context.Context, userID string, languageID int, limit int) ([]ReviewCard, error) {
+	queue, err := s.BuildReviewQueue(ctx, userID, QueueOptions{LanguageID: languageID, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	return queue.Cards, nil
+}
+
+func (s *Service) ReviewVocabulary(ctx context.Context, userID string, req ReviewRequest) (*ReviewCard, error) {
+	return s.reviewCardAt(s.db, userID, req, time.Now(), false)
+}
+
+// reviewCardAt records a review given at reviewedAt. With rejectStale set,
+// reviews given before the card's last review are refused with
+// errStaleReview instead of rescheduling the card from outdated state.
+func (s *Service) reviewCardAt(db *gorm.DB, userID string, req ReviewRequest, reviewedAt time.Time, rejectStale bool) (*ReviewCard, error) {
 	var userVocab UserVocabulary
-	err := s.db.Where("user_id = ? AND vocabulary_id = ?", userID, req.VocabularyID).First(&userVocab).Error
+	err := db.Preload("Vocabulary").Where("user_id = ? AND vocabulary_id = ?", userID, req.VocabularyID).First(&userVocab).Error
 	if err != nil {
 		return nil, errors.New("vocabulary not found")
 	}
 
-	// Update review statistics
-	userVocab.ReviewCount++
-	if req.Correct {
-		userVocab.CorrectCount++
+	if req.CardType == "" || req.CardType == CardTypeRecognition {
+		if rejectStale && isStaleReview(userVocab.SRSState, reviewedAt) {
+			return nil, errStaleReview
+		}
+		before := userVocab.SRSState
+		if s.applyReview(&userVocab.SRSState, req.Correct, userID, reviewedAt) {
+			s.handleLapse(userID, &userVocab, &userVocab.SRSState)
+		}
+
+		if err := db.Omit("Vocabulary").Save(&userVocab).Error; err != nil {
+			return nil, err
+		}
+		if err := db.Create(newReviewLog(userID, userVocab.ID, req, before, userVocab.SRSState, reviewedAt)).Error; err != nil {
+			return nil, err
+		}
+
+		card := newReviewCard(userVocab.ID, CardTypeRecognition, userVocab.SRSState, userVocab)
+		return &card, nil
 	}
-	now := time.Now()
-	userVocab.LastReviewedAt = &now
 
-	// Calculate next review using user's SRS config
-	s.calculateNextReview(&userVocab, req.Correct, userID) // ← PASS userID
+	var card VocabularyCard
+	err = db.Where("user_vocabulary_id = ? AND card_type = ? AND active = ?", userVocab.ID, req.CardType, true).First(&card).Error
+	if err != nil {
+		return nil, errors.New("card not found")
+	}
 
-	if err := s.db.Save(&userVocab).Error; err != nil {
+	if rejectStale && isStaleReview(card.SRSState, reviewedAt) {
+		return nil, errStaleReview
+	}
+	before := card.SRSState
+	if s.applyReview(&card.SRSState, req.Correct, userID, reviewedAt) {
+		if s.handleLapse(userID, &userVocab, &card.SRSState) {
+			if err := db.Model(&userVocab).Update("tags", userVocab.Tags).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := db.Omit("UserVocabulary").Save(&card).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Create(newReviewLog(userID, card.ID, req, before, card.SRSState, reviewedAt)).Error; err != nil {
 		return nil, err
 	}
 
-	return &userVocab, nil
+	reviewCard := newReviewCard(card.ID, card.CardType, card.SRSState, userVocab)
+	return &reviewCard, nil
 }
 
-func (s *Service) calculateNextReview(userVocab *UserVocabulary, correct bool, userID string) {
+// applyReview records a review on a card and schedules its next one. It
+// reports whether the card lapsed, i.e. was forgotten after graduating.
+func (s *Service) applyReview(state *SRSState, correct bool, userID string, reviewedAt time.Time) bool {
+	// Update review statistics
+	if state.ReviewCount == 0 {
+		state.FirstReviewedAt = &reviewedAt
+	}
+	state.ReviewCount++
+	if correct {
+		state.CorrectCount++
+	}
+	state.LastReviewedAt = &reviewedAt
+
+	lapsed := !correct && state.MasteryLevel >= learningMasteryLevel
+	if lapsed {
+		state.LapseCount++
+	}
+
+	// Calculate next review using user's SRS config
+	s.calculateNextReview(state, correct, userID, reviewedAt)
+
+	return lapsed
+}
+
+func (s *Service) calculateNextReview(userVocab *SRSState, correct bool, userID string, reviewedAt time.Time) {
 	// Get user's SRS config
 	config, err := s.getUserSRSConfig(context.Background(), userID)
 	if err != nil {
 		config = &s.srsConfig // Fallback to default
 	}
 
+	scheduleReview(userVocab, correct, config, reviewedAt)
+}
+
+// scheduleReview sets the next interval and review date of a card answered
+// at reviewedAt. The simulator replays it, so it must not touch the database.
+func scheduleReview(userVocab *SRSState, correct bool, config *SRSConfig, reviewedAt time.Time) {
 	if correct {
 		// Increase interval and potentially ease factor
-		if userVocab.MasteryLevel < 2 {
+		if userVocab.MasteryLevel < learningMasteryLevel {
 			// Still learning - use graduation steps
 			if userVocab.MasteryLevel < len(config.GraduationSteps) {
 				userVocab.IntervalDays = config.GraduationSteps[userVocab.MasteryLevel]
@@ -168,7 +334,7 @@ func (s *Service) calculateNextReview(userVocab *UserVocabulary, correct bool, u
 	}
 
 	// Set next review date
-	nextReview := time.Now().Add(time.Duration(userVocab.IntervalDays) * time.Hour * 24)
+	nextReview := reviewedAt.Add(time.Duration(userVocab.IntervalDays) * time.Hour * 24)
 	userVocab.NextReviewAt = &nextReview
 }
 
@@ -179,6 +345,11 @@ func (s *Service) GetVocabularyStats(ctx context.Context, userID string, languag
 		Joins("JOIN vocabulary v ON uv.vocabulary_id = v.id").
 		Where("uv.user_id = ? AND v.language_id = ?", userID, languageID)
 
+	// Suspended cards are reported separately and left out of every other figure
+	baseQuery = baseQuery.Session(&gorm.Session{})
+	baseQuery.Where("uv.suspended = ?", true).Count(&stats.Suspended)
+	baseQuery = baseQuery.Where("uv.suspended = ?", false).Session(&gorm.Session{})
+
 	// Total words
 	baseQuery.Count(&stats.TotalWords)
 
@@ -215,9 +386,7 @@ func (s *Service) GetUserVocabulary(ctx context.Context, userID string, language
 	var vocab []UserVocabulary
 	var total int64
 
-	query := s.db.Preload("Vocabulary").
-		Joins("JOIN vocabulary ON user_vocabulary.vocabulary_id = vocabulary.id").
-		Where("user_vocabulary.user_id = ? AND vocabulary.language_id = ?", userID, languageID)
+	query := s.userVocabularyQuery(userID, languageID)
 
 	query.Count(&total)
 
@@ -229,30 +398,33 @@ func (s *Service) GetUserVocabulary(ctx context.Context, userID string, language
 	return vocab, total, err
 }
 
+// userVocabularyQuery scopes user vocabulary to a user and language
+func (s *Service) userVocabularyQuery(userID string, languageID int) *gorm.DB {
+	return s.db.Preload("Vocabulary").
+		Joins("JOIN vocabulary ON user_vocabulary.vocabulary_id = vocabulary.id").
+		Where("user_vocabulary.user_id = ? AND vocabulary.language_id = ?", userID, languageID)
+}
+
 func (s *Service) DeleteVocabulary(ctx context.Context, userID, vocabularyID string) error {
-	result := s.db.Where("user_id = ? AND vocabulary_id = ?", userID, vocabularyID).Delete(&UserVocabulary{})
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
+	var userVocab UserVocabulary
+	if err := s.db.Where("user_id = ? AND vocabulary_id = ?", userID, vocabularyID).First(&userVocab).Error; err != nil {
 		return errors.New("vocabulary not found")
 	}
-	return nil
-}
 
-func (s *Service) SearchVocabulary(ctx context.Context, userID string, languageID int, query string, limit int) ([]UserVocabulary, error) {
-	var vocab []UserVocabulary
-
-	searchQuery := "%" + query + "%"
-	err := s.db.Preload("Vocabulary").
-		Joins("JOIN vocabulary ON user_vocabulary.vocabulary_id = vocabulary.id").
-		Where("user_vocabulary.user_id = ? AND vocabulary.language_id = ?", userID, languageID).
-		Where("vocabulary.word ILIKE ? OR vocabulary.translation ILIKE ? OR vocabulary.definition ILIKE ?",
-			searchQuery, searchQuery, searchQuery).
-		Limit(limit).
-		Find(&vocab).Error
-
-	return vocab, err
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var cardIDs []string
+		if err := tx.Model(&VocabularyCard{}).Where("user_vocabulary_id = ?", userVocab.ID).Pluck("id", &cardIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_vocabulary_id = ?", userVocab.ID).Delete(&VocabularyCard{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&userVocab).Error; err != nil {
+			return err
+		}
+		// Let other devices drop the cards on their next sync
+		return s.recordDeletedCards(tx, userID, vocabularyID, append(cardIDs, userVocab.ID))
+	})
 }
 
 // UpdateVocabulary updates user vocabulary
@@ -264,25 +436,31 @@ func (s *Service) UpdateVocabulary(ctx context.Context, userID, vocabularyID str
 	}
 
 	// Update user-specific fields
+	if req.Translation != "" {
+		userVocab.Translation = req.Translation
+	}
+	if req.Definition != "" {
+		userVocab.Definition = req.Definition
+	}
 	if req.ContextSentence != "" {
 		userVocab.ContextSentence = req.ContextSentence
 	}
 	if req.PersonalNote != "" {
 		userVocab.PersonalNote = req.PersonalNote
 	}
+	if req.Tags != nil {
+		userVocab.SetTags(req.Tags)
+	}
 
-	// Update global vocabulary if user is the creator
+	// The dictionary entry is shared, so only its creator may change it
 	var vocab Vocabulary
 	if err := s.db.Where("id = ?", vocabularyID).First(&vocab).Error; err == nil {
 		if vocab.CreatedBy == userID {
-			if req.Translation != "" {
-				vocab.Translation = req.Translation
-			}
 			if req.PhoneticTranscription != "" {
 				vocab.PhoneticTranscription = req.PhoneticTranscription
 			}
-			if req.Definition != "" {
-				vocab.Definition = req.Definition
+			if req.PhoneticTranscription != "" || req.Romanization != "" {
+				vocab.Romanization = romanize(s.languageCode(vocab.LanguageID), vocab.Word, vocab.PhoneticTranscription, req.Romanization)
 			}
 			if req.ExampleSentence != "" {
 				vocab.ExampleSentence = req.ExampleSentence
@@ -295,6 +473,11 @@ func (s *Service) UpdateVocabulary(ctx context.Context, userID, vocabularyID str
 		return nil, err
 	}
 
+	// Sentences decide whether a cloze card can be built
+	if err := s.refreshCards(userID, vocabularyID); err != nil {
+		return nil, err
+	}
+
 	// Reload with vocabulary data
 	s.db.Preload("Vocabulary").Where("id = ?", userVocab.ID).First(&userVocab)
 	return &userVocab, nil
@@ -308,21 +491,23 @@ func (s *Service) BatchReviewVocabulary(ctx context.Context, userID string, req
 	}
 
 	for _, review := range req.Reviews {
-		userVocab, err := s.ReviewVocabulary(ctx, userID, review)
+		card, err := s.ReviewVocabulary(ctx, userID, review)
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("Error reviewing %s: %v", review.VocabularyID, err))
 			result.Results = append(result.Results, ReviewVocabularyResult{
 				VocabularyID: review.VocabularyID,
+				CardType:     review.CardType,
 				Success:      false,
 				Error:        err.Error(),
 			})
 		} else {
 			nextReview := ""
-			if userVocab.NextReviewAt != nil {
-				nextReview = userVocab.NextReviewAt.Format(time.RFC3339)
+			if card.NextReviewAt != nil {
+				nextReview = card.NextReviewAt.Format(time.RFC3339)
 			}
 			result.Results = append(result.Results, ReviewVocabularyResult{
 				VocabularyID: review.VocabularyID,
+				CardType:     card.CardType,
 				Success:      true,
 				NextReview:   nextReview,
 			})
@@ -431,12 +616,10 @@ func (s *Service) FilterVocabulary(ctx context.Context, userID string, filter Vo
 		query = query.Where("user_vocabulary.mastery_level IN ?", filter.MasteryLevels)
 	}
 
+	var searchArgs map[string]interface{}
 	if filter.SearchQuery != "" {
-		searchTerm := "%" + strings.ToLower(filter.SearchQuery) + "%"
-		query = query.Where(
-			"LOWER(vocabulary.word) LIKE ? OR LOWER(vocabulary.translation) LIKE ? OR LOWER(vocabulary.definition) LIKE ? OR LOWER(user_vocabulary.personal_note) LIKE ?",
-			searchTerm, searchTerm, searchTerm, searchTerm,
-		)
+		searchArgs = s.searchArgs(filter.LanguageID, filter.SearchQuery)
+		query = query.Where(searchMatchSQL, searchArgs)
 	}
 
 	// Count total
@@ -444,6 +627,10 @@ func (s *Service) FilterVocabulary(ctx context.Context, userID string, filter Vo
 
 	// Apply sorting
 	sortBy := "user_vocabulary.added_at"
+	if searchArgs != nil && (filter.SortBy == "" || filter.SortBy == "relevance") {
+		// Best matches first
+		query = query.Order(clause.OrderBy{Expression: clause.NamedExpr{SQL: searchRankSQL + " DESC", Vars: []interface{}{searchArgs}}})
+	}
 	if filter.SortBy != "" {
 		switch filter.SortBy {
 		case "word":
@@ -485,62 +672,111 @@ func (s *Service) ImportVocabulary(ctx context.Context, userID string, req Impor
 		Errors: make([]string, 0),
 	}
 
-	var vocabularyItems []AddVocabularyRequest
-
-	// Parse data based on format
-	switch req.Format {
-	case "json":
-		err := json.Unmarshal([]byte(req.Data), &vocabularyItems)
-		if err != nil {
-			return nil, fmt.Errorf("invalid JSON format: %v", err)
-		}
-	case "csv":
-		vocabularyItems, err := s.parseCSVData(req.Data)
-		if err != nil {
-			return nil, fmt.Errorf("invalid CSV format: %v", err)
-		}
-		_ = vocabularyItems // Use the parsed data
-	case "anki":
-		return nil, errors.New("anki format not implemented yet")
-	default:
-		return nil, errors.New("unsupported format")
+	vocabularyItems, err := parseImportData(req.Format, req.Data)
+	if err != nil {
+		return nil, err
 	}
 
 	result.Total = len(vocabularyItems)
 
 	// Import each vocabulary item
 	for _, item := range vocabularyItems {
-		// Check if word already exists
-		if req.Options.SkipDuplicates {
-			var existing UserVocabulary
-			err := s.db.Joins("JOIN vocabulary ON user_vocabulary.vocabulary_id = vocabulary.id").
-				Where("user_vocabulary.user_id = ? AND vocabulary.word = ? AND vocabulary.language_id = ?",
-					userID, item.Word, req.LanguageID).
-				First(&existing).Error
-			if err == nil {
-				result.Skipped++
-				continue
-			}
-		}
+		s.importVocabularyItem(ctx, userID, req.LanguageID, item, req.Options, result)
+	}
 
-		_, err := s.AddVocabulary(ctx, userID, req.LanguageID, item)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("Error importing '%s': %v", item.Word, err))
-		} else {
-			result.Imported++
+	return result, nil
+}
+
+// importVocabularyItem imports a single row and records the outcome in result
+func (s *Service) importVocabularyItem(ctx context.Context, userID string, languageID int, item AddVocabularyRequest, options ImportOptions, result *ImportResult) {
+	// Check if word already exists
+	if options.SkipDuplicates {
+		var existing UserVocabulary
+		err := s.db.Joins("JOIN vocabulary ON user_vocabulary.vocabulary_id = vocabulary.id").
+			Where("user_vocabulary.user_id = ? AND vocabulary.lemma = ? AND vocabulary.sense = ? AND vocabulary.language_id = ?",
+				userID, s.requestLemma(languageID, item), normalizeSense(item.Sense), languageID).
+			First(&existing).Error
+		if err == nil {
+			result.Skipped++
+			return
 		}
 	}
 
-	return result, nil
+	_, err := s.AddVocabulary(ctx, userID, languageID, item)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("Error importing '%s': %v", item.Word, err))
+	} else {
+		result.Imported++
+	}
 }
 
 // ExportVocabulary exports user vocabulary in specified format
-func (s *Service) ExportVocabulary(ctx context.Context, userID string, languageID int, format string) (string, error) {
-	vocab, _, err := s.GetUserVocabulary(ctx, userID, languageID, 10000, 0) // Get all
+func (s *Service) ExportVocabulary(ctx context.Context, userID string, languageID int, format, filterID string) (string, error) {
+	criteria, languageID, err := s.exportScope(userID, languageID, filterID)
 	if err != nil {
 		return "", err
 	}
 
+	vocab, err := s.getAllUserVocabulary(ctx, userID, languageID, criteria, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return s.formatExport(vocab, format)
+}
+
+// getAllUserVocabulary pages through the user's whole vocabulary, reporting the
+// number of rows loaded so far after each page
+func (s *Service) getAllUserVocabulary(ctx context.Context, userID string, languageID int, criteria *SmartFilterCriteria, onPage func(loaded int)) ([]UserVocabulary, error) {
+	now := time.Now()
+	vocab := make([]UserVocabulary, 0)
+	for offset := 0; ; offset += jobExportPageSize {
+		query := s.userVocabularyQuery(userID, languageID)
+		if criteria != nil {
+			query = applySmartFilter(query, *criteria, now, "user_vocabulary")
+		}
+
+		var page []UserVocabulary
+		err := query.Order("user_vocabulary.added_at DESC, user_vocabulary.id").
+			Limit(jobExportPageSize).
+			Offset(offset).
+			Find(&page).Error
+		if err != nil {
+			return nil, err
+		}
+		vocab = append(vocab, page...)
+
+		if onPage != nil {
+			onPage(len(vocab))
+		}
+
+		if len(page) < jobExportPageSize {
+			return vocab, nil
+		}
+	}
+}
+
+// exportScope loads the smart filter an export is limited to, if any. The
+// filter's language is used when none is given.
+func (s *Service) exportScope(userID string, languageID int, filterID string) (*SmartFilterCriteria, int, error) {
+	if filterID == "" {
+		return nil, languageID, nil
+	}
+
+	filter, err := s.loadSmartFilter(userID, filterID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if languageID == 0 {
+		languageID = filter.LanguageID
+	}
+
+	criteria := filter.GetCriteria()
+	return &criteria, languageID, nil
+}
+
+// formatExport serializes vocabulary in the requested export format
+func (s *Service) formatExport(vocab []UserVocabulary, format string) (string, error) {
 	switch format {
 	case "json":
 		data, err := json.MarshalIndent(vocab, "", "  ")
@@ -572,6 +808,8 @@ func (s *Service) GetSRSConfig(ctx context.Context, userID string) (*SRSConfigRe
 			GraduationSteps:  "1,6",
 			NewWordsPerDay:   20,
 			MaxReviewsPerDay: 200,
+			LeechThreshold:   defaultLeechThreshold,
+			LeechAction:      LeechActionSuspend,
 		}
 
 		if err := s.db.Create(&userConfig).Error; err != nil {
@@ -607,7 +845,9 @@ func (s *Service) UpdateSRSConfig(ctx context.Context, userID string, configReq
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		// Create new config
 		userConfig = UserSRSConfig{
-			UserID: userID,
+			UserID:         userID,
+			LeechThreshold: defaultLeechThreshold,
+			LeechAction:    LeechActionSuspend,
 		}
 	} else if err != nil {
 		return err
@@ -622,10 +862,23 @@ func (s *Service) UpdateSRSConfig(ctx context.Context, userID string, configReq
 	userConfig.SetGraduationSteps(configReq.GraduationSteps)
 	userConfig.NewWordsPerDay = configReq.NewWordsPerDay
 	userConfig.MaxReviewsPerDay = configReq.MaxReviewsPerDay
+	if configReq.LeechThreshold != nil {
+		userConfig.LeechThreshold = *configReq.LeechThreshold
+	}
+	if configReq.LeechAction != "" {
+		userConfig.LeechAction = configReq.LeechAction
+	}
 
 	// Save to database
 	if userConfig.ID == "" {
-		return s.db.Create(&userConfig).Error
+		if err := s.db.Create(&userConfig).Error; err != nil {
+			return err
+		}
+		// Create leaves zero values to the column defaults
+		if userConfig.LeechThreshold == 0 {
+			return s.db.Model(&userConfig).Update("leech_threshold", 0).Error
+		}
+		return nil
 	} else {
 		return s.db.Save(&userConfig).Error
 	}
@@ -648,8 +901,32 @@ func (s *Service) getUserSRSConfig(ctx context.Context, userID string) (*SRSConf
 	return &config, nil
 }
 
+// parseImportData parses import data based on format
+func parseImportData(format, data string) ([]AddVocabularyRequest, error) {
+	var vocabularyItems []AddVocabularyRequest
+
+	switch format {
+	case "json":
+		if err := json.Unmarshal([]byte(data), &vocabularyItems); err != nil {
+			return nil, fmt.Errorf("invalid JSON format: %v", err)
+		}
+	case "csv":
+		items, err := parseCSVData(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV format: %v", err)
+		}
+		vocabularyItems = items
+	case "anki":
+		return nil, errors.New("anki format not implemented yet")
+	default:
+		return nil, errors.New("unsupported format")
+	}
+
+	return vocabularyItems, nil
+}
+
 // parseCSVData parses CSV format vocabulary data
-func (s *Service) parseCSVData(data string) ([]AddVocabularyRequest, error) {
+func parseCSVData(data string) ([]AddVocabularyRequest, error) {
 	lines := strings.Split(data, "\n")
 	if len(lines) < 2 {
 		return nil, errors.New("CSV must have at least a header and one data row")
@@ -716,9 +993,9 @@ func (s *Service) exportToCSV(vocab []UserVocabulary) string {
 	for _, v := range vocab {
 		line := fmt.Sprintf("%s,%s,%s,%s,%s,%s,%s,%d,%d,%d,%s",
 			escapeCsvField(v.Vocabulary.Word),
-			escapeCsvField(v.Vocabulary.Translation),
+			escapeCsvField(v.DisplayTranslation()),
 			escapeCsvField(v.Vocabulary.PhoneticTranscription),
-			escapeCsvField(v.Vocabulary.Definition),
+			escapeCsvField(v.DisplayDefinition()),
 			escapeCsvField(v.Vocabulary.ExampleSentence),
 			escapeCsvField(v.ContextSentence),
 			escapeCsvField(v.PersonalNote),
@@ -790,8 +1067,12 @@ func (s *Service) getSRSStatistics(ctx context.Context, userID string) (*SRSStat
 		}
 	}
 
-	// Estimate time to maturity (average for new words to reach mastery level 6)
-	stats.TimeToMaturity = 45 // Simplified estimate in days
+	// Days a card answered correctly every time takes to reach a mature interval
+	config, err := s.getUserSRSConfig(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	stats.TimeToMaturity = float64(timeToMaturity(*config))
 
 	return stats, nil
 }
@@ -823,6 +1104,8 @@ func (s *Service) getSRSPresets() []SRSConfigPreset {
 			GraduationSteps:  []int{1, 3, 6},
 			NewWordsPerDay:   15,
 			MaxReviewsPerDay: 150,
+			LeechThreshold:   defaultLeechThreshold,
+			LeechAction:      LeechActionSuspend,
 		},
 		{
 			Name:             "Default",
@@ -835,6 +1118,8 @@ func (s *Service) getSRSPresets() []SRSConfigPreset {
 			GraduationSteps:  []int{1, 6},
 			NewWordsPerDay:   20,
 			MaxReviewsPerDay: 200,
+			LeechThreshold:   defaultLeechThreshold,
+			LeechAction:      LeechActionSuspend,
 		},
 		{
 			Name:             "Aggressive",
@@ -847,6 +1132,8 @@ func (s *Service) getSRSPresets() []SRSConfigPreset {
 			GraduationSteps:  []int{1, 4},
 			NewWordsPerDay:   30,
 			MaxReviewsPerDay: 300,
+			LeechThreshold:   defaultLeechThreshold,
+			LeechAction:      LeechActionSuspend,
 		},
 		{
 			Name:             "Exam Prep",
@@ -859,21 +1146,14 @@ func (s *Service) getSRSPresets() []SRSConfigPreset {
 			GraduationSteps:  []int{1, 2, 4},
 			NewWordsPerDay:   40,
 			MaxReviewsPerDay: 500,
+			LeechThreshold:   defaultLeechThreshold,
+			LeechAction:      LeechActionSuspend,
 		},
 	}
 }
 
 func (s *Service) ApplySRSPreset(ctx context.Context, userID, presetName string) error {
-	presets := s.getSRSPresets()
-
-	var selectedPreset *SRSConfigPreset
-	for _, preset := range presets {
-		if strings.ToLower(preset.Name) == strings.ToLower(presetName) {
-			selectedPreset = &preset
-			break
-		}
-	}
-
+	selectedPreset := s.findSRSPreset(presetName)
 	if selectedPreset == nil {
 		return errors.New("preset not found")
 	}
@@ -887,6 +1167,8 @@ func (s *Service) ApplySRSPreset(ctx context.Context, userID, presetName string)
 		GraduationSteps:  selectedPreset.GraduationSteps,
 		NewWordsPerDay:   selectedPreset.NewWordsPerDay,
 		MaxReviewsPerDay: selectedPreset.MaxReviewsPerDay,
+		LeechThreshold:   &selectedPreset.LeechThreshold,
+		LeechAction:      selectedPreset.LeechAction,
 	}
 
 	return s.UpdateSRSConfig(ctx, userID, req)
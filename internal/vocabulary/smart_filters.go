@@ -0,0 +1,415 @@
+package vocabulary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Upper bound on the entries a bulk operation driven by a smart filter touches
+const maxBulkFilterTargets = 1000
+
+// SmartFilter is a saved query over a user's vocabulary, evaluated whenever
+// it is used, unlike a VocabularyList whose items are added by hand
+type SmartFilter struct {
+	ID          string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      string    `json:"user_id" gorm:"not null;index"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description string    `json:"description"`
+	LanguageID  int       `json:"language_id" gorm:"not null"`
+	Criteria    string    `json:"-" gorm:"type:text"` // SmartFilterCriteria as JSON
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SmartFilterCriteria are the conditions of a smart filter. Every set
+// condition must hold.
+type SmartFilterCriteria struct {
+	Tags             []string   `json:"tags,omitempty" validate:"omitempty,max=20,dive,min=1,max=50"`         // Entry carries every tag
+	ExcludeTags      []string   `json:"exclude_tags,omitempty" validate:"omitempty,max=20,dive,min=1,max=50"` // Entry carries none of these
+	SourceContentIDs []string   `json:"source_content_ids,omitempty" validate:"omitempty,max=50,dive,uuid"`
+	AddedAfter       *time.Time `json:"added_after,omitempty"`
+	AddedBefore      *time.Time `json:"added_before,omitempty"`
+	MinLapses        *int       `json:"min_lapses,omitempty" validate:"omitempty,min=0"`
+	MaxLapses        *int       `json:"max_lapses,omitempty" validate:"omitempty,min=0"`
+	DueWithinDays    *int       `json:"due_within_days,omitempty" validate:"omitempty,min=0,max=365"` // 0 means due now
+	DifficultyLevels []string   `json:"difficulty_levels,omitempty" validate:"omitempty,max=10"`
+	MasteryLevels    []int      `json:"mastery_levels,omitempty" validate:"omitempty,max=11,dive,min=0,max=10"`
+}
+
+type SmartFilterResponse struct {
+	SmartFilter
+	Criteria   SmartFilterCriteria `json:"criteria"`
+	MatchCount int64               `json:"match_count"`
+}
+
+type CreateSmartFilterRequest struct {
+	Name        string              `json:"name" validate:"required,min=1,max=255"`
+	Description string              `json:"description" validate:"max=1000"`
+	LanguageID  int                 `json:"language_id" validate:"required"`
+	Criteria    SmartFilterCriteria `json:"criteria"`
+}
+
+type UpdateSmartFilterRequest struct {
+	Name        string               `json:"name" validate:"omitempty,min=1,max=255"`
+	Description string               `json:"description" validate:"omitempty,max=1000"`
+	Criteria    *SmartFilterCriteria `json:"criteria"`
+}
+
+type SetTagsRequest struct {
+	Tags []string `json:"tags" validate:"max=20,dive,min=1,max=50"`
+}
+
+type BulkTagRequest struct {
+	VocabularyIDs []string `json:"vocabulary_ids" validate:"required_without=FilterID,omitempty,min=1,max=100"`
+	FilterID      string   `json:"filter_id" validate:"omitempty,uuid"` // Tag every entry matching the smart filter
+	Add           []string `json:"add" validate:"omitempty,max=20,dive,min=1,max=50"`
+	Remove        []string `json:"remove" validate:"omitempty,max=20,dive,min=1,max=50"`
+}
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// GetCriteria decodes the stored criteria
+func (f *SmartFilter) GetCriteria() SmartFilterCriteria {
+	var criteria SmartFilterCriteria
+	if f.Criteria != "" {
+		json.Unmarshal([]byte(f.Criteria), &criteria)
+	}
+	return criteria
+}
+
+// SetCriteria encodes the criteria for storage, normalising tags
+func (f *SmartFilter) SetCriteria(criteria SmartFilterCriteria) {
+	criteria.Tags = normalizeTags(criteria.Tags)
+	criteria.ExcludeTags = normalizeTags(criteria.ExcludeTags)
+
+	data, _ := json.Marshal(criteria)
+	f.Criteria = string(data)
+}
+
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	var userVocab UserVocabulary
+	userVocab.SetTags(tags)
+	return userVocab.GetTags()
+}
+
+// applySmartFilter narrows a query joining user_vocabulary and vocabulary to
+// the entries matching the criteria. Lapse, due date and mastery conditions
+// read the SRS state in table, user_vocabulary for recognition cards and
+// vocabulary_cards for the other card types.
+func applySmartFilter(query *gorm.DB, criteria SmartFilterCriteria, now time.Time, table string) *gorm.DB {
+	for _, tag := range criteria.Tags {
+		query = query.Where("strpos(',' || user_vocabulary.tags || ',', ?) > 0", ","+normalizeTag(tag)+",")
+	}
+	for _, tag := range criteria.ExcludeTags {
+		query = query.Where("strpos(',' || user_vocabulary.tags || ',', ?) = 0", ","+normalizeTag(tag)+",")
+	}
+	if len(criteria.SourceContentIDs) > 0 {
+		query = query.Where("user_vocabulary.source_content_id IN ?", criteria.SourceContentIDs)
+	}
+	if criteria.AddedAfter != nil {
+		query = query.Where("user_vocabulary.added_at >= ?", *criteria.AddedAfter)
+	}
+	if criteria.AddedBefore != nil {
+		query = query.Where("user_vocabulary.added_at < ?", *criteria.AddedBefore)
+	}
+	if criteria.MinLapses != nil {
+		query = query.Where(table+".lapse_count >= ?", *criteria.MinLapses)
+	}
+	if criteria.MaxLapses != nil {
+		query = query.Where(table+".lapse_count <= ?", *criteria.MaxLapses)
+	}
+	if criteria.DueWithinDays != nil {
+		query = query.Where(table+".next_review_at <= ?", now.AddDate(0, 0, *criteria.DueWithinDays))
+	}
+	if len(criteria.DifficultyLevels) > 0 {
+		query = query.Where("vocabulary.difficulty_level IN ?", criteria.DifficultyLevels)
+	}
+	if len(criteria.MasteryLevels) > 0 {
+		query = query.Where(table+".mastery_level IN ?", criteria.MasteryLevels)
+	}
+	return query
+}
+
+// CreateSmartFilter saves a new smart filter
+func (s *Service) CreateSmartFilter(ctx context.Context, userID string, req CreateSmartFilterRequest) (*SmartFilterResponse, error) {
+	filter := SmartFilter{
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+		LanguageID:  req.LanguageID,
+	}
+	filter.SetCriteria(req.Criteria)
+
+	if err := s.db.Create(&filter).Error; err != nil {
+		return nil, err
+	}
+
+	return s.newSmartFilterResponse(filter), nil
+}
+
+// GetSmartFilters returns the user's smart filters with their match counts
+func (s *Service) GetSmartFilters(ctx context.Context, userID string, languageID int) ([]SmartFilterResponse, error) {
+	var filters []SmartFilter
+	query := s.db.Where("user_id = ?", userID)
+	if languageID > 0 {
+		query = query.Where("language_id = ?", languageID)
+	}
+
+	if err := query.Order("name ASC").Find(&filters).Error; err != nil {
+		return nil, err
+	}
+
+	responses := make([]SmartFilterResponse, 0, len(filters))
+	for _, filter := range filters {
+		responses = append(responses, *s.newSmartFilterResponse(filter))
+	}
+
+	return responses, nil
+}
+
+// GetSmartFilter returns one of the user's smart filters
+func (s *Service) GetSmartFilter(ctx context.Context, userID, filterID string) (*SmartFilterResponse, error) {
+	filter, err := s.loadSmartFilter(userID, filterID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.newSmartFilterResponse(*filter), nil
+}
+
+// UpdateSmartFilter changes the name, description or criteria of a filter
+func (s *Service) UpdateSmartFilter(ctx context.Context, userID, filterID string, req UpdateSmartFilterRequest) (*SmartFilterResponse, error) {
+	filter, err := s.loadSmartFilter(userID, filterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		filter.Name = req.Name
+	}
+	if req.Description != "" {
+		filter.Description = req.Description
+	}
+	if req.Criteria != nil {
+		filter.SetCriteria(*req.Criteria)
+	}
+
+	if err := s.db.Save(filter).Error; err != nil {
+		return nil, err
+	}
+
+	return s.newSmartFilterResponse(*filter), nil
+}
+
+// DeleteSmartFilter removes a smart filter. The vocabulary is untouched.
+func (s *Service) DeleteSmartFilter(ctx context.Context, userID, filterID string) error {
+	result := s.db.Where("id = ? AND user_id = ?", filterID, userID).Delete(&SmartFilter{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("smart filter not found")
+	}
+	return nil
+}
+
+// EvaluateSmartFilter returns the entries currently matching a filter
+func (s *Service) EvaluateSmartFilter(ctx context.Context, userID, filterID string, limit, offset int) ([]UserVocabulary, int64, error) {
+	filter, err := s.loadSmartFilter(userID, filterID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var vocab []UserVocabulary
+	var total int64
+
+	query := applySmartFilter(s.userVocabularyQuery(userID, filter.LanguageID), filter.GetCriteria(), time.Now(), "user_vocabulary")
+	query.Model(&UserVocabulary{}).Count(&total)
+
+	err = query.Order("user_vocabulary.added_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&vocab).Error
+
+	return vocab, total, err
+}
+
+func (s *Service) loadSmartFilter(userID, filterID string) (*SmartFilter, error) {
+	var filter SmartFilter
+	err := s.db.Where("id = ? AND user_id = ?", filterID, userID).First(&filter).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("smart filter not found")
+		}
+		return nil, err
+	}
+	return &filter, nil
+}
+
+// smartFilterVocabularyIDs returns the vocabulary IDs matching a filter, for
+// use as the scope of bulk operations
+func (s *Service) smartFilterVocabularyIDs(userID, filterID string) ([]string, error) {
+	filter, err := s.loadSmartFilter(userID, filterID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&UserVocabulary{}).
+		Joins("JOIN vocabulary ON user_vocabulary.vocabulary_id = vocabulary.id").
+		Where("user_vocabulary.user_id = ? AND vocabulary.language_id = ?", userID, filter.LanguageID)
+
+	var vocabularyIDs []string
+	err = applySmartFilter(query, filter.GetCriteria(), time.Now(), "user_vocabulary").
+		Limit(maxBulkFilterTargets+1).
+		Pluck("user_vocabulary.vocabulary_id", &vocabularyIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vocabularyIDs) > maxBulkFilterTargets {
+		return nil, fmt.Errorf("smart filter matches more than %d entries, narrow it down to run a bulk operation", maxBulkFilterTargets)
+	}
+
+	return vocabularyIDs, nil
+}
+
+// bulkTargets resolves the vocabulary IDs a bulk operation applies to: the
+// smart filter's matches when one is given, otherwise the explicit IDs
+func (s *Service) bulkTargets(userID string, vocabularyIDs []string, filterID string) ([]string, error) {
+	if filterID == "" {
+		return vocabularyIDs, nil
+	}
+	return s.smartFilterVocabularyIDs(userID, filterID)
+}
+
+func (s *Service) newSmartFilterResponse(filter SmartFilter) *SmartFilterResponse {
+	criteria := filter.GetCriteria()
+
+	var count int64
+	query := s.db.Model(&UserVocabulary{}).
+		Joins("JOIN vocabulary ON user_vocabulary.vocabulary_id = vocabulary.id").
+		Where("user_vocabulary.user_id = ? AND vocabulary.language_id = ?", filter.UserID, filter.LanguageID)
+	applySmartFilter(query, criteria, time.Now(), "user_vocabulary").Count(&count)
+
+	return &SmartFilterResponse{
+		SmartFilter: filter,
+		Criteria:    criteria,
+		MatchCount:  count,
+	}
+}
+
+// SetVocabularyTags replaces the tags of a user's entry
+func (s *Service) SetVocabularyTags(ctx context.Context, userID, vocabularyID string, tags []string) (*UserVocabulary, error) {
+	var userVocab UserVocabulary
+	err := s.db.Preload("Vocabulary").Where("user_id = ? AND vocabulary_id = ?", userID, vocabularyID).First(&userVocab).Error
+	if err != nil {
+		return nil, errors.New("vocabulary not found")
+	}
+
+	userVocab.SetTags(tags)
+	if err := s.db.Model(&userVocab).Update("tags", userVocab.Tags).Error; err != nil {
+		return nil, err
+	}
+
+	return &userVocab, nil
+}
+
+// BulkTagVocabulary adds and removes tags on many entries at once
+func (s *Service) BulkTagVocabulary(ctx context.Context, userID string, req BulkTagRequest) (*BulkOperationResult, error) {
+	vocabularyIDs, err := s.bulkTargets(userID, req.VocabularyIDs, req.FilterID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkOperationResult{
+		Total:  len(vocabularyIDs),
+		Errors: make([]string, 0),
+	}
+	if len(vocabularyIDs) == 0 {
+		return result, nil
+	}
+
+	remove := make(map[string]bool, len(req.Remove))
+	for _, tag := range req.Remove {
+		remove[normalizeTag(tag)] = true
+	}
+
+	var entries []UserVocabulary
+	if err := s.db.Where("user_id = ? AND vocabulary_id IN ?", userID, vocabularyIDs).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		tags := make([]string, 0)
+		for _, tag := range entry.GetTags() {
+			if !remove[tag] {
+				tags = append(tags, tag)
+			}
+		}
+		entry.SetTags(append(tags, req.Add...))
+
+		if err := s.db.Model(&entry).Update("tags", entry.Tags).Error; err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		result.Processed++
+	}
+
+	if missing := result.Total - len(entries); missing > 0 {
+		result.Failed += missing
+		result.Errors = append(result.Errors, "some vocabulary was not found")
+	}
+
+	return result, nil
+}
+
+// GetTags returns every tag the user uses with the number of entries carrying it
+func (s *Service) GetTags(ctx context.Context, userID string, languageID int) ([]TagCount, error) {
+	query := s.db.Model(&UserVocabulary{}).
+		Joins("JOIN vocabulary ON user_vocabulary.vocabulary_id = vocabulary.id").
+		Where("user_vocabulary.user_id = ? AND user_vocabulary.tags <> ''", userID)
+	if languageID > 0 {
+		query = query.Where("vocabulary.language_id = ?", languageID)
+	}
+
+	var tagColumns []string
+	if err := query.Pluck("user_vocabulary.tags", &tagColumns).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, column := range tagColumns {
+		for _, tag := range strings.Split(column, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				counts[tag]++
+			}
+		}
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	return tags, nil
+}
@@ -0,0 +1,102 @@
+package vocabulary
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestSmartFilterCriteria(t *testing.T) {
+	minLapses := 3
+	filter := &SmartFilter{}
+	filter.SetCriteria(SmartFilterCriteria{
+		Tags:          []string{" Verbs", "verbs", "Travel"},
+		ExcludeTags:   []string{"LEECH"},
+		MinLapses:     &minLapses,
+		MasteryLevels: []int{0, 1},
+	})
+
+	criteria := filter.GetCriteria()
+	assert.Equal(t, []string{"verbs", "travel"}, criteria.Tags)
+	assert.Equal(t, []string{"leech"}, criteria.ExcludeTags)
+	assert.Equal(t, 3, *criteria.MinLapses)
+	assert.Nil(t, criteria.MaxLapses)
+	assert.Equal(t, []int{0, 1}, criteria.MasteryLevels)
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.Equal(t, SmartFilterCriteria{}, (&SmartFilter{}).GetCriteria())
+	})
+}
+
+func TestApplySmartFilterCardState(t *testing.T) {
+	service, _ := newMockService(t)
+	minLapses, dueWithin := 2, 0
+	criteria := SmartFilterCriteria{
+		MinLapses:        &minLapses,
+		DueWithinDays:    &dueWithin,
+		MasteryLevels:    []int{1},
+		DifficultyLevels: []string{"hard"},
+	}
+
+	sql := func(table string) string {
+		return service.db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			return applySmartFilter(tx.Table(table), criteria, time.Now(), table).Find(&[]map[string]interface{}{})
+		})
+	}
+
+	recognition := sql("user_vocabulary")
+	assert.Contains(t, recognition, "user_vocabulary.lapse_count >= 2")
+	assert.Contains(t, recognition, "user_vocabulary.next_review_at <=")
+	assert.Contains(t, recognition, "user_vocabulary.mastery_level IN (1)")
+
+	// Extra cards are filtered on their own SRS state, not the entry's
+	extra := sql("vocabulary_cards")
+	assert.Contains(t, extra, "vocabulary_cards.lapse_count >= 2")
+	assert.Contains(t, extra, "vocabulary_cards.next_review_at <=")
+	assert.Contains(t, extra, "vocabulary_cards.mastery_level IN (1)")
+	assert.NotContains(t, extra, "user_vocabulary.lapse_count")
+	assert.NotContains(t, extra, "user_vocabulary.mastery_level")
+	assert.Contains(t, extra, "vocabulary.difficulty_level IN ('hard')")
+}
+
+func TestBulkRequestValidation(t *testing.T) {
+	validate := validator.New()
+
+	assert.NoError(t, validate.Struct(BulkTagRequest{VocabularyIDs: []string{"a"}}))
+	assert.NoError(t, validate.Struct(BulkTagRequest{FilterID: "5f0c6a43-3c0e-4d7e-9d62-1f1f1b8f6a10"}))
+	assert.Error(t, validate.Struct(BulkTagRequest{}))
+	assert.Error(t, validate.Struct(BulkTagRequest{VocabularyIDs: []string{}}))
+	assert.Error(t, validate.Struct(BulkDeleteVocabularyRequest{VocabularyIDs: []string{}}))
+	assert.Error(t, validate.Struct(BulkResetProgressRequest{VocabularyIDs: make([]string, 101), ResetType: "all"}))
+}
+
+func TestSmartFilterVocabularyIDsCap(t *testing.T) {
+	matches := func(n int) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"vocabulary_id"})
+		for i := 0; i < n; i++ {
+			rows.AddRow(fmt.Sprintf("vocab-%d", i))
+		}
+		return rows
+	}
+
+	run := func(t *testing.T, n int) ([]string, error) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`SELECT \* FROM "smart_filters"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "language_id"}).AddRow("filter-1", "user-1", 1))
+		mock.ExpectQuery(`SELECT "user_vocabulary"."vocabulary_id" FROM "user_vocabulary" .* LIMIT \$\d+`).
+			WillReturnRows(matches(n))
+		return service.smartFilterVocabularyIDs("user-1", "filter-1")
+	}
+
+	ids, err := run(t, maxBulkFilterTargets)
+	assert.NoError(t, err)
+	assert.Len(t, ids, maxBulkFilterTargets)
+
+	_, err = run(t, maxBulkFilterTargets+1)
+	assert.Error(t, err)
+}
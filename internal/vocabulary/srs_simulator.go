@@ -0,0 +1,623 @@
+package vocabulary
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSimulationDays  = 30
+	defaultTargetRetention = 0.9
+
+	// matureIntervalDays is the interval from which a card counts as mature
+	matureIntervalDays = 21
+
+	// minFitReviews is the history needed before the memory model is fitted
+	// to the user rather than left at its defaults
+	minFitReviews = 50
+
+	// simulationSeed keeps simulations repeatable, so two configs are always
+	// compared on the same sequence of answers
+	simulationSeed = 42
+)
+
+// ReviewLog is one review as the scheduler saw it. The log is the history the
+// memory model is fitted to.
+type ReviewLog struct {
+	ID            string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID        string    `json:"user_id" gorm:"not null;index:idx_review_log_user_time"`
+	CardID        string    `json:"card_id" gorm:"not null;index"`
+	VocabularyID  string    `json:"vocabulary_id" gorm:"not null"`
+	CardType      string    `json:"card_type" gorm:"not null"`
+	Correct       bool      `json:"correct"`
+	ResponseTime  int       `json:"response_time"` // milliseconds
+	ReviewCount   int       `json:"review_count"`  // Reviews before this one
+	ElapsedDays   float64   `json:"elapsed_days"`  // Since the previous review, 0 for the first
+	ScheduledDays int       `json:"scheduled_days"`
+	IntervalDays  int       `json:"interval_days"` // Interval given by this review
+	EaseFactor    float64   `json:"ease_factor"`
+	MasteryLevel  int       `json:"mastery_level"`
+	ReviewedAt    time.Time `json:"reviewed_at" gorm:"not null;index:idx_review_log_user_time"`
+}
+
+// newReviewLog describes a review that moved a card from before to after
+func newReviewLog(userID, cardID string, req ReviewRequest, before, after SRSState, reviewedAt time.Time) *ReviewLog {
+	cardType := req.CardType
+	if cardType == "" {
+		cardType = CardTypeRecognition
+	}
+
+	log := &ReviewLog{
+		UserID:        userID,
+		CardID:        cardID,
+		VocabularyID:  req.VocabularyID,
+		CardType:      cardType,
+		Correct:       req.Correct,
+		ResponseTime:  req.ResponseTime,
+		ReviewCount:   before.ReviewCount,
+		ScheduledDays: before.IntervalDays,
+		IntervalDays:  after.IntervalDays,
+		EaseFactor:    after.EaseFactor,
+		MasteryLevel:  after.MasteryLevel,
+		ReviewedAt:    reviewedAt,
+	}
+	if before.LastReviewedAt != nil && before.ReviewCount > 0 {
+		log.ElapsedDays = math.Max(reviewedAt.Sub(*before.LastReviewedAt).Hours()/24, 0)
+	}
+	return log
+}
+
+// MemoryModel predicts how likely the learner is to recall a card. Recall
+// decays exponentially and falls to 90% after Stability days; each successful
+// review makes the memory more stable, more so when it was nearly forgotten.
+type MemoryModel struct {
+	FirstRecall      float64 `json:"first_recall"`      // Chance of knowing a card at its first review
+	InitialStability float64 `json:"initial_stability"` // Stability in days after learning or relearning a card
+	StabilityGrowth  float64 `json:"stability_growth"`  // Stability gain of a successful review at 90% recall
+	Reviews          int     `json:"reviews"`           // Logged reviews the model was fitted on
+	Fitted           bool    `json:"fitted"`            // False when defaults were used for lack of history
+}
+
+var defaultMemoryModel = MemoryModel{FirstRecall: 0.7, InitialStability: 1.5, StabilityGrowth: 1.5}
+
+// recall is the chance of recalling a card elapsedDays after its last review
+func (m MemoryModel) recall(elapsedDays, stability float64) float64 {
+	return math.Pow(0.9, math.Max(elapsedDays, 0)/stability)
+}
+
+// nextStability is a card's stability after a review answered with the given
+// chance of recall
+func (m MemoryModel) nextStability(stability, recall float64, correct bool) float64 {
+	if !correct {
+		return m.InitialStability
+	}
+	// Reviews well before forgetting add little, capped for long overdue ones
+	difficulty := math.Min((1-recall)/0.1, 3)
+	return stability * (1 + m.StabilityGrowth*difficulty)
+}
+
+// fitMemoryModel picks the memory model that best predicts the logged reviews.
+// Only cards whose history starts at their first review are used, since the
+// stability of the others is unknown.
+func fitMemoryModel(logs []ReviewLog) MemoryModel {
+	byCard := make(map[string][]ReviewLog)
+	for _, log := range logs {
+		byCard[log.CardID] = append(byCard[log.CardID], log)
+	}
+
+	histories := make([][]ReviewLog, 0, len(byCard))
+	firstTotal, firstCorrect, reviews := 0, 0, 0
+	for _, history := range byCard {
+		sort.Slice(history, func(i, j int) bool { return history[i].ReviewedAt.Before(history[j].ReviewedAt) })
+		if history[0].ReviewCount != 0 {
+			continue
+		}
+		firstTotal++
+		if history[0].Correct {
+			firstCorrect++
+		}
+		reviews += len(history) - 1
+		histories = append(histories, history)
+	}
+
+	model := defaultMemoryModel
+	if reviews < minFitReviews {
+		return model
+	}
+
+	bestLoss := math.Inf(1)
+	for _, initial := range []float64{0.25, 0.5, 1, 1.5, 2, 3, 4, 6, 8} {
+		for _, growth := range []float64{0.5, 1, 1.5, 2, 2.5, 3, 4, 5} {
+			candidate := MemoryModel{InitialStability: initial, StabilityGrowth: growth}
+			if loss := candidate.logLoss(histories); loss < bestLoss {
+				bestLoss = loss
+				model.InitialStability = initial
+				model.StabilityGrowth = growth
+			}
+		}
+	}
+
+	model.FirstRecall = float64(firstCorrect) / float64(firstTotal)
+	model.Reviews = reviews
+	model.Fitted = true
+	return model
+}
+
+// logLoss is how badly the model predicts the reviews after each card's first
+func (m MemoryModel) logLoss(histories [][]ReviewLog) float64 {
+	loss := 0.0
+	for _, history := range histories {
+		stability := m.InitialStability
+		for i := 1; i < len(history); i++ {
+			elapsed := history[i].ReviewedAt.Sub(history[i-1].ReviewedAt).Hours() / 24
+			p := math.Min(math.Max(m.recall(elapsed, stability), 0.001), 0.999)
+			if history[i].Correct {
+				loss -= math.Log(p)
+			} else {
+				loss -= math.Log(1 - p)
+			}
+			stability = m.nextStability(stability, p, history[i].Correct)
+		}
+	}
+	return loss
+}
+
+// SimulationDay is the projected workload of one day
+type SimulationDay struct {
+	Day      int `json:"day"`
+	Reviews  int `json:"reviews"`
+	NewCards int `json:"new_cards"`
+	Lapses   int `json:"lapses"`
+	Backlog  int `json:"backlog"` // Due cards left over by the daily review cap
+}
+
+// SRSSimulation is the projected outcome of studying with a config
+type SRSSimulation struct {
+	Days                 int             `json:"days"`
+	TotalReviews         int             `json:"total_reviews"`
+	AverageReviewsPerDay float64         `json:"average_reviews_per_day"`
+	PeakReviews          int             `json:"peak_reviews"`
+	NewCardsIntroduced   int             `json:"new_cards_introduced"`
+	AverageRecall        float64         `json:"average_recall"`       // Percentage of reviews answered correctly
+	FinalRetention       float64         `json:"final_retention"`      // Percentage of studied cards remembered on the last day
+	ExpectedKnownCards   float64         `json:"expected_known_cards"` // Studied cards remembered on the last day
+	MatureCards          int             `json:"mature_cards"`
+	Daily                []SimulationDay `json:"daily"`
+}
+
+// SRSSimulationCandidate compares a config with the user's current one
+type SRSSimulationCandidate struct {
+	Name               string        `json:"name"`
+	Config             SRSConfig     `json:"config"`
+	Simulation         SRSSimulation `json:"simulation"`
+	ReviewsPerDayDelta float64       `json:"reviews_per_day_delta"`
+	NewCardsDelta      int           `json:"new_cards_delta"`
+	RetentionDelta     float64       `json:"retention_delta"` // Percentage points of final retention
+}
+
+type SRSSimulationComparison struct {
+	MemoryModel MemoryModel              `json:"memory_model"`
+	Current     SRSSimulation            `json:"current"`
+	Candidates  []SRSSimulationCandidate `json:"candidates"`
+}
+
+type SimulateSRSRequest struct {
+	Days int `json:"days" validate:"omitempty,min=1,max=365"`
+	// Compared with the current config; all presets when both are empty
+	Preset string                  `json:"preset"`
+	Config *UpdateSRSConfigRequest `json:"config"`
+	// New cards to assume beyond the ones already waiting in the deck
+	AdditionalNewCards int `json:"additional_new_cards" validate:"min=0,max=10000"`
+}
+
+type OptimizeSRSRequest struct {
+	Days               int     `json:"days" validate:"omitempty,min=7,max=365"`
+	TargetRetention    float64 `json:"target_retention" validate:"omitempty,min=0.7,max=0.97"`
+	AdditionalNewCards int     `json:"additional_new_cards" validate:"min=0,max=10000"`
+}
+
+// SRSOptimization is the config that reaches the target retention with the
+// fewest reviews per remembered card. It is a suggestion; apply it with
+// PUT /srs-config.
+type SRSOptimization struct {
+	MemoryModel        MemoryModel   `json:"memory_model"`
+	TargetRetention    float64       `json:"target_retention"`
+	CurrentConfig      SRSConfig     `json:"current_config"`
+	RecommendedConfig  SRSConfig     `json:"recommended_config"`
+	Current            SRSSimulation `json:"current"`
+	Recommended        SRSSimulation `json:"recommended"`
+	ReviewsPerDayDelta float64       `json:"reviews_per_day_delta"`
+	Changed            bool          `json:"changed"`
+}
+
+// simCard is a card as the simulator tracks it
+type simCard struct {
+	state     SRSState
+	stability float64
+	lastDay   float64
+	studied   bool
+}
+
+// simulateSRS projects studying deck with config for the given number of
+// days. Cards that were never reviewed enter at the config's daily new-card
+// limit; due reviews above the daily cap are carried over to the next day.
+func simulateSRS(deck []SRSState, config SRSConfig, model MemoryModel, days int, start time.Time) SRSSimulation {
+	rng := rand.New(rand.NewSource(simulationSeed))
+	sim := SRSSimulation{Days: days, Daily: make([]SimulationDay, 0, days)}
+
+	cards := make([]simCard, len(deck))
+	due := make(map[int][]int)
+	newCards := make([]int, 0)
+	for i, state := range deck {
+		cards[i] = simCard{state: state}
+		if state.ReviewCount == 0 {
+			newCards = append(newCards, i)
+			continue
+		}
+
+		cards[i].studied = true
+		cards[i].stability = math.Max(float64(state.IntervalDays), model.InitialStability)
+		if state.LastReviewedAt != nil {
+			cards[i].lastDay = -start.Sub(*state.LastReviewedAt).Hours() / 24
+		}
+		day := 0
+		if state.NextReviewAt != nil && state.NextReviewAt.After(start) {
+			day = int(math.Ceil(state.NextReviewAt.Sub(start).Hours() / 24))
+		}
+		due[day] = append(due[day], i)
+	}
+
+	correctReviews := 0
+	for day := 0; day < days; day++ {
+		stats := SimulationDay{Day: day}
+		reviewedAt := start.AddDate(0, 0, day)
+
+		queue := due[day]
+		delete(due, day)
+		if config.MaxReviewsPerDay > 0 && len(queue) > config.MaxReviewsPerDay {
+			// Most overdue first, the rest wait for tomorrow
+			stats.Backlog = len(queue) - config.MaxReviewsPerDay
+			due[day+1] = append(queue[config.MaxReviewsPerDay:], due[day+1]...)
+			queue = queue[:config.MaxReviewsPerDay]
+		}
+
+		for _, i := range queue {
+			card := &cards[i]
+			p := model.recall(float64(day)-card.lastDay, card.stability)
+			correct := rng.Float64() < p
+			if correct {
+				correctReviews++
+			} else if card.state.MasteryLevel >= learningMasteryLevel {
+				stats.Lapses++
+			}
+			card.stability = model.nextStability(card.stability, p, correct)
+			card.review(correct, &config, reviewedAt, day)
+			due[day+card.state.IntervalDays] = append(due[day+card.state.IntervalDays], i)
+			stats.Reviews++
+		}
+
+		introduce := config.NewWordsPerDay
+		if introduce > len(newCards) {
+			introduce = len(newCards)
+		}
+		for _, i := range newCards[:introduce] {
+			card := &cards[i]
+			card.studied = true
+			card.stability = model.InitialStability
+			card.review(rng.Float64() < model.FirstRecall, &config, reviewedAt, day)
+			due[day+card.state.IntervalDays] = append(due[day+card.state.IntervalDays], i)
+		}
+		newCards = newCards[introduce:]
+		stats.NewCards = introduce
+
+		sim.TotalReviews += stats.Reviews
+		if stats.Reviews > sim.PeakReviews {
+			sim.PeakReviews = stats.Reviews
+		}
+		sim.NewCardsIntroduced += stats.NewCards
+		sim.Daily = append(sim.Daily, stats)
+	}
+
+	studied := 0
+	for _, card := range cards {
+		if !card.studied {
+			continue
+		}
+		studied++
+		sim.ExpectedKnownCards += model.recall(float64(days)-card.lastDay, card.stability)
+		if card.state.IntervalDays >= matureIntervalDays {
+			sim.MatureCards++
+		}
+	}
+
+	if days > 0 {
+		sim.AverageReviewsPerDay = roundTo(float64(sim.TotalReviews)/float64(days), 1)
+	}
+	sim.AverageRecall = percentage(float64(correctReviews), float64(sim.TotalReviews))
+	sim.FinalRetention = percentage(sim.ExpectedKnownCards, float64(studied))
+	sim.ExpectedKnownCards = roundTo(sim.ExpectedKnownCards, 1)
+
+	return sim
+}
+
+// review answers a simulated card with the real scheduler
+func (c *simCard) review(correct bool, config *SRSConfig, reviewedAt time.Time, day int) {
+	c.state.ReviewCount++
+	scheduleReview(&c.state, correct, config, reviewedAt)
+	if c.state.IntervalDays < 1 {
+		c.state.IntervalDays = 1
+	}
+	c.lastDay = float64(day)
+}
+
+// timeToMaturity is the number of days a card answered correctly every time
+// takes to reach a mature interval
+func timeToMaturity(config SRSConfig) int {
+	state := SRSState{EaseFactor: config.MaxEaseFactor, IntervalDays: 1}
+	days := 0
+	for i := 0; i < 100 && state.IntervalDays < matureIntervalDays; i++ {
+		scheduleReview(&state, true, &config, time.Time{})
+		if state.IntervalDays < 1 {
+			return 0 // Never matures
+		}
+		days += state.IntervalDays
+	}
+	if state.IntervalDays < matureIntervalDays {
+		return 0
+	}
+	return days - state.IntervalDays
+}
+
+// optimizerCandidates varies the scheduling parameters of a config, keeping
+// the user's daily limits
+func optimizerCandidates(current SRSConfig) []SRSConfig {
+	stepOptions := [][]int{{1}, {1, 3}, {1, 4}, {1, 6}, {2, 6}, {1, 2, 4}, {1, 3, 6}}
+	candidates := make([]SRSConfig, 0)
+	for _, steps := range stepOptions {
+		for _, maxEase := range []float64{2.0, 2.5, 3.0} {
+			for _, penalty := range []float64{0.2, 0.4, 0.6, 0.8} {
+				if maxEase <= current.MinEaseFactor {
+					continue
+				}
+				candidate := current
+				candidate.GraduationSteps = steps
+				candidate.MaxEaseFactor = maxEase
+				candidate.FailurePenalty = penalty
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	return candidates
+}
+
+// optimizeSRSConfig simulates the candidates and keeps the one with the
+// fewest reviews per remembered card among those reaching the target recall.
+// When none does, the one with the best recall wins.
+func optimizeSRSConfig(deck []SRSState, current SRSConfig, model MemoryModel, days int, target float64, start time.Time) (SRSConfig, SRSSimulation) {
+	cost := func(sim SRSSimulation) float64 {
+		return float64(sim.TotalReviews) / math.Max(sim.ExpectedKnownCards, 1)
+	}
+	meets := func(sim SRSSimulation) bool {
+		return sim.AverageRecall >= target*100
+	}
+
+	best := current
+	bestSim := simulateSRS(deck, current, model, days, start)
+	for _, candidate := range optimizerCandidates(current) {
+		sim := simulateSRS(deck, candidate, model, days, start)
+		switch {
+		case meets(sim) && !meets(bestSim):
+		case meets(sim) && cost(sim) < cost(bestSim):
+		case !meets(sim) && !meets(bestSim) && sim.AverageRecall > bestSim.AverageRecall:
+		default:
+			continue
+		}
+		best, bestSim = candidate, sim
+	}
+
+	return best, bestSim
+}
+
+// loadSimulationDeck returns the SRS state of the user's cards that are not
+// suspended, with extra new cards appended
+func (s *Service) loadSimulationDeck(userID string, additionalNewCards int) ([]SRSState, error) {
+	var deck []SRSState
+	if err := s.db.Model(&UserVocabulary{}).Where("user_id = ? AND suspended = ?", userID, false).Find(&deck).Error; err != nil {
+		return nil, err
+	}
+
+	var extra []SRSState
+	err := s.db.Model(&VocabularyCard{}).Where("user_id = ? AND active = ? AND suspended = ?", userID, true, false).Find(&extra).Error
+	if err != nil {
+		return nil, err
+	}
+	deck = append(deck, extra...)
+
+	for i := 0; i < additionalNewCards; i++ {
+		deck = append(deck, SRSState{EaseFactor: s.srsConfig.MaxEaseFactor, IntervalDays: 1})
+	}
+
+	return deck, nil
+}
+
+// userMemoryModel fits the memory model to the user's review history
+func (s *Service) userMemoryModel(userID string) (MemoryModel, error) {
+	var logs []ReviewLog
+	err := s.db.Select("card_id, correct, review_count, reviewed_at").
+		Where("user_id = ?", userID).
+		Order("reviewed_at ASC").
+		Find(&logs).Error
+	if err != nil {
+		return MemoryModel{}, err
+	}
+	return fitMemoryModel(logs), nil
+}
+
+// SimulateSRS projects the user's workload and retention under their current
+// config and compares it with a preset, a custom config or every preset
+func (s *Service) SimulateSRS(ctx context.Context, userID string, req SimulateSRSRequest) (*SRSSimulationComparison, error) {
+	current, err := s.getUserSRSConfig(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]SRSSimulationCandidate, 0)
+	switch {
+	case req.Config != nil:
+		if err := req.Config.Validate(); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, SRSSimulationCandidate{Name: "Custom", Config: req.Config.toSRSConfig(current)})
+	case req.Preset != "":
+		preset := s.findSRSPreset(req.Preset)
+		if preset == nil {
+			return nil, errors.New("preset not found")
+		}
+		candidates = append(candidates, SRSSimulationCandidate{Name: preset.Name, Config: preset.toSRSConfig()})
+	default:
+		for _, preset := range s.getSRSPresets() {
+			candidates = append(candidates, SRSSimulationCandidate{Name: preset.Name, Config: preset.toSRSConfig()})
+		}
+	}
+
+	deck, err := s.loadSimulationDeck(userID, req.AdditionalNewCards)
+	if err != nil {
+		return nil, err
+	}
+	model, err := s.userMemoryModel(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	days := req.Days
+	if days <= 0 {
+		days = defaultSimulationDays
+	}
+	start := time.Now()
+
+	comparison := &SRSSimulationComparison{
+		MemoryModel: model,
+		Current:     simulateSRS(deck, *current, model, days, start),
+	}
+	for _, candidate := range candidates {
+		candidate.Simulation = simulateSRS(deck, candidate.Config, model, days, start)
+		candidate.ReviewsPerDayDelta = roundTo(candidate.Simulation.AverageReviewsPerDay-comparison.Current.AverageReviewsPerDay, 1)
+		candidate.NewCardsDelta = candidate.Simulation.NewCardsIntroduced - comparison.Current.NewCardsIntroduced
+		candidate.RetentionDelta = roundTo(candidate.Simulation.FinalRetention-comparison.Current.FinalRetention, 2)
+		comparison.Candidates = append(comparison.Candidates, candidate)
+	}
+
+	return comparison, nil
+}
+
+// OptimizeSRS fits the memory model to the user's reviews and searches for
+// scheduler parameters that reach the target retention with less work
+func (s *Service) OptimizeSRS(ctx context.Context, userID string, req OptimizeSRSRequest) (*SRSOptimization, error) {
+	current, err := s.getUserSRSConfig(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	deck, err := s.loadSimulationDeck(userID, req.AdditionalNewCards)
+	if err != nil {
+		return nil, err
+	}
+	model, err := s.userMemoryModel(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	days := req.Days
+	if days <= 0 {
+		days = defaultSimulationDays
+	}
+	target := req.TargetRetention
+	if target <= 0 {
+		target = defaultTargetRetention
+	}
+	start := time.Now()
+
+	recommended, recommendedSim := optimizeSRSConfig(deck, *current, model, days, target, start)
+	currentSim := simulateSRS(deck, *current, model, days, start)
+
+	return &SRSOptimization{
+		MemoryModel:        model,
+		TargetRetention:    target,
+		CurrentConfig:      *current,
+		RecommendedConfig:  recommended,
+		Current:            currentSim,
+		Recommended:        recommendedSim,
+		ReviewsPerDayDelta: roundTo(recommendedSim.AverageReviewsPerDay-currentSim.AverageReviewsPerDay, 1),
+		Changed:            !sameSchedule(recommended, *current),
+	}, nil
+}
+
+// findSRSPreset looks a preset up by name, ignoring case
+func (s *Service) findSRSPreset(name string) *SRSConfigPreset {
+	for _, preset := range s.getSRSPresets() {
+		if strings.EqualFold(preset.Name, name) {
+			return &preset
+		}
+	}
+	return nil
+}
+
+func (p SRSConfigPreset) toSRSConfig() SRSConfig {
+	return SRSConfig{
+		EasyBonus:        p.EasyBonus,
+		HardPenalty:      p.HardPenalty,
+		FailurePenalty:   p.FailurePenalty,
+		MinEaseFactor:    p.MinEaseFactor,
+		MaxEaseFactor:    p.MaxEaseFactor,
+		GraduationSteps:  p.GraduationSteps,
+		NewWordsPerDay:   p.NewWordsPerDay,
+		MaxReviewsPerDay: p.MaxReviewsPerDay,
+		LeechThreshold:   p.LeechThreshold,
+		LeechAction:      p.LeechAction,
+	}
+}
+
+// toSRSConfig applies the request to a config, keeping the leech threshold
+// and action when the request leaves them out
+func (r UpdateSRSConfigRequest) toSRSConfig(current *SRSConfig) SRSConfig {
+	config := SRSConfig{
+		EasyBonus:        r.EasyBonus,
+		HardPenalty:      r.HardPenalty,
+		FailurePenalty:   r.FailurePenalty,
+		MinEaseFactor:    r.MinEaseFactor,
+		MaxEaseFactor:    r.MaxEaseFactor,
+		GraduationSteps:  r.GraduationSteps,
+		NewWordsPerDay:   r.NewWordsPerDay,
+		MaxReviewsPerDay: r.MaxReviewsPerDay,
+		LeechThreshold:   current.LeechThreshold,
+		LeechAction:      r.LeechAction,
+	}
+	if r.LeechThreshold != nil {
+		config.LeechThreshold = *r.LeechThreshold
+	}
+	if config.LeechAction == "" {
+		config.LeechAction = current.LeechAction
+	}
+	return config
+}
+
+// sameSchedule reports whether two configs schedule cards identically
+func sameSchedule(a, b SRSConfig) bool {
+	if a.FailurePenalty != b.FailurePenalty || a.MinEaseFactor != b.MinEaseFactor || a.MaxEaseFactor != b.MaxEaseFactor {
+		return false
+	}
+	if len(a.GraduationSteps) != len(b.GraduationSteps) {
+		return false
+	}
+	for i := range a.GraduationSteps {
+		if a.GraduationSteps[i] != b.GraduationSteps[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+package vocabulary
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testSRSConfig() SRSConfig {
+	return SRSConfig{
+		FailurePenalty:   0.2,
+		MinEaseFactor:    1.3,
+		MaxEaseFactor:    2.5,
+		GraduationSteps:  []int{1, 6},
+		NewWordsPerDay:   20,
+		MaxReviewsPerDay: 200,
+	}
+}
+
+func newCards(n int) []SRSState {
+	deck := make([]SRSState, n)
+	for i := range deck {
+		deck[i] = SRSState{EaseFactor: 2.5, IntervalDays: 1}
+	}
+	return deck
+}
+
+func TestTimeToMaturity(t *testing.T) {
+	// Intervals 1, 6, 15, 37: the review on day 22 makes the card mature
+	assert.Equal(t, 22, timeToMaturity(testSRSConfig()))
+
+	stuck := testSRSConfig()
+	stuck.MaxEaseFactor = 1.0
+	stuck.GraduationSteps = []int{1}
+	assert.Equal(t, 0, timeToMaturity(stuck))
+}
+
+func TestSimulateSRS(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	config := testSRSConfig()
+
+	sim := simulateSRS(newCards(100), config, defaultMemoryModel, 30, start)
+	assert.Equal(t, 30, sim.Days)
+	assert.Len(t, sim.Daily, 30)
+	assert.Equal(t, 100, sim.NewCardsIntroduced)
+	assert.Equal(t, 20, sim.Daily[0].NewCards)
+	assert.Equal(t, 0, sim.Daily[5].NewCards)
+	assert.Greater(t, sim.TotalReviews, 0)
+	assert.Greater(t, sim.FinalRetention, 0.0)
+	assert.LessOrEqual(t, sim.FinalRetention, 100.0)
+
+	// Repeatable for the same inputs
+	assert.Equal(t, sim, simulateSRS(newCards(100), config, defaultMemoryModel, 30, start))
+
+	// More new cards a day means more reviews
+	faster := config
+	faster.NewWordsPerDay = 50
+	assert.Greater(t, simulateSRS(newCards(500), faster, defaultMemoryModel, 30, start).TotalReviews,
+		simulateSRS(newCards(500), config, defaultMemoryModel, 30, start).TotalReviews)
+}
+
+func TestSimulateSRSReviewCap(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	due := start.Add(-time.Hour)
+	last := start.AddDate(0, 0, -3)
+
+	deck := make([]SRSState, 50)
+	for i := range deck {
+		deck[i] = SRSState{ReviewCount: 3, MasteryLevel: 2, EaseFactor: 2.5, IntervalDays: 3, NextReviewAt: &due, LastReviewedAt: &last}
+	}
+
+	config := testSRSConfig()
+	config.MaxReviewsPerDay = 20
+	sim := simulateSRS(deck, config, defaultMemoryModel, 5, start)
+
+	assert.Equal(t, 20, sim.Daily[0].Reviews)
+	assert.Equal(t, 30, sim.Daily[0].Backlog)
+	assert.Equal(t, 20, sim.Daily[1].Reviews)
+	assert.GreaterOrEqual(t, sim.Daily[1].Backlog, 10) // Plus cards forgotten on day 0
+}
+
+func TestFitMemoryModel(t *testing.T) {
+	// Too little history keeps the defaults
+	assert.Equal(t, defaultMemoryModel, fitMemoryModel(nil))
+
+	// Cards recalled after long gaps fit a more stable memory than cards
+	// forgotten after short ones
+	history := func(gapDays int, correct bool) []ReviewLog {
+		logs := make([]ReviewLog, 0)
+		start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+		for card := 0; card < 30; card++ {
+			id := string(rune('a'+card%26)) + string(rune('0'+card/26))
+			at := start
+			for i := 0; i < 4; i++ {
+				logs = append(logs, ReviewLog{CardID: id, ReviewCount: i, Correct: i == 0 || correct, ReviewedAt: at})
+				at = at.AddDate(0, 0, gapDays)
+			}
+		}
+		return logs
+	}
+
+	strong := fitMemoryModel(history(10, true))
+	weak := fitMemoryModel(history(1, false))
+	assert.True(t, strong.Fitted)
+	assert.Equal(t, 90, strong.Reviews)
+	assert.Equal(t, 1.0, strong.FirstRecall)
+	assert.Greater(t, strong.InitialStability, weak.InitialStability)
+}
+
+func TestOptimizeSRSConfigMeetsTarget(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	config, sim := optimizeSRSConfig(newCards(60), testSRSConfig(), defaultMemoryModel, 60, 0.7, start)
+
+	assert.GreaterOrEqual(t, sim.AverageRecall, 70.0)
+	assert.Equal(t, testSRSConfig().NewWordsPerDay, config.NewWordsPerDay)
+	assert.Equal(t, testSRSConfig().MaxReviewsPerDay, config.MaxReviewsPerDay)
+}
@@ -0,0 +1,306 @@
+package vocabulary
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Outcomes of a synced review event
+const (
+	SyncEventApplied    = "applied"    // The review was replayed through the scheduler
+	SyncEventSuperseded = "superseded" // The card already has a later review, kept for history only
+	SyncEventRejected   = "rejected"   // The review cannot be applied and should not be retried
+)
+
+const (
+	// maxClockSkew is how far ahead of the server a device clock may run
+	maxClockSkew = 5 * time.Minute
+
+	// syncTokenOverlap re-sends changes made just before a token was issued,
+	// so writes committed while a sync was running are never missed
+	syncTokenOverlap = 2 * time.Second
+)
+
+var errStaleReview = errors.New("card was reviewed more recently on another device")
+
+// ReviewEvent is a review given offline and uploaded by a device. Events are
+// kept so retried uploads are recognised by their idempotency key.
+type ReviewEvent struct {
+	ID             string     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID         string     `json:"user_id" gorm:"not null;uniqueIndex:idx_review_event_key"`
+	IdempotencyKey string     `json:"idempotency_key" gorm:"not null;uniqueIndex:idx_review_event_key"`
+	DeviceID       string     `json:"device_id"`
+	VocabularyID   string     `json:"vocabulary_id" gorm:"not null"`
+	CardType       string     `json:"card_type" gorm:"not null"`
+	CardID         string     `json:"card_id"`
+	Correct        bool       `json:"correct"`
+	ResponseTime   int        `json:"response_time"`
+	ReviewedAt     time.Time  `json:"reviewed_at" gorm:"not null"` // Device clock
+	Status         string     `json:"status" gorm:"not null"`
+	Error          string     `json:"error,omitempty"`
+	NextReviewAt   *time.Time `json:"next_review_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// DeletedCard records a card removed from the user's deck so devices can drop
+// it on their next sync
+type DeletedCard struct {
+	ID           string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       string    `json:"user_id" gorm:"not null;index:idx_deleted_card_user_time"`
+	CardID       string    `json:"card_id" gorm:"not null"`
+	VocabularyID string    `json:"vocabulary_id" gorm:"not null"`
+	DeletedAt    time.Time `json:"deleted_at" gorm:"not null;index:idx_deleted_card_user_time"`
+}
+
+type SyncReviewEventInput struct {
+	IdempotencyKey string    `json:"idempotency_key" validate:"required,max=100"`
+	VocabularyID   string    `json:"vocabulary_id" validate:"required"`
+	CardType       string    `json:"card_type" validate:"omitempty,oneof=recognition production cloze listening"`
+	Correct        bool      `json:"correct"`
+	ResponseTime   int       `json:"response_time" validate:"min=0"` // milliseconds
+	ReviewedAt     time.Time `json:"reviewed_at" validate:"required"`
+}
+
+type SyncReviewsRequest struct {
+	DeviceID  string                 `json:"device_id" validate:"max=100"`
+	SyncToken string                 `json:"sync_token"` // Token of the last sync, empty for a full download
+	Events    []SyncReviewEventInput `json:"events" validate:"max=1000,dive"`
+}
+
+// SyncEventResult is the outcome of one uploaded event. Retried events get
+// the outcome of their first upload with Duplicate set.
+type SyncEventResult struct {
+	IdempotencyKey string     `json:"idempotency_key"`
+	Status         string     `json:"status"`
+	Duplicate      bool       `json:"duplicate"`
+	CardID         string     `json:"card_id,omitempty"`
+	NextReviewAt   *time.Time `json:"next_review_at,omitempty"`
+	Error          string     `json:"error,omitempty"`
+}
+
+// SyncReviewsResponse carries the outcome of the uploaded events and every
+// card that changed since the device's last sync
+type SyncReviewsResponse struct {
+	Results        []SyncEventResult `json:"results"`
+	Cards          []ReviewCard      `json:"cards"`            // Added or changed cards, with their current state
+	RemovedCardIDs []string          `json:"removed_card_ids"` // Deleted or deactivated cards
+	SyncToken      string            `json:"sync_token"`       // Send back on the next sync
+	ServerTime     time.Time         `json:"server_time"`
+	FullSync       bool              `json:"full_sync"` // Cards holds the whole deck
+}
+
+// formatSyncToken encodes the time a sync started
+func formatSyncToken(t time.Time) string {
+	return strconv.FormatInt(t.UnixMicro(), 36)
+}
+
+// parseSyncToken decodes a sync token, returning the zero time for an empty
+// token
+func parseSyncToken(token string) (time.Time, error) {
+	if token == "" {
+		return time.Time{}, nil
+	}
+	micros, err := strconv.ParseInt(token, 36, 64)
+	if err != nil || micros <= 0 {
+		return time.Time{}, errors.New("invalid sync token")
+	}
+	return time.UnixMicro(micros), nil
+}
+
+// orderReviewEvents sorts events by the time they were given on the device,
+// keeping upload order for simultaneous ones
+func orderReviewEvents(events []SyncReviewEventInput) []SyncReviewEventInput {
+	ordered := make([]SyncReviewEventInput, len(events))
+	copy(ordered, events)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].ReviewedAt.Before(ordered[j].ReviewedAt)
+	})
+	return ordered
+}
+
+// isStaleReview reports whether a review given at reviewedAt predates the
+// card's last review. When the same card is reviewed on two devices the
+// latest review wins: it reflects what the learner knows now, and replaying
+// an older answer on top of it would schedule the card from outdated state.
+func isStaleReview(state SRSState, reviewedAt time.Time) bool {
+	return state.LastReviewedAt != nil && !reviewedAt.After(*state.LastReviewedAt)
+}
+
+// SyncReviews replays the reviews a device gave offline and returns the cards
+// that changed since its last sync. Events are applied oldest first; each is
+// committed on its own, so a failed upload can be retried as a whole.
+func (s *Service) SyncReviews(ctx context.Context, userID string, req SyncReviewsRequest) (*SyncReviewsResponse, error) {
+	since, err := parseSyncToken(req.SyncToken)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	response := &SyncReviewsResponse{
+		Results:    make([]SyncEventResult, 0, len(req.Events)),
+		ServerTime: now,
+		FullSync:   since.IsZero(),
+	}
+
+	for _, input := range orderReviewEvents(req.Events) {
+		result, err := s.replayReviewEvent(userID, req.DeviceID, input, now)
+		if err != nil {
+			return nil, err
+		}
+		response.Results = append(response.Results, *result)
+	}
+
+	// Issue the token before reading so later writes show up next time
+	response.SyncToken = formatSyncToken(time.Now())
+	if !since.IsZero() {
+		since = since.Add(-syncTokenOverlap)
+	}
+
+	response.Cards, response.RemovedCardIDs, err = s.changedCards(userID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// replayReviewEvent applies one uploaded review unless its idempotency key
+// was seen before
+func (s *Service) replayReviewEvent(userID, deviceID string, input SyncReviewEventInput, now time.Time) (*SyncEventResult, error) {
+	cardType := input.CardType
+	if cardType == "" {
+		cardType = CardTypeRecognition
+	}
+
+	event := ReviewEvent{
+		UserID:         userID,
+		IdempotencyKey: input.IdempotencyKey,
+		DeviceID:       deviceID,
+		VocabularyID:   input.VocabularyID,
+		CardType:       cardType,
+		Correct:        input.Correct,
+		ResponseTime:   input.ResponseTime,
+		ReviewedAt:     input.ReviewedAt,
+		Status:         SyncEventRejected,
+	}
+
+	duplicate := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Claim the key first so concurrent uploads of the same event
+		// cannot both be applied
+		created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&event)
+		if created.Error != nil {
+			return created.Error
+		}
+		if created.RowsAffected == 0 {
+			duplicate = true
+			return tx.Where("user_id = ? AND idempotency_key = ?", userID, input.IdempotencyKey).First(&event).Error
+		}
+
+		if input.ReviewedAt.After(now.Add(maxClockSkew)) {
+			event.Error = "review time is in the future"
+			return tx.Save(&event).Error
+		}
+
+		card, err := s.reviewCardAt(tx, userID, ReviewRequest{
+			VocabularyID: input.VocabularyID,
+			CardType:     cardType,
+			Correct:      input.Correct,
+			ResponseTime: input.ResponseTime,
+		}, input.ReviewedAt, true)
+		switch {
+		case errors.Is(err, errStaleReview):
+			event.Status = SyncEventSuperseded
+			event.Error = err.Error()
+		case err != nil:
+			event.Error = err.Error()
+		default:
+			event.Status = SyncEventApplied
+			event.CardID = card.CardID
+			event.NextReviewAt = card.NextReviewAt
+		}
+		return tx.Save(&event).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SyncEventResult{
+		IdempotencyKey: event.IdempotencyKey,
+		Status:         event.Status,
+		Duplicate:      duplicate,
+		CardID:         event.CardID,
+		NextReviewAt:   event.NextReviewAt,
+		Error:          event.Error,
+	}, nil
+}
+
+// changedCards returns the user's cards changed after since and the IDs of
+// cards removed after it. A zero since returns the whole deck.
+func (s *Service) changedCards(userID string, since time.Time) ([]ReviewCard, []string, error) {
+	cards := make([]ReviewCard, 0)
+	removed := make([]string, 0)
+
+	recognition := s.db.Preload("Vocabulary").Where("user_id = ?", userID)
+	if !since.IsZero() {
+		recognition = recognition.Where("updated_at > ?", since)
+	}
+	var entries []UserVocabulary
+	if err := recognition.Find(&entries).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range entries {
+		cards = append(cards, newReviewCard(entry.ID, CardTypeRecognition, entry.SRSState, entry))
+	}
+
+	extra := s.db.Preload("UserVocabulary.Vocabulary").Where("user_id = ?", userID)
+	if since.IsZero() {
+		extra = extra.Where("active = ?", true)
+	} else {
+		extra = extra.Where("updated_at > ?", since)
+	}
+	var extraCards []VocabularyCard
+	if err := extra.Find(&extraCards).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, card := range extraCards {
+		if !card.Active {
+			removed = append(removed, card.ID)
+			continue
+		}
+		cards = append(cards, newReviewCard(card.ID, card.CardType, card.SRSState, card.UserVocabulary))
+	}
+
+	if !since.IsZero() {
+		var deleted []string
+		err := s.db.Model(&DeletedCard{}).
+			Where("user_id = ? AND deleted_at > ?", userID, since).
+			Pluck("card_id", &deleted).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		removed = append(removed, deleted...)
+	}
+
+	return cards, removed, nil
+}
+
+// recordDeletedCards leaves a record of deleted cards for devices to sync
+func (s *Service) recordDeletedCards(tx *gorm.DB, userID, vocabularyID string, cardIDs []string) error {
+	if len(cardIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	deleted := make([]DeletedCard, len(cardIDs))
+	for i, cardID := range cardIDs {
+		deleted[i] = DeletedCard{UserID: userID, CardID: cardID, VocabularyID: vocabularyID, DeletedAt: now}
+	}
+	return tx.Create(&deleted).Error
+}
@@ -0,0 +1,52 @@
+package vocabulary
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncToken(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 30, 15, 123456000, time.UTC)
+
+	parsed, err := parseSyncToken(formatSyncToken(at))
+	assert.NoError(t, err)
+	assert.True(t, at.Equal(parsed))
+
+	parsed, err = parseSyncToken("")
+	assert.NoError(t, err)
+	assert.True(t, parsed.IsZero())
+
+	_, err = parseSyncToken("not a token!")
+	assert.Error(t, err)
+}
+
+func TestOrderReviewEvents(t *testing.T) {
+	base := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	events := []SyncReviewEventInput{
+		{IdempotencyKey: "c", ReviewedAt: base.Add(2 * time.Minute)},
+		{IdempotencyKey: "a", ReviewedAt: base},
+		{IdempotencyKey: "b1", ReviewedAt: base.Add(time.Minute)},
+		{IdempotencyKey: "b2", ReviewedAt: base.Add(time.Minute)},
+	}
+
+	ordered := orderReviewEvents(events)
+	keys := make([]string, len(ordered))
+	for i, event := range ordered {
+		keys[i] = event.IdempotencyKey
+	}
+	assert.Equal(t, []string{"a", "b1", "b2", "c"}, keys)
+	assert.Equal(t, "c", events[0].IdempotencyKey) // Input untouched
+}
+
+func TestIsStaleReview(t *testing.T) {
+	last := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	assert.False(t, isStaleReview(SRSState{}, last))
+
+	state := SRSState{LastReviewedAt: &last}
+	assert.True(t, isStaleReview(state, last.Add(-time.Minute)))
+	assert.True(t, isStaleReview(state, last))
+	assert.False(t, isStaleReview(state, last.Add(time.Second)))
+}
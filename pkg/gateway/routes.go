@@ -61,6 +61,9 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 		authGroup.POST("/reset-password", proxyTo(services.AuthServiceURL))
 	}
 
+	// Stored files (no auth required, records link to them publicly)
+	v1.GET("/files/*key", proxyTo(services.ContentServiceURL))
+
 	// Protected routes
 	protected := v1.Group("/")
 	protected.Use(services.jwtService.AuthMiddleware())
@@ -71,6 +74,7 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 		userGroup.GET("/me", proxyTo(services.AuthServiceURL))
 		userGroup.PUT("/me", proxyTo(services.AuthServiceURL))
 		userGroup.DELETE("/me", proxyTo(services.AuthServiceURL))
+		userGroup.PUT("/:id/role", proxyTo(services.AuthServiceURL))
 		userGroup.GET("/languages", proxyTo(services.AuthServiceURL))
 		userGroup.POST("/languages", proxyTo(services.AuthServiceURL))
 		userGroup.PUT("/languages/:id", proxyTo(services.AuthServiceURL))
@@ -85,11 +89,44 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 		contentGroup.GET("/:id", proxyTo(services.ContentServiceURL))
 		contentGroup.PUT("/:id", proxyTo(services.ContentServiceURL))
 		contentGroup.DELETE("/:id", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/mine", proxyTo(services.ContentServiceURL))
+		contentGroup.POST("/:id/submit", proxyTo(services.ContentServiceURL))
+		contentGroup.POST("/:id/withdraw", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/:id/history", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/admin/review-queue", proxyTo(services.ContentServiceURL))
+		contentGroup.POST("/admin/review-queue/:id", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/admin/audit", proxyTo(services.ContentServiceURL))
 		contentGroup.POST("/:id/rate", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/:id/ratings", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/:id/reviews", proxyTo(services.ContentServiceURL))
+		contentGroup.POST("/reviews/:review_id/vote", proxyTo(services.ContentServiceURL))
+		contentGroup.DELETE("/reviews/:review_id/vote", proxyTo(services.ContentServiceURL))
+		contentGroup.POST("/reviews/:review_id/report", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/admin/reviews/reported", proxyTo(services.ContentServiceURL))
+		contentGroup.POST("/admin/reviews/:review_id/resolve", proxyTo(services.ContentServiceURL))
 		contentGroup.GET("/:id/episodes", proxyTo(services.ContentServiceURL))
 		contentGroup.POST("/:id/episodes", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/:id/episodes/:episode_id/transcript", proxyTo(services.ContentServiceURL))
+		contentGroup.PUT("/:id/episodes/:episode_id/transcript", proxyTo(services.ContentServiceURL))
+		contentGroup.DELETE("/:id/episodes/:episode_id/transcript", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/:id/episodes/:episode_id/subtitles", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/:id/episodes/:episode_id/subtitles/:language", proxyTo(services.ContentServiceURL))
+		contentGroup.PUT("/:id/episodes/:episode_id/subtitles/:language", proxyTo(services.ContentServiceURL))
+		contentGroup.DELETE("/:id/episodes/:episode_id/subtitles/:language", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/subtitles/search", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/:id/difficulty", proxyTo(services.ContentServiceURL))
+		contentGroup.POST("/admin/difficulty/recompute", proxyTo(services.ContentServiceURL))
 		contentGroup.GET("/recommendations", proxyTo(services.ContentServiceURL))
 		contentGroup.GET("/languages", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/search", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/genres", proxyTo(services.ContentServiceURL))
+		contentGroup.POST("/:id/poster", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/metadata/search", proxyTo(services.ContentServiceURL))
+		contentGroup.POST("/import", proxyTo(services.ContentServiceURL))
+		contentGroup.POST("/admin/metadata/refresh", proxyTo(services.ContentServiceURL))
+		contentGroup.POST("/admin/catalogue/import", proxyTo(services.ContentServiceURL))
+		contentGroup.GET("/admin/catalogue/export", proxyTo(services.ContentServiceURL))
+		contentGroup.POST("/admin/storage/cleanup", proxyTo(services.ContentServiceURL))
 	}
 
 	// Progress routes
@@ -100,6 +137,17 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 		progressGroup.GET("/analytics", proxyTo(services.ProgressServiceURL))
 		progressGroup.GET("/recent", proxyTo(services.ProgressServiceURL))
 		progressGroup.GET("/calendar", proxyTo(services.ProgressServiceURL))
+		progressGroup.GET("/watchlists", proxyTo(services.ProgressServiceURL))
+		progressGroup.POST("/watchlists", proxyTo(services.ProgressServiceURL))
+		progressGroup.PUT("/watchlists/order", proxyTo(services.ProgressServiceURL))
+		progressGroup.GET("/watchlists/:id", proxyTo(services.ProgressServiceURL))
+		progressGroup.PUT("/watchlists/:id", proxyTo(services.ProgressServiceURL))
+		progressGroup.DELETE("/watchlists/:id", proxyTo(services.ProgressServiceURL))
+		progressGroup.POST("/watchlists/:id/items", proxyTo(services.ProgressServiceURL))
+		progressGroup.PUT("/watchlists/:id/items/order", proxyTo(services.ProgressServiceURL))
+		progressGroup.DELETE("/watchlists/:id/items/:item_id", proxyTo(services.ProgressServiceURL))
+		progressGroup.GET("/content/:content_id/episodes", proxyTo(services.ProgressServiceURL))
+		progressGroup.GET("/continue-watching", proxyTo(services.ProgressServiceURL))
 	}
 
 	// Vocabulary routes
@@ -109,11 +157,65 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 		vocabGroup.GET("/", proxyTo(services.VocabularyServiceURL))
 		vocabGroup.GET("/reviews", proxyTo(services.VocabularyServiceURL))
 		vocabGroup.POST("/reviews", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/reviews/queue", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/reviews/sessions", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/reviews/sessions", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/reviews/sessions/:session_id", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/reviews/sessions/:session_id/answer", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/reviews/sessions/:session_id/undo", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/reviews/sessions/:session_id/pause", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/reviews/sessions/:session_id/resume", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/reviews/sessions/:session_id/finish", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/reviews/sync", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/leeches", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/cards/suspend", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/cards/unsuspend", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/cards/bury", proxyTo(services.VocabularyServiceURL))
 		vocabGroup.GET("/stats", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/srs-config", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.PUT("/srs-config", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.PUT("/srs-config/preset/:preset", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/srs-config/simulate", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/srs-config/optimize", proxyTo(services.VocabularyServiceURL))
 		vocabGroup.DELETE("/:id", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/:id/cards", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.PUT("/:id/tags", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/tags", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/bulk-tag", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/filters", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/filters", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/filters/:filter_id", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.PUT("/filters/:filter_id", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.DELETE("/filters/:filter_id", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/filters/:filter_id/vocabulary", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/lists/public", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/lists/public/:list_id", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/lists/subscriptions", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/lists/:list_id/fork", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/lists/:list_id/subscribe", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.DELETE("/lists/:list_id/subscribe", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/lists/:list_id/items", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.DELETE("/lists/:list_id/items/:vocabulary_id", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.PUT("/lists/:list_id/items/order", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/lists/:list_id/members", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.PUT("/lists/:list_id/members/:user_id", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.DELETE("/lists/:list_id/members/:user_id", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/lists/:list_id/progress", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/lists/:list_id/groups", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.DELETE("/lists/:list_id/groups/:group_id", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/groups/:group_id/lists", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/mining/episodes/:episode_id", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/mining/episodes/:episode_id", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/coverage", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/useful-words", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/admin/frequency-lists", proxyTo(services.VocabularyServiceURL))
 		vocabGroup.GET("/search", proxyTo(services.VocabularyServiceURL))
 		vocabGroup.POST("/import", proxyTo(services.VocabularyServiceURL))
 		vocabGroup.GET("/export", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.POST("/jobs", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/jobs", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/jobs/:job_id", proxyTo(services.VocabularyServiceURL))
+		vocabGroup.GET("/jobs/:job_id/download", proxyTo(services.VocabularyServiceURL))
 	}
 
 	// Phonetic routes
@@ -123,12 +225,15 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 		phoneticGroup.GET("/progress", proxyTo(services.PhoneticServiceURL))
 		phoneticGroup.POST("/practice", proxyTo(services.PhoneticServiceURL))
 		phoneticGroup.GET("/exercises", proxyTo(services.PhoneticServiceURL))
+		phoneticGroup.POST("/admin/phonemes/:id/audio", proxyTo(services.PhoneticServiceURL))
+		phoneticGroup.POST("/admin/minimal-pairs/:id/audio/:word", proxyTo(services.PhoneticServiceURL))
 	}
 
 	// Social routes
 	socialGroup := protected.Group("/social")
 	{
 		socialGroup.GET("/profile/:user_id", proxyTo(services.SocialServiceURL))
+		socialGroup.POST("/profile/avatar", proxyTo(services.SocialServiceURL))
 		socialGroup.POST("/follow/:user_id", proxyTo(services.SocialServiceURL))
 		socialGroup.DELETE("/follow/:user_id", proxyTo(services.SocialServiceURL))
 		socialGroup.GET("/followers", proxyTo(services.SocialServiceURL))
@@ -194,7 +299,13 @@ func proxyTo(serviceURL string) gin.HandlerFunc {
 		}
 
 		// Make request
-		client := &http.Client{Timeout: 30 * time.Second}
+		client := &http.Client{
+			Timeout: 30 * time.Second,
+			// Redirects are passed on to the client, e.g. to presigned file URLs
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
 		resp, err := client.Do(req)
 		if err != nil {
 			c.JSON(http.StatusBadGateway, gin.H{"error": "Service unavailable"})
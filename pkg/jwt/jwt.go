@@ -11,7 +11,8 @@ import (
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
-	Type   string `json:"type"` // "access" or "refresh"
+	Role   string `json:"role,omitempty"` // Access tokens only, see Role constants
+	Type   string `json:"type"`           // "access" or "refresh"
 	jwt.RegisteredClaims
 }
 
@@ -70,12 +71,20 @@ func (s *Service) GenerateTokenPair(userID, email string) (*TokenPair, error) {
 	}, nil
 }
 
-// GenerateAccessToken creates a new access token
+// GenerateAccessToken creates a new access token for a learner
 func (s *Service) GenerateAccessToken(userID, email string) (string, error) {
+	return s.GenerateAccessTokenWithRole(userID, email, RoleLearner)
+}
+
+// GenerateAccessTokenWithRole creates a new access token carrying the user's
+// role. The role is checked by RoleMiddleware until the token expires, so a
+// role change takes effect when the user next logs in or refreshes.
+func (s *Service) GenerateAccessTokenWithRole(userID, email, role string) (string, error) {
 	now := time.Now()
 	claims := Claims{
 		UserID: userID,
 		Email:  email,
+		Role:   role,
 		Type:   "access",
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   userID,
@@ -1,9 +1,12 @@
 package jwt
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -114,6 +117,68 @@ func TestJWTService(t *testing.T) {
 	})
 }
 
+func TestHasRole(t *testing.T) {
+	assert.True(t, HasRole(RoleAdmin, RoleAdmin))
+	assert.True(t, HasRole(RoleAdmin, RoleEditor))
+	assert.True(t, HasRole(RoleEditor, RoleEditor))
+	assert.False(t, HasRole(RoleEditor, RoleAdmin))
+	assert.False(t, HasRole(RoleLearner, RoleEditor))
+	assert.True(t, HasRole(RoleLearner, RoleLearner))
+
+	t.Run("TokensWithoutRole", func(t *testing.T) {
+		assert.True(t, HasRole("", RoleLearner))
+		assert.False(t, HasRole("", RoleEditor))
+		assert.False(t, HasRole("superuser", RoleEditor))
+	})
+
+	t.Run("UnknownRequiredRole", func(t *testing.T) {
+		assert.False(t, HasRole(RoleAdmin, "owner"))
+	})
+}
+
+func TestRoleMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := NewService(Config{SecretKey: "test-secret-key"})
+
+	router := gin.New()
+	router.GET("/admin", service.AdminMiddleware(), func(c *gin.Context) {
+		userID, _ := GetUserIDFromContext(c)
+		c.String(http.StatusOK, userID)
+	})
+	router.GET("/editor", service.EditorMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	token := func(role string) string {
+		token, err := service.GenerateAccessTokenWithRole("user-"+role, role+"@example.com", role)
+		assert.NoError(t, err)
+		return token
+	}
+
+	learnerToken, err := service.GenerateAccessToken("user-learner", "learner@example.com")
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusUnauthorized, request("/admin", "").Code)
+	assert.Equal(t, http.StatusForbidden, request("/admin", learnerToken).Code)
+	assert.Equal(t, http.StatusForbidden, request("/admin", token(RoleEditor)).Code)
+	assert.Equal(t, http.StatusForbidden, request("/editor", learnerToken).Code)
+	assert.Equal(t, http.StatusOK, request("/editor", token(RoleEditor)).Code)
+	assert.Equal(t, http.StatusOK, request("/editor", token(RoleAdmin)).Code)
+
+	w := request("/admin", token(RoleAdmin))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "user-admin", w.Body.String())
+}
+
 func TestHelperFunctions(t *testing.T) {
 	t.Run("generateJTI", func(t *testing.T) {
 		jti1 := generateJTI()
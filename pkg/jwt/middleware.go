@@ -81,8 +81,8 @@ func (s *Service) RefreshTokenMiddleware() gin.HandlerFunc {
 	}
 }
 
-// AdminMiddleware requires admin role (placeholder implementation)
-func (s *Service) AdminMiddleware() gin.HandlerFunc {
+// RoleMiddleware requires a valid access token whose role includes required
+func (s *Service) RoleMiddleware(required string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// First validate the token
 		tokenString := extractTokenFromHeader(c.GetHeader("Authorization"))
@@ -99,9 +99,12 @@ func (s *Service) AdminMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// TODO: Check if user has admin role
-		// This would typically involve checking the user's role in the database
-		// For now, we'll just set the user info and continue
+		if !HasRole(claims.Role, required) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("token_id", claims.ID)
@@ -110,6 +113,16 @@ func (s *Service) AdminMiddleware() gin.HandlerFunc {
 	}
 }
 
+// AdminMiddleware requires the admin role
+func (s *Service) AdminMiddleware() gin.HandlerFunc {
+	return s.RoleMiddleware(RoleAdmin)
+}
+
+// EditorMiddleware requires the editor or admin role
+func (s *Service) EditorMiddleware() gin.HandlerFunc {
+	return s.RoleMiddleware(RoleEditor)
+}
+
 // Helper function to extract token from Authorization header
 func extractTokenFromHeader(authHeader string) string {
 	if authHeader == "" {
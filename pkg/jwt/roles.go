@@ -0,0 +1,35 @@
+package jwt
+
+// User roles, from least to most privileged
+const (
+	RoleLearner = "learner"
+	RoleEditor  = "editor" // Reviews and publishes catalogue content
+	RoleAdmin   = "admin"  // Also runs maintenance jobs and manages roles
+)
+
+var roleRanks = map[string]int{
+	RoleLearner: 1,
+	RoleEditor:  2,
+	RoleAdmin:   3,
+}
+
+// IsValidRole reports whether role is one of the known roles
+func IsValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// HasRole reports whether a user with role may do what required allows. Roles
+// include the ones below them, so admins can do everything editors can. Tokens
+// issued before roles existed carry no role and only count as learners.
+func HasRole(role, required string) bool {
+	requiredRank, ok := roleRanks[required]
+	if !ok {
+		return false
+	}
+	rank, ok := roleRanks[role]
+	if !ok {
+		rank = roleRanks[RoleLearner]
+	}
+	return rank >= requiredRank
+}